@@ -1,27 +1,57 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/techrook/23-market/config"
 	"github.com/techrook/23-market/database"
+	"github.com/techrook/23-market/internal/admin"
+	"github.com/techrook/23-market/internal/analytics"
 	"github.com/techrook/23-market/internal/auth"
+	"github.com/techrook/23-market/internal/category"
+	"github.com/techrook/23-market/internal/digital"
+	"github.com/techrook/23-market/internal/exchange"
+	"github.com/techrook/23-market/internal/inventory"
+	"github.com/techrook/23-market/internal/kyc"
+	"github.com/techrook/23-market/internal/moderation"
+	"github.com/techrook/23-market/internal/notification"
+	"github.com/techrook/23-market/internal/payout"
+	"github.com/techrook/23-market/internal/pricing"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/productio"
+	"github.com/techrook/23-market/internal/productqa"
+	"github.com/techrook/23-market/internal/productreview"
+	"github.com/techrook/23-market/internal/recommendation"
+	"github.com/techrook/23-market/internal/search"
+	"github.com/techrook/23-market/internal/shipping"
 	"github.com/techrook/23-market/internal/server"
 	"github.com/techrook/23-market/internal/user"
 	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/internal/vendorreview"
+	"github.com/techrook/23-market/pkg/secretbox"
+	"github.com/techrook/23-market/pkg/storage"
 )
 
 func main() {
 
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 	authCfg := auth.LoadConfig()
 
 	if err := database.Connect(cfg); err != nil {
 		log.Fatalf("Database connection failed: %v", err)
 	}
 
+	if err := database.Migrate(database.DB); err != nil {
+		log.Fatalf("Failed to migrate MongoDB documents: %v", err)
+	}
+
 	if err := database.EnsureIndexes(database.DB); err != nil {
 		log.Fatalf("Failed to ensure MongoDB indexes: %v", err)
 	}
@@ -41,12 +71,132 @@ func main() {
 	userHandler := user.NewHandler(user.NewService(userRepo))
 	authHandler := auth.NewHandler(authService, authCfg)
 
-	vendorService := vendor.NewService(vendorRepo)
+	adminHandler := admin.NewHandler(admin.NewService(userRepo, authRepo))
+
+	blobStore, err := storage.NewLocalStore(cfg.StorageDir)
+	if err != nil {
+		log.Fatalf("Failed to initialise blob storage: %v", err)
+	}
+
+	notificationService := notification.NewService(notification.NewNotificationRepository(database.DB))
+	notificationHandler := notification.NewHandler(notificationService)
+
+	kycService := kyc.NewService(kyc.NewKYCRepository(database.DB), vendorRepo, blobStore, notificationService)
+	kycHandler := kyc.NewHandler(kycService)
+
+	vendorReviewService := vendorreview.NewService(vendorreview.NewReviewRepository(database.DB), vendorRepo)
+	vendorReviewHandler := vendorreview.NewHandler(vendorReviewService)
+
+	shippingService := shipping.NewService(shipping.NewShippingRepository(database.DB), vendorRepo, userRepo)
+	shippingHandler := shipping.NewHandler(shippingService)
+
+	payoutBox, err := secretbox.New(cfg.PayoutSecret)
+	if err != nil {
+		log.Fatalf("Failed to initialise payout encryption: %v", err)
+	}
+	// The fake provider only moves money in logs, so production uses it only when asked to.
+	var payoutProvider payout.Provider
+	switch {
+	case cfg.PayoutProvider == "fake", cfg.PayoutProvider == "" && !cfg.IsProduction():
+		payoutProvider = payout.NewFakeProvider()
+	case cfg.PayoutProvider == "":
+		log.Printf("⚠️ No PAYOUT_PROVIDER configured, payouts are disabled")
+	default:
+		log.Fatalf("Unknown payout provider %q", cfg.PayoutProvider)
+	}
+	payoutMinimums, err := payout.ParseMinimums(cfg.PayoutMinimums)
+	if err != nil {
+		log.Fatalf("Invalid PAYOUT_MINIMUMS: %v", err)
+	}
+	payoutService := payout.NewService(
+		payout.NewPayoutRepository(database.DB),
+		vendorRepo,
+		payoutBox,
+		payoutProvider,
+		notificationService,
+		payout.Config{HoldPeriod: cfg.PayoutHoldPeriod, Minimums: payoutMinimums},
+	)
+	payoutHandler := payout.NewHandler(payoutService)
+
+	analyticsRepo := analytics.NewAnalyticsRepository(database.DB)
+	analyticsService := analytics.NewService(analyticsRepo, vendorRepo)
+	analyticsHandler := analytics.NewHandler(analyticsService)
+
+	productRepo := product.NewProductRepository(database.DB)
+	categoryService := category.NewService(category.NewCategoryRepository(database.DB), productRepo)
+	categoryHandler := category.NewHandler(categoryService)
+
+	var searchEngine search.Engine = search.NewMongoEngine(database.DB)
+	if cfg.SearchEngine == "memory" {
+		searchEngine = search.NewMemoryEngine()
+	}
+	reviewRepo := productreview.NewReviewRepository(database.DB)
+	searchService := search.NewService(searchEngine, productRepo, vendorRepo, categoryService, reviewRepo)
+	searchHandler := search.NewHandler(searchService)
+
+	vendorService := vendor.NewService(vendorRepo, searchService)
 	vendorHandler := vendor.NewHandler(vendorService)
 
+	inventoryService := inventory.NewService(inventory.NewInventoryRepository(database.DB), productRepo, vendorRepo, vendorService)
+	inventoryHandler := inventory.NewHandler(inventoryService)
+
+	moderationService := moderation.NewService(moderation.NewRulesRepository(database.DB), productRepo, vendorRepo, searchService, notificationService, blobStore)
+	moderationHandler := moderation.NewHandler(moderationService)
+
+	pricingService := pricing.NewService(pricing.NewPricingRepository(database.DB), productRepo, vendorRepo, searchService, moderationService)
+	pricingHandler := pricing.NewHandler(pricingService)
+
+	exchangeService, err := exchange.NewService(exchange.NewRateRepository(database.DB), cfg.BaseCurrency)
+	if err != nil {
+		log.Fatalf("Failed to initialise exchange rates: %v", err)
+	}
+	if err := exchangeService.Reload(context.Background()); err != nil {
+		log.Fatalf("Failed to load exchange rates: %v", err)
+	}
+	exchangeHandler := exchange.NewHandler(exchangeService)
+
+	productService := product.NewService(productRepo, vendorRepo, inventoryService, categoryService, searchService, pricingService, exchangeService, moderationService, blobStore)
+	productHandler := product.NewHandler(productService)
+
+	productioService := productio.NewService(productio.NewJobRepository(database.DB), productRepo, vendorRepo, categoryService, searchService, pricingService, moderationService, inventoryService, blobStore)
+	productioHandler := productio.NewHandler(productioService)
+
+	qaHandler := productqa.NewHandler(productqa.NewService(productqa.NewQARepository(database.DB), productRepo, vendorRepo, notificationService))
+	recommendationService := recommendation.NewService(
+		recommendation.NewNeighbourRepository(database.DB),
+		productRepo,
+		vendorRepo,
+		productService,
+		analyticsRepo,
+		recommendation.Config{Window: cfg.RecommendationWindow},
+	)
+	recommendationHandler := recommendation.NewHandler(recommendationService)
+
+	digitalHandler := digital.NewHandler(digital.NewService(
+		digital.NewDigitalRepository(database.DB),
+		productRepo,
+		vendorRepo,
+		blobStore,
+		notificationService,
+		digital.Config{Secret: cfg.DownloadSecret, LinkTTL: cfg.DownloadLinkTTL, DownloadLimit: cfg.DownloadLimit},
+	))
+
+	reviewHandler := productreview.NewHandler(productreview.NewService(reviewRepo, productRepo, vendorRepo, searchService, blobStore, notificationService))
+
+	schedulerCtx, stopSchedulers := context.WithCancel(context.Background())
+	defer stopSchedulers()
+	if payoutProvider != nil {
+		go payout.RunScheduler(schedulerCtx, payoutService, cfg.PayoutInterval)
+	}
+	go inventory.RunExpiry(schedulerCtx, inventoryService, time.Minute)
+	go productio.RunWorker(schedulerCtx, productioService, 5*time.Second)
+	go pricing.RunScheduler(schedulerCtx, pricingService, time.Minute)
+	go exchange.RunRefresher(schedulerCtx, exchangeService, time.Minute)
+	go recommendation.RunRecomputer(schedulerCtx, recommendationService, cfg.RecommendationInterval)
+
 	r := gin.Default()
 
-	server.SetupRoutes(r,authHandler,userHandler,vendorHandler, userRepo)
+	server.SetupRoutes(r,authHandler,userHandler,vendorHandler, adminHandler, kycHandler, notificationHandler, vendorReviewHandler, shippingHandler, payoutHandler, analyticsHandler, productHandler, inventoryHandler, categoryHandler, searchHandler, productioHandler, pricingHandler, exchangeHandler, qaHandler, reviewHandler, recommendationHandler, digitalHandler, moderationHandler, userRepo)
 
 	addr := fmt.Sprintf(":%s", cfg.Port)
 	log.Printf("🚀 Server starting on http://localhost%s [%s]", addr, cfg.Environment)
@@ -11,6 +11,12 @@ import (
 	"github.com/joho/godotenv"
 )
 
+// Development defaults for secrets; Validate rejects them in production.
+const (
+	devPayoutSecret   = "dev-payout-key-change-in-prod"
+	devDownloadSecret = "dev-download-key-change-in-prod"
+)
+
 type Config struct {
 	Port            string
 	GinMode         string
@@ -20,6 +26,29 @@ type Config struct {
 	MongoMaxPoolSize uint64
 	MongoMinPoolSize uint64
 	MongoTimeout    time.Duration
+	StorageDir      string
+	PayoutSecret     string
+	PayoutInterval   time.Duration
+	PayoutHoldPeriod time.Duration
+	// PayoutMinimums lists per-currency payout thresholds as "USD:10.00,JPY:1500";
+	// other currencies pay out from 10 whole units.
+	PayoutMinimums   string
+	// PayoutProvider names the payment rail; "fake" is the only one so far.
+	// Left empty it means "fake" outside production and no payouts in production.
+	PayoutProvider   string
+	// SearchEngine is "mongo" or "memory"; the in-memory engine is for
+	// development and starts empty until a reindex.
+	SearchEngine string
+	// BaseCurrency is what exchange rates are quoted against.
+	BaseCurrency string
+	RecommendationInterval time.Duration
+	// RecommendationWindow is how far back orders count towards
+	// "frequently bought together".
+	RecommendationWindow time.Duration
+	// DownloadSecret signs digital product download links.
+	DownloadSecret  string
+	DownloadLinkTTL time.Duration
+	DownloadLimit   int
 }
 
 func Load() *Config {
@@ -35,6 +64,19 @@ func Load() *Config {
 		MongoMaxPoolSize: uint64(getEnvInt("MONGO_MAX_POOL_SIZE", 100)),
 		MongoMinPoolSize: uint64(getEnvInt("MONGO_MIN_POOL_SIZE", 10)),
 		MongoTimeout:    time.Duration(getEnvInt("MONGO_TIMEOUT_SECONDS", 10)) * time.Second,
+		StorageDir:      getEnv("STORAGE_DIR", "./storage"),
+		PayoutSecret:     getEnv("PAYOUT_ENCRYPTION_KEY", devPayoutSecret),
+		PayoutInterval:   time.Duration(getEnvInt("PAYOUT_INTERVAL_HOURS", 24)) * time.Hour,
+		PayoutHoldPeriod: time.Duration(getEnvInt("PAYOUT_HOLD_DAYS", 7)) * 24 * time.Hour,
+		PayoutMinimums:   getEnv("PAYOUT_MINIMUMS", ""),
+		PayoutProvider:   getEnv("PAYOUT_PROVIDER", ""),
+		SearchEngine:     getEnv("SEARCH_ENGINE", "mongo"),
+		BaseCurrency:     getEnv("BASE_CURRENCY", "USD"),
+		RecommendationInterval: time.Duration(getEnvInt("RECOMMENDATION_INTERVAL_HOURS", 6)) * time.Hour,
+		RecommendationWindow:   time.Duration(getEnvInt("RECOMMENDATION_WINDOW_DAYS", 180)) * 24 * time.Hour,
+		DownloadSecret:         getEnv("DOWNLOAD_SIGNING_KEY", devDownloadSecret),
+		DownloadLinkTTL:        time.Duration(getEnvInt("DOWNLOAD_LINK_TTL_MINUTES", 15)) * time.Minute,
+		DownloadLimit:          getEnvInt("DOWNLOAD_LIMIT", 5),
 
 	}
 }
@@ -56,6 +98,20 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+// Validate fails when production would run with a development secret.
+func (c *Config) Validate() error {
+	if !c.IsProduction() {
+		return nil
+	}
+	if c.PayoutSecret == devPayoutSecret {
+		return fmt.Errorf("PAYOUT_ENCRYPTION_KEY must be set in production")
+	}
+	if c.DownloadSecret == devDownloadSecret {
+		return fmt.Errorf("DOWNLOAD_SIGNING_KEY must be set in production")
+	}
+	return nil
+}
+
 func (c *Config) IsProduction() bool {
 	return c.Environment == "production" || c.GinMode == "release"
 }
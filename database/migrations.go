@@ -0,0 +1,253 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Migrate rewrites documents stored in older formats. Every step must be idempotent.
+// It runs before EnsureIndexes so that unique indexes are built on clean data.
+func Migrate(db *mongo.Database) error {
+	ctx := context.Background()
+	vendors := db.Collection("vendors")
+
+	if err := migrateVendorSlugs(ctx, vendors); err != nil {
+		return err
+	}
+
+	// Vendor statuses used to be "Activated"/"Dectivated" before the onboarding lifecycle.
+	// Profiles used to be activated on creation, so an activated vendor that never
+	// filled in a business name starts onboarding as a draft rather than selling.
+	legacyStatuses := []struct {
+		filter primitive.M
+		to     string
+	}{
+		{primitive.M{"status": "Activated", "business_name": primitive.M{"$in": primitive.A{"", nil}}}, "draft"},
+		{primitive.M{"status": "Activated"}, "approved"},
+		{primitive.M{"status": "Dectivated"}, "closed"},
+	}
+	for _, legacy := range legacyStatuses {
+		_, err := vendors.UpdateMany(ctx,
+			legacy.filter,
+			primitive.M{"$set": primitive.M{
+				"status":            legacy.to,
+				"status_changed_at": time.Now(),
+				"status_history":    primitive.A{},
+			}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Vendors created before KYC have no documents yet.
+	_, err := vendors.UpdateMany(ctx,
+		primitive.M{"kyc_status": primitive.M{"$exists": false}},
+		primitive.M{"$set": primitive.M{"kyc_status": "not_submitted"}},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Vendors created before business hours are always open in UTC.
+	_, err = vendors.UpdateMany(ctx,
+		primitive.M{"timezone": primitive.M{"$exists": false}},
+		primitive.M{"$set": primitive.M{
+			"timezone":      "UTC",
+			"opening_hours": primitive.A{},
+			"holidays":      primitive.A{},
+		}},
+	)
+	if err != nil {
+		return err
+	}
+
+	// Reservations used to hold every line before they were stored.
+	_, err = db.Collection("inventory_reservations").UpdateMany(ctx,
+		primitive.M{"held": primitive.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: primitive.M{"held": "$lines.variant_id"}}}},
+	)
+	if err != nil {
+		return err
+	}
+
+	if err := migrateDefaultVariants(ctx, db.Collection("products")); err != nil {
+		return err
+	}
+	return migrateVariantStock(ctx, db.Collection("products"), db.Collection("inventory_items"))
+}
+
+// Products created before variants get a single default variant whose SKU is
+// the tail of the product ID, matching product.NewDefaultVariant.
+func migrateDefaultVariants(ctx context.Context, products *mongo.Collection) error {
+	cursor, err := products.Find(ctx,
+		primitive.M{"variants": primitive.M{"$exists": false}},
+		options.Find().SetProjection(primitive.M{"_id": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		_, err := products.UpdateOne(ctx,
+			primitive.M{"_id": doc.ID, "variants": primitive.M{"$exists": false}},
+			primitive.M{"$set": primitive.M{
+				"options": primitive.A{},
+				"variants": primitive.A{primitive.M{
+					"_id":           primitive.NewObjectID(),
+					"sku":           strings.ToUpper(doc.ID.Hex()[14:]),
+					"option_values": primitive.A{},
+					"weight_grams":  0,
+				}},
+			}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// Variant stock used to live on the product document; it moves to one
+// inventory item per variant with nothing reserved.
+func migrateVariantStock(ctx context.Context, products, items *mongo.Collection) error {
+	cursor, err := products.Find(ctx,
+		primitive.M{"variants.stock": primitive.M{"$exists": true}},
+		options.Find().SetProjection(primitive.M{"_id": 1, "vendor_id": 1, "variants._id": 1, "variants.stock": 1}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID       primitive.ObjectID `bson:"_id"`
+			VendorID primitive.ObjectID `bson:"vendor_id"`
+			Variants []struct {
+				ID    primitive.ObjectID `bson:"_id"`
+				Stock int64              `bson:"stock"`
+			} `bson:"variants"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, v := range doc.Variants {
+			_, err := items.UpdateOne(ctx,
+				primitive.M{"variant_id": v.ID},
+				primitive.M{"$setOnInsert": primitive.M{
+					"vendor_id":  doc.VendorID,
+					"product_id": doc.ID,
+					"on_hand":    v.Stock,
+					"reserved":   0,
+					"created_at": now,
+					"updated_at": now,
+				}},
+				options.Update().SetUpsert(true),
+			)
+			if err != nil {
+				return err
+			}
+		}
+		_, err := products.UpdateOne(ctx,
+			primitive.M{"_id": doc.ID},
+			primitive.M{"$unset": primitive.M{"variants.$[].stock": ""}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return cursor.Err()
+}
+
+// Nothing used to stop two vendors from sharing a slug. Every slug becomes
+// unique ignoring case; the oldest vendor keeps a contested slug and later
+// ones get a suffix. Vendors without a slug pick one when they complete
+// their profile, so they are left alone.
+func migrateVendorSlugs(ctx context.Context, vendors *mongo.Collection) error {
+	cursor, err := vendors.Find(ctx, primitive.M{"slug": primitive.M{"$gt": ""}},
+		options.Find().
+			SetProjection(primitive.M{"_id": 1, "slug": 1}).
+			SetSort(primitive.D{{Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return err
+	}
+	defer cursor.Close(ctx)
+
+	taken := make(map[string]bool)
+	type rename struct {
+		id   primitive.ObjectID
+		base string
+	}
+	var renames []rename
+	for cursor.Next(ctx) {
+		var doc struct {
+			ID   primitive.ObjectID `bson:"_id"`
+			Slug string             `bson:"slug"`
+		}
+		if err := cursor.Decode(&doc); err != nil {
+			return err
+		}
+		key := strings.ToLower(doc.Slug)
+		if !taken[key] {
+			taken[key] = true
+			continue
+		}
+		renames = append(renames, rename{id: doc.ID, base: doc.Slug})
+	}
+	if err := cursor.Err(); err != nil {
+		return err
+	}
+
+	// Renames are assigned after every kept slug is known, so a suffixed
+	// slug never takes one that an older vendor already holds.
+	for _, r := range renames {
+		slug := uniqueSlug(r.base, taken)
+		taken[strings.ToLower(slug)] = true
+		_, err := vendors.UpdateOne(ctx,
+			primitive.M{"_id": r.id},
+			primitive.M{"$set": primitive.M{"slug": slug}},
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// uniqueSlug appends the smallest number that makes base unused, keeping the
+// result within the 100 character slug limit.
+func uniqueSlug(base string, taken map[string]bool) string {
+	const maxLen = 100
+	if len(base) > maxLen {
+		base = base[:maxLen]
+	}
+	if !taken[strings.ToLower(base)] {
+		return base
+	}
+	for n := 2; ; n++ {
+		suffix := fmt.Sprint(n)
+		head := base
+		if len(head)+len(suffix) > maxLen {
+			head = head[:maxLen-len(suffix)]
+		}
+		if slug := head + suffix; !taken[strings.ToLower(slug)] {
+			return slug
+		}
+	}
+}
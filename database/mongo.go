@@ -60,20 +60,240 @@ func GetCollection(name string) *mongo.Collection {
 
 func EnsureIndexes(db *mongo.Database) error {
 	ctx := context.Background()
-	users := db.Collection("users")
+	slugCollation := &options.Collation{Locale: "en", Strength: 2}
 
-	// Unique index on email
-	_, err := users.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys:    primitive.M{"email": 1},
-		Options: options.Index().SetUnique(true),
-	})
-	if err != nil {
-		return err
+	indexes := map[string][]mongo.IndexModel{
+		"users": {
+			// Unique index on email
+			{Keys: primitive.M{"email": 1}, Options: options.Index().SetUnique(true)},
+			// Optional: index on role for faster queries
+			{Keys: primitive.M{"role": 1}},
+			// Admin user listing sorts newest first
+			{Keys: primitive.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+		},
+		"vendors": {
+			// Case-insensitive unique slug; database.Migrate de-duplicates existing vendors
+			{
+				Keys: primitive.M{"slug": 1},
+				Options: options.Index().
+					SetUnique(true).
+					SetCollation(slugCollation).
+					SetPartialFilterExpression(primitive.M{"slug": primitive.M{"$gt": ""}}),
+			},
+			// Old slugs redirect to the vendor's current storefront
+			{Keys: primitive.M{"previous_slugs": 1}, Options: options.Index().SetCollation(slugCollation)},
+			// Public store directory: name search and the three sort orders
+			{Keys: primitive.D{{Key: "business_name", Value: "text"}}, Options: options.Index().SetName("business_name_text")},
+			{Keys: primitive.D{{Key: "status", Value: 1}, {Key: "rating_average", Value: -1}, {Key: "_id", Value: -1}}},
+			{Keys: primitive.D{{Key: "status", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}},
+			{Keys: primitive.D{{Key: "status", Value: 1}, {Key: "rating_count", Value: -1}, {Key: "_id", Value: -1}}},
+		},
+		"kyc_documents": {
+			// One current document per type per vendor
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "type", Value: 1}}, Options: options.Index().SetUnique(true)},
+			// Admin review queue
+			{Keys: primitive.D{{Key: "status", Value: 1}, {Key: "updated_at", Value: 1}}},
+		},
+		"vendor_reviews": {
+			// One review per buyer per vendor
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "buyer_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		"notifications": {
+			{Keys: primitive.D{{Key: "user_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		"shipping_profiles": {
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"products": {
+			// Vendor dashboard listing, optionally by status
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "status", Value: 1}, {Key: "updated_at", Value: -1}}},
+			// Public catalogue, newest first
+			{Keys: primitive.D{{Key: "status", Value: 1}, {Key: "published_at", Value: -1}, {Key: "_id", Value: -1}}},
+			// Category browsing matches anywhere in a product's category path
+			{Keys: primitive.D{{Key: "category_path", Value: 1}, {Key: "status", Value: 1}, {Key: "published_at", Value: -1}}},
+			{Keys: primitive.D{{Key: "category_id", Value: 1}}},
+			// Admin moderation queue, longest waiting first
+			{Keys: primitive.D{{Key: "moderation.state", Value: 1}, {Key: "moderation.updated_at", Value: 1}}},
+			// SKUs are unique per vendor across all their products' variants
+			{
+				Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "variants.sku", Value: 1}},
+				Options: options.Index().
+					SetUnique(true).
+					SetCollation(slugCollation).
+					SetPartialFilterExpression(primitive.M{"variants.sku": primitive.M{"$gt": ""}}),
+			},
+		},
+		"categories": {
+			{Keys: primitive.M{"slug": 1}, Options: options.Index().SetUnique(true).SetCollation(slugCollation)},
+			{Keys: primitive.M{"parent_id": 1}},
+			// Renames and moves rewrite every descendant
+			{Keys: primitive.M{"ancestors._id": 1}},
+		},
+		"search_documents": {
+			// Weights match the field weights of the in-memory engine.
+			{
+				Keys: primitive.D{
+					{Key: "title", Value: "text"},
+					{Key: "skus", Value: "text"},
+					{Key: "vendor_name", Value: "text"},
+					{Key: "attributes.value", Value: "text"},
+					{Key: "description", Value: "text"},
+				},
+				Options: options.Index().SetName("search_text").SetWeights(primitive.M{
+					"title":            10,
+					"skus":             5,
+					"vendor_name":      3,
+					"attributes.value": 2,
+					"description":      1,
+				}),
+			},
+			{Keys: primitive.D{{Key: "category_path", Value: 1}, {Key: "published_at", Value: -1}}},
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}}},
+			{Keys: primitive.D{{Key: "indexed_at", Value: 1}}},
+		},
+		"search_terms": {
+			{Keys: primitive.D{{Key: "length", Value: 1}}},
+		},
+		"inventory_items": {
+			{Keys: primitive.D{{Key: "variant_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "updated_at", Value: -1}}},
+		},
+		"inventory_reservations": {
+			// Expiry sweep
+			{Keys: primitive.D{{Key: "status", Value: 1}, {Key: "expires_at", Value: 1}}},
+			// Per-buyer limits on active reservations
+			{Keys: primitive.D{{Key: "owner_id", Value: 1}, {Key: "status", Value: 1}}},
+			// Releases that stopped part way, retried by the expiry sweep
+			{Keys: primitive.D{{Key: "updated_at", Value: 1}}, Options: options.Index().SetPartialFilterExpression(primitive.M{"return_pending": true})},
+			// Sales that stopped part way, retried by the expiry sweep
+			{Keys: primitive.D{{Key: "commit_pending", Value: 1}, {Key: "updated_at", Value: 1}}, Options: options.Index().SetPartialFilterExpression(primitive.M{"commit_pending": true})},
+		},
+		"inventory_adjustments": {
+			{Keys: primitive.D{{Key: "variant_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		"import_jobs": {
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			// Worker queue
+			{Keys: primitive.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}}},
+		},
+		"price_schedules": {
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "product_id", Value: 1}, {Key: "starts_at", Value: -1}}},
+			// Scheduler sweeps
+			{Keys: primitive.D{{Key: "status", Value: 1}, {Key: "starts_at", Value: 1}}},
+			{Keys: primitive.D{{Key: "status", Value: 1}, {Key: "ends_at", Value: 1}}},
+		},
+		"price_history": {
+			{Keys: primitive.D{{Key: "variant_id", Value: 1}, {Key: "recorded_at", Value: -1}}},
+			{Keys: primitive.D{{Key: "product_id", Value: 1}, {Key: "recorded_at", Value: 1}}},
+		},
+		"product_questions": {
+			{Keys: primitive.D{{Key: "product_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: primitive.D{{Key: "product_id", Value: 1}, {Key: "status", Value: 1}, {Key: "score", Value: -1}}},
+			// Vendor inbox, optionally unanswered only
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+			// Moderation queue
+			{Keys: primitive.D{{Key: "status", Value: 1}, {Key: "report_count", Value: -1}}},
+		},
+		"product_answers": {
+			{Keys: primitive.D{{Key: "question_id", Value: 1}, {Key: "status", Value: 1}, {Key: "official", Value: -1}, {Key: "score", Value: -1}}},
+			{Keys: primitive.D{{Key: "status", Value: 1}, {Key: "report_count", Value: -1}}},
+		},
+		// One vote and one report per user per post.
+		"qa_votes": {
+			{Keys: primitive.D{{Key: "kind", Value: 1}, {Key: "target_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: primitive.D{{Key: "target_id", Value: 1}}},
+		},
+		"qa_reports": {
+			{Keys: primitive.D{{Key: "kind", Value: 1}, {Key: "target_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: primitive.D{{Key: "target_id", Value: 1}}},
+		},
+		// A buyer's delivered lines are looked up when they review a product.
+		"delivered_order_lines": {
+			{Keys: primitive.D{{Key: "buyer_id", Value: 1}, {Key: "product_id", Value: 1}, {Key: "delivered_at", Value: 1}}},
+		},
+		"product_reviews": {
+			// One review per buyer per product
+			{Keys: primitive.D{{Key: "product_id", Value: 1}, {Key: "buyer_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: primitive.D{{Key: "product_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: primitive.D{{Key: "product_id", Value: 1}, {Key: "status", Value: 1}, {Key: "helpful_count", Value: -1}}},
+			// Vendor inbox, optionally unanswered only
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "status", Value: 1}, {Key: "created_at", Value: -1}}},
+			// Moderation queue
+			{Keys: primitive.D{{Key: "status", Value: 1}, {Key: "report_count", Value: -1}}},
+		},
+		// One vote and one report per user per review.
+		"product_review_votes": {
+			{Keys: primitive.D{{Key: "review_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"product_review_reports": {
+			{Keys: primitive.D{{Key: "review_id", Value: 1}, {Key: "user_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"exchange_rates": {
+			{Keys: primitive.D{{Key: "base", Value: 1}, {Key: "published_at", Value: -1}}},
+		},
+		"payout_accounts": {
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "currency", Value: 1}, {Key: "is_default", Value: -1}}},
+		},
+		"ledger_entries": {
+			// Makes recording idempotent: an order, refund or payout moves a balance once per entry type.
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "type", Value: 1}, {Key: "reference", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+			{Keys: primitive.D{{Key: "available_at", Value: 1}}},
+		},
+		"payouts": {
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "created_at", Value: -1}}},
+		},
+		"sales_events": {
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "order_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "day", Value: 1}}},
+			// Co-purchase window for recommendations
+			{Keys: primitive.D{{Key: "occurred_at", Value: 1}}},
+		},
+		"digital_assets": {
+			{Keys: primitive.D{{Key: "product_id", Value: 1}, {Key: "created_at", Value: 1}}},
+		},
+		"digital_entitlements": {
+			// Makes fulfilment idempotent: one entitlement per product per order.
+			{Keys: primitive.D{{Key: "order_id", Value: 1}, {Key: "product_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: primitive.D{{Key: "buyer_id", Value: 1}, {Key: "granted_at", Value: -1}}},
+		},
+		// Download limits rely on the counter being unique per file per purchase.
+		"digital_downloads": {
+			{Keys: primitive.D{{Key: "entitlement_id", Value: 1}, {Key: "asset_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+			{Keys: primitive.D{{Key: "asset_id", Value: 1}}},
+		},
+		// Recomputes drop whatever they didn't refresh.
+		"product_neighbours": {
+			{Keys: primitive.D{{Key: "computed_at", Value: 1}}},
+		},
+		// The rollup keys must be unique for the $merge rebuild to match on them.
+		"vendor_daily_sales": {
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "day", Value: 1}, {Key: "currency", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"vendor_daily_products": {
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "day", Value: 1}, {Key: "currency", Value: 1}, {Key: "product_id", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"storefront_daily_views": {
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "day", Value: 1}}, Options: options.Index().SetUnique(true)},
+		},
+		"storefront_daily_visitors": {
+			// One counted view per visitor per storefront per day
+			{Keys: primitive.D{{Key: "vendor_id", Value: 1}, {Key: "day", Value: 1}, {Key: "visitor", Value: 1}}, Options: options.Index().SetUnique(true)},
+			// Only today's visitors matter; older markers expire
+			{Keys: primitive.D{{Key: "created_at", Value: 1}}, Options: options.Index().SetExpireAfterSeconds(2 * 24 * 60 * 60)},
+		},
+		"payout_batches": {
+			// Only one batch may be running at a time.
+			{Keys: primitive.D{{Key: "status", Value: 1}}, Options: options.Index().SetUnique(true).SetPartialFilterExpression(primitive.M{"status": "running"})},
+			{Keys: primitive.D{{Key: "started_at", Value: -1}}},
+		},
 	}
 
-	// Optional: index on role for faster queries
-	_, err = users.Indexes().CreateOne(ctx, mongo.IndexModel{
-		Keys: primitive.M{"role": 1},
-	})
-	return err
-}
\ No newline at end of file
+	for collection, models := range indexes {
+		if _, err := db.Collection(collection).Indexes().CreateMany(ctx, models); err != nil {
+			return fmt.Errorf("failed to create %s indexes: %w", collection, err)
+		}
+	}
+	return nil
+}
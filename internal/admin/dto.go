@@ -0,0 +1,22 @@
+package admin
+
+import (
+	"time"
+
+	"github.com/techrook/23-market/internal/user"
+)
+
+type ListUsersQuery struct {
+	Page          int       `form:"page" binding:"omitempty,min=1"`
+	PageSize      int       `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Role          user.Role `form:"role" binding:"omitempty,oneof=vendor user admin"`
+	Verified      *bool     `form:"verified"`
+	Suspended     *bool     `form:"suspended"`
+	Email         string    `form:"email" binding:"omitempty,max=254"`
+	CreatedAfter  time.Time `form:"created_after" time_format:"2006-01-02"`
+	CreatedBefore time.Time `form:"created_before" time_format:"2006-01-02"`
+}
+
+type SuspendUserRequest struct {
+	Reason string `json:"reason" binding:"required,min=3,max=500"`
+}
@@ -0,0 +1,136 @@
+package admin
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/user"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	adminService Service
+}
+
+func NewHandler(adminService Service) *Handler {
+	return &Handler{
+		adminService: adminService,
+	}
+}
+
+func (h *Handler) ListUsers(c *gin.Context) {
+	var query ListUsersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = defaultPageSize
+	}
+
+	users, total, err := h.adminService.ListUsers(c.Request.Context(), query)
+	if err != nil {
+		response.InternalError(c, "Failed to list users", err, response.IsProduction(c))
+		return
+	}
+	response.Paginated(c, users, query.Page, query.PageSize, int(total), "Users retrieved successfully")
+}
+
+func (h *Handler) GetUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	u, err := h.adminService.GetUser(c.Request.Context(), userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to fetch user")
+		return
+	}
+	response.OK(c, u, "User retrieved successfully")
+}
+
+func (h *Handler) SuspendUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req SuspendUserRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	u, err := h.adminService.SuspendUser(c.Request.Context(), userID, req.Reason)
+	if err != nil {
+		h.handleError(c, err, "Failed to suspend user")
+		return
+	}
+	response.OK(c, u, "User suspended successfully")
+}
+
+func (h *Handler) UnsuspendUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	u, err := h.adminService.UnsuspendUser(c.Request.Context(), userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to unsuspend user")
+		return
+	}
+	response.OK(c, u, "User unsuspended successfully")
+}
+
+func (h *Handler) VerifyUser(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	u, err := h.adminService.VerifyUser(c.Request.Context(), userID)
+	if err != nil {
+		h.handleError(c, err, "Failed to verify user")
+		return
+	}
+	response.OK(c, u, "User verified successfully")
+}
+
+func (h *Handler) ForceLogout(c *gin.Context) {
+	userID, ok := userIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.adminService.ForceLogout(c.Request.Context(), userID); err != nil {
+		h.handleError(c, err, "Failed to log out user")
+		return
+	}
+	response.OK(c, nil, "User logged out of all sessions")
+}
+
+func (h *Handler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, user.ErrUserNotFound):
+		response.NotFound(c, "User", response.IsProduction(c))
+	case errors.Is(err, ErrCannotSuspendAdmin):
+		response.Forbidden(c, "Admin accounts cannot be suspended", response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func userIDParam(c *gin.Context) (primitive.ObjectID, bool) {
+	userID, err := primitive.ObjectIDFromHex(c.Param("userID"))
+	if err != nil {
+		response.BadRequest(c, "Invalid user ID", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
@@ -0,0 +1,116 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/techrook/23-market/internal/auth"
+	"github.com/techrook/23-market/internal/user"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultPageSize = 20
+)
+
+var (
+	ErrCannotSuspendAdmin = errors.New("admin accounts cannot be suspended")
+)
+
+type Service interface {
+	ListUsers(ctx context.Context, query ListUsersQuery) ([]user.UserResponse, int64, error)
+	GetUser(ctx context.Context, userID primitive.ObjectID) (*user.UserResponse, error)
+	SuspendUser(ctx context.Context, userID primitive.ObjectID, reason string) (*user.UserResponse, error)
+	UnsuspendUser(ctx context.Context, userID primitive.ObjectID) (*user.UserResponse, error)
+	VerifyUser(ctx context.Context, userID primitive.ObjectID) (*user.UserResponse, error)
+	ForceLogout(ctx context.Context, userID primitive.ObjectID) error
+}
+
+type service struct {
+	userRepo user.Repository
+	authRepo auth.Repository
+}
+
+func NewService(userRepo user.Repository, authRepo auth.Repository) Service {
+	return &service{
+		userRepo: userRepo,
+		authRepo: authRepo,
+	}
+}
+
+func (s *service) ListUsers(ctx context.Context, query ListUsersQuery) ([]user.UserResponse, int64, error) {
+	filter := user.ListFilter{
+		Role:         query.Role,
+		Verified:     query.Verified,
+		Suspended:    query.Suspended,
+		EmailPrefix:  query.Email,
+		CreatedAfter: query.CreatedAfter,
+	}
+	if !query.CreatedBefore.IsZero() {
+		// created_before is a calendar date, so include the whole day.
+		filter.CreatedBefore = query.CreatedBefore.Add(24 * time.Hour)
+	}
+
+	users, total, err := s.userRepo.List(ctx, filter, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := make([]user.UserResponse, 0, len(users))
+	for i := range users {
+		resp = append(resp, users[i].ToResponse())
+	}
+	return resp, total, nil
+}
+
+func (s *service) GetUser(ctx context.Context, userID primitive.ObjectID) (*user.UserResponse, error) {
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	resp := u.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) SuspendUser(ctx context.Context, userID primitive.ObjectID, reason string) (*user.UserResponse, error) {
+	u, err := s.userRepo.FindByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u.Role == user.RoleAdmin {
+		return nil, ErrCannotSuspendAdmin
+	}
+
+	if err := s.userRepo.SetSuspended(ctx, userID, true, reason); err != nil {
+		return nil, err
+	}
+	if err := s.authRepo.DeleteAllUserRefreshTokens(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.GetUser(ctx, userID)
+}
+
+func (s *service) UnsuspendUser(ctx context.Context, userID primitive.ObjectID) (*user.UserResponse, error) {
+	if err := s.userRepo.SetSuspended(ctx, userID, false, ""); err != nil {
+		return nil, err
+	}
+	return s.GetUser(ctx, userID)
+}
+
+func (s *service) VerifyUser(ctx context.Context, userID primitive.ObjectID) (*user.UserResponse, error) {
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	if err := s.userRepo.Verify(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.GetUser(ctx, userID)
+}
+
+func (s *service) ForceLogout(ctx context.Context, userID primitive.ObjectID) error {
+	if _, err := s.userRepo.FindByID(ctx, userID); err != nil {
+		return err
+	}
+	return s.authRepo.DeleteAllUserRefreshTokens(ctx, userID)
+}
@@ -0,0 +1,105 @@
+package analytics
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Bucket string
+
+const (
+	DayBucket   Bucket = "day"
+	WeekBucket  Bucket = "week"
+	MonthBucket Bucket = "month"
+)
+
+// MaxRange bounds how many days a single report may cover.
+const MaxRange = 366
+
+type SaleItem struct {
+	ProductID primitive.ObjectID `bson:"product_id"`
+	Title     string             `bson:"title"`
+	Quantity  int64              `bson:"quantity"`
+	Amount    int64              `bson:"amount"`
+}
+
+// Sale is one vendor's share of a paid order. Day is the calendar date in the
+// vendor's timezone at the time of sale, stored as UTC midnight, so reports
+// line up with the vendor's own days.
+type Sale struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	VendorID   primitive.ObjectID `bson:"vendor_id"`
+	OrderID    primitive.ObjectID `bson:"order_id"`
+	BuyerID    primitive.ObjectID `bson:"buyer_id"`
+	Currency   string             `bson:"currency"`
+	Total      int64              `bson:"total"`
+	Items      []SaleItem         `bson:"items"`
+	Day        time.Time          `bson:"day"`
+	OccurredAt time.Time          `bson:"occurred_at"`
+}
+
+// Period is one bucket of a sales series.
+type Period struct {
+	Start   time.Time `bson:"_id"`
+	Revenue int64     `bson:"revenue"`
+	Orders  int64     `bson:"orders"`
+	Views   int64     `bson:"views"`
+}
+
+type ProductStats struct {
+	ProductID primitive.ObjectID `bson:"_id"`
+	Title     string             `bson:"title"`
+	Quantity  int64              `bson:"quantity"`
+	Revenue   int64              `bson:"revenue"`
+}
+
+// CoPurchase is another product bought in the same orders, and how many
+// orders they shared.
+type CoPurchase struct {
+	ProductID primitive.ObjectID `bson:"product_id"`
+	Orders    int64              `bson:"orders"`
+}
+
+// ProductCoPurchases lists what was bought alongside one product, most shared
+// orders first.
+type ProductCoPurchases struct {
+	ProductID primitive.ObjectID `bson:"_id"`
+	With      []CoPurchase       `bson:"with"`
+}
+
+type CustomerStats struct {
+	Customers       int64 `bson:"customers"`
+	RepeatCustomers int64 `bson:"repeat_customers"`
+}
+
+// calendarDay maps t to its date in loc, expressed as UTC midnight.
+func calendarDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+func ratio(numerator, denominator int64) float64 {
+	if denominator == 0 {
+		return 0
+	}
+	return float64(numerator) / float64(denominator)
+}
+
+func average(total, count int64) int64 {
+	if count == 0 {
+		return 0
+	}
+	return total / count
+}
+
+func (p *Period) toResponse() PeriodResponse {
+	return PeriodResponse{
+		PeriodStart:       p.Start.Format("2006-01-02"),
+		Revenue:           p.Revenue,
+		Orders:            p.Orders,
+		AverageOrderValue: average(p.Revenue, p.Orders),
+		Views:             p.Views,
+		ConversionRate:    ratio(p.Orders, p.Views),
+	}
+}
@@ -0,0 +1,101 @@
+package analytics
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReportQuery dates are calendar dates in the vendor's timezone, both inclusive.
+// Currency is only needed when the vendor has sold in more than one currency.
+type ReportQuery struct {
+	From     time.Time `form:"from" binding:"required" time_format:"2006-01-02"`
+	To       time.Time `form:"to" binding:"required" time_format:"2006-01-02"`
+	Bucket   Bucket    `form:"bucket" binding:"omitempty,oneof=day week month"`
+	Currency string    `form:"currency" binding:"omitempty,len=3,uppercase"`
+	Limit    int       `form:"limit" binding:"omitempty,min=1,max=50"`
+}
+
+type PeriodResponse struct {
+	PeriodStart       string  `json:"period_start"`
+	Revenue           int64   `json:"revenue"`
+	Orders            int64   `json:"orders"`
+	AverageOrderValue int64   `json:"average_order_value"`
+	Views             int64   `json:"views"`
+	ConversionRate    float64 `json:"conversion_rate"`
+}
+
+type SummaryResponse struct {
+	From               string           `json:"from"`
+	To                 string           `json:"to"`
+	Bucket             string           `json:"bucket"`
+	Currency           string           `json:"currency"`
+	Revenue            int64            `json:"revenue"`
+	Orders             int64            `json:"orders"`
+	AverageOrderValue  int64            `json:"average_order_value"`
+	Views              int64            `json:"views"`
+	ConversionRate     float64          `json:"conversion_rate"`
+	Customers          int64            `json:"customers"`
+	RepeatCustomers    int64            `json:"repeat_customers"`
+	RepeatCustomerRate float64          `json:"repeat_customer_rate"`
+	Series             []PeriodResponse `json:"series"`
+}
+
+type ProductResponse struct {
+	ProductID string `json:"product_id"`
+	Title     string `json:"title"`
+	Quantity  int64  `json:"quantity"`
+	Revenue   int64  `json:"revenue"`
+}
+
+type RebuildResponse struct {
+	SalesDays   int64 `json:"sales_days"`
+	ProductDays int64 `json:"product_days"`
+}
+
+// RecordSaleRequest is one vendor's share of a paid order, as reported by the
+// order flow. Amounts are in minor units of Currency.
+type RecordSaleRequest struct {
+	VendorID   string                  `json:"vendor_id" binding:"required"`
+	OrderID    string                  `json:"order_id" binding:"required"`
+	BuyerID    string                  `json:"buyer_id" binding:"required"`
+	Currency   string                  `json:"currency" binding:"required,len=3,uppercase"`
+	Total      int64                   `json:"total" binding:"required,min=1"`
+	Items      []RecordSaleItemRequest `json:"items" binding:"required,min=1,dive"`
+	OccurredAt time.Time               `json:"occurred_at"`
+}
+
+type RecordSaleItemRequest struct {
+	ProductID string `json:"product_id" binding:"required"`
+	Title     string `json:"title" binding:"required"`
+	Quantity  int64  `json:"quantity" binding:"required,min=1"`
+	Amount    int64  `json:"amount" binding:"min=0"`
+}
+
+func (r RecordSaleRequest) toSale() (Sale, error) {
+	var ids [3]primitive.ObjectID
+	for i, hex := range []string{r.VendorID, r.OrderID, r.BuyerID} {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return Sale{}, ErrInvalidSale
+		}
+		ids[i] = id
+	}
+	sale := Sale{
+		VendorID:   ids[0],
+		OrderID:    ids[1],
+		BuyerID:    ids[2],
+		Currency:   r.Currency,
+		Total:      r.Total,
+		Items:      make([]SaleItem, 0, len(r.Items)),
+		OccurredAt: r.OccurredAt,
+	}
+	for _, it := range r.Items {
+		productID, err := primitive.ObjectIDFromHex(it.ProductID)
+		if err != nil {
+			return Sale{}, ErrInvalidSale
+		}
+		sale.Items = append(sale.Items, SaleItem{ProductID: productID, Title: it.Title, Quantity: it.Quantity, Amount: it.Amount})
+	}
+	return sale, nil
+}
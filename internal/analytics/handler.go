@@ -0,0 +1,141 @@
+package analytics
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	analyticsService Service
+}
+
+func NewHandler(analyticsService Service) *Handler {
+	return &Handler{
+		analyticsService: analyticsService,
+	}
+}
+
+// TrackStorefrontViews counts a view for every storefront the wrapped handler
+// serves successfully, once per visitor per day. Redirects and misses are not
+// counted.
+func (h *Handler) TrackStorefrontViews() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if c.Writer.Status() != http.StatusOK {
+			return
+		}
+		hexID := c.GetString(vendor.StorefrontVendorIDKey)
+		vendorID, err := primitive.ObjectIDFromHex(hexID)
+		if err != nil {
+			return
+		}
+		if err := h.analyticsService.RecordStorefrontView(c.Request.Context(), vendorID, visitorKey(c)); err != nil {
+			log.Printf("⚠️ failed to record storefront view for vendor %s: %v", hexID, err)
+		}
+	}
+}
+
+// visitorKey identifies an anonymous visitor by client IP and user agent. Only
+// a hash is stored so the raw address never reaches the database.
+func visitorKey(c *gin.Context) string {
+	sum := sha256.Sum256([]byte(c.ClientIP() + "|" + c.Request.UserAgent()))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (h *Handler) Summary(c *gin.Context) {
+	userID, query, ok := bindReport(c)
+	if !ok {
+		return
+	}
+
+	summary, err := h.analyticsService.Summary(c.Request.Context(), userID, query)
+	if err != nil {
+		handleError(c, err, "Failed to compute sales analytics")
+		return
+	}
+	response.OK(c, summary, "Sales analytics retrieved successfully")
+}
+
+func (h *Handler) TopProducts(c *gin.Context) {
+	userID, query, ok := bindReport(c)
+	if !ok {
+		return
+	}
+
+	products, err := h.analyticsService.TopProducts(c.Request.Context(), userID, query)
+	if err != nil {
+		handleError(c, err, "Failed to compute top products")
+		return
+	}
+	response.OK(c, products, "Top products retrieved successfully")
+}
+
+// RecordSale takes a paid order's share for one vendor from the order flow.
+// Reporting the same order again is a no-op.
+func (h *Handler) RecordSale(c *gin.Context) {
+	var req RecordSaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	sale, err := req.toSale()
+	if err == nil {
+		err = h.analyticsService.RecordSale(c.Request.Context(), sale)
+	}
+	if err != nil {
+		handleError(c, err, "Failed to record sale")
+		return
+	}
+	response.OK(c, nil, "Sale recorded")
+}
+
+func (h *Handler) RebuildRollups(c *gin.Context) {
+	result, err := h.analyticsService.RebuildRollups(c.Request.Context())
+	if err != nil {
+		response.InternalError(c, "Failed to rebuild analytics rollups", err, response.IsProduction(c))
+		return
+	}
+	response.OK(c, result, "Analytics rollups rebuilt")
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, vendor.ErrVendorNotFound):
+		response.NotFound(c, "Vendor", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotApproved):
+		response.Forbidden(c, "Only approved vendors can view analytics", response.IsProduction(c))
+	case errors.Is(err, ErrInvalidRange), errors.Is(err, ErrCurrencyRequired), errors.Is(err, ErrInvalidSale):
+		response.BadRequest(c, err.Error(), nil, response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func bindReport(c *gin.Context) (primitive.ObjectID, ReportQuery, bool) {
+	var query ReportQuery
+	val, exists := c.Get("subjectID")
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, query, false
+	}
+	userID, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, query, false
+	}
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return primitive.NilObjectID, query, false
+	}
+	return userID, query, true
+}
@@ -0,0 +1,342 @@
+package analytics
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	InsertSale(ctx context.Context, sale *Sale) error
+	// IncrementRollups adds a sale to the daily sales and product rollups.
+	IncrementRollups(ctx context.Context, sale *Sale) error
+	IncrementViews(ctx context.Context, vendorID primitive.ObjectID, day time.Time) error
+	// RecordVisitor notes that visitor saw the storefront on day and reports
+	// whether it is their first view that day.
+	RecordVisitor(ctx context.Context, vendorID primitive.ObjectID, day time.Time, visitor string) (bool, error)
+
+	Currencies(ctx context.Context, vendorID primitive.ObjectID, from, to time.Time) ([]string, error)
+	Series(ctx context.Context, vendorID primitive.ObjectID, currency string, from, to time.Time, bucket Bucket) ([]Period, error)
+	TopProducts(ctx context.Context, vendorID primitive.ObjectID, currency string, from, to time.Time, limit int) ([]ProductStats, error)
+	CustomerStats(ctx context.Context, vendorID primitive.ObjectID, currency string, from, to time.Time) (CustomerStats, error)
+	// CoPurchases pairs up products bought in the same order since the given
+	// time, across vendors. Pairs sharing fewer than minOrders orders are
+	// dropped and each product keeps its perProduct most shared.
+	CoPurchases(ctx context.Context, since time.Time, minOrders int64, perProduct int) ([]ProductCoPurchases, error)
+
+	// RebuildRollups recomputes the daily sales and product rollups from the
+	// raw sales events, repairing any drift from failed increments.
+	RebuildRollups(ctx context.Context) (int64, int64, error)
+}
+
+type AnalyticsRepository struct {
+	sales         *mongo.Collection
+	dailySales    *mongo.Collection
+	dailyProducts *mongo.Collection
+	dailyViews    *mongo.Collection
+	dailyVisitors *mongo.Collection
+}
+
+func NewAnalyticsRepository(db *mongo.Database) Repository {
+	return &AnalyticsRepository{
+		sales:         db.Collection("sales_events"),
+		dailySales:    db.Collection("vendor_daily_sales"),
+		dailyProducts: db.Collection("vendor_daily_products"),
+		dailyViews:    db.Collection("storefront_daily_views"),
+		dailyVisitors: db.Collection("storefront_daily_visitors"),
+	}
+}
+
+func (r *AnalyticsRepository) InsertSale(ctx context.Context, sale *Sale) error {
+	if _, err := r.sales.InsertOne(ctx, sale); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateSale
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *AnalyticsRepository) IncrementRollups(ctx context.Context, sale *Sale) error {
+	_, err := r.dailySales.UpdateOne(
+		ctx,
+		bson.M{"vendor_id": sale.VendorID, "day": sale.Day, "currency": sale.Currency},
+		bson.M{"$inc": bson.M{"orders": 1, "revenue": sale.Total}},
+		options.Update().SetUpsert(true),
+	)
+	if err != nil || len(sale.Items) == 0 {
+		return err
+	}
+
+	models := make([]mongo.WriteModel, 0, len(sale.Items))
+	for _, item := range sale.Items {
+		models = append(models, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{
+				"vendor_id":  sale.VendorID,
+				"day":        sale.Day,
+				"currency":   sale.Currency,
+				"product_id": item.ProductID,
+			}).
+			SetUpdate(bson.M{
+				"$inc": bson.M{"quantity": item.Quantity, "revenue": item.Amount},
+				"$set": bson.M{"title": item.Title},
+			}).
+			SetUpsert(true))
+	}
+	_, err = r.dailyProducts.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (r *AnalyticsRepository) IncrementViews(ctx context.Context, vendorID primitive.ObjectID, day time.Time) error {
+	_, err := r.dailyViews.UpdateOne(
+		ctx,
+		bson.M{"vendor_id": vendorID, "day": day},
+		bson.M{"$inc": bson.M{"views": 1}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *AnalyticsRepository) RecordVisitor(ctx context.Context, vendorID primitive.ObjectID, day time.Time, visitor string) (bool, error) {
+	_, err := r.dailyVisitors.InsertOne(ctx, bson.M{
+		"vendor_id":  vendorID,
+		"day":        day,
+		"visitor":    visitor,
+		"created_at": time.Now(),
+	})
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *AnalyticsRepository) Currencies(ctx context.Context, vendorID primitive.ObjectID, from, to time.Time) ([]string, error) {
+	values, err := r.dailySales.Distinct(ctx, "currency", rangeFilter(vendorID, from, to))
+	if err != nil {
+		return nil, err
+	}
+	currencies := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok {
+			currencies = append(currencies, s)
+		}
+	}
+	return currencies, nil
+}
+
+// Series buckets the daily sales rollups and the daily view counts together in
+// one pipeline; days are already vendor-local, so truncation runs in UTC.
+func (r *AnalyticsRepository) Series(ctx context.Context, vendorID primitive.ObjectID, currency string, from, to time.Time, bucket Bucket) ([]Period, error) {
+	period := bson.M{"$dateTrunc": bson.M{"date": "$day", "unit": string(bucket), "startOfWeek": "monday"}}
+
+	salesFilter := rangeFilter(vendorID, from, to)
+	salesFilter["currency"] = currency
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: salesFilter}},
+		{{Key: "$project", Value: bson.M{"period": period, "revenue": 1, "orders": 1, "views": bson.M{"$literal": 0}}}},
+		{{Key: "$unionWith", Value: bson.M{
+			"coll": r.dailyViews.Name(),
+			"pipeline": bson.A{
+				bson.M{"$match": rangeFilter(vendorID, from, to)},
+				bson.M{"$project": bson.M{"period": period, "revenue": bson.M{"$literal": 0}, "orders": bson.M{"$literal": 0}, "views": 1}},
+			},
+		}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":     "$period",
+			"revenue": bson.M{"$sum": "$revenue"},
+			"orders":  bson.M{"$sum": "$orders"},
+			"views":   bson.M{"$sum": "$views"},
+		}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+
+	cursor, err := r.dailySales.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	periods := []Period{}
+	if err := cursor.All(ctx, &periods); err != nil {
+		return nil, err
+	}
+	return periods, nil
+}
+
+func (r *AnalyticsRepository) TopProducts(ctx context.Context, vendorID primitive.ObjectID, currency string, from, to time.Time, limit int) ([]ProductStats, error) {
+	filter := rangeFilter(vendorID, from, to)
+	filter["currency"] = currency
+
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.M{"day": 1}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$product_id",
+			"title":    bson.M{"$last": "$title"},
+			"quantity": bson.M{"$sum": "$quantity"},
+			"revenue":  bson.M{"$sum": "$revenue"},
+		}}},
+		{{Key: "$sort", Value: bson.D{{Key: "revenue", Value: -1}, {Key: "quantity", Value: -1}, {Key: "_id", Value: 1}}}},
+		{{Key: "$limit", Value: limit}},
+	}
+
+	cursor, err := r.dailyProducts.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	products := []ProductStats{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+// CustomerStats counts buyers who ordered in the currency in the range and, of
+// those, how many have ordered from the vendor in it more than once up to the
+// end of the range, so the counts line up with the revenue they sit next to.
+func (r *AnalyticsRepository) CustomerStats(ctx context.Context, vendorID primitive.ObjectID, currency string, from, to time.Time) (CustomerStats, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"vendor_id": vendorID, "currency": currency, "day": bson.M{"$lte": to}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      "$buyer_id",
+			"orders":   bson.M{"$sum": 1},
+			"in_range": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{"$day", from}}, 1, 0}}},
+		}}},
+		{{Key: "$match", Value: bson.M{"in_range": bson.M{"$gt": 0}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":              nil,
+			"customers":        bson.M{"$sum": 1},
+			"repeat_customers": bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$gte": bson.A{"$orders", 2}}, 1, 0}}},
+		}}},
+	}
+
+	cursor, err := r.sales.Aggregate(ctx, pipeline)
+	if err != nil {
+		return CustomerStats{}, err
+	}
+	var stats []CustomerStats
+	if err := cursor.All(ctx, &stats); err != nil {
+		return CustomerStats{}, err
+	}
+	if len(stats) == 0 {
+		return CustomerStats{}, nil
+	}
+	return stats[0], nil
+}
+
+// maxBasketSize leaves very large orders out of co-purchase counts: bulk and
+// wholesale baskets say little about which items go together and add pairs
+// quadratically.
+const maxBasketSize = 50
+
+func (r *AnalyticsRepository) CoPurchases(ctx context.Context, since time.Time, minOrders int64, perProduct int) ([]ProductCoPurchases, error) {
+	basketSize := bson.M{"$size": "$products"}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"occurred_at": bson.M{"$gte": since}}}},
+		{{Key: "$unwind", Value: "$items"}},
+		// A sale is one vendor's share of an order; regroup by order.
+		{{Key: "$group", Value: bson.M{"_id": "$order_id", "products": bson.M{"$addToSet": "$items.product_id"}}}},
+		{{Key: "$match", Value: bson.M{"$expr": bson.M{"$and": bson.A{
+			bson.M{"$gte": bson.A{basketSize, 2}},
+			bson.M{"$lte": bson.A{basketSize, maxBasketSize}},
+		}}}}},
+		{{Key: "$project", Value: bson.M{"a": "$products", "b": "$products"}}},
+		{{Key: "$unwind", Value: "$a"}},
+		{{Key: "$unwind", Value: "$b"}},
+		{{Key: "$match", Value: bson.M{"$expr": bson.M{"$ne": bson.A{"$a", "$b"}}}}},
+		{{Key: "$group", Value: bson.M{"_id": bson.M{"a": "$a", "b": "$b"}, "orders": bson.M{"$sum": 1}}}},
+		{{Key: "$match", Value: bson.M{"orders": bson.M{"$gte": minOrders}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "orders", Value: -1}, {Key: "_id.b", Value: 1}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":  "$_id.a",
+			"with": bson.M{"$push": bson.M{"product_id": "$_id.b", "orders": "$orders"}},
+		}}},
+		{{Key: "$project", Value: bson.M{"with": bson.M{"$slice": bson.A{"$with", perProduct}}}}},
+	}
+
+	cursor, err := r.sales.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, err
+	}
+	var products []ProductCoPurchases
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *AnalyticsRepository) RebuildRollups(ctx context.Context) (int64, int64, error) {
+	salesPipeline := mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":     bson.M{"vendor_id": "$vendor_id", "day": "$day", "currency": "$currency"},
+			"orders":  bson.M{"$sum": 1},
+			"revenue": bson.M{"$sum": "$total"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":       0,
+			"vendor_id": "$_id.vendor_id",
+			"day":       "$_id.day",
+			"currency":  "$_id.currency",
+			"orders":    1,
+			"revenue":   1,
+		}}},
+		mergeStage(r.dailySales.Name(), "vendor_id", "day", "currency"),
+	}
+	if _, err := r.sales.Aggregate(ctx, salesPipeline); err != nil {
+		return 0, 0, err
+	}
+
+	productPipeline := mongo.Pipeline{
+		{{Key: "$unwind", Value: "$items"}},
+		{{Key: "$sort", Value: bson.M{"occurred_at": 1}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":      bson.M{"vendor_id": "$vendor_id", "day": "$day", "currency": "$currency", "product_id": "$items.product_id"},
+			"title":    bson.M{"$last": "$items.title"},
+			"quantity": bson.M{"$sum": "$items.quantity"},
+			"revenue":  bson.M{"$sum": "$items.amount"},
+		}}},
+		{{Key: "$project", Value: bson.M{
+			"_id":        0,
+			"vendor_id":  "$_id.vendor_id",
+			"day":        "$_id.day",
+			"currency":   "$_id.currency",
+			"product_id": "$_id.product_id",
+			"title":      1,
+			"quantity":   1,
+			"revenue":    1,
+		}}},
+		mergeStage(r.dailyProducts.Name(), "vendor_id", "day", "currency", "product_id"),
+	}
+	if _, err := r.sales.Aggregate(ctx, productPipeline); err != nil {
+		return 0, 0, err
+	}
+
+	salesDays, err := r.dailySales.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return 0, 0, err
+	}
+	productDays, err := r.dailyProducts.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return 0, 0, err
+	}
+	return salesDays, productDays, nil
+}
+
+func mergeStage(into string, on ...string) bson.D {
+	return bson.D{{Key: "$merge", Value: bson.M{
+		"into":           into,
+		"on":             on,
+		"whenMatched":    "replace",
+		"whenNotMatched": "insert",
+	}}}
+}
+
+func rangeFilter(vendorID primitive.ObjectID, from, to time.Time) bson.M {
+	return bson.M{"vendor_id": vendorID, "day": bson.M{"$gte": from, "$lte": to}}
+}
@@ -0,0 +1,213 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/techrook/23-market/internal/vendor"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const defaultTopProducts = 10
+
+var (
+	ErrDuplicateSale    = errors.New("sale already recorded")
+	ErrInvalidSale      = errors.New("invalid sale")
+	ErrInvalidRange     = errors.New("invalid date range")
+	ErrCurrencyRequired = errors.New("vendor has sales in several currencies")
+)
+
+// Recorder is the hook the order flow uses to feed analytics once an order is
+// paid. Recording the same order twice is a no-op.
+type Recorder interface {
+	RecordSale(ctx context.Context, sale Sale) error
+	// RecordStorefrontView counts a storefront view at most once per visitor
+	// per vendor-local day.
+	RecordStorefrontView(ctx context.Context, vendorID primitive.ObjectID, visitor string) error
+}
+
+type Service interface {
+	Recorder
+
+	Summary(ctx context.Context, userID primitive.ObjectID, query ReportQuery) (*SummaryResponse, error)
+	TopProducts(ctx context.Context, userID primitive.ObjectID, query ReportQuery) ([]ProductResponse, error)
+	RebuildRollups(ctx context.Context) (*RebuildResponse, error)
+}
+
+type service struct {
+	analyticsRepo Repository
+	vendorRepo    vendor.Repository
+}
+
+func NewService(analyticsRepo Repository, vendorRepo vendor.Repository) Service {
+	return &service{
+		analyticsRepo: analyticsRepo,
+		vendorRepo:    vendorRepo,
+	}
+}
+
+func (s *service) RecordSale(ctx context.Context, sale Sale) error {
+	if sale.Total <= 0 || len(sale.Currency) != 3 {
+		return ErrInvalidSale
+	}
+	v, err := s.vendorRepo.GetVendorByID(ctx, sale.VendorID)
+	if err != nil {
+		return err
+	}
+
+	if sale.OccurredAt.IsZero() {
+		sale.OccurredAt = time.Now()
+	}
+	sale.ID = primitive.NewObjectID()
+	sale.Day = calendarDay(sale.OccurredAt, v.Location())
+
+	if err := s.analyticsRepo.InsertSale(ctx, &sale); err != nil {
+		if errors.Is(err, ErrDuplicateSale) {
+			return nil
+		}
+		return err
+	}
+	// A failed increment leaves the rollups behind the raw events; the
+	// rebuild job brings them back in line.
+	return s.analyticsRepo.IncrementRollups(ctx, &sale)
+}
+
+func (s *service) RecordStorefrontView(ctx context.Context, vendorID primitive.ObjectID, visitor string) error {
+	v, err := s.vendorRepo.GetVendorByID(ctx, vendorID)
+	if err != nil {
+		return err
+	}
+	day := calendarDay(time.Now(), v.Location())
+	first, err := s.analyticsRepo.RecordVisitor(ctx, v.ID, day, visitor)
+	if err != nil || !first {
+		return err
+	}
+	return s.analyticsRepo.IncrementViews(ctx, v.ID, day)
+}
+
+func (s *service) Summary(ctx context.Context, userID primitive.ObjectID, query ReportQuery) (*SummaryResponse, error) {
+	v, from, to, err := s.reportScope(ctx, userID, query)
+	if err != nil {
+		return nil, err
+	}
+	currency, err := s.reportCurrency(ctx, v.ID, from, to, query.Currency)
+	if err != nil {
+		return nil, err
+	}
+	bucket := query.Bucket
+	if bucket == "" {
+		bucket = DayBucket
+	}
+
+	periods, err := s.analyticsRepo.Series(ctx, v.ID, currency, from, to, bucket)
+	if err != nil {
+		return nil, err
+	}
+	customers, err := s.analyticsRepo.CustomerStats(ctx, v.ID, currency, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &SummaryResponse{
+		From:               from.Format("2006-01-02"),
+		To:                 to.Format("2006-01-02"),
+		Bucket:             string(bucket),
+		Currency:           currency,
+		Customers:          customers.Customers,
+		RepeatCustomers:    customers.RepeatCustomers,
+		RepeatCustomerRate: ratio(customers.RepeatCustomers, customers.Customers),
+		Series:             make([]PeriodResponse, 0, len(periods)),
+	}
+	for i := range periods {
+		resp.Revenue += periods[i].Revenue
+		resp.Orders += periods[i].Orders
+		resp.Views += periods[i].Views
+		resp.Series = append(resp.Series, periods[i].toResponse())
+	}
+	resp.AverageOrderValue = average(resp.Revenue, resp.Orders)
+	resp.ConversionRate = ratio(resp.Orders, resp.Views)
+	return resp, nil
+}
+
+func (s *service) TopProducts(ctx context.Context, userID primitive.ObjectID, query ReportQuery) ([]ProductResponse, error) {
+	v, from, to, err := s.reportScope(ctx, userID, query)
+	if err != nil {
+		return nil, err
+	}
+	currency, err := s.reportCurrency(ctx, v.ID, from, to, query.Currency)
+	if err != nil {
+		return nil, err
+	}
+	limit := query.Limit
+	if limit == 0 {
+		limit = defaultTopProducts
+	}
+
+	products, err := s.analyticsRepo.TopProducts(ctx, v.ID, currency, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]ProductResponse, 0, len(products))
+	for _, p := range products {
+		resp = append(resp, ProductResponse{
+			ProductID: p.ProductID.Hex(),
+			Title:     p.Title,
+			Quantity:  p.Quantity,
+			Revenue:   p.Revenue,
+		})
+	}
+	return resp, nil
+}
+
+func (s *service) RebuildRollups(ctx context.Context) (*RebuildResponse, error) {
+	salesDays, productDays, err := s.analyticsRepo.RebuildRollups(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &RebuildResponse{SalesDays: salesDays, ProductDays: productDays}, nil
+}
+
+// reportScope resolves the caller's vendor, which must be approved, and
+// normalises the requested dates to the UTC-midnight form the rollups are
+// keyed by.
+func (s *service) reportScope(ctx context.Context, userID primitive.ObjectID, query ReportQuery) (*vendor.Vendor, time.Time, time.Time, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, time.Time{}, time.Time{}, err
+	}
+	if !v.CanSell() {
+		return nil, time.Time{}, time.Time{}, vendor.ErrVendorNotApproved
+	}
+
+	from := calendarDay(query.From, query.From.Location())
+	to := calendarDay(query.To, query.To.Location())
+	if to.Before(from) {
+		return nil, time.Time{}, time.Time{}, fmt.Errorf("%w: to is before from", ErrInvalidRange)
+	}
+	if to.Sub(from) >= MaxRange*24*time.Hour {
+		return nil, time.Time{}, time.Time{}, fmt.Errorf("%w: at most %d days per report", ErrInvalidRange, MaxRange)
+	}
+	return v, from, to, nil
+}
+
+// reportCurrency falls back to the only currency the vendor sold in during the
+// range; with no sales the report is empty anyway.
+func (s *service) reportCurrency(ctx context.Context, vendorID primitive.ObjectID, from, to time.Time, requested string) (string, error) {
+	if requested != "" {
+		return requested, nil
+	}
+	currencies, err := s.analyticsRepo.Currencies(ctx, vendorID, from, to)
+	if err != nil {
+		return "", err
+	}
+	switch len(currencies) {
+	case 0:
+		return "", nil
+	case 1:
+		return currencies[0], nil
+	default:
+		return "", fmt.Errorf("%w: %v", ErrCurrencyRequired, currencies)
+	}
+}
@@ -10,6 +10,9 @@ type Config struct {
 	JWTExpiry          time.Duration
 	RefreshTokenExpiry time.Duration
 	RefreshTokenPrefix string 
+	// ServiceToken authenticates internal calls from other services, such as
+	// the order flow. Internal routes are closed while it is empty.
+	ServiceToken string
 }
 
 func LoadConfig() *Config {
@@ -18,6 +21,7 @@ func LoadConfig() *Config {
 		JWTExpiry:          15 * time.Minute,
 		RefreshTokenExpiry: 7 * 24 * time.Hour,
 		RefreshTokenPrefix: "rt_",
+		ServiceToken:       getEnv("INTERNAL_API_TOKEN", ""),
 	}
 }
 
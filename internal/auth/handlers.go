@@ -75,6 +75,10 @@ func (h *Handler) Login(c *gin.Context) {
 			response.Unauthorized(c, "Invalid email or password", response.IsProduction(c))
 			return
 		}
+		if errors.Is(err, ErrAccountSuspended) {
+			response.Forbidden(c, "Account suspended", response.IsProduction(c))
+			return
+		}
 		response.InternalError(c, "Login failed", err, response.IsProduction(c))
 		return
 	}
@@ -110,6 +114,10 @@ func (h *Handler) Refresh(c *gin.Context) {
 			response.Unauthorized(c, "Session expired, please login again", response.IsProduction(c))
 			return
 		}
+		if errors.Is(err, ErrAccountSuspended) {
+			response.Forbidden(c, "Account suspended", response.IsProduction(c))
+			return
+		}
 		response.InternalError(c, "Token refresh failed", err, response.IsProduction(c))
 		return
 	}
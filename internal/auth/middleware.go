@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"strings"
 
@@ -66,4 +67,22 @@ func RequireRole(allowedRoles ...user.Role) gin.HandlerFunc {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Insufficient permissions"})
 		c.Abort()
 	}
-}
\ No newline at end of file
+}
+
+// ServiceTokenHeader carries the shared token of internal callers.
+const ServiceTokenHeader = "X-Service-Token"
+
+// RequireServiceToken admits internal callers presenting the configured
+// ServiceToken. There are no end users behind these calls, so nothing is set
+// on the context.
+func RequireServiceToken(cfg *Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := c.GetHeader(ServiceTokenHeader)
+		if cfg.ServiceToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(cfg.ServiceToken)) != 1 {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid service token"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/user"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SelfAlias can be used in place of the caller's own ID in subject path parameters.
+const SelfAlias = "me"
+
+// RequireSelfOrAdmin resolves the record owner named by the given path parameter
+// and stores it as "subjectID". Callers may act on their own records (by ID or
+// the "me" alias); admins may act on anyone's. Routes without the parameter
+// resolve to the caller. Must run after AuthMiddleware.
+func RequireSelfOrAdmin(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		callerVal, exists := c.Get("userID")
+		if !exists {
+			response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+			c.Abort()
+			return
+		}
+		callerID, ok := callerVal.(primitive.ObjectID)
+		if !ok {
+			response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+			c.Abort()
+			return
+		}
+
+		raw := c.Param(param)
+		if raw == "" || raw == SelfAlias || raw == callerID.Hex() {
+			c.Set("subjectID", callerID)
+			c.Next()
+			return
+		}
+
+		role, _ := c.Get("userRole")
+		if role != user.RoleAdmin {
+			response.Forbidden(c, "You do not have access to this resource", response.IsProduction(c))
+			c.Abort()
+			return
+		}
+
+		subjectID, err := primitive.ObjectIDFromHex(raw)
+		if err != nil {
+			response.BadRequest(c, "Invalid user ID", nil, response.IsProduction(c))
+			c.Abort()
+			return
+		}
+
+		c.Set("subjectID", subjectID)
+		c.Next()
+	}
+}
@@ -20,6 +20,7 @@ var (
 	ErrEmailNotVerified    = errors.New("email not verified")
 	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
 	ErrTokenGeneration     = errors.New("failed to generate token")
+	ErrAccountSuspended    = errors.New("account suspended")
 )
 
 type Service interface {
@@ -103,6 +104,10 @@ func (s *service) Login(ctx context.Context, req LoginRequest) (*TokenPair, erro
 		return nil, ErrInvalidCredentials
 	}
 
+	if u.IsSuspended {
+		return nil, ErrAccountSuspended
+	}
+
 	// Todo: Check email verification
 	// if !u.IsVerified {
 	// 	return nil, ErrEmailNotVerified
@@ -137,6 +142,11 @@ func (s *service) Refresh(ctx context.Context, refreshToken string) (*TokenPair,
 		return nil, ErrUserNotFound
 	}
 
+	if u.IsSuspended {
+		_ = s.authRepo.DeleteRefreshToken(ctx, tokenKey)
+		return nil, ErrAccountSuspended
+	}
+
 	accessToken, err := GenerateAccessToken(s.cfg, u)
 	if err != nil {
 		return nil, ErrTokenGeneration
@@ -0,0 +1,199 @@
+package category
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidSchema     = errors.New("invalid attribute schema")
+	ErrInvalidAttributes = errors.New("invalid product attributes")
+)
+
+var attributeNamePattern = regexp.MustCompile(`^[a-z0-9]+(?:[_-][a-z0-9]+)*$`)
+
+type AttributeType string
+
+const (
+	TextAttribute    AttributeType = "text"
+	NumberAttribute  AttributeType = "number"
+	BooleanAttribute AttributeType = "boolean"
+	EnumAttribute    AttributeType = "enum"
+)
+
+const maxTextAttributeLength = 100
+
+// AttributeDef describes one attribute products in a category carry, such as
+// voltage for electronics. Names are stored lower-case; Values lists the
+// allowed values of an enum.
+type AttributeDef struct {
+	Name     string        `bson:"name"`
+	Label    string        `bson:"label"`
+	Type     AttributeType `bson:"type"`
+	Values   []string      `bson:"values,omitempty"`
+	Required bool          `bson:"required"`
+	Unit     string        `bson:"unit,omitempty"`
+}
+
+// Schema is the set of attributes a product in a category may carry: the
+// category's own definitions plus those of its ancestors, nearest first.
+type Schema []AttributeDef
+
+func newAttributeDefs(inputs []AttributeInput) ([]AttributeDef, error) {
+	defs := make([]AttributeDef, 0, len(inputs))
+	seen := make(map[string]bool, len(inputs))
+	for _, in := range inputs {
+		def := AttributeDef{
+			Name:     strings.ToLower(strings.TrimSpace(in.Name)),
+			Label:    strings.TrimSpace(in.Label),
+			Type:     in.Type,
+			Required: in.Required,
+			Unit:     strings.TrimSpace(in.Unit),
+		}
+		if !attributeNamePattern.MatchString(def.Name) {
+			return nil, fmt.Errorf("%w: attribute names may only contain lowercase letters, digits, hyphens and underscores", ErrInvalidSchema)
+		}
+		if seen[def.Name] {
+			return nil, fmt.Errorf("%w: attribute %q is defined twice", ErrInvalidSchema, def.Name)
+		}
+		seen[def.Name] = true
+		if def.Label == "" {
+			def.Label = in.Name
+		}
+
+		switch def.Type {
+		case EnumAttribute:
+			if len(in.Values) == 0 {
+				return nil, fmt.Errorf("%w: enum attribute %q needs allowed values", ErrInvalidSchema, def.Name)
+			}
+			values := make(map[string]bool, len(in.Values))
+			for _, v := range in.Values {
+				v = strings.TrimSpace(v)
+				if values[strings.ToLower(v)] {
+					return nil, fmt.Errorf("%w: attribute %q lists %q twice", ErrInvalidSchema, def.Name, v)
+				}
+				values[strings.ToLower(v)] = true
+				def.Values = append(def.Values, v)
+			}
+		default:
+			if len(in.Values) > 0 {
+				return nil, fmt.Errorf("%w: only enum attributes take allowed values", ErrInvalidSchema)
+			}
+		}
+		defs = append(defs, def)
+	}
+	return defs, nil
+}
+
+// schemaOf merges the definitions along a category path, given root first. A
+// category can redefine an attribute it inherits.
+func schemaOf(path []Category) Schema {
+	schema := Schema{}
+	defined := make(map[string]bool)
+	for i := len(path) - 1; i >= 0; i-- {
+		for _, def := range path[i].Attributes {
+			if !defined[def.Name] {
+				defined[def.Name] = true
+				schema = append(schema, def)
+			}
+		}
+	}
+	return schema
+}
+
+// Validate checks a product's attributes against the schema and returns them
+// normalised: names lower-case, numbers and booleans in canonical form and
+// enum values spelled as defined.
+func (s Schema) Validate(attributes map[string]string) (map[string]string, error) {
+	defs := make(map[string]*AttributeDef, len(s))
+	for i := range s {
+		defs[s[i].Name] = &s[i]
+	}
+
+	clean := make(map[string]string, len(attributes))
+	for name, value := range attributes {
+		name = strings.ToLower(strings.TrimSpace(name))
+		def, ok := defs[name]
+		if !ok {
+			return nil, fmt.Errorf("%w: %q is not an attribute of this category", ErrInvalidAttributes, name)
+		}
+		v, err := def.normalise(strings.TrimSpace(value))
+		if err != nil {
+			return nil, err
+		}
+		clean[name] = v
+	}
+
+	var missing []string
+	for _, def := range s {
+		if _, ok := clean[def.Name]; def.Required && !ok {
+			missing = append(missing, def.Name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%w: missing required %s", ErrInvalidAttributes, strings.Join(missing, ", "))
+	}
+	return clean, nil
+}
+
+func (d *AttributeDef) normalise(value string) (string, error) {
+	if value == "" {
+		return "", fmt.Errorf("%w: %q is empty", ErrInvalidAttributes, d.Name)
+	}
+	switch d.Type {
+	case NumberAttribute:
+		// Accept the unit after the number, as in "230V" or "230 V".
+		f, err := strconv.ParseFloat(strings.TrimSpace(strings.TrimSuffix(value, d.Unit)), 64)
+		if err != nil || math.IsInf(f, 0) || math.IsNaN(f) {
+			return "", fmt.Errorf("%w: %q must be a number", ErrInvalidAttributes, d.Name)
+		}
+		return strconv.FormatFloat(f, 'f', -1, 64), nil
+	case BooleanAttribute:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", fmt.Errorf("%w: %q must be true or false", ErrInvalidAttributes, d.Name)
+		}
+		return strconv.FormatBool(b), nil
+	case EnumAttribute:
+		for _, allowed := range d.Values {
+			if strings.EqualFold(value, allowed) {
+				return allowed, nil
+			}
+		}
+		return "", fmt.Errorf("%w: %q must be one of %s", ErrInvalidAttributes, d.Name, strings.Join(d.Values, ", "))
+	default:
+		if len([]rune(value)) > maxTextAttributeLength {
+			return "", fmt.Errorf("%w: %q is longer than %d characters", ErrInvalidAttributes, d.Name, maxTextAttributeLength)
+		}
+		return value, nil
+	}
+}
+
+func (d *AttributeDef) ToResponse() AttributeResponse {
+	return AttributeResponse{
+		Name:     d.Name,
+		Label:    d.Label,
+		Type:     string(d.Type),
+		Values:   d.Values,
+		Required: d.Required,
+		Unit:     d.Unit,
+	}
+}
+
+func (s Schema) ToResponse() []AttributeResponse {
+	return attributeResponses(s)
+}
+
+func attributeResponses(defs []AttributeDef) []AttributeResponse {
+	resp := make([]AttributeResponse, 0, len(defs))
+	for i := range defs {
+		resp = append(resp, defs[i].ToResponse())
+	}
+	return resp
+}
@@ -0,0 +1,147 @@
+package category
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Ancestor is a denormalised copy of a parent category, kept on every
+// descendant so breadcrumbs and subtree queries need no recursion.
+type Ancestor struct {
+	ID   primitive.ObjectID `bson:"_id"`
+	Name string             `bson:"name"`
+	Slug string             `bson:"slug"`
+}
+
+// Category is a node in the taxonomy. Ancestors run from the root down to the
+// direct parent; a root category has none.
+type Category struct {
+	ID        primitive.ObjectID  `bson:"_id,omitempty"`
+	ParentID  *primitive.ObjectID `bson:"parent_id"`
+	Name      string              `bson:"name"`
+	Slug      string              `bson:"slug"`
+	Position  int                 `bson:"position"`
+	Ancestors []Ancestor          `bson:"ancestors"`
+	CreatedAt time.Time           `bson:"created_at"`
+	UpdatedAt time.Time           `bson:"updated_at"`
+	// Attributes are the category's own definitions; descendants inherit them.
+	Attributes []AttributeDef `bson:"attributes,omitempty"`
+}
+
+var (
+	slugPattern = regexp.MustCompile(`^[a-z0-9]+(?:-[a-z0-9]+)*$`)
+	slugUnsafe  = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// Slugify derives a slug from a category name.
+func Slugify(name string) string {
+	return strings.Trim(slugUnsafe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+}
+
+func validSlug(slug string) bool {
+	return slugPattern.MatchString(slug)
+}
+
+func (c *Category) asAncestor() Ancestor {
+	return Ancestor{ID: c.ID, Name: c.Name, Slug: c.Slug}
+}
+
+// Path is the category's ancestors followed by itself.
+func (c *Category) Path() []Ancestor {
+	return append(append([]Ancestor{}, c.Ancestors...), c.asAncestor())
+}
+
+// PathIDs lists the IDs in Path; products store it to match whole subtrees.
+func (c *Category) PathIDs() []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(c.Ancestors)+1)
+	for _, a := range c.Ancestors {
+		ids = append(ids, a.ID)
+	}
+	return append(ids, c.ID)
+}
+
+// IsDescendantOf reports whether id is one of the category's ancestors.
+func (c *Category) IsDescendantOf(id primitive.ObjectID) bool {
+	for _, a := range c.Ancestors {
+		if a.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// sortSiblings orders categories by position, then name.
+func sortSiblings(categories []Category) {
+	sort.SliceStable(categories, func(i, j int) bool {
+		if categories[i].Position != categories[j].Position {
+			return categories[i].Position < categories[j].Position
+		}
+		return strings.ToLower(categories[i].Name) < strings.ToLower(categories[j].Name)
+	})
+}
+
+func breadcrumbs(path []Ancestor) []BreadcrumbResponse {
+	crumbs := make([]BreadcrumbResponse, 0, len(path))
+	for _, a := range path {
+		crumbs = append(crumbs, BreadcrumbResponse{ID: a.ID.Hex(), Name: a.Name, Slug: a.Slug})
+	}
+	return crumbs
+}
+
+func (c *Category) ToResponse() CategoryResponse {
+	resp := CategoryResponse{
+		ID:          c.ID.Hex(),
+		Name:        c.Name,
+		Slug:        c.Slug,
+		Position:    c.Position,
+		Depth:       len(c.Ancestors),
+		Breadcrumbs: breadcrumbs(c.Path()),
+		Attributes:  attributeResponses(c.Attributes),
+		CreatedAt:   c.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   c.UpdatedAt.Format(time.RFC3339),
+	}
+	if c.ParentID != nil {
+		resp.ParentID = c.ParentID.Hex()
+	}
+	return resp
+}
+
+// buildTree nests categories under their parents. counts holds direct product
+// counts per category; each node reports its whole subtree.
+func buildTree(categories []Category, counts map[primitive.ObjectID]int64) []TreeNode {
+	children := make(map[primitive.ObjectID][]Category)
+	var roots []Category
+	for _, c := range categories {
+		if c.ParentID == nil {
+			roots = append(roots, c)
+			continue
+		}
+		children[*c.ParentID] = append(children[*c.ParentID], c)
+	}
+
+	var build func(level []Category) []TreeNode
+	build = func(level []Category) []TreeNode {
+		sortSiblings(level)
+		nodes := make([]TreeNode, 0, len(level))
+		for _, c := range level {
+			node := TreeNode{
+				ID:           c.ID.Hex(),
+				Name:         c.Name,
+				Slug:         c.Slug,
+				Position:     c.Position,
+				ProductCount: counts[c.ID],
+				Children:     build(children[c.ID]),
+			}
+			for _, child := range node.Children {
+				node.ProductCount += child.ProductCount
+			}
+			nodes = append(nodes, node)
+		}
+		return nodes
+	}
+	return build(roots)
+}
@@ -0,0 +1,85 @@
+package category
+
+type CreateCategoryRequest struct {
+	Name string `json:"name" binding:"required,min=2,max=100"`
+	// Slug defaults to one derived from the name.
+	Slug     string `json:"slug" binding:"omitempty,min=2,max=100"`
+	ParentID string `json:"parent_id" binding:"omitempty,len=24,hexadecimal"`
+	Position int    `json:"position" binding:"min=0"`
+}
+
+type UpdateCategoryRequest struct {
+	Name     *string `json:"name,omitempty" binding:"omitempty,min=2,max=100"`
+	Slug     *string `json:"slug,omitempty" binding:"omitempty,min=2,max=100"`
+	Position *int    `json:"position,omitempty" binding:"omitempty,min=0"`
+}
+
+// MoveCategoryRequest reparents a category; an empty parent makes it a root.
+type MoveCategoryRequest struct {
+	ParentID string `json:"parent_id" binding:"omitempty,len=24,hexadecimal"`
+	Position *int   `json:"position,omitempty" binding:"omitempty,min=0"`
+}
+
+type AttributeInput struct {
+	Name  string        `json:"name" binding:"required,min=1,max=50"`
+	Label string        `json:"label" binding:"omitempty,max=100"`
+	Type  AttributeType `json:"type" binding:"required,oneof=text number boolean enum"`
+	// Values lists the allowed values of an enum attribute.
+	Values   []string `json:"values" binding:"max=100,dive,required,max=100"`
+	Required bool     `json:"required"`
+	Unit     string   `json:"unit" binding:"omitempty,max=20"`
+}
+
+// SetAttributesRequest replaces the category's own attribute definitions;
+// inherited ones are managed on the ancestor that defines them.
+type SetAttributesRequest struct {
+	Attributes []AttributeInput `json:"attributes" binding:"max=50,dive"`
+}
+
+type AttributeResponse struct {
+	Name     string   `json:"name"`
+	Label    string   `json:"label"`
+	Type     string   `json:"type"`
+	Values   []string `json:"values,omitempty"`
+	Required bool     `json:"required"`
+	Unit     string   `json:"unit,omitempty"`
+}
+
+type BreadcrumbResponse struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
+
+type CategoryResponse struct {
+	ID          string               `json:"id"`
+	ParentID    string               `json:"parent_id,omitempty"`
+	Name        string               `json:"name"`
+	Slug        string               `json:"slug"`
+	Position    int                  `json:"position"`
+	Depth       int                  `json:"depth"`
+	Breadcrumbs []BreadcrumbResponse `json:"breadcrumbs"`
+	Attributes  []AttributeResponse  `json:"attributes"`
+	CreatedAt   string               `json:"created_at"`
+	UpdatedAt   string               `json:"updated_at"`
+}
+
+// TreeNode is a category with its subtree. ProductCount includes products
+// in every descendant.
+type TreeNode struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Slug         string     `json:"slug"`
+	Position     int        `json:"position"`
+	ProductCount int64      `json:"product_count"`
+	Children     []TreeNode `json:"children"`
+}
+
+// CategoryDetailResponse adds the full attribute schema, including
+// attributes inherited from ancestors, that products in the category follow.
+type CategoryDetailResponse struct {
+	CategoryResponse
+	Schema       []AttributeResponse `json:"schema"`
+	ProductCount int64               `json:"product_count"`
+	Children     []TreeNode          `json:"children"`
+}
@@ -0,0 +1,151 @@
+package category
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	categoryService Service
+}
+
+func NewHandler(categoryService Service) *Handler {
+	return &Handler{
+		categoryService: categoryService,
+	}
+}
+
+func (h *Handler) CreateCategory(c *gin.Context) {
+	var req CreateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	category, err := h.categoryService.CreateCategory(c.Request.Context(), req)
+	if err != nil {
+		handleError(c, err, "Failed to create category")
+		return
+	}
+	response.Created(c, category, "Category created successfully")
+}
+
+func (h *Handler) UpdateCategory(c *gin.Context) {
+	categoryID, ok := categoryIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	category, err := h.categoryService.UpdateCategory(c.Request.Context(), categoryID, req)
+	if err != nil {
+		handleError(c, err, "Failed to update category")
+		return
+	}
+	response.OK(c, category, "Category updated successfully")
+}
+
+func (h *Handler) MoveCategory(c *gin.Context) {
+	categoryID, ok := categoryIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req MoveCategoryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	category, err := h.categoryService.MoveCategory(c.Request.Context(), categoryID, req)
+	if err != nil {
+		handleError(c, err, "Failed to move category")
+		return
+	}
+	response.OK(c, category, "Category moved successfully")
+}
+
+func (h *Handler) DeleteCategory(c *gin.Context) {
+	categoryID, ok := categoryIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.categoryService.DeleteCategory(c.Request.Context(), categoryID); err != nil {
+		handleError(c, err, "Failed to delete category")
+		return
+	}
+	response.NoContent(c)
+}
+
+func (h *Handler) SetAttributes(c *gin.Context) {
+	categoryID, ok := categoryIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req SetAttributesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	category, err := h.categoryService.SetAttributes(c.Request.Context(), categoryID, req)
+	if err != nil {
+		handleError(c, err, "Failed to update category attributes")
+		return
+	}
+	response.OK(c, category, "Category attributes updated successfully")
+}
+
+func (h *Handler) Tree(c *gin.Context) {
+	tree, err := h.categoryService.Tree(c.Request.Context())
+	if err != nil {
+		handleError(c, err, "Failed to get categories")
+		return
+	}
+	response.OK(c, tree, "Categories retrieved successfully")
+}
+
+func (h *Handler) GetCategory(c *gin.Context) {
+	category, err := h.categoryService.GetCategory(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		handleError(c, err, "Failed to get category")
+		return
+	}
+	response.OK(c, category, "Category retrieved successfully")
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrCategoryNotFound):
+		response.NotFound(c, "Category", response.IsProduction(c))
+	case errors.Is(err, ErrInvalidSlug), errors.Is(err, ErrInvalidMove), errors.Is(err, ErrInvalidSchema):
+		response.BadRequest(c, err.Error(), nil, response.IsProduction(c))
+	case errors.Is(err, ErrSlugTaken):
+		response.Conflict(c, "Category slug is already taken", nil, response.IsProduction(c))
+	case errors.Is(err, ErrParentHasProducts):
+		response.Conflict(c, "Parent category has products; move them to a subcategory first", nil, response.IsProduction(c))
+	case errors.Is(err, ErrCategoryNotEmpty):
+		response.Conflict(c, "Only empty categories without subcategories can be deleted", nil, response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func categoryIDParam(c *gin.Context) (primitive.ObjectID, bool) {
+	categoryID, err := primitive.ObjectIDFromHex(c.Param("categoryID"))
+	if err != nil {
+		response.BadRequest(c, "Invalid category ID", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return categoryID, true
+}
@@ -0,0 +1,146 @@
+package category
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	Create(ctx context.Context, c *Category) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*Category, error)
+	GetBySlug(ctx context.Context, slug string) (*Category, error)
+	List(ctx context.Context) ([]Category, error)
+	GetMany(ctx context.Context, ids []primitive.ObjectID) ([]Category, error)
+	HasChildren(ctx context.Context, id primitive.ObjectID) (bool, error)
+	Update(ctx context.Context, c *Category) error
+	Delete(ctx context.Context, id primitive.ObjectID) error
+
+	// RenameInDescendants refreshes the copy of a renamed category kept in
+	// its descendants' ancestors.
+	RenameInDescendants(ctx context.Context, a Ancestor) error
+	// RebaseDescendants rewrites the ancestors of every descendant of id so
+	// they start with path, which must end with id itself.
+	RebaseDescendants(ctx context.Context, id primitive.ObjectID, path []Ancestor) error
+}
+
+type CategoryRepository struct {
+	collection *mongo.Collection
+	collation  *options.Collation
+}
+
+func NewCategoryRepository(db *mongo.Database) Repository {
+	return &CategoryRepository{
+		collection: db.Collection("categories"),
+		collation:  &options.Collation{Locale: "en", Strength: 2},
+	}
+}
+
+func (r *CategoryRepository) Create(ctx context.Context, c *Category) error {
+	if _, err := r.collection.InsertOne(ctx, c); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrSlugTaken
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *CategoryRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*Category, error) {
+	var c Category
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&c)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrCategoryNotFound
+	}
+	return &c, err
+}
+
+func (r *CategoryRepository) GetBySlug(ctx context.Context, slug string) (*Category, error) {
+	var c Category
+	err := r.collection.FindOne(ctx, bson.M{"slug": slug}, options.FindOne().SetCollation(r.collation)).Decode(&c)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrCategoryNotFound
+	}
+	return &c, err
+}
+
+func (r *CategoryRepository) List(ctx context.Context) ([]Category, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	categories := []Category{}
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *CategoryRepository) GetMany(ctx context.Context, ids []primitive.ObjectID) ([]Category, error) {
+	cursor, err := r.collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+	categories := []Category{}
+	if err := cursor.All(ctx, &categories); err != nil {
+		return nil, err
+	}
+	return categories, nil
+}
+
+func (r *CategoryRepository) HasChildren(ctx context.Context, id primitive.ObjectID) (bool, error) {
+	n, err := r.collection.CountDocuments(ctx, bson.M{"parent_id": id}, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+func (r *CategoryRepository) Update(ctx context.Context, c *Category) error {
+	res, err := r.collection.ReplaceOne(ctx, bson.M{"_id": c.ID}, c)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrSlugTaken
+		}
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+func (r *CategoryRepository) Delete(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrCategoryNotFound
+	}
+	return nil
+}
+
+func (r *CategoryRepository) RenameInDescendants(ctx context.Context, a Ancestor) error {
+	_, err := r.collection.UpdateMany(ctx,
+		bson.M{"ancestors._id": a.ID},
+		bson.M{"$set": bson.M{"ancestors.$[a].name": a.Name, "ancestors.$[a].slug": a.Slug}},
+		options.Update().SetArrayFilters(options.ArrayFilters{Filters: []interface{}{bson.M{"a._id": a.ID}}}),
+	)
+	return err
+}
+
+func (r *CategoryRepository) RebaseDescendants(ctx context.Context, id primitive.ObjectID, path []Ancestor) error {
+	// Keep everything below id and swap in the new path above it. The path is
+	// wrapped in $literal so a name starting with "$" isn't read as a field.
+	below := bson.M{"$slice": bson.A{
+		"$ancestors",
+		bson.M{"$add": bson.A{bson.M{"$indexOfArray": bson.A{"$ancestors._id", id}}, 1}},
+		bson.M{"$max": bson.A{bson.M{"$size": "$ancestors"}, 1}},
+	}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"ancestors": bson.M{"$concatArrays": bson.A{bson.M{"$literal": path}, below}}}}},
+	}
+	_, err := r.collection.UpdateMany(ctx, bson.M{"ancestors._id": id}, update)
+	return err
+}
@@ -0,0 +1,370 @@
+package category
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrCategoryNotFound  = errors.New("category not found")
+	ErrSlugTaken         = errors.New("category slug already taken")
+	ErrInvalidSlug       = errors.New("slug may only contain lowercase letters, digits and single hyphens")
+	ErrInvalidMove       = errors.New("a category cannot be moved under itself or its descendants")
+	ErrCategoryNotLeaf   = errors.New("products can only be assigned to leaf categories")
+	ErrParentHasProducts = errors.New("category has products assigned and cannot get subcategories")
+	ErrCategoryNotEmpty  = errors.New("category still has subcategories or products")
+)
+
+// Products is the part of the product catalogue the taxonomy keeps in step.
+// Products store the IDs of their category's path so a subtree matches with
+// one query.
+type Products interface {
+	// CountByCategory counts publicly visible products per assigned category.
+	CountByCategory(ctx context.Context) (map[primitive.ObjectID]int64, error)
+	CategoryInUse(ctx context.Context, categoryID primitive.ObjectID) (bool, error)
+	// RebaseCategoryPath rewrites the stored path of every product under
+	// categoryID so it starts with path, which must end with categoryID.
+	RebaseCategoryPath(ctx context.Context, categoryID primitive.ObjectID, path []primitive.ObjectID) error
+}
+
+type Service interface {
+	CreateCategory(ctx context.Context, req CreateCategoryRequest) (*CategoryResponse, error)
+	UpdateCategory(ctx context.Context, id primitive.ObjectID, req UpdateCategoryRequest) (*CategoryResponse, error)
+	MoveCategory(ctx context.Context, id primitive.ObjectID, req MoveCategoryRequest) (*CategoryResponse, error)
+	DeleteCategory(ctx context.Context, id primitive.ObjectID) error
+	SetAttributes(ctx context.Context, id primitive.ObjectID, req SetAttributesRequest) (*CategoryResponse, error)
+
+	Tree(ctx context.Context) ([]TreeNode, error)
+	GetCategory(ctx context.Context, slug string) (*CategoryDetailResponse, error)
+
+	// LeafPath returns the path IDs a product assigned to id should store.
+	LeafPath(ctx context.Context, id primitive.ObjectID) ([]primitive.ObjectID, error)
+	Resolve(ctx context.Context, slug string) (*Category, error)
+	// Lookup returns the categories with the given IDs that still exist.
+	Lookup(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]Category, error)
+	// Schema returns the attribute schema of products in category id. A nil
+	// id, for products outside the taxonomy, has an empty schema.
+	Schema(ctx context.Context, id *primitive.ObjectID) (Schema, error)
+}
+
+type service struct {
+	categoryRepo Repository
+	products     Products
+}
+
+func NewService(categoryRepo Repository, products Products) Service {
+	return &service{
+		categoryRepo: categoryRepo,
+		products:     products,
+	}
+}
+
+func (s *service) CreateCategory(ctx context.Context, req CreateCategoryRequest) (*CategoryResponse, error) {
+	slug, err := cleanSlug(req.Slug, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	c := &Category{
+		ID:        primitive.NewObjectID(),
+		Name:      strings.TrimSpace(req.Name),
+		Slug:      slug,
+		Position:  req.Position,
+		Ancestors: []Ancestor{},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if req.ParentID != "" {
+		parent, err := s.parent(ctx, req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		c.ParentID = &parent.ID
+		c.Ancestors = parent.Path()
+	}
+
+	if err := s.categoryRepo.Create(ctx, c); err != nil {
+		return nil, err
+	}
+	resp := c.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) UpdateCategory(ctx context.Context, id primitive.ObjectID, req UpdateCategoryRequest) (*CategoryResponse, error) {
+	c, err := s.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	renamed := false
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		renamed = renamed || name != c.Name
+		c.Name = name
+	}
+	if req.Slug != nil {
+		slug, err := cleanSlug(*req.Slug, c.Name)
+		if err != nil {
+			return nil, err
+		}
+		renamed = renamed || slug != c.Slug
+		c.Slug = slug
+	}
+	if req.Position != nil {
+		c.Position = *req.Position
+	}
+	c.UpdatedAt = time.Now()
+
+	if err := s.categoryRepo.Update(ctx, c); err != nil {
+		return nil, err
+	}
+	if renamed {
+		if err := s.categoryRepo.RenameInDescendants(ctx, c.asAncestor()); err != nil {
+			return nil, err
+		}
+	}
+	resp := c.ToResponse()
+	return &resp, nil
+}
+
+// MoveCategory reparents a category, then rewrites the paths of its
+// descendants and their products. Each step is idempotent, so repeating a
+// move that failed part way through repairs the tree.
+func (s *service) MoveCategory(ctx context.Context, id primitive.ObjectID, req MoveCategoryRequest) (*CategoryResponse, error) {
+	c, err := s.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.ParentID = nil
+	c.Ancestors = []Ancestor{}
+	if req.ParentID != "" {
+		parent, err := s.parent(ctx, req.ParentID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.ID == c.ID || parent.IsDescendantOf(c.ID) {
+			return nil, ErrInvalidMove
+		}
+		c.ParentID = &parent.ID
+		c.Ancestors = parent.Path()
+	}
+	if req.Position != nil {
+		c.Position = *req.Position
+	}
+	c.UpdatedAt = time.Now()
+
+	if err := s.categoryRepo.Update(ctx, c); err != nil {
+		return nil, err
+	}
+	if err := s.categoryRepo.RebaseDescendants(ctx, c.ID, c.Path()); err != nil {
+		return nil, err
+	}
+	if err := s.products.RebaseCategoryPath(ctx, c.ID, c.PathIDs()); err != nil {
+		return nil, err
+	}
+	resp := c.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) DeleteCategory(ctx context.Context, id primitive.ObjectID) error {
+	if _, err := s.categoryRepo.GetByID(ctx, id); err != nil {
+		return err
+	}
+	hasChildren, err := s.categoryRepo.HasChildren(ctx, id)
+	if err != nil {
+		return err
+	}
+	inUse, err := s.products.CategoryInUse(ctx, id)
+	if err != nil {
+		return err
+	}
+	if hasChildren || inUse {
+		return ErrCategoryNotEmpty
+	}
+	return s.categoryRepo.Delete(ctx, id)
+}
+
+// SetAttributes replaces the category's own attribute definitions. Products
+// already in the category are checked against the new schema the next time
+// their attributes or category change.
+func (s *service) SetAttributes(ctx context.Context, id primitive.ObjectID, req SetAttributesRequest) (*CategoryResponse, error) {
+	c, err := s.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	defs, err := newAttributeDefs(req.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	c.Attributes = defs
+	c.UpdatedAt = time.Now()
+	if err := s.categoryRepo.Update(ctx, c); err != nil {
+		return nil, err
+	}
+	resp := c.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) Tree(ctx context.Context) ([]TreeNode, error) {
+	categories, err := s.categoryRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.products.CountByCategory(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return buildTree(categories, counts), nil
+}
+
+func (s *service) GetCategory(ctx context.Context, slug string) (*CategoryDetailResponse, error) {
+	c, err := s.categoryRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := s.Tree(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Walk down the tree along the category's path to pick up its subtree.
+	level := tree
+	var node *TreeNode
+	for _, a := range c.Path() {
+		node = nil
+		for i := range level {
+			if level[i].ID == a.ID.Hex() {
+				node = &level[i]
+				break
+			}
+		}
+		if node == nil {
+			break
+		}
+		level = node.Children
+	}
+
+	schema, err := s.schema(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &CategoryDetailResponse{
+		CategoryResponse: c.ToResponse(),
+		Schema:           attributeResponses(schema),
+		Children:         []TreeNode{},
+	}
+	if node != nil {
+		resp.ProductCount = node.ProductCount
+		resp.Children = node.Children
+	}
+	return resp, nil
+}
+
+func (s *service) LeafPath(ctx context.Context, id primitive.ObjectID) ([]primitive.ObjectID, error) {
+	c, err := s.categoryRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	hasChildren, err := s.categoryRepo.HasChildren(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if hasChildren {
+		return nil, ErrCategoryNotLeaf
+	}
+	return c.PathIDs(), nil
+}
+
+func (s *service) Resolve(ctx context.Context, slug string) (*Category, error) {
+	return s.categoryRepo.GetBySlug(ctx, slug)
+}
+
+func (s *service) Lookup(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]Category, error) {
+	found := make(map[primitive.ObjectID]Category, len(ids))
+	if len(ids) == 0 {
+		return found, nil
+	}
+	categories, err := s.categoryRepo.GetMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range categories {
+		found[c.ID] = c
+	}
+	return found, nil
+}
+
+func (s *service) Schema(ctx context.Context, id *primitive.ObjectID) (Schema, error) {
+	if id == nil {
+		return Schema{}, nil
+	}
+	c, err := s.categoryRepo.GetByID(ctx, *id)
+	if err != nil {
+		return nil, err
+	}
+	return s.schema(ctx, c)
+}
+
+// schema loads the category's ancestors to merge in the attributes they
+// define.
+func (s *service) schema(ctx context.Context, c *Category) (Schema, error) {
+	path := []Category{*c}
+	if len(c.Ancestors) > 0 {
+		ids := make([]primitive.ObjectID, 0, len(c.Ancestors))
+		for _, a := range c.Ancestors {
+			ids = append(ids, a.ID)
+		}
+		ancestors, err := s.Lookup(ctx, ids)
+		if err != nil {
+			return nil, err
+		}
+		path = path[:0]
+		for _, id := range ids {
+			if a, ok := ancestors[id]; ok {
+				path = append(path, a)
+			}
+		}
+		path = append(path, *c)
+	}
+	return schemaOf(path), nil
+}
+
+// parent loads a prospective parent; categories holding products must stay
+// leaves.
+func (s *service) parent(ctx context.Context, hexID string) (*Category, error) {
+	parentID, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return nil, ErrCategoryNotFound
+	}
+	parent, err := s.categoryRepo.GetByID(ctx, parentID)
+	if err != nil {
+		return nil, err
+	}
+	inUse, err := s.products.CategoryInUse(ctx, parent.ID)
+	if err != nil {
+		return nil, err
+	}
+	if inUse {
+		return nil, ErrParentHasProducts
+	}
+	return parent, nil
+}
+
+func cleanSlug(slug, name string) (string, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if slug == "" {
+		slug = Slugify(name)
+	}
+	if !validSlug(slug) {
+		return "", ErrInvalidSlug
+	}
+	return slug, nil
+}
@@ -0,0 +1,76 @@
+package digital
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MaxAssetSize        = 200 << 20 // 200 MB
+	MaxAssetsPerProduct = 10
+)
+
+// Asset is a file delivered to buyers of a digital product.
+type Asset struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	ProductID   primitive.ObjectID `bson:"product_id"`
+	VendorID    primitive.ObjectID `bson:"vendor_id"`
+	FileName    string             `bson:"file_name"`
+	ContentType string             `bson:"content_type"`
+	Size        int64              `bson:"size"`
+	BlobKey     string             `bson:"blob_key"`
+	CreatedAt   time.Time          `bson:"created_at"`
+}
+
+// Entitlement is a buyer's right to download a digital product they paid
+// for. There is one per product per order.
+type Entitlement struct {
+	ID        primitive.ObjectID `bson:"_id"`
+	BuyerID   primitive.ObjectID `bson:"buyer_id"`
+	OrderID   primitive.ObjectID `bson:"order_id"`
+	ProductID primitive.ObjectID `bson:"product_id"`
+	VendorID  primitive.ObjectID `bson:"vendor_id"`
+	// Title is the product's title at the time of purchase.
+	Title     string    `bson:"title"`
+	GrantedAt time.Time `bson:"granted_at"`
+}
+
+// PaidOrder is what the order flow passes in once payment is confirmed.
+// ProductIDs may include physical products, which are skipped.
+type PaidOrder struct {
+	OrderID    primitive.ObjectID
+	BuyerID    primitive.ObjectID
+	ProductIDs []primitive.ObjectID
+}
+
+// downloadKey identifies one file of one entitlement, which is what the
+// download limit counts against.
+type downloadKey struct {
+	EntitlementID primitive.ObjectID
+	AssetID       primitive.ObjectID
+}
+
+func NewAsset(productID, vendorID primitive.ObjectID, fileName, contentType string) *Asset {
+	id := primitive.NewObjectID()
+	return &Asset{
+		ID:          id,
+		ProductID:   productID,
+		VendorID:    vendorID,
+		FileName:    fileName,
+		ContentType: contentType,
+		BlobKey:     "digital/" + productID.Hex() + "/" + id.Hex(),
+		CreatedAt:   time.Now(),
+	}
+}
+
+func (a *Asset) ToResponse() AssetResponse {
+	return AssetResponse{
+		ID:          a.ID.Hex(),
+		ProductID:   a.ProductID.Hex(),
+		FileName:    a.FileName,
+		ContentType: a.ContentType,
+		Size:        a.Size,
+		CreatedAt:   a.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,70 @@
+package digital
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+type LibraryQuery struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+// DownloadQuery carries the signature and expiry of a download link.
+type DownloadQuery struct {
+	Expires   int64  `form:"expires" binding:"required"`
+	Signature string `form:"signature" binding:"required,len=64,hexadecimal"`
+}
+
+type AssetResponse struct {
+	ID          string `json:"id"`
+	ProductID   string `json:"product_id"`
+	FileName    string `json:"file_name"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type LibraryFileResponse struct {
+	ID            string `json:"id"`
+	FileName      string `json:"file_name"`
+	ContentType   string `json:"content_type"`
+	Size          int64  `json:"size"`
+	DownloadsLeft int    `json:"downloads_left"`
+	// DownloadURL is left out once the download limit is reached.
+	DownloadURL string `json:"download_url,omitempty"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+}
+
+type LibraryItemResponse struct {
+	ID        string                `json:"id"`
+	ProductID string                `json:"product_id"`
+	OrderID   string                `json:"order_id"`
+	Title     string                `json:"title"`
+	Files     []LibraryFileResponse `json:"files"`
+	GrantedAt string                `json:"granted_at"`
+}
+
+// FulfilOrderRequest is a paid order as reported by the order flow.
+type FulfilOrderRequest struct {
+	OrderID    string   `json:"order_id" binding:"required"`
+	BuyerID    string   `json:"buyer_id" binding:"required"`
+	ProductIDs []string `json:"product_ids" binding:"required,min=1"`
+}
+
+func (r FulfilOrderRequest) toOrder() (PaidOrder, error) {
+	orderID, err := primitive.ObjectIDFromHex(r.OrderID)
+	if err != nil {
+		return PaidOrder{}, ErrInvalidOrder
+	}
+	buyerID, err := primitive.ObjectIDFromHex(r.BuyerID)
+	if err != nil {
+		return PaidOrder{}, ErrInvalidOrder
+	}
+	order := PaidOrder{OrderID: orderID, BuyerID: buyerID, ProductIDs: make([]primitive.ObjectID, 0, len(r.ProductIDs))}
+	for _, hex := range r.ProductIDs {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return PaidOrder{}, ErrInvalidOrder
+		}
+		order.ProductIDs = append(order.ProductIDs, id)
+	}
+	return order, nil
+}
@@ -0,0 +1,228 @@
+package digital
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	digitalService Service
+}
+
+func NewHandler(digitalService Service) *Handler {
+	return &Handler{
+		digitalService: digitalService,
+	}
+}
+
+func (h *Handler) UploadAsset(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := idParam(c, "productID", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart envelope around the file itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxAssetSize+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			handleError(c, err, "Failed to upload file")
+			return
+		}
+		response.BadRequest(c, "A file is required", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.BadRequest(c, "Could not read uploaded file", nil, response.IsProduction(c))
+		return
+	}
+	defer file.Close()
+
+	asset, err := h.digitalService.UploadAsset(c.Request.Context(), userID, productID, filepath.Base(fileHeader.Filename), file)
+	if err != nil {
+		handleError(c, err, "Failed to upload file")
+		return
+	}
+	response.Created(c, asset, "File uploaded successfully")
+}
+
+func (h *Handler) ListAssets(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := idParam(c, "productID", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	assets, err := h.digitalService.ListAssets(c.Request.Context(), userID, productID)
+	if err != nil {
+		handleError(c, err, "Failed to list files")
+		return
+	}
+	response.OK(c, assets, "Files retrieved successfully")
+}
+
+func (h *Handler) DeleteAsset(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := idParam(c, "productID", "Invalid product ID")
+	if !ok {
+		return
+	}
+	assetID, ok := idParam(c, "assetID", "Invalid file ID")
+	if !ok {
+		return
+	}
+
+	if err := h.digitalService.DeleteAsset(c.Request.Context(), userID, productID, assetID); err != nil {
+		handleError(c, err, "Failed to delete file")
+		return
+	}
+	response.OK(c, nil, "File deleted successfully")
+}
+
+func (h *Handler) Library(c *gin.Context) {
+	buyerID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var query LibraryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+
+	items, total, err := h.digitalService.Library(c.Request.Context(), buyerID, query)
+	if err != nil {
+		handleError(c, err, "Failed to list library")
+		return
+	}
+	response.Paginated(c, items, query.Page, query.PageSize, int(total), "Library retrieved successfully")
+}
+
+// Download needs no login: the signed link is the credential.
+func (h *Handler) Download(c *gin.Context) {
+	entitlementID, ok := idParam(c, "entitlementID", "Invalid download link")
+	if !ok {
+		return
+	}
+	assetID, ok := idParam(c, "assetID", "Invalid download link")
+	if !ok {
+		return
+	}
+
+	var query DownloadQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid download link", nil, response.IsProduction(c))
+		return
+	}
+
+	asset, blob, err := h.digitalService.OpenDownload(c.Request.Context(), entitlementID, assetID, query)
+	if err != nil {
+		handleError(c, err, "Failed to download file")
+		return
+	}
+	defer blob.Close()
+	c.DataFromReader(http.StatusOK, asset.Size, asset.ContentType, blob, map[string]string{
+		"Content-Disposition":    fmt.Sprintf("attachment; filename=%q", asset.FileName),
+		"Cache-Control":          "no-store",
+		"X-Content-Type-Options": "nosniff",
+	})
+}
+
+// FulfilOrder takes a paid order from the order flow.
+func (h *Handler) FulfilOrder(c *gin.Context) {
+	var req FulfilOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	order, err := req.toOrder()
+	if err == nil {
+		err = h.digitalService.FulfilOrder(c.Request.Context(), order)
+	}
+	if err != nil {
+		handleError(c, err, "Failed to fulfil order")
+		return
+	}
+	response.OK(c, nil, "Order fulfilled")
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, ErrAssetNotFound):
+		response.NotFound(c, "File", response.IsProduction(c))
+	case errors.Is(err, ErrEntitlementNotFound):
+		response.NotFound(c, "Purchase", response.IsProduction(c))
+	case errors.Is(err, product.ErrProductNotFound):
+		response.NotFound(c, "Product", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotFound):
+		response.NotFound(c, "Vendor", response.IsProduction(c))
+	case errors.Is(err, ErrInvalidOrder):
+		response.BadRequest(c, err.Error(), nil, response.IsProduction(c))
+	case errors.Is(err, ErrNotDigital):
+		response.BadRequest(c, "Files can only be added to digital products", nil, response.IsProduction(c))
+	case errors.Is(err, ErrTooManyAssets):
+		response.Conflict(c, fmt.Sprintf("A product can have at most %d files", MaxAssetsPerProduct), nil, response.IsProduction(c))
+	case errors.Is(err, ErrFileTooLarge), errors.As(err, &tooLarge):
+		response.Error(c, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", "File exceeds the 200MB limit", nil, response.IsProduction(c))
+	case errors.Is(err, ErrInvalidLink):
+		response.Forbidden(c, "Download link is invalid", response.IsProduction(c))
+	case errors.Is(err, ErrLinkExpired):
+		response.Error(c, http.StatusGone, "LINK_EXPIRED", "Download link has expired; get a new one from your library", nil, response.IsProduction(c))
+	case errors.Is(err, ErrDownloadLimitReached):
+		response.Forbidden(c, "Download limit reached for this file", response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func idParam(c *gin.Context, name, message string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param(name))
+	if err != nil {
+		response.BadRequest(c, message, nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func callerID(c *gin.Context) (primitive.ObjectID, bool) {
+	val, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	userID, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
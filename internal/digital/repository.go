@@ -0,0 +1,178 @@
+package digital
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	CreateAsset(ctx context.Context, asset *Asset) error
+	GetAsset(ctx context.Context, id primitive.ObjectID) (*Asset, error)
+	CountAssets(ctx context.Context, productID primitive.ObjectID) (int64, error)
+	ListAssets(ctx context.Context, productIDs []primitive.ObjectID) ([]Asset, error)
+	// DeleteAsset removes the asset and its download counts.
+	DeleteAsset(ctx context.Context, productID, id primitive.ObjectID) (*Asset, error)
+
+	// Grant stores an entitlement unless the order already has one for the
+	// product, and reports whether it was new.
+	Grant(ctx context.Context, e *Entitlement) (bool, error)
+	GetEntitlement(ctx context.Context, id primitive.ObjectID) (*Entitlement, error)
+	ListEntitlements(ctx context.Context, buyerID primitive.ObjectID, page, pageSize int) ([]Entitlement, int64, error)
+
+	// DownloadCounts returns how often each file of the entitlements has
+	// been downloaded.
+	DownloadCounts(ctx context.Context, entitlementIDs []primitive.ObjectID) (map[downloadKey]int, error)
+	// CountDownload records a download unless the file has already been
+	// downloaded limit times for the entitlement, in which case it returns
+	// ErrDownloadLimitReached.
+	CountDownload(ctx context.Context, entitlementID, assetID primitive.ObjectID, limit int) error
+}
+
+type DigitalRepository struct {
+	assets       *mongo.Collection
+	entitlements *mongo.Collection
+	downloads    *mongo.Collection
+}
+
+func NewDigitalRepository(db *mongo.Database) Repository {
+	return &DigitalRepository{
+		assets:       db.Collection("digital_assets"),
+		entitlements: db.Collection("digital_entitlements"),
+		downloads:    db.Collection("digital_downloads"),
+	}
+}
+
+func (r *DigitalRepository) CreateAsset(ctx context.Context, asset *Asset) error {
+	_, err := r.assets.InsertOne(ctx, asset)
+	return err
+}
+
+func (r *DigitalRepository) GetAsset(ctx context.Context, id primitive.ObjectID) (*Asset, error) {
+	var asset Asset
+	err := r.assets.FindOne(ctx, bson.M{"_id": id}).Decode(&asset)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrAssetNotFound
+	}
+	return &asset, err
+}
+
+func (r *DigitalRepository) CountAssets(ctx context.Context, productID primitive.ObjectID) (int64, error) {
+	return r.assets.CountDocuments(ctx, bson.M{"product_id": productID})
+}
+
+func (r *DigitalRepository) ListAssets(ctx context.Context, productIDs []primitive.ObjectID) ([]Asset, error) {
+	cursor, err := r.assets.Find(ctx,
+		bson.M{"product_id": bson.M{"$in": productIDs}},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	assets := []Asset{}
+	if err := cursor.All(ctx, &assets); err != nil {
+		return nil, err
+	}
+	return assets, nil
+}
+
+func (r *DigitalRepository) DeleteAsset(ctx context.Context, productID, id primitive.ObjectID) (*Asset, error) {
+	var asset Asset
+	err := r.assets.FindOneAndDelete(ctx, bson.M{"_id": id, "product_id": productID}).Decode(&asset)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrAssetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	_, err = r.downloads.DeleteMany(ctx, bson.M{"asset_id": id})
+	return &asset, err
+}
+
+func (r *DigitalRepository) Grant(ctx context.Context, e *Entitlement) (bool, error) {
+	res, err := r.entitlements.UpdateOne(ctx,
+		bson.M{"order_id": e.OrderID, "product_id": e.ProductID},
+		bson.M{"$setOnInsert": e},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		// A concurrent fulfilment of the same order got there first.
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return res.UpsertedCount > 0, nil
+}
+
+func (r *DigitalRepository) GetEntitlement(ctx context.Context, id primitive.ObjectID) (*Entitlement, error) {
+	var e Entitlement
+	err := r.entitlements.FindOne(ctx, bson.M{"_id": id}).Decode(&e)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrEntitlementNotFound
+	}
+	return &e, err
+}
+
+func (r *DigitalRepository) ListEntitlements(ctx context.Context, buyerID primitive.ObjectID, page, pageSize int) ([]Entitlement, int64, error) {
+	filter := bson.M{"buyer_id": buyerID}
+	total, err := r.entitlements.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "granted_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.entitlements.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	var entitlements []Entitlement
+	if err := cursor.All(ctx, &entitlements); err != nil {
+		return nil, 0, err
+	}
+	return entitlements, total, nil
+}
+
+func (r *DigitalRepository) DownloadCounts(ctx context.Context, entitlementIDs []primitive.ObjectID) (map[downloadKey]int, error) {
+	cursor, err := r.downloads.Find(ctx, bson.M{"entitlement_id": bson.M{"$in": entitlementIDs}})
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		EntitlementID primitive.ObjectID `bson:"entitlement_id"`
+		AssetID       primitive.ObjectID `bson:"asset_id"`
+		Count         int                `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	counts := make(map[downloadKey]int, len(rows))
+	for _, row := range rows {
+		counts[downloadKey{EntitlementID: row.EntitlementID, AssetID: row.AssetID}] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *DigitalRepository) CountDownload(ctx context.Context, entitlementID, assetID primitive.ObjectID, limit int) error {
+	// Once the count reaches the limit the filter stops matching and the
+	// upsert collides with the existing counter on the unique index.
+	_, err := r.downloads.UpdateOne(ctx,
+		bson.M{"entitlement_id": entitlementID, "asset_id": assetID, "count": bson.M{"$lt": limit}},
+		bson.M{
+			"$inc": bson.M{"count": 1},
+			"$set": bson.M{"last_downloaded_at": time.Now()},
+		},
+		options.Update().SetUpsert(true),
+	)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrDownloadLimitReached
+	}
+	return err
+}
@@ -0,0 +1,308 @@
+package digital
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/techrook/23-market/internal/notification"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrAssetNotFound        = errors.New("file not found")
+	ErrEntitlementNotFound  = errors.New("purchase not found")
+	ErrNotDigital           = errors.New("files can only be added to digital products")
+	ErrTooManyAssets        = errors.New("product already has the maximum number of files")
+	ErrFileTooLarge         = errors.New("file too large")
+	ErrInvalidLink          = errors.New("download link is invalid")
+	ErrLinkExpired          = errors.New("download link has expired")
+	ErrDownloadLimitReached = errors.New("download limit reached")
+	ErrInvalidOrder         = errors.New("order, buyer and product IDs must be valid object IDs")
+)
+
+type Config struct {
+	// Secret keys the HMAC on download links.
+	Secret string
+	// LinkTTL is how long a download link works after it is issued.
+	LinkTTL time.Duration
+	// DownloadLimit is how many times a buyer can download each file of a
+	// purchase.
+	DownloadLimit int
+}
+
+// Fulfiller is the hook the order flow uses once payment for an order is
+// confirmed, through POST /internal/fulfilments. It grants the buyer the
+// digital products in the order; fulfilling the same order twice is a no-op.
+type Fulfiller interface {
+	FulfilOrder(ctx context.Context, order PaidOrder) error
+}
+
+type Service interface {
+	Fulfiller
+
+	UploadAsset(ctx context.Context, userID, productID primitive.ObjectID, fileName string, file io.Reader) (*AssetResponse, error)
+	ListAssets(ctx context.Context, userID, productID primitive.ObjectID) ([]AssetResponse, error)
+	DeleteAsset(ctx context.Context, userID, productID, assetID primitive.ObjectID) error
+
+	// Library lists the buyer's digital purchases with freshly signed
+	// download links.
+	Library(ctx context.Context, buyerID primitive.ObjectID, query LibraryQuery) ([]LibraryItemResponse, int64, error)
+	// OpenDownload checks a signed link, counts the download and opens the
+	// file. The caller must close the reader.
+	OpenDownload(ctx context.Context, entitlementID, assetID primitive.ObjectID, query DownloadQuery) (*Asset, io.ReadCloser, error)
+}
+
+type service struct {
+	digitalRepo Repository
+	productRepo product.Repository
+	vendorRepo  vendor.Repository
+	blobs       storage.BlobStore
+	notifier    notification.Notifier
+	signer      signer
+	cfg         Config
+}
+
+func NewService(digitalRepo Repository, productRepo product.Repository, vendorRepo vendor.Repository, blobs storage.BlobStore, notifier notification.Notifier, cfg Config) Service {
+	return &service{
+		digitalRepo: digitalRepo,
+		productRepo: productRepo,
+		vendorRepo:  vendorRepo,
+		blobs:       blobs,
+		notifier:    notifier,
+		signer:      signer{key: []byte(cfg.Secret)},
+		cfg:         cfg,
+	}
+}
+
+func (s *service) FulfilOrder(ctx context.Context, order PaidOrder) error {
+	seen := make(map[primitive.ObjectID]bool, len(order.ProductIDs))
+	var granted []string
+	for _, productID := range order.ProductIDs {
+		if seen[productID] {
+			continue
+		}
+		seen[productID] = true
+
+		p, err := s.productRepo.GetByID(ctx, productID)
+		if err != nil {
+			return err
+		}
+		if !p.IsDigital() {
+			continue
+		}
+		isNew, err := s.digitalRepo.Grant(ctx, &Entitlement{
+			ID:        primitive.NewObjectID(),
+			BuyerID:   order.BuyerID,
+			OrderID:   order.OrderID,
+			ProductID: p.ID,
+			VendorID:  p.VendorID,
+			Title:     p.Title,
+			GrantedAt: time.Now(),
+		})
+		if err != nil {
+			return err
+		}
+		if isNew {
+			granted = append(granted, p.Title)
+		}
+	}
+
+	if len(granted) > 0 {
+		if err := s.notifier.Notify(ctx, order.BuyerID, notification.DigitalOrderReadyType,
+			"Your downloads are ready",
+			strings.Join(granted, ", ")+" can now be downloaded from your library.",
+			map[string]string{"order_id": order.OrderID.Hex()},
+		); err != nil {
+			log.Printf("⚠️ Failed to notify user %s about %s: %v", order.BuyerID.Hex(), notification.DigitalOrderReadyType, err)
+		}
+	}
+	return nil
+}
+
+func (s *service) UploadAsset(ctx context.Context, userID, productID primitive.ObjectID, fileName string, file io.Reader) (*AssetResponse, error) {
+	v, p, err := s.vendorProduct(ctx, userID, productID)
+	if err != nil {
+		return nil, err
+	}
+	if !p.IsDigital() {
+		return nil, ErrNotDigital
+	}
+	count, err := s.digitalRepo.CountAssets(ctx, p.ID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= MaxAssetsPerProduct {
+		return nil, ErrTooManyAssets
+	}
+
+	// Record what the bytes are rather than what the client claims.
+	buffered := bufio.NewReader(file)
+	head, _ := buffered.Peek(512)
+
+	asset := NewAsset(p.ID, v.ID, fileName, http.DetectContentType(head))
+	size, err := s.blobs.Put(ctx, asset.BlobKey, io.LimitReader(buffered, MaxAssetSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store digital asset: %w", err)
+	}
+	if size > MaxAssetSize {
+		_ = s.blobs.Delete(ctx, asset.BlobKey)
+		return nil, ErrFileTooLarge
+	}
+	asset.Size = size
+
+	if err := s.digitalRepo.CreateAsset(ctx, asset); err != nil {
+		_ = s.blobs.Delete(ctx, asset.BlobKey)
+		return nil, err
+	}
+	resp := asset.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) ListAssets(ctx context.Context, userID, productID primitive.ObjectID) ([]AssetResponse, error) {
+	_, p, err := s.vendorProduct(ctx, userID, productID)
+	if err != nil {
+		return nil, err
+	}
+	assets, err := s.digitalRepo.ListAssets(ctx, []primitive.ObjectID{p.ID})
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]AssetResponse, 0, len(assets))
+	for i := range assets {
+		resp = append(resp, assets[i].ToResponse())
+	}
+	return resp, nil
+}
+
+// DeleteAsset also takes the file away from buyers who already bought it.
+func (s *service) DeleteAsset(ctx context.Context, userID, productID, assetID primitive.ObjectID) error {
+	_, p, err := s.vendorProduct(ctx, userID, productID)
+	if err != nil {
+		return err
+	}
+	asset, err := s.digitalRepo.DeleteAsset(ctx, p.ID, assetID)
+	if err != nil {
+		return err
+	}
+	if err := s.blobs.Delete(ctx, asset.BlobKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("⚠️ Failed to delete digital asset blob %s: %v", asset.BlobKey, err)
+	}
+	return nil
+}
+
+func (s *service) Library(ctx context.Context, buyerID primitive.ObjectID, query LibraryQuery) ([]LibraryItemResponse, int64, error) {
+	entitlements, total, err := s.digitalRepo.ListEntitlements(ctx, buyerID, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(entitlements) == 0 {
+		return []LibraryItemResponse{}, total, nil
+	}
+
+	productIDs := make([]primitive.ObjectID, 0, len(entitlements))
+	entitlementIDs := make([]primitive.ObjectID, 0, len(entitlements))
+	for _, e := range entitlements {
+		productIDs = append(productIDs, e.ProductID)
+		entitlementIDs = append(entitlementIDs, e.ID)
+	}
+	assets, err := s.digitalRepo.ListAssets(ctx, productIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+	byProduct := make(map[primitive.ObjectID][]Asset)
+	for _, a := range assets {
+		byProduct[a.ProductID] = append(byProduct[a.ProductID], a)
+	}
+	counts, err := s.digitalRepo.DownloadCounts(ctx, entitlementIDs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	expires := time.Now().Add(s.cfg.LinkTTL).Unix()
+	resp := make([]LibraryItemResponse, 0, len(entitlements))
+	for _, e := range entitlements {
+		item := LibraryItemResponse{
+			ID:        e.ID.Hex(),
+			ProductID: e.ProductID.Hex(),
+			OrderID:   e.OrderID.Hex(),
+			Title:     e.Title,
+			Files:     []LibraryFileResponse{},
+			GrantedAt: e.GrantedAt.Format(time.RFC3339),
+		}
+		for _, a := range byProduct[e.ProductID] {
+			file := LibraryFileResponse{
+				ID:            a.ID.Hex(),
+				FileName:      a.FileName,
+				ContentType:   a.ContentType,
+				Size:          a.Size,
+				DownloadsLeft: s.cfg.DownloadLimit - counts[downloadKey{EntitlementID: e.ID, AssetID: a.ID}],
+			}
+			if file.DownloadsLeft > 0 {
+				file.DownloadURL = downloadURL(e.ID, a.ID, expires, s.signer.sign(e.ID, a.ID, expires))
+				file.ExpiresAt = time.Unix(expires, 0).UTC().Format(time.RFC3339)
+			} else {
+				file.DownloadsLeft = 0
+			}
+			item.Files = append(item.Files, file)
+		}
+		resp = append(resp, item)
+	}
+	return resp, total, nil
+}
+
+func (s *service) OpenDownload(ctx context.Context, entitlementID, assetID primitive.ObjectID, query DownloadQuery) (*Asset, io.ReadCloser, error) {
+	if !s.signer.valid(entitlementID, assetID, query.Expires, query.Signature) {
+		return nil, nil, ErrInvalidLink
+	}
+	if time.Now().Unix() > query.Expires {
+		return nil, nil, ErrLinkExpired
+	}
+
+	e, err := s.digitalRepo.GetEntitlement(ctx, entitlementID)
+	if err != nil {
+		return nil, nil, err
+	}
+	asset, err := s.digitalRepo.GetAsset(ctx, assetID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if asset.ProductID != e.ProductID {
+		return nil, nil, ErrAssetNotFound
+	}
+
+	// Open first so a missing blob doesn't use up a download.
+	blob, err := s.blobs.Open(ctx, asset.BlobKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrAssetNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.digitalRepo.CountDownload(ctx, e.ID, asset.ID, s.cfg.DownloadLimit); err != nil {
+		blob.Close()
+		return nil, nil, err
+	}
+	return asset, blob, nil
+}
+
+func (s *service) vendorProduct(ctx context.Context, userID, productID primitive.ObjectID) (*vendor.Vendor, *product.Product, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := s.productRepo.GetForVendor(ctx, v.ID, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return v, p, nil
+}
@@ -0,0 +1,13 @@
+package exchange
+
+type PublishRatesRequest struct {
+	// Rates maps currency codes to decimal strings such as "1550.25", quoted
+	// against the base currency.
+	Rates map[string]string `json:"rates" binding:"required,min=1,max=200"`
+}
+
+type RateTableResponse struct {
+	Base        string            `json:"base"`
+	Rates       map[string]string `json:"rates"`
+	PublishedAt string            `json:"published_at,omitempty"`
+}
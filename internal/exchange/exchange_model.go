@@ -0,0 +1,31 @@
+package exchange
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// RateTable is one published set of exchange rates. Tables are never edited;
+// publishing inserts a new one and the latest wins, which keeps a record of
+// what buyers were shown and when.
+type RateTable struct {
+	ID   primitive.ObjectID `bson:"_id,omitempty"`
+	Base string             `bson:"base"`
+	// Rates maps a currency code to how many major units of it one major
+	// unit of Base buys, as an exact decimal string.
+	Rates       map[string]string  `bson:"rates"`
+	PublishedBy primitive.ObjectID `bson:"published_by"`
+	PublishedAt time.Time          `bson:"published_at"`
+}
+
+func (t *RateTable) ToResponse() RateTableResponse {
+	resp := RateTableResponse{
+		Base:  t.Base,
+		Rates: t.Rates,
+	}
+	if !t.PublishedAt.IsZero() {
+		resp.PublishedAt = t.PublishedAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,71 @@
+package exchange
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	exchangeService Service
+}
+
+func NewHandler(exchangeService Service) *Handler {
+	return &Handler{
+		exchangeService: exchangeService,
+	}
+}
+
+func (h *Handler) CurrentRates(c *gin.Context) {
+	rates, err := h.exchangeService.CurrentRates(c.Request.Context())
+	if err != nil {
+		handleError(c, err, "Failed to get exchange rates")
+		return
+	}
+	response.OK(c, rates, "Exchange rates retrieved successfully")
+}
+
+func (h *Handler) PublishRates(c *gin.Context) {
+	adminID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var req PublishRatesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	rates, err := h.exchangeService.PublishRates(c.Request.Context(), adminID, req)
+	if err != nil {
+		handleError(c, err, "Failed to publish exchange rates")
+		return
+	}
+	response.OK(c, rates, "Exchange rates published successfully")
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrInvalidRates):
+		response.BadRequest(c, err.Error(), nil, response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func callerID(c *gin.Context) (primitive.ObjectID, bool) {
+	val, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	userID, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
@@ -0,0 +1,25 @@
+package exchange
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunRefresher reloads the published rates every interval until ctx is
+// cancelled, so every instance converts with the same table.
+func RunRefresher(ctx context.Context, s Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Reload(ctx); err != nil {
+				log.Printf("⚠️ failed to reload exchange rates: %v", err)
+			}
+		}
+	}
+}
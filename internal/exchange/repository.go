@@ -0,0 +1,44 @@
+package exchange
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	Publish(ctx context.Context, t *RateTable) error
+	// Latest returns the most recently published table for base, or nil when
+	// none has been published yet.
+	Latest(ctx context.Context, base string) (*RateTable, error)
+}
+
+type RateRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRateRepository(db *mongo.Database) Repository {
+	return &RateRepository{
+		collection: db.Collection("exchange_rates"),
+	}
+}
+
+func (r *RateRepository) Publish(ctx context.Context, t *RateTable) error {
+	_, err := r.collection.InsertOne(ctx, t)
+	return err
+}
+
+func (r *RateRepository) Latest(ctx context.Context, base string) (*RateTable, error) {
+	opts := options.FindOne().SetSort(bson.D{{Key: "published_at", Value: -1}, {Key: "_id", Value: -1}})
+	var t RateTable
+	err := r.collection.FindOne(ctx, bson.M{"base": base}, opts).Decode(&t)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -0,0 +1,122 @@
+package exchange
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/techrook/23-market/pkg/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var ErrInvalidRates = errors.New("invalid exchange rates")
+
+type Service interface {
+	CurrentRates(ctx context.Context) (*RateTableResponse, error)
+	// PublishRates replaces the whole table; currencies left out can no
+	// longer be shown.
+	PublishRates(ctx context.Context, adminID primitive.ObjectID, req PublishRatesRequest) (*RateTableResponse, error)
+	// Reload picks up a table published by another instance.
+	Reload(ctx context.Context) error
+
+	// Supports and Convert show amounts in a buyer's currency and implement
+	// product.Converter.
+	Supports(currency string) bool
+	Convert(m money.Money, to string) (money.Money, error)
+}
+
+type service struct {
+	rateRepo Repository
+	rates    *money.Rates
+
+	mu      sync.RWMutex
+	current RateTable
+}
+
+// NewService quotes every rate against base, which should be the currency
+// most vendors sell in.
+func NewService(rateRepo Repository, base string) (Service, error) {
+	rates, err := money.NewRates(base)
+	if err != nil {
+		return nil, fmt.Errorf("exchange base currency %q: %w", base, err)
+	}
+	return &service{
+		rateRepo: rateRepo,
+		rates:    rates,
+		current:  RateTable{Base: rates.Base(), Rates: map[string]string{}},
+	}, nil
+}
+
+func (s *service) CurrentRates(ctx context.Context) (*RateTableResponse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	resp := s.current.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) PublishRates(ctx context.Context, adminID primitive.ObjectID, req PublishRatesRequest) (*RateTableResponse, error) {
+	table := &RateTable{
+		ID:          primitive.NewObjectID(),
+		Base:        s.rates.Base(),
+		Rates:       make(map[string]string, len(req.Rates)),
+		PublishedBy: adminID,
+		PublishedAt: time.Now(),
+	}
+	for code, rate := range req.Rates {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if code == table.Base {
+			return nil, fmt.Errorf("%w: the base currency %s is always 1", ErrInvalidRates, code)
+		}
+		table.Rates[code] = strings.TrimSpace(rate)
+	}
+	// Validate against a scratch table so a bad rate never reaches buyers.
+	scratch, _ := money.NewRates(table.Base)
+	if err := scratch.Replace(table.Rates, table.PublishedAt); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidRates, err)
+	}
+
+	if err := s.rateRepo.Publish(ctx, table); err != nil {
+		return nil, err
+	}
+	if err := s.apply(table); err != nil {
+		return nil, err
+	}
+	resp := table.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) Reload(ctx context.Context) error {
+	table, err := s.rateRepo.Latest(ctx, s.rates.Base())
+	if err != nil || table == nil {
+		return err
+	}
+	s.mu.RLock()
+	unchanged := table.ID == s.current.ID
+	s.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+	return s.apply(table)
+}
+
+func (s *service) apply(table *RateTable) error {
+	if err := s.rates.Replace(table.Rates, table.PublishedAt); err != nil {
+		return fmt.Errorf("rate table %s: %w", table.ID.Hex(), err)
+	}
+	s.mu.Lock()
+	s.current = *table
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *service) Supports(currency string) bool {
+	return s.rates.Quotes(currency)
+}
+
+// Convert rounds half up, the way shoppers expect a shown price to round.
+func (s *service) Convert(m money.Money, to string) (money.Money, error) {
+	return s.rates.Convert(m, to, money.HalfUp)
+}
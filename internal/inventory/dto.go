@@ -0,0 +1,68 @@
+package inventory
+
+type AdjustStockRequest struct {
+	Reason Reason `json:"reason" binding:"required,oneof=restock damage manual_correction"`
+	// Delta is signed: restocks add, damage removes, corrections may do either.
+	Delta int64  `json:"delta" binding:"required"`
+	Note  string `json:"note" binding:"omitempty,max=500"`
+}
+
+type ReservationItemRequest struct {
+	VariantID string `json:"variant_id" binding:"required"`
+	Quantity  int64  `json:"quantity" binding:"required,min=1,max=1000"`
+}
+
+type ReserveRequest struct {
+	// Reference ties the reservation to the caller's cart or checkout.
+	Reference string                   `json:"reference" binding:"omitempty,max=100"`
+	Items     []ReservationItemRequest `json:"items" binding:"required,min=1,max=50,dive"`
+}
+
+type ListItemsQuery struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type ListAdjustmentsQuery struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type ItemResponse struct {
+	ProductID    string `json:"product_id"`
+	ProductTitle string `json:"product_title,omitempty"`
+	VariantID    string `json:"variant_id"`
+	SKU          string `json:"sku"`
+	OnHand       int64  `json:"on_hand"`
+	Reserved     int64  `json:"reserved"`
+	Available    int64  `json:"available"`
+	UpdatedAt    string `json:"updated_at,omitempty"`
+}
+
+type ReservationLineResponse struct {
+	ProductID string `json:"product_id"`
+	VariantID string `json:"variant_id"`
+	SKU       string `json:"sku"`
+	Quantity  int64  `json:"quantity"`
+}
+
+type ReservationResponse struct {
+	ID        string                    `json:"id"`
+	Reference string                    `json:"reference,omitempty"`
+	Status    string                    `json:"status"`
+	Lines     []ReservationLineResponse `json:"lines"`
+	ExpiresAt string                    `json:"expires_at"`
+	CreatedAt string                    `json:"created_at"`
+}
+
+type AdjustmentResponse struct {
+	ID          string `json:"id"`
+	SKU         string `json:"sku"`
+	Reason      string `json:"reason"`
+	Delta       int64  `json:"delta"`
+	OnHandAfter int64  `json:"on_hand_after"`
+	Note        string `json:"note,omitempty"`
+	Reference   string `json:"reference,omitempty"`
+	ActorID     string `json:"actor_id"`
+	CreatedAt   string `json:"created_at"`
+}
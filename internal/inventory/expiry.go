@@ -0,0 +1,30 @@
+package inventory
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunExpiry returns stock held by expired reservations every interval until
+// ctx is cancelled.
+func RunExpiry(ctx context.Context, s Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.ExpireReservations(ctx)
+			if err != nil {
+				log.Printf("⚠️ reservation expiry failed: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("✅ released %d expired reservations", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,204 @@
+package inventory
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	inventoryService Service
+}
+
+func NewHandler(inventoryService Service) *Handler {
+	return &Handler{
+		inventoryService: inventoryService,
+	}
+}
+
+func (h *Handler) ListItems(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var query ListItemsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+
+	items, total, err := h.inventoryService.ListItems(c.Request.Context(), userID, query)
+	if err != nil {
+		handleError(c, err, "Failed to list inventory")
+		return
+	}
+	response.Paginated(c, items, query.Page, query.PageSize, int(total), "Inventory retrieved successfully")
+}
+
+func (h *Handler) GetItem(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	item, err := h.inventoryService.GetItem(c.Request.Context(), userID, c.Param("sku"))
+	if err != nil {
+		handleError(c, err, "Failed to get inventory")
+		return
+	}
+	response.OK(c, item, "Inventory retrieved successfully")
+}
+
+func (h *Handler) AdjustStock(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var req AdjustStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	item, err := h.inventoryService.AdjustStock(c.Request.Context(), userID, c.Param("sku"), req)
+	if err != nil {
+		handleError(c, err, "Failed to adjust stock")
+		return
+	}
+	response.OK(c, item, "Stock adjusted successfully")
+}
+
+func (h *Handler) ListAdjustments(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var query ListAdjustmentsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+
+	adjustments, total, err := h.inventoryService.ListAdjustments(c.Request.Context(), userID, c.Param("sku"), query)
+	if err != nil {
+		handleError(c, err, "Failed to list adjustments")
+		return
+	}
+	response.Paginated(c, adjustments, query.Page, query.PageSize, int(total), "Adjustments retrieved successfully")
+}
+
+func (h *Handler) Reserve(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var req ReserveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	reservation, err := h.inventoryService.Reserve(c.Request.Context(), userID, req)
+	if err != nil {
+		handleError(c, err, "Failed to reserve stock")
+		return
+	}
+	response.Created(c, reservation, "Stock reserved successfully")
+}
+
+func (h *Handler) Release(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	reservationID, err := primitive.ObjectIDFromHex(c.Param("reservationID"))
+	if err != nil {
+		response.BadRequest(c, "Invalid reservation ID", nil, response.IsProduction(c))
+		return
+	}
+
+	if err := h.inventoryService.Release(c.Request.Context(), userID, reservationID); err != nil {
+		handleError(c, err, "Failed to release reservation")
+		return
+	}
+	response.NoContent(c)
+}
+
+// Commit is called by the order flow once a checkout has been paid for.
+func (h *Handler) Commit(c *gin.Context) {
+	reservationID, err := primitive.ObjectIDFromHex(c.Param("reservationID"))
+	if err != nil {
+		response.BadRequest(c, "Invalid reservation ID", nil, response.IsProduction(c))
+		return
+	}
+
+	if err := h.inventoryService.Commit(c.Request.Context(), reservationID); err != nil {
+		handleError(c, err, "Failed to commit reservation")
+		return
+	}
+	response.OK(c, nil, "Reservation committed")
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, vendor.ErrVendorNotFound):
+		response.NotFound(c, "Vendor", response.IsProduction(c))
+	case errors.Is(err, product.ErrVariantNotFound):
+		response.NotFound(c, "SKU", response.IsProduction(c))
+	case errors.Is(err, ErrItemNotFound):
+		response.NotFound(c, "Inventory item", response.IsProduction(c))
+	case errors.Is(err, ErrReservationNotFound):
+		response.NotFound(c, "Reservation", response.IsProduction(c))
+	case errors.Is(err, ErrInvalidAdjustment):
+		response.BadRequest(c, err.Error(), nil, response.IsProduction(c))
+	case errors.Is(err, ErrInsufficientStock):
+		response.Conflict(c, err.Error(), nil, response.IsProduction(c))
+	case errors.Is(err, ErrBelowReserved):
+		response.Conflict(c, "Stock cannot drop below what is reserved for pending checkouts", nil, response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorClosed):
+		response.Conflict(c, "The vendor is not accepting orders right now", nil, response.IsProduction(c))
+	case errors.Is(err, ErrVariantUnavailable):
+		response.Conflict(c, "This item is not available for sale", nil, response.IsProduction(c))
+	case errors.Is(err, ErrReservationLimit):
+		response.Error(c, http.StatusTooManyRequests, "RESERVATION_LIMIT", err.Error(), nil, response.IsProduction(c))
+	case errors.Is(err, ErrReservationNotActive):
+		response.Conflict(c, "Reservation has already ended", nil, response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func callerID(c *gin.Context) (primitive.ObjectID, bool) {
+	val, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	userID, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
@@ -0,0 +1,165 @@
+package inventory
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ReservationTTL is how long checkout may hold stock before it is returned.
+const ReservationTTL = 15 * time.Minute
+
+// MaxReservationLines caps how many variants one checkout can hold.
+const MaxReservationLines = 50
+
+// Per-buyer caps across all active reservations, so one account can't hold a
+// vendor's whole stock by reserving again and again.
+const (
+	MaxActiveReservations = 3
+	MaxReservedUnits      = 100
+)
+
+// Item tracks stock for one product variant. Reserved units are held by
+// active reservations and are not available to other buyers; Reserved never
+// exceeds OnHand.
+type Item struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	VendorID  primitive.ObjectID `bson:"vendor_id"`
+	ProductID primitive.ObjectID `bson:"product_id"`
+	VariantID primitive.ObjectID `bson:"variant_id"`
+	OnHand    int64              `bson:"on_hand"`
+	Reserved  int64              `bson:"reserved"`
+	CreatedAt time.Time          `bson:"created_at"`
+	UpdatedAt time.Time          `bson:"updated_at"`
+
+	// Filled in from the product when listing.
+	SKU          string `bson:"sku,omitempty"`
+	ProductTitle string `bson:"product_title,omitempty"`
+}
+
+func (i *Item) Available() int64 {
+	if i.OnHand < i.Reserved {
+		return 0
+	}
+	return i.OnHand - i.Reserved
+}
+
+type ReservationStatus string
+
+const (
+	// PendingReservation is stored before any stock is held, so a checkout
+	// interrupted part way still has a record of what to hand back.
+	PendingReservation   ReservationStatus = "pending"
+	ActiveReservation    ReservationStatus = "active"
+	CommittedReservation ReservationStatus = "committed"
+	ReleasedReservation  ReservationStatus = "released"
+	ExpiredReservation   ReservationStatus = "expired"
+)
+
+// ReservationLine is the quantity of one variant held by a reservation.
+// Digital lines are never short, so no stock is held for them.
+type ReservationLine struct {
+	VendorID  primitive.ObjectID `bson:"vendor_id"`
+	ProductID primitive.ObjectID `bson:"product_id"`
+	VariantID primitive.ObjectID `bson:"variant_id"`
+	SKU       string             `bson:"sku"`
+	Quantity  int64              `bson:"quantity"`
+	Digital   bool               `bson:"digital,omitempty"`
+}
+
+// Reservation holds stock for a buyer during checkout. Held records the
+// variants whose units have been taken off available stock; only those are
+// ever handed back or sold. Leaving the pending or active status is a
+// conditional update, so its quantities are returned exactly once.
+// ReturnPending stays set on a released or expired reservation until every
+// held line is back in stock, and Returned records the variants already
+// handed back, so an interrupted release can be finished later. CommitPending
+// and Consumed do the same for a sale.
+type Reservation struct {
+	ID            primitive.ObjectID   `bson:"_id,omitempty"`
+	OwnerID       primitive.ObjectID   `bson:"owner_id"`
+	Reference     string               `bson:"reference,omitempty"`
+	Lines         []ReservationLine    `bson:"lines"`
+	Status        ReservationStatus    `bson:"status"`
+	Held          []primitive.ObjectID `bson:"held"`
+	ReturnPending bool                 `bson:"return_pending,omitempty"`
+	Returned      []primitive.ObjectID `bson:"returned,omitempty"`
+	CommitPending bool                 `bson:"commit_pending,omitempty"`
+	Consumed      []primitive.ObjectID `bson:"consumed,omitempty"`
+	ExpiresAt     time.Time            `bson:"expires_at"`
+	CreatedAt     time.Time            `bson:"created_at"`
+	UpdatedAt     time.Time            `bson:"updated_at"`
+}
+
+type Reason string
+
+const (
+	RestockReason          Reason = "restock"
+	DamageReason           Reason = "damage"
+	ManualCorrectionReason Reason = "manual_correction"
+	// SaleReason is recorded when a committed reservation leaves the shelf;
+	// vendors can't submit it.
+	SaleReason Reason = "sale"
+)
+
+// Adjustment is an immutable record of a change to an item's on-hand count.
+type Adjustment struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	VendorID    primitive.ObjectID `bson:"vendor_id"`
+	VariantID   primitive.ObjectID `bson:"variant_id"`
+	SKU         string             `bson:"sku"`
+	Reason      Reason             `bson:"reason"`
+	Delta       int64              `bson:"delta"`
+	OnHandAfter int64              `bson:"on_hand_after"`
+	Note        string             `bson:"note,omitempty"`
+	Reference   string             `bson:"reference,omitempty"`
+	ActorID     primitive.ObjectID `bson:"actor_id"`
+	CreatedAt   time.Time          `bson:"created_at"`
+}
+
+func (i *Item) ToResponse() ItemResponse {
+	return ItemResponse{
+		ProductID:    i.ProductID.Hex(),
+		ProductTitle: i.ProductTitle,
+		VariantID:    i.VariantID.Hex(),
+		SKU:          i.SKU,
+		OnHand:       i.OnHand,
+		Reserved:     i.Reserved,
+		Available:    i.Available(),
+		UpdatedAt:    i.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func (r *Reservation) ToResponse() ReservationResponse {
+	lines := make([]ReservationLineResponse, 0, len(r.Lines))
+	for _, l := range r.Lines {
+		lines = append(lines, ReservationLineResponse{
+			ProductID: l.ProductID.Hex(),
+			VariantID: l.VariantID.Hex(),
+			SKU:       l.SKU,
+			Quantity:  l.Quantity,
+		})
+	}
+	return ReservationResponse{
+		ID:        r.ID.Hex(),
+		Reference: r.Reference,
+		Status:    string(r.Status),
+		Lines:     lines,
+		ExpiresAt: r.ExpiresAt.Format(time.RFC3339),
+		CreatedAt: r.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (a *Adjustment) ToResponse() AdjustmentResponse {
+	return AdjustmentResponse{
+		ID:          a.ID.Hex(),
+		SKU:         a.SKU,
+		Reason:      string(a.Reason),
+		Delta:       a.Delta,
+		OnHandAfter: a.OnHandAfter,
+		Note:        a.Note,
+		Reference:   a.Reference,
+		ActorID:     a.ActorID.Hex(),
+		CreatedAt:   a.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,438 @@
+package inventory
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Repository changes stock counts only through single conditional updates so
+// that concurrent checkouts can never take on_hand or available below zero.
+type Repository interface {
+	// EnsureItem creates an empty item for the variant if it has none.
+	EnsureItem(ctx context.Context, vendorID, productID, variantID primitive.ObjectID) error
+	GetItem(ctx context.Context, variantID primitive.ObjectID) (*Item, error)
+	ListItems(ctx context.Context, vendorID primitive.ObjectID, page, pageSize int) ([]Item, int64, error)
+	Items(ctx context.Context, variantIDs []primitive.ObjectID) ([]Item, error)
+
+	// Adjust adds delta to on_hand unless that would leave it below reserved.
+	Adjust(ctx context.Context, variantID primitive.ObjectID, delta int64) (*Item, error)
+	// Hold reserves quantity if at least that much is available.
+	Hold(ctx context.Context, variantID primitive.ObjectID, quantity int64) error
+	// Unhold returns reserved units to available.
+	Unhold(ctx context.Context, variantID primitive.ObjectID, quantity int64) error
+	// Consume removes reserved units from the shelf.
+	Consume(ctx context.Context, variantID primitive.ObjectID, quantity int64) (*Item, error)
+
+	CreateReservation(ctx context.Context, r *Reservation) error
+	GetReservation(ctx context.Context, id primitive.ObjectID) (*Reservation, error)
+	// ActiveReservations lists the owner's reservations that hold or are
+	// about to hold stock.
+	ActiveReservations(ctx context.Context, ownerID primitive.ObjectID) ([]Reservation, error)
+	// MarkHeld records that the variant's units are held. It fails with
+	// ErrReservationNotActive once the reservation is no longer pending.
+	MarkHeld(ctx context.Context, id, variantID primitive.ObjectID) error
+	// TransitionReservation moves a reservation from one status to another.
+	// It fails with ErrReservationNotActive if another caller got there
+	// first. Released and expired reservations are left with ReturnPending
+	// set, committed ones with CommitPending.
+	TransitionReservation(ctx context.Context, id primitive.ObjectID, from, to ReservationStatus) (*Reservation, error)
+	// ListExpired lists pending and active reservations past their TTL.
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]Reservation, error)
+	// MarkReturned records that the variant's units are back in stock.
+	MarkReturned(ctx context.Context, id, variantID primitive.ObjectID) error
+	// FinishReturn clears ReturnPending once every line has been returned.
+	FinishReturn(ctx context.Context, id primitive.ObjectID) error
+	// ListPendingReturns lists ended reservations with lines still held that
+	// haven't been touched since before.
+	ListPendingReturns(ctx context.Context, before time.Time, limit int) ([]Reservation, error)
+	// MarkConsumed records that the variant's units have left the shelf.
+	MarkConsumed(ctx context.Context, id, variantID primitive.ObjectID) error
+	// FinishCommit clears CommitPending once every line has been consumed.
+	FinishCommit(ctx context.Context, id primitive.ObjectID) error
+	// ListPendingCommits lists committed reservations with lines still on
+	// the shelf that haven't been touched since before.
+	ListPendingCommits(ctx context.Context, before time.Time, limit int) ([]Reservation, error)
+
+	InsertAdjustment(ctx context.Context, a *Adjustment) error
+	ListAdjustments(ctx context.Context, variantID primitive.ObjectID, page, pageSize int) ([]Adjustment, int64, error)
+}
+
+type InventoryRepository struct {
+	items        *mongo.Collection
+	reservations *mongo.Collection
+	adjustments  *mongo.Collection
+	products     string
+}
+
+func NewInventoryRepository(db *mongo.Database) Repository {
+	return &InventoryRepository{
+		items:        db.Collection("inventory_items"),
+		reservations: db.Collection("inventory_reservations"),
+		adjustments:  db.Collection("inventory_adjustments"),
+		products:     "products",
+	}
+}
+
+func (r *InventoryRepository) EnsureItem(ctx context.Context, vendorID, productID, variantID primitive.ObjectID) error {
+	now := time.Now()
+	_, err := r.items.UpdateOne(ctx,
+		bson.M{"variant_id": variantID},
+		bson.M{"$setOnInsert": bson.M{
+			"vendor_id":  vendorID,
+			"product_id": productID,
+			"on_hand":    int64(0),
+			"reserved":   int64(0),
+			"created_at": now,
+			"updated_at": now,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	// Two concurrent upserts can race on the unique index; either way the item exists.
+	if mongo.IsDuplicateKeyError(err) {
+		return nil
+	}
+	return err
+}
+
+func (r *InventoryRepository) GetItem(ctx context.Context, variantID primitive.ObjectID) (*Item, error) {
+	var item Item
+	err := r.items.FindOne(ctx, bson.M{"variant_id": variantID}).Decode(&item)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrItemNotFound
+	}
+	return &item, err
+}
+
+func (r *InventoryRepository) ListItems(ctx context.Context, vendorID primitive.ObjectID, page, pageSize int) ([]Item, int64, error) {
+	filter := bson.M{"vendor_id": vendorID}
+	total, err := r.items.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// The SKU lives on the product so renames never leave a stale copy here.
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: filter}},
+		{{Key: "$sort", Value: bson.D{{Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}}}},
+		{{Key: "$skip", Value: (page - 1) * pageSize}},
+		{{Key: "$limit", Value: pageSize}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         r.products,
+			"localField":   "product_id",
+			"foreignField": "_id",
+			"pipeline":     bson.A{bson.M{"$project": bson.M{"title": 1, "variants._id": 1, "variants.sku": 1}}},
+			"as":           "product",
+		}}},
+		{{Key: "$set", Value: bson.M{
+			"product_title": bson.M{"$first": "$product.title"},
+			"sku": bson.M{"$first": bson.M{"$map": bson.M{
+				"input": bson.M{"$filter": bson.M{
+					"input": bson.M{"$first": "$product.variants"},
+					"cond":  bson.M{"$eq": bson.A{"$$this._id", "$variant_id"}},
+				}},
+				"in": "$$this.sku",
+			}}},
+		}}},
+		{{Key: "$unset", Value: "product"}},
+	}
+
+	cursor, err := r.items.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	items := []Item{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func (r *InventoryRepository) Items(ctx context.Context, variantIDs []primitive.ObjectID) ([]Item, error) {
+	cursor, err := r.items.Find(ctx, bson.M{"variant_id": bson.M{"$in": variantIDs}})
+	if err != nil {
+		return nil, err
+	}
+	items := []Item{}
+	if err := cursor.All(ctx, &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func (r *InventoryRepository) Adjust(ctx context.Context, variantID primitive.ObjectID, delta int64) (*Item, error) {
+	filter := bson.M{
+		"variant_id": variantID,
+		"$expr":      bson.M{"$gte": bson.A{bson.M{"$add": bson.A{"$on_hand", delta}}, "$reserved"}},
+	}
+	update := bson.M{
+		"$inc": bson.M{"on_hand": delta},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var item Item
+	err := r.items.FindOneAndUpdate(ctx, filter, update, opts).Decode(&item)
+	if err == mongo.ErrNoDocuments {
+		return nil, r.missOrShort(ctx, variantID, ErrBelowReserved)
+	}
+	return &item, err
+}
+
+func (r *InventoryRepository) Hold(ctx context.Context, variantID primitive.ObjectID, quantity int64) error {
+	res, err := r.items.UpdateOne(ctx,
+		bson.M{
+			"variant_id": variantID,
+			"$expr":      bson.M{"$gte": bson.A{bson.M{"$subtract": bson.A{"$on_hand", "$reserved"}}, quantity}},
+		},
+		bson.M{
+			"$inc": bson.M{"reserved": quantity},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrInsufficientStock
+	}
+	return nil
+}
+
+func (r *InventoryRepository) Unhold(ctx context.Context, variantID primitive.ObjectID, quantity int64) error {
+	res, err := r.items.UpdateOne(ctx,
+		bson.M{"variant_id": variantID, "reserved": bson.M{"$gte": quantity}},
+		bson.M{
+			"$inc": bson.M{"reserved": -quantity},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return r.missOrShort(ctx, variantID, ErrInsufficientStock)
+	}
+	return nil
+}
+
+func (r *InventoryRepository) Consume(ctx context.Context, variantID primitive.ObjectID, quantity int64) (*Item, error) {
+	filter := bson.M{
+		"variant_id": variantID,
+		"reserved":   bson.M{"$gte": quantity},
+		"on_hand":    bson.M{"$gte": quantity},
+	}
+	update := bson.M{
+		"$inc": bson.M{"on_hand": -quantity, "reserved": -quantity},
+		"$set": bson.M{"updated_at": time.Now()},
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var item Item
+	err := r.items.FindOneAndUpdate(ctx, filter, update, opts).Decode(&item)
+	if err == mongo.ErrNoDocuments {
+		return nil, r.missOrShort(ctx, variantID, ErrInsufficientStock)
+	}
+	return &item, err
+}
+
+// missOrShort tells a missing item apart from one whose condition failed.
+func (r *InventoryRepository) missOrShort(ctx context.Context, variantID primitive.ObjectID, short error) error {
+	if _, err := r.GetItem(ctx, variantID); err != nil {
+		return err
+	}
+	return short
+}
+
+func (r *InventoryRepository) CreateReservation(ctx context.Context, res *Reservation) error {
+	_, err := r.reservations.InsertOne(ctx, res)
+	return err
+}
+
+func (r *InventoryRepository) GetReservation(ctx context.Context, id primitive.ObjectID) (*Reservation, error) {
+	var res Reservation
+	err := r.reservations.FindOne(ctx, bson.M{"_id": id}).Decode(&res)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReservationNotFound
+	}
+	return &res, err
+}
+
+func (r *InventoryRepository) ActiveReservations(ctx context.Context, ownerID primitive.ObjectID) ([]Reservation, error) {
+	cursor, err := r.reservations.Find(ctx, bson.M{
+		"owner_id": ownerID,
+		"status":   bson.M{"$in": bson.A{PendingReservation, ActiveReservation}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	reservations := []Reservation{}
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+func (r *InventoryRepository) MarkHeld(ctx context.Context, id, variantID primitive.ObjectID) error {
+	res, err := r.reservations.UpdateOne(ctx,
+		bson.M{"_id": id, "status": PendingReservation},
+		bson.M{
+			"$addToSet": bson.M{"held": variantID},
+			"$set":      bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrReservationNotActive
+	}
+	return nil
+}
+
+func (r *InventoryRepository) TransitionReservation(ctx context.Context, id primitive.ObjectID, from, to ReservationStatus) (*Reservation, error) {
+	filter := bson.M{"_id": id, "status": from}
+	set := bson.M{"status": to, "updated_at": time.Now()}
+	switch to {
+	case ReleasedReservation, ExpiredReservation:
+		set["return_pending"] = true
+	case CommittedReservation:
+		set["commit_pending"] = true
+	}
+	update := bson.M{"$set": set}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+
+	var res Reservation
+	err := r.reservations.FindOneAndUpdate(ctx, filter, update, opts).Decode(&res)
+	if err == mongo.ErrNoDocuments {
+		if _, err := r.GetReservation(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrReservationNotActive
+	}
+	return &res, err
+}
+
+func (r *InventoryRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]Reservation, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "expires_at", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.reservations.Find(ctx, bson.M{
+		"status":     bson.M{"$in": bson.A{PendingReservation, ActiveReservation}},
+		"expires_at": bson.M{"$lte": now},
+	}, opts)
+	if err != nil {
+		return nil, err
+	}
+	reservations := []Reservation{}
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+func (r *InventoryRepository) MarkReturned(ctx context.Context, id, variantID primitive.ObjectID) error {
+	_, err := r.reservations.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$addToSet": bson.M{"returned": variantID},
+			"$set":      bson.M{"updated_at": time.Now()},
+		},
+	)
+	return err
+}
+
+func (r *InventoryRepository) FinishReturn(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.reservations.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$unset": bson.M{"return_pending": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+		},
+	)
+	return err
+}
+
+func (r *InventoryRepository) ListPendingReturns(ctx context.Context, before time.Time, limit int) ([]Reservation, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.reservations.Find(ctx, bson.M{"return_pending": true, "updated_at": bson.M{"$lte": before}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	reservations := []Reservation{}
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+func (r *InventoryRepository) MarkConsumed(ctx context.Context, id, variantID primitive.ObjectID) error {
+	_, err := r.reservations.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$addToSet": bson.M{"consumed": variantID},
+			"$set":      bson.M{"updated_at": time.Now()},
+		},
+	)
+	return err
+}
+
+func (r *InventoryRepository) FinishCommit(ctx context.Context, id primitive.ObjectID) error {
+	_, err := r.reservations.UpdateOne(ctx,
+		bson.M{"_id": id},
+		bson.M{
+			"$unset": bson.M{"commit_pending": ""},
+			"$set":   bson.M{"updated_at": time.Now()},
+		},
+	)
+	return err
+}
+
+func (r *InventoryRepository) ListPendingCommits(ctx context.Context, before time.Time, limit int) ([]Reservation, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.reservations.Find(ctx, bson.M{"commit_pending": true, "updated_at": bson.M{"$lte": before}}, opts)
+	if err != nil {
+		return nil, err
+	}
+	reservations := []Reservation{}
+	if err := cursor.All(ctx, &reservations); err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+func (r *InventoryRepository) InsertAdjustment(ctx context.Context, a *Adjustment) error {
+	_, err := r.adjustments.InsertOne(ctx, a)
+	return err
+}
+
+func (r *InventoryRepository) ListAdjustments(ctx context.Context, variantID primitive.ObjectID, page, pageSize int) ([]Adjustment, int64, error) {
+	filter := bson.M{"variant_id": variantID}
+	total, err := r.adjustments.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.adjustments.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	adjustments := []Adjustment{}
+	if err := cursor.All(ctx, &adjustments); err != nil {
+		return nil, 0, err
+	}
+	return adjustments, total, nil
+}
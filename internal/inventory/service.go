@@ -0,0 +1,585 @@
+package inventory
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/vendor"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// pendingReturnGrace is how long a release or a sale may take before the
+// expiry sweep assumes it was interrupted and finishes it.
+const pendingReturnGrace = time.Minute
+
+var (
+	ErrItemNotFound         = errors.New("inventory item not found")
+	ErrInsufficientStock    = errors.New("insufficient stock")
+	ErrBelowReserved        = errors.New("on-hand stock cannot drop below the reserved quantity")
+	ErrInvalidAdjustment    = errors.New("invalid stock adjustment")
+	ErrVariantUnavailable   = errors.New("variant is not available for sale")
+	ErrReservationNotFound  = errors.New("reservation not found")
+	ErrReservationNotActive = errors.New("reservation is no longer active")
+	ErrReservationLimit     = errors.New("too much stock reserved")
+)
+
+type Service interface {
+	ListItems(ctx context.Context, userID primitive.ObjectID, query ListItemsQuery) ([]ItemResponse, int64, error)
+	GetItem(ctx context.Context, userID primitive.ObjectID, sku string) (*ItemResponse, error)
+	AdjustStock(ctx context.Context, userID primitive.ObjectID, sku string, req AdjustStockRequest) (*ItemResponse, error)
+	ListAdjustments(ctx context.Context, userID primitive.ObjectID, sku string, query ListAdjustmentsQuery) ([]AdjustmentResponse, int64, error)
+
+	Reserve(ctx context.Context, buyerID primitive.ObjectID, req ReserveRequest) (*ReservationResponse, error)
+	Release(ctx context.Context, buyerID, reservationID primitive.ObjectID) error
+	// Commit turns an active reservation into a sale once checkout has been
+	// paid for, taking the units off the shelf.
+	Commit(ctx context.Context, reservationID primitive.ObjectID) error
+	// ExpireReservations returns stock held by reservations past their TTL.
+	ExpireReservations(ctx context.Context) (int, error)
+
+	Available(ctx context.Context, variantIDs []primitive.ObjectID) (product.Stock, error)
+
+	// OnHand and SetOnHand read and overwrite physical counts for bulk
+	// catalogue import and export. SetOnHand records the difference as a
+	// manual correction.
+	OnHand(ctx context.Context, variantIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error)
+	SetOnHand(ctx context.Context, actorID primitive.ObjectID, p *product.Product, variantID primitive.ObjectID, onHand int64, reference string) error
+}
+
+// OrderGate decides whether a vendor takes orders right now. vendor.Service
+// implements it.
+type OrderGate interface {
+	EnsureAcceptingOrders(ctx context.Context, vendorID primitive.ObjectID) (*vendor.Vendor, error)
+}
+
+type service struct {
+	inventoryRepo Repository
+	productRepo   product.Repository
+	vendorRepo    vendor.Repository
+	orders        OrderGate
+}
+
+func NewService(inventoryRepo Repository, productRepo product.Repository, vendorRepo vendor.Repository, orders OrderGate) Service {
+	return &service{
+		inventoryRepo: inventoryRepo,
+		productRepo:   productRepo,
+		vendorRepo:    vendorRepo,
+		orders:        orders,
+	}
+}
+
+func (s *service) ListItems(ctx context.Context, userID primitive.ObjectID, query ListItemsQuery) ([]ItemResponse, int64, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	items, total, err := s.inventoryRepo.ListItems(ctx, v.ID, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]ItemResponse, 0, len(items))
+	for i := range items {
+		resp = append(resp, items[i].ToResponse())
+	}
+	return resp, total, nil
+}
+
+func (s *service) GetItem(ctx context.Context, userID primitive.ObjectID, sku string) (*ItemResponse, error) {
+	p, variant, err := s.vendorVariant(ctx, userID, sku)
+	if err != nil {
+		return nil, err
+	}
+	item, err := s.inventoryRepo.GetItem(ctx, variant.ID)
+	if errors.Is(err, ErrItemNotFound) {
+		// Variants nobody has stocked yet simply have nothing on hand.
+		item = &Item{ProductID: p.ID, VariantID: variant.ID}
+	} else if err != nil {
+		return nil, err
+	}
+	return itemResponse(item, p, variant), nil
+}
+
+func (s *service) AdjustStock(ctx context.Context, userID primitive.ObjectID, sku string, req AdjustStockRequest) (*ItemResponse, error) {
+	if err := validateAdjustment(req.Reason, req.Delta); err != nil {
+		return nil, err
+	}
+	p, variant, err := s.vendorVariant(ctx, userID, sku)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.inventoryRepo.EnsureItem(ctx, p.VendorID, p.ID, variant.ID); err != nil {
+		return nil, err
+	}
+	item, err := s.inventoryRepo.Adjust(ctx, variant.ID, req.Delta)
+	if err != nil {
+		return nil, err
+	}
+
+	adjustment := &Adjustment{
+		VendorID:    p.VendorID,
+		VariantID:   variant.ID,
+		SKU:         variant.SKU,
+		Reason:      req.Reason,
+		Delta:       req.Delta,
+		OnHandAfter: item.OnHand,
+		Note:        req.Note,
+		ActorID:     userID,
+		CreatedAt:   time.Now(),
+	}
+	if err := s.inventoryRepo.InsertAdjustment(ctx, adjustment); err != nil {
+		return nil, err
+	}
+	return itemResponse(item, p, variant), nil
+}
+
+func (s *service) ListAdjustments(ctx context.Context, userID primitive.ObjectID, sku string, query ListAdjustmentsQuery) ([]AdjustmentResponse, int64, error) {
+	_, variant, err := s.vendorVariant(ctx, userID, sku)
+	if err != nil {
+		return nil, 0, err
+	}
+	adjustments, total, err := s.inventoryRepo.ListAdjustments(ctx, variant.ID, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]AdjustmentResponse, 0, len(adjustments))
+	for i := range adjustments {
+		resp = append(resp, adjustments[i].ToResponse())
+	}
+	return resp, total, nil
+}
+
+func (s *service) Reserve(ctx context.Context, buyerID primitive.ObjectID, req ReserveRequest) (*ReservationResponse, error) {
+	lines, err := s.reservationLines(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkReservationLimits(ctx, buyerID, lines); err != nil {
+		return nil, err
+	}
+
+	// The reservation is stored before any stock is held, and each line is
+	// recorded as it is held, so a checkout that stops part way is handed
+	// back by the expiry sweep. If any line is short the ones already held
+	// are returned so the checkout is all or nothing.
+	now := time.Now()
+	reservation := &Reservation{
+		ID:        primitive.NewObjectID(),
+		OwnerID:   buyerID,
+		Reference: req.Reference,
+		Lines:     lines,
+		Status:    PendingReservation,
+		Held:      []primitive.ObjectID{},
+		ExpiresAt: now.Add(ReservationTTL),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.inventoryRepo.CreateReservation(ctx, reservation); err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		if line.Digital {
+			continue
+		}
+		err := s.inventoryRepo.Hold(ctx, line.VariantID, line.Quantity)
+		if err == nil {
+			if err = s.inventoryRepo.MarkHeld(ctx, reservation.ID, line.VariantID); err != nil {
+				s.unhold(ctx, []ReservationLine{line})
+			}
+		}
+		if err != nil {
+			s.abandon(ctx, reservation.ID)
+			if errors.Is(err, ErrInsufficientStock) {
+				return nil, fmt.Errorf("%w: %s", ErrInsufficientStock, line.SKU)
+			}
+			return nil, err
+		}
+	}
+
+	active, err := s.inventoryRepo.TransitionReservation(ctx, reservation.ID, PendingReservation, ActiveReservation)
+	if err != nil {
+		s.abandon(ctx, reservation.ID)
+		return nil, err
+	}
+	resp := active.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) Release(ctx context.Context, buyerID, reservationID primitive.ObjectID) error {
+	reservation, err := s.inventoryRepo.GetReservation(ctx, reservationID)
+	if err != nil {
+		return err
+	}
+	if reservation.OwnerID != buyerID {
+		return ErrReservationNotFound
+	}
+	return s.end(ctx, reservationID, ActiveReservation, ReleasedReservation)
+}
+
+// Commit is safe to repeat: committing a reservation that has already been
+// sold finishes whatever an earlier attempt left on the shelf.
+func (s *service) Commit(ctx context.Context, reservationID primitive.ObjectID) error {
+	reservation, err := s.inventoryRepo.TransitionReservation(ctx, reservationID, ActiveReservation, CommittedReservation)
+	if errors.Is(err, ErrReservationNotActive) {
+		reservation, err = s.inventoryRepo.GetReservation(ctx, reservationID)
+		if err == nil && reservation.Status != CommittedReservation {
+			return ErrReservationNotActive
+		}
+		if err == nil && !reservation.CommitPending {
+			return nil
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return s.consumeLines(ctx, reservation)
+}
+
+func (s *service) ExpireReservations(ctx context.Context) (int, error) {
+	if err := s.retryPendingReturns(ctx); err != nil {
+		return 0, err
+	}
+	if err := s.retryPendingCommits(ctx); err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for {
+		reservations, err := s.inventoryRepo.ListExpired(ctx, time.Now(), 100)
+		if err != nil {
+			return expired, err
+		}
+		for _, r := range reservations {
+			// A pending reservation this old belongs to a checkout that
+			// stopped while holding stock.
+			err := s.end(ctx, r.ID, r.Status, ExpiredReservation)
+			switch {
+			case errors.Is(err, ErrReservationNotActive):
+				// Committed or released while we were looking at it.
+			case err != nil:
+				return expired, err
+			default:
+				expired++
+			}
+		}
+		if len(reservations) < 100 {
+			return expired, nil
+		}
+	}
+}
+
+func (s *service) Available(ctx context.Context, variantIDs []primitive.ObjectID) (product.Stock, error) {
+	stock := make(product.Stock, len(variantIDs))
+	if len(variantIDs) == 0 {
+		return stock, nil
+	}
+	items, err := s.inventoryRepo.Items(ctx, variantIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		stock[items[i].VariantID] = items[i].Available()
+	}
+	return stock, nil
+}
+
+func (s *service) OnHand(ctx context.Context, variantIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error) {
+	onHand := make(map[primitive.ObjectID]int64, len(variantIDs))
+	if len(variantIDs) == 0 {
+		return onHand, nil
+	}
+	items, err := s.inventoryRepo.Items(ctx, variantIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		onHand[items[i].VariantID] = items[i].OnHand
+	}
+	return onHand, nil
+}
+
+func (s *service) SetOnHand(ctx context.Context, actorID primitive.ObjectID, p *product.Product, variantID primitive.ObjectID, onHand int64, reference string) error {
+	variant := p.Variant(variantID)
+	if variant == nil {
+		return product.ErrVariantNotFound
+	}
+	if onHand < 0 {
+		return fmt.Errorf("%w: stock cannot be negative", ErrInvalidAdjustment)
+	}
+	if err := s.inventoryRepo.EnsureItem(ctx, p.VendorID, p.ID, variantID); err != nil {
+		return err
+	}
+	current, err := s.inventoryRepo.GetItem(ctx, variantID)
+	if err != nil {
+		return err
+	}
+	delta := onHand - current.OnHand
+	if delta == 0 {
+		return nil
+	}
+	item, err := s.inventoryRepo.Adjust(ctx, variantID, delta)
+	if err != nil {
+		return err
+	}
+	return s.inventoryRepo.InsertAdjustment(ctx, &Adjustment{
+		VendorID:    p.VendorID,
+		VariantID:   variantID,
+		SKU:         variant.SKU,
+		Reason:      ManualCorrectionReason,
+		Delta:       delta,
+		OnHandAfter: item.OnHand,
+		Reference:   reference,
+		ActorID:     actorID,
+		CreatedAt:   time.Now(),
+	})
+}
+
+// end moves a reservation from one status to a final one and returns its
+// units. The status change is what guarantees the units are returned only
+// once.
+func (s *service) end(ctx context.Context, reservationID primitive.ObjectID, from, to ReservationStatus) error {
+	reservation, err := s.inventoryRepo.TransitionReservation(ctx, reservationID, from, to)
+	if err != nil {
+		return err
+	}
+	return s.returnLines(ctx, reservation)
+}
+
+// abandon releases a reservation whose checkout failed while holding stock.
+// Anything it can't hand back now is left to the expiry sweep.
+func (s *service) abandon(ctx context.Context, reservationID primitive.ObjectID) {
+	err := s.end(ctx, reservationID, PendingReservation, ReleasedReservation)
+	if err != nil && !errors.Is(err, ErrReservationNotActive) {
+		log.Printf("⚠️ failed to release abandoned reservation %s: %v", reservationID.Hex(), err)
+	}
+}
+
+// returnLines hands back every held line of an ended reservation not yet
+// returned, recording each one as it goes. If a line fails the reservation
+// stays pending and the expiry sweep picks it up again.
+func (s *service) returnLines(ctx context.Context, reservation *Reservation) error {
+	returned := idSet(reservation.Returned)
+	held := idSet(reservation.Held)
+	for _, line := range reservation.Lines {
+		if !held[line.VariantID] || returned[line.VariantID] {
+			continue
+		}
+		// An item that no longer exists has nothing left to return.
+		if err := s.inventoryRepo.Unhold(ctx, line.VariantID, line.Quantity); err != nil && !errors.Is(err, ErrItemNotFound) {
+			return err
+		}
+		if err := s.inventoryRepo.MarkReturned(ctx, reservation.ID, line.VariantID); err != nil {
+			return err
+		}
+	}
+	return s.inventoryRepo.FinishReturn(ctx, reservation.ID)
+}
+
+// retryPendingReturns finishes releases that failed part way. Reservations
+// touched within pendingReturnGrace are left alone, as their release may
+// still be running.
+func (s *service) retryPendingReturns(ctx context.Context) error {
+	for {
+		reservations, err := s.inventoryRepo.ListPendingReturns(ctx, time.Now().Add(-pendingReturnGrace), 100)
+		if err != nil {
+			return err
+		}
+		for i := range reservations {
+			if err := s.returnLines(ctx, &reservations[i]); err != nil {
+				log.Printf("⚠️ failed to return stock held by reservation %s: %v", reservations[i].ID.Hex(), err)
+			}
+		}
+		if len(reservations) < 100 {
+			return nil
+		}
+	}
+}
+
+// consumeLines takes every held line of a committed reservation off the
+// shelf, recording each one as it goes so that a commit which stops part way
+// can be finished by calling Commit again or by the expiry sweep.
+func (s *service) consumeLines(ctx context.Context, reservation *Reservation) error {
+	consumed := idSet(reservation.Consumed)
+	held := idSet(reservation.Held)
+	for _, line := range reservation.Lines {
+		if !held[line.VariantID] || consumed[line.VariantID] {
+			continue
+		}
+		item, err := s.inventoryRepo.Consume(ctx, line.VariantID, line.Quantity)
+		if err != nil {
+			return err
+		}
+		adjustment := &Adjustment{
+			VendorID:    line.VendorID,
+			VariantID:   line.VariantID,
+			SKU:         line.SKU,
+			Reason:      SaleReason,
+			Delta:       -line.Quantity,
+			OnHandAfter: item.OnHand,
+			Reference:   reservation.ID.Hex(),
+			ActorID:     reservation.OwnerID,
+			CreatedAt:   time.Now(),
+		}
+		if err := s.inventoryRepo.InsertAdjustment(ctx, adjustment); err != nil {
+			return err
+		}
+		if err := s.inventoryRepo.MarkConsumed(ctx, reservation.ID, line.VariantID); err != nil {
+			return err
+		}
+	}
+	return s.inventoryRepo.FinishCommit(ctx, reservation.ID)
+}
+
+// retryPendingCommits finishes sales that failed part way, leaving alone
+// those touched within pendingReturnGrace.
+func (s *service) retryPendingCommits(ctx context.Context) error {
+	for {
+		reservations, err := s.inventoryRepo.ListPendingCommits(ctx, time.Now().Add(-pendingReturnGrace), 100)
+		if err != nil {
+			return err
+		}
+		for i := range reservations {
+			if err := s.consumeLines(ctx, &reservations[i]); err != nil {
+				log.Printf("⚠️ failed to take stock sold by reservation %s off the shelf: %v", reservations[i].ID.Hex(), err)
+			}
+		}
+		if len(reservations) < 100 {
+			return nil
+		}
+	}
+}
+
+func idSet(ids []primitive.ObjectID) map[primitive.ObjectID]bool {
+	set := make(map[primitive.ObjectID]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
+// checkReservationLimits keeps a buyer within MaxActiveReservations and
+// MaxReservedUnits once lines are added to what they already hold.
+func (s *service) checkReservationLimits(ctx context.Context, buyerID primitive.ObjectID, lines []ReservationLine) error {
+	active, err := s.inventoryRepo.ActiveReservations(ctx, buyerID)
+	if err != nil {
+		return err
+	}
+	if len(active) >= MaxActiveReservations {
+		return fmt.Errorf("%w: at most %d checkouts may hold stock at once", ErrReservationLimit, MaxActiveReservations)
+	}
+	// Digital lines hold no stock, so they don't count towards the cap.
+	var units int64
+	for _, r := range active {
+		for _, line := range r.Lines {
+			if !line.Digital {
+				units += line.Quantity
+			}
+		}
+	}
+	for _, line := range lines {
+		if !line.Digital {
+			units += line.Quantity
+		}
+	}
+	if units > MaxReservedUnits {
+		return fmt.Errorf("%w: at most %d units may be held at once", ErrReservationLimit, MaxReservedUnits)
+	}
+	return nil
+}
+
+func (s *service) unhold(ctx context.Context, lines []ReservationLine) {
+	for _, line := range lines {
+		if err := s.inventoryRepo.Unhold(ctx, line.VariantID, line.Quantity); err != nil {
+			log.Printf("⚠️ failed to return %d of %s to stock: %v", line.Quantity, line.VariantID.Hex(), err)
+		}
+	}
+}
+
+// reservationLines resolves requested variants, merging repeats, and checks
+// each belongs to a listed product of a vendor taking orders right now.
+func (s *service) reservationLines(ctx context.Context, items []ReservationItemRequest) ([]ReservationLine, error) {
+	lines := make([]ReservationLine, 0, len(items))
+	index := make(map[primitive.ObjectID]int, len(items))
+	accepting := make(map[primitive.ObjectID]error)
+
+	for _, it := range items {
+		variantID, err := primitive.ObjectIDFromHex(it.VariantID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", product.ErrVariantNotFound, it.VariantID)
+		}
+		if i, ok := index[variantID]; ok {
+			lines[i].Quantity += it.Quantity
+			continue
+		}
+
+		p, err := s.productRepo.GetByVariantID(ctx, variantID)
+		if err != nil {
+			return nil, err
+		}
+		if !p.IsListed() {
+			return nil, ErrVariantUnavailable
+		}
+		vendorErr, seen := accepting[p.VendorID]
+		if !seen {
+			_, vendorErr = s.orders.EnsureAcceptingOrders(ctx, p.VendorID)
+			accepting[p.VendorID] = vendorErr
+		}
+		switch {
+		case errors.Is(vendorErr, vendor.ErrVendorNotApproved):
+			return nil, ErrVariantUnavailable
+		case vendorErr != nil:
+			return nil, vendorErr
+		}
+
+		index[variantID] = len(lines)
+		lines = append(lines, ReservationLine{
+			VendorID:  p.VendorID,
+			ProductID: p.ID,
+			VariantID: variantID,
+			SKU:       p.Variant(variantID).SKU,
+			Quantity:  it.Quantity,
+			Digital:   p.IsDigital(),
+		})
+	}
+	return lines, nil
+}
+
+// vendorVariant finds one of the caller's variants by SKU.
+func (s *service) vendorVariant(ctx context.Context, userID primitive.ObjectID, sku string) (*product.Product, *product.Variant, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := s.productRepo.GetBySKU(ctx, v.ID, sku)
+	if err != nil {
+		return nil, nil, err
+	}
+	variant := p.VariantBySKU(sku)
+	if variant == nil {
+		return nil, nil, product.ErrVariantNotFound
+	}
+	return p, variant, nil
+}
+
+func validateAdjustment(reason Reason, delta int64) error {
+	switch {
+	case delta == 0:
+		return fmt.Errorf("%w: delta cannot be zero", ErrInvalidAdjustment)
+	case reason == RestockReason && delta < 0:
+		return fmt.Errorf("%w: a restock must add stock", ErrInvalidAdjustment)
+	case reason == DamageReason && delta > 0:
+		return fmt.Errorf("%w: damage must remove stock", ErrInvalidAdjustment)
+	}
+	return nil
+}
+
+func itemResponse(item *Item, p *product.Product, variant *product.Variant) *ItemResponse {
+	item.SKU = variant.SKU
+	item.ProductTitle = p.Title
+	resp := item.ToResponse()
+	return &resp
+}
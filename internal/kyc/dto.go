@@ -0,0 +1,29 @@
+package kyc
+
+type UploadDocumentRequest struct {
+	Type DocumentType `form:"type" binding:"required"`
+}
+
+type RejectDocumentRequest struct {
+	Reason string `json:"reason" binding:"required,min=3,max=500"`
+}
+
+type ListDocumentsQuery struct {
+	Page     int          `form:"page" binding:"omitempty,min=1"`
+	PageSize int          `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Status   ReviewStatus `form:"status" binding:"omitempty,oneof=pending approved rejected"`
+}
+
+type DocumentResponse struct {
+	ID              string `json:"id"`
+	VendorID        string `json:"vendor_id"`
+	Type            string `json:"type"`
+	FileName        string `json:"file_name"`
+	ContentType     string `json:"content_type"`
+	Size            int64  `json:"size"`
+	Status          string `json:"status"`
+	RejectionReason string `json:"rejection_reason,omitempty"`
+	ReviewedAt      string `json:"reviewed_at,omitempty"`
+	CreatedAt       string `json:"created_at"`
+	UpdatedAt       string `json:"updated_at"`
+}
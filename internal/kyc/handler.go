@@ -0,0 +1,224 @@
+package kyc
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	kycService Service
+}
+
+func NewHandler(kycService Service) *Handler {
+	return &Handler{
+		kycService: kycService,
+	}
+}
+
+func (h *Handler) UploadDocument(c *gin.Context) {
+	userID, ok := contextID(c, "subjectID")
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart envelope around the file itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxDocumentSize+1<<20)
+
+	var req UploadDocumentRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "A file is required", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.BadRequest(c, "Could not read uploaded file", nil, response.IsProduction(c))
+		return
+	}
+	defer file.Close()
+
+	doc, err := h.kycService.UploadDocument(c.Request.Context(), userID, req.Type, filepath.Base(fileHeader.Filename), file)
+	if err != nil {
+		handleError(c, err, "Failed to upload document")
+		return
+	}
+	response.Created(c, doc, "Document uploaded successfully")
+}
+
+func (h *Handler) ListVendorDocuments(c *gin.Context) {
+	userID, ok := contextID(c, "subjectID")
+	if !ok {
+		return
+	}
+
+	docs, err := h.kycService.ListVendorDocuments(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err, "Failed to list documents")
+		return
+	}
+	response.OK(c, docs, "Documents retrieved successfully")
+}
+
+func (h *Handler) DownloadVendorDocument(c *gin.Context) {
+	userID, ok := contextID(c, "subjectID")
+	if !ok {
+		return
+	}
+	documentID, ok := documentIDParam(c)
+	if !ok {
+		return
+	}
+
+	doc, blob, err := h.kycService.OpenVendorDocument(c.Request.Context(), userID, documentID)
+	if err != nil {
+		handleError(c, err, "Failed to download document")
+		return
+	}
+	serveDocument(c, doc, blob)
+}
+
+func (h *Handler) ListReviewQueue(c *gin.Context) {
+	var query ListDocumentsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+	if query.Status == "" {
+		query.Status = PendingReviewStatus
+	}
+
+	docs, total, err := h.kycService.ListReviewQueue(c.Request.Context(), query.Status, query.Page, query.PageSize)
+	if err != nil {
+		response.InternalError(c, "Failed to list documents", err, response.IsProduction(c))
+		return
+	}
+	response.Paginated(c, docs, query.Page, query.PageSize, int(total), "Documents retrieved successfully")
+}
+
+func (h *Handler) DownloadDocument(c *gin.Context) {
+	documentID, ok := documentIDParam(c)
+	if !ok {
+		return
+	}
+
+	doc, blob, err := h.kycService.OpenDocument(c.Request.Context(), documentID)
+	if err != nil {
+		handleError(c, err, "Failed to download document")
+		return
+	}
+	serveDocument(c, doc, blob)
+}
+
+func (h *Handler) ApproveDocument(c *gin.Context) {
+	documentID, ok := documentIDParam(c)
+	if !ok {
+		return
+	}
+	reviewerID, ok := contextID(c, "userID")
+	if !ok {
+		return
+	}
+
+	doc, err := h.kycService.ApproveDocument(c.Request.Context(), documentID, reviewerID)
+	if err != nil {
+		handleError(c, err, "Failed to approve document")
+		return
+	}
+	response.OK(c, doc, "Document approved")
+}
+
+func (h *Handler) RejectDocument(c *gin.Context) {
+	documentID, ok := documentIDParam(c)
+	if !ok {
+		return
+	}
+	reviewerID, ok := contextID(c, "userID")
+	if !ok {
+		return
+	}
+
+	var req RejectDocumentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	doc, err := h.kycService.RejectDocument(c.Request.Context(), documentID, reviewerID, req.Reason)
+	if err != nil {
+		handleError(c, err, "Failed to reject document")
+		return
+	}
+	response.OK(c, doc, "Document rejected")
+}
+
+func serveDocument(c *gin.Context, doc *Document, blob io.ReadCloser) {
+	defer blob.Close()
+	c.DataFromReader(http.StatusOK, doc.Size, doc.ContentType, blob, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", doc.FileName),
+	})
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, ErrDocumentNotFound):
+		response.NotFound(c, "Document", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotFound):
+		response.NotFound(c, "Vendor", response.IsProduction(c))
+	case errors.Is(err, ErrDocumentAlreadyReviewed):
+		response.Conflict(c, "Document has already been reviewed", nil, response.IsProduction(c))
+	case errors.Is(err, ErrConcurrentUpload):
+		response.Conflict(c, "Another upload of this document is in progress", nil, response.IsProduction(c))
+	case errors.Is(err, ErrInvalidDocumentType):
+		response.BadRequest(c, "Invalid document type", nil, response.IsProduction(c))
+	case errors.Is(err, ErrUnsupportedFileType):
+		response.BadRequest(c, "Only PDF, JPEG and PNG files are accepted", nil, response.IsProduction(c))
+	case errors.Is(err, ErrFileTooLarge), errors.As(err, &tooLarge):
+		response.Error(c, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", "File exceeds the 10MB limit", nil, response.IsProduction(c))
+	case errors.Is(err, ErrVendorClosed):
+		response.Forbidden(c, "Vendor account is closed", response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func documentIDParam(c *gin.Context) (primitive.ObjectID, bool) {
+	documentID, err := primitive.ObjectIDFromHex(c.Param("documentID"))
+	if err != nil {
+		response.BadRequest(c, "Invalid document ID", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return documentID, true
+}
+
+func contextID(c *gin.Context, key string) (primitive.ObjectID, bool) {
+	val, exists := c.Get(key)
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	id, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
@@ -0,0 +1,117 @@
+package kyc
+
+import (
+	"time"
+
+	"github.com/techrook/23-market/internal/vendor"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type DocumentType string
+
+const (
+	BusinessRegistrationDocument DocumentType = "business_registration"
+	OwnerIDDocument              DocumentType = "owner_id"
+	ProofOfAddressDocument       DocumentType = "proof_of_address"
+	TaxCertificateDocument       DocumentType = "tax_certificate"
+)
+
+// RequiredDocumentTypes must all be approved before a vendor's KYC is verified.
+var RequiredDocumentTypes = []DocumentType{BusinessRegistrationDocument, OwnerIDDocument}
+
+type ReviewStatus string
+
+const (
+	PendingReviewStatus  ReviewStatus = "pending"
+	ApprovedReviewStatus ReviewStatus = "approved"
+	RejectedReviewStatus ReviewStatus = "rejected"
+)
+
+// Document is the current upload for one document type; re-uploading replaces it.
+type Document struct {
+	ID              primitive.ObjectID  `json:"id" bson:"_id,omitempty"`
+	VendorID        primitive.ObjectID  `json:"vendor_id" bson:"vendor_id"`
+	UserID          primitive.ObjectID  `json:"user_id" bson:"user_id"`
+	Type            DocumentType        `json:"type" bson:"type"`
+	FileName        string              `json:"file_name" bson:"file_name"`
+	ContentType     string              `json:"content_type" bson:"content_type"`
+	Size            int64               `json:"size" bson:"size"`
+	BlobKey         string              `json:"-" bson:"blob_key"`
+	Status          ReviewStatus        `json:"status" bson:"status"`
+	RejectionReason string              `json:"rejection_reason,omitempty" bson:"rejection_reason,omitempty"`
+	ReviewedBy      *primitive.ObjectID `json:"reviewed_by,omitempty" bson:"reviewed_by,omitempty"`
+	ReviewedAt      *time.Time          `json:"reviewed_at,omitempty" bson:"reviewed_at,omitempty"`
+	CreatedAt       time.Time           `json:"created_at" bson:"created_at"`
+	UpdatedAt       time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+func NewDocument(vendorID, userID primitive.ObjectID, docType DocumentType, fileName, contentType string) *Document {
+	now := time.Now()
+	id := primitive.NewObjectID()
+	return &Document{
+		ID:          id,
+		VendorID:    vendorID,
+		UserID:      userID,
+		Type:        docType,
+		FileName:    fileName,
+		ContentType: contentType,
+		BlobKey:     "kyc/" + vendorID.Hex() + "/" + id.Hex(),
+		Status:      PendingReviewStatus,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+func IsValidDocumentType(t DocumentType) bool {
+	switch t {
+	case BusinessRegistrationDocument, OwnerIDDocument, ProofOfAddressDocument, TaxCertificateDocument:
+		return true
+	}
+	return false
+}
+
+// AggregateStatus derives the vendor-level KYC status from its current documents.
+func AggregateStatus(docs []Document) vendor.KYCStatus {
+	if len(docs) == 0 {
+		return vendor.KYCNotSubmitted
+	}
+
+	byType := make(map[DocumentType]ReviewStatus, len(docs))
+	for _, d := range docs {
+		if d.Status == RejectedReviewStatus {
+			return vendor.KYCActionRequired
+		}
+		byType[d.Type] = d.Status
+	}
+
+	status := vendor.KYCVerified
+	for _, required := range RequiredDocumentTypes {
+		reviewStatus, ok := byType[required]
+		if !ok {
+			return vendor.KYCIncomplete
+		}
+		if reviewStatus == PendingReviewStatus {
+			status = vendor.KYCPending
+		}
+	}
+	return status
+}
+
+func (d *Document) ToResponse() DocumentResponse {
+	resp := DocumentResponse{
+		ID:              d.ID.Hex(),
+		VendorID:        d.VendorID.Hex(),
+		Type:            string(d.Type),
+		FileName:        d.FileName,
+		ContentType:     d.ContentType,
+		Size:            d.Size,
+		Status:          string(d.Status),
+		RejectionReason: d.RejectionReason,
+		CreatedAt:       d.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:       d.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if d.ReviewedAt != nil {
+		resp.ReviewedAt = d.ReviewedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return resp
+}
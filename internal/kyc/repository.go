@@ -0,0 +1,142 @@
+package kyc
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	// ReplaceDocument stores doc as the vendor's current document of its type and
+	// returns the one it replaced, if any. When doc can't be stored the replaced
+	// document is put back; it is only returned with the error if that failed too.
+	ReplaceDocument(ctx context.Context, doc *Document) (*Document, error)
+	GetDocument(ctx context.Context, id primitive.ObjectID) (*Document, error)
+	ListByVendor(ctx context.Context, vendorID primitive.ObjectID) ([]Document, error)
+	ListByStatus(ctx context.Context, status ReviewStatus, page, pageSize int) ([]Document, int64, error)
+	Review(ctx context.Context, id primitive.ObjectID, status ReviewStatus, reason string, reviewerID primitive.ObjectID) (*Document, error)
+}
+
+type KYCRepository struct {
+	collection *mongo.Collection
+}
+
+func NewKYCRepository(db *mongo.Database) Repository {
+	return &KYCRepository{
+		collection: db.Collection("kyc_documents"),
+	}
+}
+
+// The new upload gets a fresh ID so a review started on the old file can't approve it.
+func (r *KYCRepository) ReplaceDocument(ctx context.Context, doc *Document) (*Document, error) {
+	var previous *Document
+	var old Document
+	err := r.collection.FindOneAndDelete(
+		ctx,
+		bson.M{"vendor_id": doc.VendorID, "type": doc.Type},
+	).Decode(&old)
+	switch {
+	case err == nil:
+		previous = &old
+	case err != mongo.ErrNoDocuments:
+		return nil, err
+	}
+
+	if _, err := r.collection.InsertOne(ctx, doc); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			err = ErrConcurrentUpload
+		}
+		if previous == nil {
+			return nil, err
+		}
+		// A concurrent upload may already hold the slot, in which case the
+		// replaced document is gone and the caller cleans up after it.
+		if _, restoreErr := r.collection.InsertOne(ctx, previous); restoreErr != nil {
+			return previous, err
+		}
+		return nil, err
+	}
+	return previous, nil
+}
+
+func (r *KYCRepository) GetDocument(ctx context.Context, id primitive.ObjectID) (*Document, error) {
+	var d Document
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&d)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrDocumentNotFound
+	}
+	return &d, err
+}
+
+func (r *KYCRepository) ListByVendor(ctx context.Context, vendorID primitive.ObjectID) ([]Document, error) {
+	cursor, err := r.collection.Find(
+		ctx,
+		bson.M{"vendor_id": vendorID},
+		options.Find().SetSort(bson.M{"type": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	docs := []Document{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+func (r *KYCRepository) ListByStatus(ctx context.Context, status ReviewStatus, page, pageSize int) ([]Document, int64, error) {
+	filter := bson.M{"status": status}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Oldest uploads first so the review queue is worked in order.
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	docs := []Document{}
+	if err := cursor.All(ctx, &docs); err != nil {
+		return nil, 0, err
+	}
+	return docs, total, nil
+}
+
+// Review only applies to pending documents, so two admins can't review the same upload.
+func (r *KYCRepository) Review(ctx context.Context, id primitive.ObjectID, status ReviewStatus, reason string, reviewerID primitive.ObjectID) (*Document, error) {
+	now := time.Now()
+	var d Document
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id, "status": PendingReviewStatus},
+		bson.M{"$set": bson.M{
+			"status":           status,
+			"rejection_reason": reason,
+			"reviewed_by":      reviewerID,
+			"reviewed_at":      now,
+			"updated_at":       now,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&d)
+	if err == mongo.ErrNoDocuments {
+		if _, getErr := r.GetDocument(ctx, id); getErr != nil {
+			return nil, getErr
+		}
+		return nil, ErrDocumentAlreadyReviewed
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
@@ -0,0 +1,217 @@
+package kyc
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/techrook/23-market/internal/notification"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const MaxDocumentSize = 10 << 20
+
+var allowedContentTypes = map[string]bool{
+	"application/pdf": true,
+	"image/jpeg":      true,
+	"image/png":       true,
+}
+
+var (
+	ErrDocumentNotFound        = errors.New("kyc document not found")
+	ErrDocumentAlreadyReviewed = errors.New("kyc document already reviewed")
+	ErrInvalidDocumentType     = errors.New("invalid kyc document type")
+	ErrUnsupportedFileType     = errors.New("unsupported file type")
+	ErrFileTooLarge            = errors.New("file too large")
+	ErrConcurrentUpload        = errors.New("another upload of this document is in progress")
+	ErrVendorClosed            = errors.New("vendor is closed")
+)
+
+type Service interface {
+	UploadDocument(ctx context.Context, userID primitive.ObjectID, docType DocumentType, fileName string, file io.Reader) (*DocumentResponse, error)
+	ListVendorDocuments(ctx context.Context, userID primitive.ObjectID) ([]DocumentResponse, error)
+	OpenVendorDocument(ctx context.Context, userID, documentID primitive.ObjectID) (*Document, io.ReadCloser, error)
+
+	ListReviewQueue(ctx context.Context, status ReviewStatus, page, pageSize int) ([]DocumentResponse, int64, error)
+	OpenDocument(ctx context.Context, documentID primitive.ObjectID) (*Document, io.ReadCloser, error)
+	ApproveDocument(ctx context.Context, documentID, reviewerID primitive.ObjectID) (*DocumentResponse, error)
+	RejectDocument(ctx context.Context, documentID, reviewerID primitive.ObjectID, reason string) (*DocumentResponse, error)
+}
+
+type service struct {
+	kycRepo    Repository
+	vendorRepo vendor.Repository
+	blobs      storage.BlobStore
+	notifier   notification.Notifier
+}
+
+func NewService(kycRepo Repository, vendorRepo vendor.Repository, blobs storage.BlobStore, notifier notification.Notifier) Service {
+	return &service{
+		kycRepo:    kycRepo,
+		vendorRepo: vendorRepo,
+		blobs:      blobs,
+		notifier:   notifier,
+	}
+}
+
+func (s *service) UploadDocument(ctx context.Context, userID primitive.ObjectID, docType DocumentType, fileName string, file io.Reader) (*DocumentResponse, error) {
+	if !IsValidDocumentType(docType) {
+		return nil, ErrInvalidDocumentType
+	}
+
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if v.Status == vendor.ClosedVendorStatus {
+		return nil, ErrVendorClosed
+	}
+
+	// Trust the file's bytes, not the client's Content-Type header.
+	buffered := bufio.NewReader(file)
+	head, _ := buffered.Peek(512)
+	contentType := http.DetectContentType(head)
+	if !allowedContentTypes[contentType] {
+		return nil, ErrUnsupportedFileType
+	}
+
+	doc := NewDocument(v.ID, userID, docType, fileName, contentType)
+	size, err := s.blobs.Put(ctx, doc.BlobKey, io.LimitReader(buffered, MaxDocumentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store kyc document: %w", err)
+	}
+	if size > MaxDocumentSize {
+		_ = s.blobs.Delete(ctx, doc.BlobKey)
+		return nil, ErrFileTooLarge
+	}
+	doc.Size = size
+
+	previous, err := s.kycRepo.ReplaceDocument(ctx, doc)
+	if err != nil {
+		_ = s.blobs.Delete(ctx, doc.BlobKey)
+		if previous != nil {
+			if delErr := s.blobs.Delete(ctx, previous.BlobKey); delErr != nil {
+				log.Printf("⚠️ Failed to delete replaced KYC blob %s: %v", previous.BlobKey, delErr)
+			}
+		}
+		return nil, err
+	}
+	if previous != nil {
+		if err := s.blobs.Delete(ctx, previous.BlobKey); err != nil {
+			log.Printf("⚠️ Failed to delete replaced KYC blob %s: %v", previous.BlobKey, err)
+		}
+	}
+
+	if err := s.refreshVendorStatus(ctx, v.ID); err != nil {
+		return nil, err
+	}
+
+	resp := doc.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) ListVendorDocuments(ctx context.Context, userID primitive.ObjectID) ([]DocumentResponse, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	docs, err := s.kycRepo.ListByVendor(ctx, v.ID)
+	if err != nil {
+		return nil, err
+	}
+	return toResponses(docs), nil
+}
+
+func (s *service) OpenVendorDocument(ctx context.Context, userID, documentID primitive.ObjectID) (*Document, io.ReadCloser, error) {
+	doc, err := s.kycRepo.GetDocument(ctx, documentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if doc.UserID != userID {
+		return nil, nil, ErrDocumentNotFound
+	}
+	return s.open(ctx, doc)
+}
+
+func (s *service) ListReviewQueue(ctx context.Context, status ReviewStatus, page, pageSize int) ([]DocumentResponse, int64, error) {
+	docs, total, err := s.kycRepo.ListByStatus(ctx, status, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	return toResponses(docs), total, nil
+}
+
+func (s *service) OpenDocument(ctx context.Context, documentID primitive.ObjectID) (*Document, io.ReadCloser, error) {
+	doc, err := s.kycRepo.GetDocument(ctx, documentID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.open(ctx, doc)
+}
+
+func (s *service) ApproveDocument(ctx context.Context, documentID, reviewerID primitive.ObjectID) (*DocumentResponse, error) {
+	return s.review(ctx, documentID, reviewerID, ApprovedReviewStatus, "")
+}
+
+func (s *service) RejectDocument(ctx context.Context, documentID, reviewerID primitive.ObjectID, reason string) (*DocumentResponse, error) {
+	return s.review(ctx, documentID, reviewerID, RejectedReviewStatus, reason)
+}
+
+func (s *service) review(ctx context.Context, documentID, reviewerID primitive.ObjectID, status ReviewStatus, reason string) (*DocumentResponse, error) {
+	doc, err := s.kycRepo.Review(ctx, documentID, status, reason, reviewerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.refreshVendorStatus(ctx, doc.VendorID); err != nil {
+		return nil, err
+	}
+
+	title := "KYC document approved"
+	body := fmt.Sprintf("Your %s document was approved.", doc.Type)
+	if status == RejectedReviewStatus {
+		title = "KYC document rejected"
+		body = fmt.Sprintf("Your %s document was rejected: %s", doc.Type, reason)
+	}
+	data := map[string]string{"document_id": doc.ID.Hex(), "status": string(status)}
+	if err := s.notifier.Notify(ctx, doc.UserID, notification.KYCDocumentReviewedType, title, body, data); err != nil {
+		log.Printf("⚠️ Failed to notify user %s about KYC review: %v", doc.UserID.Hex(), err)
+	}
+
+	resp := doc.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) refreshVendorStatus(ctx context.Context, vendorID primitive.ObjectID) error {
+	docs, err := s.kycRepo.ListByVendor(ctx, vendorID)
+	if err != nil {
+		return err
+	}
+	return s.vendorRepo.SetKYCStatus(ctx, vendorID, AggregateStatus(docs))
+}
+
+func (s *service) open(ctx context.Context, doc *Document) (*Document, io.ReadCloser, error) {
+	blob, err := s.blobs.Open(ctx, doc.BlobKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrDocumentNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return doc, blob, nil
+}
+
+func toResponses(docs []Document) []DocumentResponse {
+	resp := make([]DocumentResponse, 0, len(docs))
+	for i := range docs {
+		resp = append(resp, docs[i].ToResponse())
+	}
+	return resp
+}
@@ -0,0 +1,82 @@
+package moderation
+
+import "github.com/techrook/23-market/internal/product"
+
+type PriceLimitInput struct {
+	Currency string `json:"currency" binding:"required,len=3,uppercase"`
+	Min      int64  `json:"min" binding:"min=0"`
+	// Max of 0 leaves prices unbounded above.
+	Max int64 `json:"max" binding:"min=0"`
+}
+
+// UpdateRulesRequest replaces the whole rule set.
+type UpdateRulesRequest struct {
+	BannedKeywords     []string          `json:"banned_keywords" binding:"max=1000,dive,required,max=100"`
+	PriceLimits        []PriceLimitInput `json:"price_limits" binding:"max=200,dive"`
+	MaxDiscountPercent int               `json:"max_discount_percent" binding:"min=0,max=99"`
+	MinImages          int               `json:"min_images" binding:"min=0,max=10"`
+	ReviewAll          bool              `json:"review_all"`
+}
+
+type Decision string
+
+const (
+	ApproveDecision Decision = "approve"
+	RejectDecision  Decision = "reject"
+)
+
+// DecisionRequest approves or rejects several listings at once. Each one is
+// decided on its own; one failing doesn't stop the rest.
+type DecisionRequest struct {
+	ProductIDs []string `json:"product_ids" binding:"required,min=1,max=100,dive,len=24,hexadecimal"`
+	Decision   Decision `json:"decision" binding:"required,oneof=approve reject"`
+	// Reason is shown to the vendor and is required to reject.
+	Reason string `json:"reason" binding:"required_if=Decision reject,max=500"`
+}
+
+type QueueQuery struct {
+	State    product.ModerationState `form:"state" binding:"omitempty,oneof=pending approved rejected"`
+	Page     int                     `form:"page" binding:"omitempty,min=1"`
+	PageSize int                     `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type PriceLimitResponse struct {
+	Currency string `json:"currency"`
+	Min      int64  `json:"min"`
+	Max      int64  `json:"max,omitempty"`
+}
+
+type RulesResponse struct {
+	BannedKeywords     []string             `json:"banned_keywords"`
+	PriceLimits        []PriceLimitResponse `json:"price_limits"`
+	MaxDiscountPercent int                  `json:"max_discount_percent"`
+	MinImages          int                  `json:"min_images"`
+	ReviewAll          bool                 `json:"review_all"`
+	UpdatedAt          string               `json:"updated_at,omitempty"`
+}
+
+type QueueItemResponse struct {
+	ProductID   string                      `json:"product_id"`
+	VendorID    string                      `json:"vendor_id"`
+	Title       string                      `json:"title"`
+	Description string                      `json:"description"`
+	Price       int64                       `json:"price"`
+	Currency    string                      `json:"currency"`
+	Status      string                      `json:"status"`
+	Attributes  map[string]string           `json:"attributes"`
+	Images      []product.ImageResponse     `json:"images"`
+	Moderation  *product.ModerationResponse `json:"moderation"`
+	UpdatedAt   string                      `json:"updated_at"`
+}
+
+type DecisionResult struct {
+	ProductID string `json:"product_id"`
+	State     string `json:"state,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type DecisionResponse struct {
+	Results   []DecisionResult `json:"results"`
+	Succeeded int              `json:"succeeded"`
+	Failed    int              `json:"failed"`
+}
@@ -0,0 +1,148 @@
+package moderation
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	moderationService Service
+}
+
+func NewHandler(moderationService Service) *Handler {
+	return &Handler{
+		moderationService: moderationService,
+	}
+}
+
+func (h *Handler) GetRules(c *gin.Context) {
+	rules, err := h.moderationService.GetRules(c.Request.Context())
+	if err != nil {
+		handleError(c, err, "Failed to get moderation rules")
+		return
+	}
+	response.OK(c, rules, "Moderation rules retrieved successfully")
+}
+
+func (h *Handler) UpdateRules(c *gin.Context) {
+	adminID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateRulesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	rules, err := h.moderationService.UpdateRules(c.Request.Context(), adminID, req)
+	if err != nil {
+		handleError(c, err, "Failed to update moderation rules")
+		return
+	}
+	response.OK(c, rules, "Moderation rules updated successfully")
+}
+
+func (h *Handler) Queue(c *gin.Context) {
+	var query QueueQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+
+	items, total, err := h.moderationService.Queue(c.Request.Context(), query)
+	if err != nil {
+		handleError(c, err, "Failed to list moderation queue")
+		return
+	}
+	response.Paginated(c, items, query.Page, query.PageSize, int(total), "Moderation queue retrieved successfully")
+}
+
+func (h *Handler) Decide(c *gin.Context) {
+	adminID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var req DecisionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	result, err := h.moderationService.Decide(c.Request.Context(), adminID, req)
+	if err != nil {
+		handleError(c, err, "Failed to moderate listings")
+		return
+	}
+	response.OK(c, result, "Moderation decisions recorded")
+}
+
+func (h *Handler) GetImage(c *gin.Context) {
+	productID, ok := idParam(c, "productID", "Invalid product ID")
+	if !ok {
+		return
+	}
+	imageID, ok := idParam(c, "imageID", "Invalid image ID")
+	if !ok {
+		return
+	}
+
+	img, blob, err := h.moderationService.OpenImage(c.Request.Context(), productID, imageID)
+	if err != nil {
+		handleError(c, err, "Failed to get image")
+		return
+	}
+	defer blob.Close()
+	c.DataFromReader(http.StatusOK, img.Size, img.ContentType, blob, map[string]string{
+		"Cache-Control": "private, no-store",
+	})
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, product.ErrProductNotFound):
+		response.NotFound(c, "Product", response.IsProduction(c))
+	case errors.Is(err, ErrImageNotFound):
+		response.NotFound(c, "Image", response.IsProduction(c))
+	case errors.Is(err, ErrInvalidRules):
+		response.BadRequest(c, err.Error(), nil, response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func idParam(c *gin.Context, name, message string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param(name))
+	if err != nil {
+		response.BadRequest(c, message, nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func callerID(c *gin.Context) (primitive.ObjectID, bool) {
+	val, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	userID, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
@@ -0,0 +1,154 @@
+package moderation
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/techrook/23-market/internal/product"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// rulesID is the _id of the single rules document.
+const rulesID = "listing_rules"
+
+// Rules are the automatic checks a listing goes through when it goes live and
+// whenever it changes while live. Prohibited content is rejected outright;
+// anything that needs judgement is held for an admin.
+type Rules struct {
+	ID string `bson:"_id"`
+	// BannedKeywords reject listings that mention them in the title,
+	// description, options or attributes. Keywords are stored normalised and
+	// match whole words.
+	BannedKeywords []string `bson:"banned_keywords"`
+	// PriceLimits hold listings priced outside the expected range for their
+	// currency for review.
+	PriceLimits []PriceLimit `bson:"price_limits"`
+	// MaxDiscountPercent holds listings whose compare-at price claims a bigger
+	// discount for review; 0 turns the check off.
+	MaxDiscountPercent int `bson:"max_discount_percent"`
+	// MinImages rejects listings with fewer images; the vendor can add more
+	// and the listing is screened again.
+	MinImages int `bson:"min_images"`
+	// ReviewAll holds every listing that passes the rules for review instead
+	// of approving it.
+	ReviewAll bool                `bson:"review_all"`
+	UpdatedBy *primitive.ObjectID `bson:"updated_by,omitempty"`
+	UpdatedAt time.Time           `bson:"updated_at"`
+}
+
+// PriceLimit bounds prices in one currency, in minor units. A zero Max has no
+// upper bound.
+type PriceLimit struct {
+	Currency string `bson:"currency"`
+	Min      int64  `bson:"min"`
+	Max      int64  `bson:"max"`
+}
+
+func defaultRules() *Rules {
+	return &Rules{ID: rulesID, BannedKeywords: []string{}, PriceLimits: []PriceLimit{}}
+}
+
+// normalizeText lower-cases s and turns every run of characters that aren't
+// letters or digits into a single space, so keywords match whole words.
+func normalizeText(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// Screen runs the rules over a listing and returns the resulting moderation
+// state. Reasons name every rule the listing broke.
+func (r *Rules) Screen(p *product.Product, now time.Time) product.Moderation {
+	var rejected, held []string
+
+	if keyword, ok := r.bannedKeyword(p); ok {
+		rejected = append(rejected, fmt.Sprintf("mentions banned term %q", keyword))
+	}
+	if len(p.Images) < r.MinImages {
+		rejected = append(rejected, fmt.Sprintf("has %d of the %d images required", len(p.Images), r.MinImages))
+	}
+	held = append(held, r.priceProblems(p)...)
+
+	switch {
+	case len(rejected) > 0:
+		return product.Moderation{State: product.RejectedModeration, Reason: "Listing " + strings.Join(append(rejected, held...), "; "), UpdatedAt: now}
+	case len(held) > 0:
+		return product.Moderation{State: product.PendingModeration, Reason: "Listing " + strings.Join(held, "; "), UpdatedAt: now}
+	case r.ReviewAll:
+		return product.Moderation{State: product.PendingModeration, Reason: "Awaiting review", UpdatedAt: now}
+	default:
+		return product.Moderation{State: product.ApprovedModeration, UpdatedAt: now}
+	}
+}
+
+func (r *Rules) bannedKeyword(p *product.Product) (string, bool) {
+	if len(r.BannedKeywords) == 0 {
+		return "", false
+	}
+	texts := []string{p.Title, p.Description}
+	for _, o := range p.Options {
+		texts = append(texts, o.Name)
+		texts = append(texts, o.Values...)
+	}
+	for name, value := range p.Attributes {
+		texts = append(texts, name, value)
+	}
+
+	for _, text := range texts {
+		padded := " " + normalizeText(text) + " "
+		for _, keyword := range r.BannedKeywords {
+			if strings.Contains(padded, " "+keyword+" ") {
+				return keyword, true
+			}
+		}
+	}
+	return "", false
+}
+
+// priceProblems checks every variant's current price against the limits for
+// the listing's currency and its compare-at price against the discount cap.
+func (r *Rules) priceProblems(p *product.Product) []string {
+	var limit *PriceLimit
+	for i := range r.PriceLimits {
+		if r.PriceLimits[i].Currency == p.Currency {
+			limit = &r.PriceLimits[i]
+		}
+	}
+
+	var problems []string
+	outOfRange, discounted := false, false
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		price := p.EffectivePrice(v)
+		if limit != nil && !outOfRange && (price < limit.Min || (limit.Max > 0 && price > limit.Max)) {
+			outOfRange = true
+			problems = append(problems, fmt.Sprintf("is priced at %d %s, outside the expected range", price, p.Currency))
+		}
+		if r.MaxDiscountPercent > 0 && !discounted {
+			if ref := p.ReferencePrice(v); ref != nil && *ref > 0 && (*ref-price)*100 > int64(r.MaxDiscountPercent)*(*ref) {
+				discounted = true
+				problems = append(problems, fmt.Sprintf("claims a discount of more than %d%%", r.MaxDiscountPercent))
+			}
+		}
+	}
+	return problems
+}
+
+func (r *Rules) ToResponse() RulesResponse {
+	resp := RulesResponse{
+		BannedKeywords:     r.BannedKeywords,
+		PriceLimits:        make([]PriceLimitResponse, 0, len(r.PriceLimits)),
+		MaxDiscountPercent: r.MaxDiscountPercent,
+		MinImages:          r.MinImages,
+		ReviewAll:          r.ReviewAll,
+	}
+	for _, l := range r.PriceLimits {
+		resp.PriceLimits = append(resp.PriceLimits, PriceLimitResponse{Currency: l.Currency, Min: l.Min, Max: l.Max})
+	}
+	if !r.UpdatedAt.IsZero() {
+		resp.UpdatedAt = r.UpdatedAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,44 @@
+package moderation
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	// GetRules returns the stored rules, or an empty rule set that approves
+	// everything if none have been saved yet.
+	GetRules(ctx context.Context) (*Rules, error)
+	SaveRules(ctx context.Context, rules *Rules) error
+}
+
+type RulesRepository struct {
+	collection *mongo.Collection
+}
+
+func NewRulesRepository(db *mongo.Database) Repository {
+	return &RulesRepository{
+		collection: db.Collection("moderation_rules"),
+	}
+}
+
+func (r *RulesRepository) GetRules(ctx context.Context) (*Rules, error) {
+	var rules Rules
+	err := r.collection.FindOne(ctx, bson.M{"_id": rulesID}).Decode(&rules)
+	if err == mongo.ErrNoDocuments {
+		return defaultRules(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &rules, nil
+}
+
+func (r *RulesRepository) SaveRules(ctx context.Context, rules *Rules) error {
+	rules.ID = rulesID
+	_, err := r.collection.ReplaceOne(ctx, bson.M{"_id": rulesID}, rules, options.Replace().SetUpsert(true))
+	return err
+}
@@ -0,0 +1,270 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/techrook/23-market/internal/notification"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/money"
+	"github.com/techrook/23-market/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidRules  = errors.New("invalid moderation rules")
+	ErrImageNotFound = errors.New("image not found")
+)
+
+type Service interface {
+	// Screen and Screened implement product.Moderator.
+	Screen(ctx context.Context, p *product.Product) error
+	Screened(ctx context.Context, p *product.Product)
+
+	GetRules(ctx context.Context) (*RulesResponse, error)
+	// UpdateRules applies to listings screened from now on; listings already
+	// decided keep their state.
+	UpdateRules(ctx context.Context, adminID primitive.ObjectID, req UpdateRulesRequest) (*RulesResponse, error)
+
+	Queue(ctx context.Context, query QueueQuery) ([]QueueItemResponse, int64, error)
+	Decide(ctx context.Context, adminID primitive.ObjectID, req DecisionRequest) (*DecisionResponse, error)
+	// OpenImage streams an image of any product so admins can review
+	// listings buyers can't see yet. The caller must close the reader.
+	OpenImage(ctx context.Context, productID, imageID primitive.ObjectID) (*product.Image, io.ReadCloser, error)
+}
+
+type service struct {
+	rulesRepo   Repository
+	productRepo product.Repository
+	vendorRepo  vendor.Repository
+	indexer     product.Indexer
+	notifier    notification.Notifier
+	blobs       storage.BlobStore
+}
+
+func NewService(rulesRepo Repository, productRepo product.Repository, vendorRepo vendor.Repository, indexer product.Indexer, notifier notification.Notifier, blobs storage.BlobStore) Service {
+	return &service{
+		rulesRepo:   rulesRepo,
+		productRepo: productRepo,
+		vendorRepo:  vendorRepo,
+		indexer:     indexer,
+		notifier:    notifier,
+		blobs:       blobs,
+	}
+}
+
+func (s *service) Screen(ctx context.Context, p *product.Product) error {
+	rules, err := s.rulesRepo.GetRules(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	m := rules.Screen(p, now)
+	// An edit can't undo an admin's rejection on its own; the listing goes
+	// back to the queue instead.
+	if m.State == product.ApprovedModeration && p.Moderation != nil &&
+		p.Moderation.State == product.RejectedModeration && p.Moderation.ReviewedBy != nil {
+		m = product.Moderation{State: product.PendingModeration, Reason: "Edited after being rejected", UpdatedAt: now}
+	}
+	p.Moderation = &m
+	return nil
+}
+
+// Screened tells the vendor when the rules rejected their listing. Listings
+// held for review hear nothing until an admin decides.
+func (s *service) Screened(ctx context.Context, p *product.Product) {
+	if p.ModerationState() == product.RejectedModeration {
+		s.notifyRejected(ctx, p)
+	}
+}
+
+func (s *service) GetRules(ctx context.Context) (*RulesResponse, error) {
+	rules, err := s.rulesRepo.GetRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resp := rules.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) UpdateRules(ctx context.Context, adminID primitive.ObjectID, req UpdateRulesRequest) (*RulesResponse, error) {
+	rules := defaultRules()
+	rules.MaxDiscountPercent = req.MaxDiscountPercent
+	rules.MinImages = req.MinImages
+	rules.ReviewAll = req.ReviewAll
+	rules.UpdatedBy = &adminID
+	rules.UpdatedAt = time.Now()
+
+	seen := make(map[string]bool)
+	for _, k := range req.BannedKeywords {
+		keyword := normalizeText(k)
+		if keyword == "" {
+			return nil, fmt.Errorf("%w: keyword %q has no letters or digits", ErrInvalidRules, k)
+		}
+		if !seen[keyword] {
+			seen[keyword] = true
+			rules.BannedKeywords = append(rules.BannedKeywords, keyword)
+		}
+	}
+	sort.Strings(rules.BannedKeywords)
+
+	currencies := make(map[string]bool)
+	for _, l := range req.PriceLimits {
+		if !money.IsCurrency(l.Currency) {
+			return nil, fmt.Errorf("%w: unknown currency %s", ErrInvalidRules, l.Currency)
+		}
+		if currencies[l.Currency] {
+			return nil, fmt.Errorf("%w: %s has more than one price limit", ErrInvalidRules, l.Currency)
+		}
+		if l.Max > 0 && l.Max < l.Min {
+			return nil, fmt.Errorf("%w: %s max is below its min", ErrInvalidRules, l.Currency)
+		}
+		currencies[l.Currency] = true
+		rules.PriceLimits = append(rules.PriceLimits, PriceLimit{Currency: l.Currency, Min: l.Min, Max: l.Max})
+	}
+
+	if err := s.rulesRepo.SaveRules(ctx, rules); err != nil {
+		return nil, err
+	}
+	resp := rules.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) Queue(ctx context.Context, query QueueQuery) ([]QueueItemResponse, int64, error) {
+	if query.State == "" {
+		query.State = product.PendingModeration
+	}
+	products, total, err := s.productRepo.ListForModeration(ctx, query.State, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	items := make([]QueueItemResponse, 0, len(products))
+	for i := range products {
+		items = append(items, queueItem(&products[i]))
+	}
+	return items, total, nil
+}
+
+func (s *service) Decide(ctx context.Context, adminID primitive.ObjectID, req DecisionRequest) (*DecisionResponse, error) {
+	state := product.ApprovedModeration
+	if req.Decision == RejectDecision {
+		state = product.RejectedModeration
+	}
+
+	resp := &DecisionResponse{Results: make([]DecisionResult, 0, len(req.ProductIDs))}
+	for _, hexID := range req.ProductIDs {
+		result := DecisionResult{ProductID: hexID}
+		p, err := s.decide(ctx, adminID, hexID, state, req.Reason)
+		switch {
+		case err == nil:
+			result.State = string(p.ModerationState())
+			resp.Succeeded++
+		case errors.Is(err, product.ErrProductNotFound), errors.Is(err, product.ErrProductChanged):
+			result.Error = err.Error()
+			resp.Failed++
+		default:
+			log.Printf("⚠️ Failed to moderate product %s: %v", hexID, err)
+			result.Error = "failed to save decision"
+			resp.Failed++
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
+
+// decide records one admin decision and brings the search index and the
+// vendor up to date with it.
+func (s *service) decide(ctx context.Context, adminID primitive.ObjectID, hexID string, state product.ModerationState, reason string) (*product.Product, error) {
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return nil, product.ErrProductNotFound
+	}
+	p, err := s.productRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err = s.productRepo.SetModeration(ctx, p.ID, p.UpdatedAt, product.Moderation{
+		State:      state,
+		Reason:     reason,
+		ReviewedBy: &adminID,
+		UpdatedAt:  time.Now(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.indexer.IndexProduct(ctx, p); err != nil {
+		log.Printf("⚠️ failed to index product %s: %v", p.ID.Hex(), err)
+	}
+	if state == product.RejectedModeration {
+		s.notifyRejected(ctx, p)
+	}
+	return p, nil
+}
+
+func (s *service) OpenImage(ctx context.Context, productID, imageID primitive.ObjectID) (*product.Image, io.ReadCloser, error) {
+	p, err := s.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	img := p.Image(imageID)
+	if img == nil {
+		return nil, nil, ErrImageNotFound
+	}
+	blob, err := s.blobs.Open(ctx, img.BlobKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrImageNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return img, blob, nil
+}
+
+func (s *service) notifyRejected(ctx context.Context, p *product.Product) {
+	v, err := s.vendorRepo.GetVendorByID(ctx, p.VendorID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load vendor %s to notify about %s: %v", p.VendorID.Hex(), notification.ListingRejectedType, err)
+		return
+	}
+	if err := s.notifier.Notify(ctx, v.UserID, notification.ListingRejectedType,
+		"Listing rejected",
+		fmt.Sprintf("%q was rejected: %s", p.Title, p.Moderation.Reason),
+		map[string]string{"product_id": p.ID.Hex()},
+	); err != nil {
+		log.Printf("⚠️ Failed to notify user %s about %s: %v", v.UserID.Hex(), notification.ListingRejectedType, err)
+	}
+}
+
+func queueItem(p *product.Product) QueueItemResponse {
+	item := QueueItemResponse{
+		ProductID:   p.ID.Hex(),
+		VendorID:    p.VendorID.Hex(),
+		Title:       p.Title,
+		Description: p.Description,
+		Price:       p.Price,
+		Currency:    p.Currency,
+		Status:      string(p.Status),
+		Attributes:  p.Attributes,
+		Images:      make([]product.ImageResponse, 0, len(p.Images)),
+		Moderation:  p.Moderation.ToResponse(),
+		UpdatedAt:   p.UpdatedAt.Format(time.RFC3339),
+	}
+	if item.Attributes == nil {
+		item.Attributes = map[string]string{}
+	}
+	for _, img := range p.Images {
+		item.Images = append(item.Images, product.ImageResponse{
+			ID:          img.ID.Hex(),
+			URL:         "/admin/moderation/products/" + p.ID.Hex() + "/images/" + img.ID.Hex(),
+			ContentType: img.ContentType,
+		})
+	}
+	return item
+}
@@ -0,0 +1,18 @@
+package notification
+
+type ListNotificationsQuery struct {
+	Page       int  `form:"page" binding:"omitempty,min=1"`
+	PageSize   int  `form:"page_size" binding:"omitempty,min=1,max=100"`
+	UnreadOnly bool `form:"unread"`
+}
+
+type NotificationResponse struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"`
+	Title     string            `json:"title"`
+	Body      string            `json:"body"`
+	Data      map[string]string `json:"data,omitempty"`
+	IsRead    bool              `json:"is_read"`
+	ReadAt    string            `json:"read_at,omitempty"`
+	CreatedAt string            `json:"created_at"`
+}
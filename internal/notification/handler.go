@@ -0,0 +1,81 @@
+package notification
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	notificationService Service
+}
+
+func NewHandler(notificationService Service) *Handler {
+	return &Handler{
+		notificationService: notificationService,
+	}
+}
+
+func (h *Handler) ListNotifications(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var query ListNotificationsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+
+	notifications, total, err := h.notificationService.ListNotifications(c.Request.Context(), userID, query)
+	if err != nil {
+		response.InternalError(c, "Failed to list notifications", err, response.IsProduction(c))
+		return
+	}
+	response.Paginated(c, notifications, query.Page, query.PageSize, int(total), "Notifications retrieved successfully")
+}
+
+func (h *Handler) MarkRead(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	notificationID, err := primitive.ObjectIDFromHex(c.Param("notificationID"))
+	if err != nil {
+		response.BadRequest(c, "Invalid notification ID", nil, response.IsProduction(c))
+		return
+	}
+
+	if err := h.notificationService.MarkRead(c.Request.Context(), userID, notificationID); err != nil {
+		if errors.Is(err, ErrNotificationNotFound) {
+			response.NotFound(c, "Notification", response.IsProduction(c))
+			return
+		}
+		response.InternalError(c, "Failed to update notification", err, response.IsProduction(c))
+		return
+	}
+	response.OK(c, nil, "Notification marked as read")
+}
+
+func callerID(c *gin.Context) (primitive.ObjectID, bool) {
+	val, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	userID, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
@@ -0,0 +1,62 @@
+package notification
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Type string
+
+const (
+	KYCDocumentReviewedType Type = "kyc_document_reviewed"
+	PayoutSentType          Type = "payout_sent"
+	PayoutFailedType        Type = "payout_failed"
+
+	ProductQuestionAskedType    Type = "product_question_asked"
+	ProductQuestionAnsweredType Type = "product_question_answered"
+	ProductReviewPostedType     Type = "product_review_posted"
+	ProductReviewRespondedType  Type = "product_review_responded"
+
+	DigitalOrderReadyType Type = "digital_order_ready"
+	ListingRejectedType   Type = "listing_rejected"
+)
+
+type Notification struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	UserID    primitive.ObjectID `json:"user_id" bson:"user_id"`
+	Type      Type               `json:"type" bson:"type"`
+	Title     string             `json:"title" bson:"title"`
+	Body      string             `json:"body" bson:"body"`
+	Data      map[string]string  `json:"data,omitempty" bson:"data,omitempty"`
+	ReadAt    *time.Time         `json:"read_at,omitempty" bson:"read_at,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+}
+
+func NewNotification(userID primitive.ObjectID, kind Type, title, body string, data map[string]string) *Notification {
+	return &Notification{
+		ID:        primitive.NewObjectID(),
+		UserID:    userID,
+		Type:      kind,
+		Title:     title,
+		Body:      body,
+		Data:      data,
+		CreatedAt: time.Now(),
+	}
+}
+
+func (n *Notification) ToResponse() NotificationResponse {
+	resp := NotificationResponse{
+		ID:        n.ID.Hex(),
+		Type:      string(n.Type),
+		Title:     n.Title,
+		Body:      n.Body,
+		Data:      n.Data,
+		IsRead:    n.ReadAt != nil,
+		CreatedAt: n.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if n.ReadAt != nil {
+		resp.ReadAt = n.ReadAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return resp
+}
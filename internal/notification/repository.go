@@ -0,0 +1,74 @@
+package notification
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	Create(ctx context.Context, n *Notification) error
+	ListByUser(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, page, pageSize int) ([]Notification, int64, error)
+	MarkRead(ctx context.Context, userID, id primitive.ObjectID) error
+}
+
+type NotificationRepository struct {
+	collection *mongo.Collection
+}
+
+func NewNotificationRepository(db *mongo.Database) Repository {
+	return &NotificationRepository{
+		collection: db.Collection("notifications"),
+	}
+}
+
+func (r *NotificationRepository) Create(ctx context.Context, n *Notification) error {
+	_, err := r.collection.InsertOne(ctx, n)
+	return err
+}
+
+func (r *NotificationRepository) ListByUser(ctx context.Context, userID primitive.ObjectID, unreadOnly bool, page, pageSize int) ([]Notification, int64, error) {
+	filter := bson.M{"user_id": userID}
+	if unreadOnly {
+		filter["read_at"] = bson.M{"$exists": false}
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	notifications := []Notification{}
+	if err := cursor.All(ctx, &notifications); err != nil {
+		return nil, 0, err
+	}
+	return notifications, total, nil
+}
+
+func (r *NotificationRepository) MarkRead(ctx context.Context, userID, id primitive.ObjectID) error {
+	res, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "user_id": userID},
+		bson.M{"$set": bson.M{"read_at": time.Now()}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
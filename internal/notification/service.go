@@ -0,0 +1,54 @@
+package notification
+
+import (
+	"context"
+	"errors"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
+// Notifier is what other packages depend on to tell a user about something.
+type Notifier interface {
+	Notify(ctx context.Context, userID primitive.ObjectID, kind Type, title, body string, data map[string]string) error
+}
+
+type Service interface {
+	Notifier
+	ListNotifications(ctx context.Context, userID primitive.ObjectID, query ListNotificationsQuery) ([]NotificationResponse, int64, error)
+	MarkRead(ctx context.Context, userID, notificationID primitive.ObjectID) error
+}
+
+type service struct {
+	notificationRepo Repository
+}
+
+func NewService(notificationRepo Repository) Service {
+	return &service{
+		notificationRepo: notificationRepo,
+	}
+}
+
+func (s *service) Notify(ctx context.Context, userID primitive.ObjectID, kind Type, title, body string, data map[string]string) error {
+	return s.notificationRepo.Create(ctx, NewNotification(userID, kind, title, body, data))
+}
+
+func (s *service) ListNotifications(ctx context.Context, userID primitive.ObjectID, query ListNotificationsQuery) ([]NotificationResponse, int64, error) {
+	notifications, total, err := s.notificationRepo.ListByUser(ctx, userID, query.UnreadOnly, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := make([]NotificationResponse, 0, len(notifications))
+	for i := range notifications {
+		resp = append(resp, notifications[i].ToResponse())
+	}
+	return resp, total, nil
+}
+
+func (s *service) MarkRead(ctx context.Context, userID, notificationID primitive.ObjectID) error {
+	return s.notificationRepo.MarkRead(ctx, userID, notificationID)
+}
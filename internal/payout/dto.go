@@ -0,0 +1,73 @@
+package payout
+
+type CreateAccountRequest struct {
+	HolderName    string `json:"holder_name" binding:"required,min=2,max=200"`
+	BankName      string `json:"bank_name" binding:"required,min=2,max=200"`
+	Country       string `json:"country" binding:"required,len=2,uppercase"`
+	Currency      string `json:"currency" binding:"required,len=3,uppercase"`
+	AccountNumber string `json:"account_number" binding:"required,min=4,max=34,alphanum"`
+	RoutingNumber string `json:"routing_number" binding:"omitempty,max=20,alphanum"`
+	IsDefault     bool   `json:"is_default"`
+}
+
+type AdjustmentRequest struct {
+	Amount      int64  `json:"amount" binding:"required"`
+	Currency    string `json:"currency" binding:"required,len=3,uppercase"`
+	Description string `json:"description" binding:"required,min=3,max=500"`
+}
+
+type ListQuery struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type AccountResponse struct {
+	ID         string `json:"id"`
+	Type       string `json:"type"`
+	HolderName string `json:"holder_name"`
+	BankName   string `json:"bank_name"`
+	Country    string `json:"country"`
+	Currency   string `json:"currency"`
+	Last4      string `json:"last4"`
+	IsDefault  bool   `json:"is_default"`
+	CreatedAt  string `json:"created_at"`
+}
+
+type BalanceResponse struct {
+	Currency  string `json:"currency"`
+	Available int64  `json:"available"`
+	Pending   int64  `json:"pending"`
+}
+
+type LedgerEntryResponse struct {
+	ID          string `json:"id"`
+	Type        string `json:"type"`
+	Amount      int64  `json:"amount"`
+	Currency    string `json:"currency"`
+	Reference   string `json:"reference"`
+	Description string `json:"description,omitempty"`
+	AvailableAt string `json:"available_at"`
+	CreatedAt   string `json:"created_at"`
+}
+
+type PayoutResponse struct {
+	ID                string `json:"id"`
+	AccountID         string `json:"account_id"`
+	Amount            int64  `json:"amount"`
+	Currency          string `json:"currency"`
+	Status            string `json:"status"`
+	ProviderReference string `json:"provider_reference,omitempty"`
+	FailureReason     string `json:"failure_reason,omitempty"`
+	CreatedAt         string `json:"created_at"`
+	UpdatedAt         string `json:"updated_at"`
+}
+
+type BatchResponse struct {
+	ID         string `json:"id"`
+	Status     string `json:"status"`
+	Paid       int    `json:"paid"`
+	Failed     int    `json:"failed"`
+	Skipped    int    `json:"skipped"`
+	StartedAt  string `json:"started_at"`
+	FinishedAt string `json:"finished_at,omitempty"`
+}
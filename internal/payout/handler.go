@@ -0,0 +1,243 @@
+package payout
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	payoutService Service
+}
+
+func NewHandler(payoutService Service) *Handler {
+	return &Handler{
+		payoutService: payoutService,
+	}
+}
+
+func (h *Handler) AddAccount(c *gin.Context) {
+	userID, ok := contextID(c, "subjectID")
+	if !ok {
+		return
+	}
+
+	var req CreateAccountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	account, err := h.payoutService.AddAccount(c.Request.Context(), userID, req)
+	if err != nil {
+		handleError(c, err, "Failed to add payout account")
+		return
+	}
+	response.Created(c, account, "Payout account added successfully")
+}
+
+func (h *Handler) ListAccounts(c *gin.Context) {
+	userID, ok := contextID(c, "subjectID")
+	if !ok {
+		return
+	}
+
+	accounts, err := h.payoutService.ListAccounts(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err, "Failed to list payout accounts")
+		return
+	}
+	response.OK(c, accounts, "Payout accounts retrieved successfully")
+}
+
+func (h *Handler) DeleteAccount(c *gin.Context) {
+	userID, ok := contextID(c, "subjectID")
+	if !ok {
+		return
+	}
+	accountID, ok := idParam(c, "accountID", "Invalid account ID")
+	if !ok {
+		return
+	}
+
+	if err := h.payoutService.DeleteAccount(c.Request.Context(), userID, accountID); err != nil {
+		handleError(c, err, "Failed to delete payout account")
+		return
+	}
+	response.OK(c, nil, "Payout account deleted successfully")
+}
+
+func (h *Handler) SetDefaultAccount(c *gin.Context) {
+	userID, ok := contextID(c, "subjectID")
+	if !ok {
+		return
+	}
+	accountID, ok := idParam(c, "accountID", "Invalid account ID")
+	if !ok {
+		return
+	}
+
+	if err := h.payoutService.SetDefaultAccount(c.Request.Context(), userID, accountID); err != nil {
+		handleError(c, err, "Failed to update payout account")
+		return
+	}
+	response.OK(c, nil, "Default payout account updated")
+}
+
+func (h *Handler) GetBalances(c *gin.Context) {
+	userID, ok := contextID(c, "subjectID")
+	if !ok {
+		return
+	}
+
+	balances, err := h.payoutService.GetBalances(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err, "Failed to get balance")
+		return
+	}
+	response.OK(c, balances, "Balance retrieved successfully")
+}
+
+func (h *Handler) ListLedger(c *gin.Context) {
+	userID, ok := contextID(c, "subjectID")
+	if !ok {
+		return
+	}
+	query, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
+	entries, total, err := h.payoutService.ListLedger(c.Request.Context(), userID, query.Page, query.PageSize)
+	if err != nil {
+		handleError(c, err, "Failed to list ledger entries")
+		return
+	}
+	response.Paginated(c, entries, query.Page, query.PageSize, int(total), "Ledger entries retrieved successfully")
+}
+
+func (h *Handler) ListPayouts(c *gin.Context) {
+	userID, ok := contextID(c, "subjectID")
+	if !ok {
+		return
+	}
+	query, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
+	payouts, total, err := h.payoutService.ListPayouts(c.Request.Context(), userID, query.Page, query.PageSize)
+	if err != nil {
+		handleError(c, err, "Failed to list payouts")
+		return
+	}
+	response.Paginated(c, payouts, query.Page, query.PageSize, int(total), "Payouts retrieved successfully")
+}
+
+func (h *Handler) AdjustBalance(c *gin.Context) {
+	actorID, ok := contextID(c, "userID")
+	if !ok {
+		return
+	}
+	vendorID, ok := idParam(c, "vendorID", "Invalid vendor ID")
+	if !ok {
+		return
+	}
+
+	var req AdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	entry, err := h.payoutService.AdjustBalance(c.Request.Context(), vendorID, actorID, req)
+	if err != nil {
+		handleError(c, err, "Failed to adjust balance")
+		return
+	}
+	response.Created(c, entry, "Balance adjusted successfully")
+}
+
+func (h *Handler) RunBatch(c *gin.Context) {
+	batch, err := h.payoutService.RunBatch(c.Request.Context())
+	if err != nil {
+		handleError(c, err, "Failed to run payout batch")
+		return
+	}
+	response.OK(c, batch, "Payout batch completed")
+}
+
+func (h *Handler) ListBatches(c *gin.Context) {
+	query, ok := bindListQuery(c)
+	if !ok {
+		return
+	}
+
+	batches, total, err := h.payoutService.ListBatches(c.Request.Context(), query.Page, query.PageSize)
+	if err != nil {
+		handleError(c, err, "Failed to list payout batches")
+		return
+	}
+	response.Paginated(c, batches, query.Page, query.PageSize, int(total), "Payout batches retrieved successfully")
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrAccountNotFound):
+		response.NotFound(c, "Payout account", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotFound):
+		response.NotFound(c, "Vendor", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorClosed):
+		response.Forbidden(c, "Vendor account is closed", response.IsProduction(c))
+	case errors.Is(err, ErrBatchRunning):
+		response.Conflict(c, "A payout batch is already running", nil, response.IsProduction(c))
+	case errors.Is(err, ErrInvalidAmount):
+		response.BadRequest(c, "Amount must be positive", nil, response.IsProduction(c))
+	case errors.Is(err, ErrNoProvider):
+		response.Error(c, http.StatusServiceUnavailable, "PAYOUTS_DISABLED", "No payout provider is configured", nil, response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func bindListQuery(c *gin.Context) (ListQuery, bool) {
+	var query ListQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return query, false
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+	return query, true
+}
+
+func idParam(c *gin.Context, name, message string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param(name))
+	if err != nil {
+		response.BadRequest(c, message, nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func contextID(c *gin.Context, key string) (primitive.ObjectID, bool) {
+	val, exists := c.Get(key)
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	id, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
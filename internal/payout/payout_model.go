@@ -0,0 +1,169 @@
+package payout
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type AccountType string
+
+const (
+	BankAccountType AccountType = "bank_account"
+)
+
+// Account is a vendor payout destination. Account and routing numbers are
+// stored encrypted; only the last four digits are kept in the clear.
+type Account struct {
+	ID                     primitive.ObjectID `bson:"_id,omitempty"`
+	VendorID               primitive.ObjectID `bson:"vendor_id"`
+	Type                   AccountType        `bson:"type"`
+	HolderName             string             `bson:"holder_name"`
+	BankName               string             `bson:"bank_name"`
+	Country                string             `bson:"country"`
+	Currency               string             `bson:"currency"`
+	EncryptedAccountNumber string             `bson:"encrypted_account_number"`
+	EncryptedRoutingNumber string             `bson:"encrypted_routing_number,omitempty"`
+	Last4                  string             `bson:"last4"`
+	IsDefault              bool               `bson:"is_default"`
+	CreatedAt              time.Time          `bson:"created_at"`
+	UpdatedAt              time.Time          `bson:"updated_at"`
+}
+
+type EntryType string
+
+const (
+	EarningEntry        EntryType = "earning"
+	FeeEntry            EntryType = "fee"
+	RefundEntry         EntryType = "refund"
+	PayoutEntry         EntryType = "payout"
+	PayoutReversalEntry EntryType = "payout_reversal"
+	AdjustmentEntry     EntryType = "adjustment"
+)
+
+// LedgerEntry is an immutable signed movement on a vendor balance, in minor
+// units. Earnings and reversals are positive; fees, refunds and payouts are
+// negative. An entry counts towards the available balance from AvailableAt.
+type LedgerEntry struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	VendorID    primitive.ObjectID `bson:"vendor_id"`
+	Type        EntryType          `bson:"type"`
+	Amount      int64              `bson:"amount"`
+	Currency    string             `bson:"currency"`
+	Reference   string             `bson:"reference"`
+	Description string             `bson:"description,omitempty"`
+	ActorID     primitive.ObjectID `bson:"actor_id,omitempty"`
+	AvailableAt time.Time          `bson:"available_at"`
+	CreatedAt   time.Time          `bson:"created_at"`
+}
+
+type Balance struct {
+	Currency  string `bson:"_id"`
+	Available int64  `bson:"available"`
+	Pending   int64  `bson:"pending"`
+}
+
+// VendorBalance is one vendor's available balance in one currency, as used
+// when building a payout batch.
+type VendorBalance struct {
+	VendorID  primitive.ObjectID
+	Currency  string
+	Available int64
+}
+
+type Status string
+
+const (
+	ProcessingStatus Status = "processing"
+	PaidStatus       Status = "paid"
+	FailedStatus     Status = "failed"
+)
+
+type Payout struct {
+	ID                primitive.ObjectID `bson:"_id,omitempty"`
+	VendorID          primitive.ObjectID `bson:"vendor_id"`
+	AccountID         primitive.ObjectID `bson:"account_id"`
+	BatchID           primitive.ObjectID `bson:"batch_id"`
+	Amount            int64              `bson:"amount"`
+	Currency          string             `bson:"currency"`
+	Status            Status             `bson:"status"`
+	Provider          string             `bson:"provider"`
+	ProviderReference string             `bson:"provider_reference,omitempty"`
+	FailureReason     string             `bson:"failure_reason,omitempty"`
+	CreatedAt         time.Time          `bson:"created_at"`
+	UpdatedAt         time.Time          `bson:"updated_at"`
+}
+
+type BatchStatus string
+
+const (
+	RunningBatchStatus   BatchStatus = "running"
+	CompletedBatchStatus BatchStatus = "completed"
+	AbandonedBatchStatus BatchStatus = "abandoned"
+)
+
+type Batch struct {
+	ID         primitive.ObjectID `bson:"_id,omitempty"`
+	Status     BatchStatus        `bson:"status"`
+	Paid       int                `bson:"paid"`
+	Failed     int                `bson:"failed"`
+	Skipped    int                `bson:"skipped"`
+	StartedAt  time.Time          `bson:"started_at"`
+	FinishedAt *time.Time         `bson:"finished_at,omitempty"`
+}
+
+func (a *Account) ToResponse() AccountResponse {
+	return AccountResponse{
+		ID:         a.ID.Hex(),
+		Type:       string(a.Type),
+		HolderName: a.HolderName,
+		BankName:   a.BankName,
+		Country:    a.Country,
+		Currency:   a.Currency,
+		Last4:      a.Last4,
+		IsDefault:  a.IsDefault,
+		CreatedAt:  a.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (e *LedgerEntry) ToResponse() LedgerEntryResponse {
+	return LedgerEntryResponse{
+		ID:          e.ID.Hex(),
+		Type:        string(e.Type),
+		Amount:      e.Amount,
+		Currency:    e.Currency,
+		Reference:   e.Reference,
+		Description: e.Description,
+		AvailableAt: e.AvailableAt.Format(time.RFC3339),
+		CreatedAt:   e.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (p *Payout) ToResponse() PayoutResponse {
+	return PayoutResponse{
+		ID:                p.ID.Hex(),
+		AccountID:         p.AccountID.Hex(),
+		Amount:            p.Amount,
+		Currency:          p.Currency,
+		Status:            string(p.Status),
+		ProviderReference: p.ProviderReference,
+		FailureReason:     p.FailureReason,
+		CreatedAt:         p.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:         p.UpdatedAt.Format(time.RFC3339),
+	}
+}
+
+func (b *Batch) ToResponse() BatchResponse {
+	resp := BatchResponse{
+		ID:        b.ID.Hex(),
+		Status:    string(b.Status),
+		Paid:      b.Paid,
+		Failed:    b.Failed,
+		Skipped:   b.Skipped,
+		StartedAt: b.StartedAt.Format(time.RFC3339),
+	}
+	if b.FinishedAt != nil {
+		resp.FinishedAt = b.FinishedAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,79 @@
+package payout
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+)
+
+// Transfer is what a provider needs to move money to a vendor's account.
+// Account details are decrypted only for the duration of the call.
+type Transfer struct {
+	PayoutID      string
+	Amount        int64
+	Currency      string
+	HolderName    string
+	BankName      string
+	Country       string
+	AccountNumber string
+	RoutingNumber string
+}
+
+// Provider sends transfers to a payment rail (bank, PSP). Send must be
+// idempotent on PayoutID so a retried batch cannot pay twice.
+type Provider interface {
+	Name() string
+	Send(ctx context.Context, t Transfer) (reference string, err error)
+}
+
+// FakeProvider records transfers in memory instead of moving money. It is used
+// for local development and never fails unless told to.
+type FakeProvider struct {
+	mu        sync.Mutex
+	transfers map[string]Transfer
+	// FailAccounts makes transfers to these account numbers fail.
+	FailAccounts map[string]bool
+}
+
+func NewFakeProvider() *FakeProvider {
+	return &FakeProvider{
+		transfers:    make(map[string]Transfer),
+		FailAccounts: make(map[string]bool),
+	}
+}
+
+func (p *FakeProvider) Name() string {
+	return "fake"
+}
+
+func (p *FakeProvider) Send(ctx context.Context, t Transfer) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.FailAccounts[t.AccountNumber] {
+		return "", fmt.Errorf("fake provider: account rejected")
+	}
+	p.transfers[t.PayoutID] = t
+	log.Printf("💸 fake payout %s: %d %s to %s ****%s", t.PayoutID, t.Amount, t.Currency, t.HolderName, last4(t.AccountNumber))
+	return "fake_" + t.PayoutID, nil
+}
+
+// Transfers returns a copy of everything sent so far.
+func (p *FakeProvider) Transfers() []Transfer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	out := make([]Transfer, 0, len(p.transfers))
+	for _, t := range p.transfers {
+		out = append(out, t)
+	}
+	return out
+}
+
+func last4(number string) string {
+	if len(number) <= 4 {
+		return number
+	}
+	return number[len(number)-4:]
+}
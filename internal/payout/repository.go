@@ -0,0 +1,325 @@
+package payout
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	CreateAccount(ctx context.Context, a *Account) error
+	ListAccounts(ctx context.Context, vendorID primitive.ObjectID) ([]Account, error)
+	DeleteAccount(ctx context.Context, vendorID, id primitive.ObjectID) error
+	SetDefaultAccount(ctx context.Context, vendorID, id primitive.ObjectID) error
+	// PayoutAccount picks the vendor's default account for currency, falling
+	// back to their oldest account in that currency.
+	PayoutAccount(ctx context.Context, vendorID primitive.ObjectID, currency string) (*Account, error)
+
+	InsertEntry(ctx context.Context, e *LedgerEntry) error
+	ListEntries(ctx context.Context, vendorID primitive.ObjectID, page, pageSize int) ([]LedgerEntry, int64, error)
+	Balances(ctx context.Context, vendorID primitive.ObjectID, now time.Time) ([]Balance, error)
+	PayableBalances(ctx context.Context, now time.Time) ([]VendorBalance, error)
+
+	CreatePayout(ctx context.Context, p *Payout) error
+	CompletePayout(ctx context.Context, id primitive.ObjectID, status Status, reference, reason string) error
+	ListPayouts(ctx context.Context, vendorID primitive.ObjectID, page, pageSize int) ([]Payout, int64, error)
+
+	// StartBatch claims the single running-batch slot, first abandoning a
+	// running batch older than staleAfter (e.g. left behind by a crash).
+	StartBatch(ctx context.Context, staleAfter time.Duration) (*Batch, error)
+	FinishBatch(ctx context.Context, b *Batch) error
+	ListBatches(ctx context.Context, page, pageSize int) ([]Batch, int64, error)
+}
+
+type PayoutRepository struct {
+	accounts *mongo.Collection
+	ledger   *mongo.Collection
+	payouts  *mongo.Collection
+	batches  *mongo.Collection
+}
+
+func NewPayoutRepository(db *mongo.Database) Repository {
+	return &PayoutRepository{
+		accounts: db.Collection("payout_accounts"),
+		ledger:   db.Collection("ledger_entries"),
+		payouts:  db.Collection("payouts"),
+		batches:  db.Collection("payout_batches"),
+	}
+}
+
+func (r *PayoutRepository) CreateAccount(ctx context.Context, a *Account) error {
+	if _, err := r.accounts.InsertOne(ctx, a); err != nil {
+		return err
+	}
+	if a.IsDefault {
+		return r.SetDefaultAccount(ctx, a.VendorID, a.ID)
+	}
+	return nil
+}
+
+func (r *PayoutRepository) ListAccounts(ctx context.Context, vendorID primitive.ObjectID) ([]Account, error) {
+	cursor, err := r.accounts.Find(
+		ctx,
+		bson.M{"vendor_id": vendorID},
+		options.Find().SetSort(bson.D{{Key: "is_default", Value: -1}, {Key: "created_at", Value: 1}}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	accounts := []Account{}
+	if err := cursor.All(ctx, &accounts); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (r *PayoutRepository) DeleteAccount(ctx context.Context, vendorID, id primitive.ObjectID) error {
+	res, err := r.accounts.DeleteOne(ctx, bson.M{"_id": id, "vendor_id": vendorID})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrAccountNotFound
+	}
+	return nil
+}
+
+func (r *PayoutRepository) SetDefaultAccount(ctx context.Context, vendorID, id primitive.ObjectID) error {
+	now := time.Now()
+	res, err := r.accounts.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "vendor_id": vendorID},
+		bson.M{"$set": bson.M{"is_default": true, "updated_at": now}},
+	)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrAccountNotFound
+	}
+	_, err = r.accounts.UpdateMany(
+		ctx,
+		bson.M{"vendor_id": vendorID, "_id": bson.M{"$ne": id}, "is_default": true},
+		bson.M{"$set": bson.M{"is_default": false, "updated_at": now}},
+	)
+	return err
+}
+
+func (r *PayoutRepository) PayoutAccount(ctx context.Context, vendorID primitive.ObjectID, currency string) (*Account, error) {
+	var a Account
+	err := r.accounts.FindOne(
+		ctx,
+		bson.M{"vendor_id": vendorID, "currency": currency},
+		options.FindOne().SetSort(bson.D{{Key: "is_default", Value: -1}, {Key: "created_at", Value: 1}}),
+	).Decode(&a)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrAccountNotFound
+	}
+	return &a, err
+}
+
+func (r *PayoutRepository) InsertEntry(ctx context.Context, e *LedgerEntry) error {
+	if _, err := r.ledger.InsertOne(ctx, e); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrDuplicateEntry
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *PayoutRepository) ListEntries(ctx context.Context, vendorID primitive.ObjectID, page, pageSize int) ([]LedgerEntry, int64, error) {
+	filter := bson.M{"vendor_id": vendorID}
+	total, err := r.ledger.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.ledger.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries := []LedgerEntry{}
+	if err := cursor.All(ctx, &entries); err != nil {
+		return nil, 0, err
+	}
+	return entries, total, nil
+}
+
+// availableStage splits each entry's amount into available and pending by
+// comparing available_at with now.
+func availableStage(groupID interface{}, now time.Time) bson.D {
+	released := bson.M{"$lte": bson.A{"$available_at", now}}
+	return bson.D{{Key: "$group", Value: bson.M{
+		"_id":       groupID,
+		"available": bson.M{"$sum": bson.M{"$cond": bson.A{released, "$amount", 0}}},
+		"pending":   bson.M{"$sum": bson.M{"$cond": bson.A{released, 0, "$amount"}}},
+	}}}
+}
+
+func (r *PayoutRepository) Balances(ctx context.Context, vendorID primitive.ObjectID, now time.Time) ([]Balance, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"vendor_id": vendorID}}},
+		availableStage("$currency", now),
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	cursor, err := r.ledger.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	balances := []Balance{}
+	if err := cursor.All(ctx, &balances); err != nil {
+		return nil, err
+	}
+	return balances, nil
+}
+
+func (r *PayoutRepository) PayableBalances(ctx context.Context, now time.Time) ([]VendorBalance, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"available_at": bson.M{"$lte": now}}}},
+		{{Key: "$group", Value: bson.M{
+			"_id":       bson.M{"vendor_id": "$vendor_id", "currency": "$currency"},
+			"available": bson.M{"$sum": "$amount"},
+		}}},
+		{{Key: "$match", Value: bson.M{"available": bson.M{"$gt": 0}}}},
+	}
+	cursor, err := r.ledger.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		ID struct {
+			VendorID primitive.ObjectID `bson:"vendor_id"`
+			Currency string             `bson:"currency"`
+		} `bson:"_id"`
+		Available int64 `bson:"available"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	balances := make([]VendorBalance, 0, len(rows))
+	for _, row := range rows {
+		balances = append(balances, VendorBalance{
+			VendorID:  row.ID.VendorID,
+			Currency:  row.ID.Currency,
+			Available: row.Available,
+		})
+	}
+	return balances, nil
+}
+
+func (r *PayoutRepository) CreatePayout(ctx context.Context, p *Payout) error {
+	_, err := r.payouts.InsertOne(ctx, p)
+	return err
+}
+
+func (r *PayoutRepository) CompletePayout(ctx context.Context, id primitive.ObjectID, status Status, reference, reason string) error {
+	_, err := r.payouts.UpdateOne(
+		ctx,
+		bson.M{"_id": id, "status": ProcessingStatus},
+		bson.M{"$set": bson.M{
+			"status":             status,
+			"provider_reference": reference,
+			"failure_reason":     reason,
+			"updated_at":         time.Now(),
+		}},
+	)
+	return err
+}
+
+func (r *PayoutRepository) ListPayouts(ctx context.Context, vendorID primitive.ObjectID, page, pageSize int) ([]Payout, int64, error) {
+	filter := bson.M{"vendor_id": vendorID}
+	total, err := r.payouts.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.payouts.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	payouts := []Payout{}
+	if err := cursor.All(ctx, &payouts); err != nil {
+		return nil, 0, err
+	}
+	return payouts, total, nil
+}
+
+func (r *PayoutRepository) StartBatch(ctx context.Context, staleAfter time.Duration) (*Batch, error) {
+	now := time.Now()
+	_, err := r.batches.UpdateMany(
+		ctx,
+		bson.M{"status": RunningBatchStatus, "started_at": bson.M{"$lt": now.Add(-staleAfter)}},
+		bson.M{"$set": bson.M{"status": AbandonedBatchStatus, "finished_at": now}},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &Batch{
+		ID:        primitive.NewObjectID(),
+		Status:    RunningBatchStatus,
+		StartedAt: now,
+	}
+	if _, err := r.batches.InsertOne(ctx, batch); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return nil, ErrBatchRunning
+		}
+		return nil, err
+	}
+	return batch, nil
+}
+
+func (r *PayoutRepository) FinishBatch(ctx context.Context, b *Batch) error {
+	now := time.Now()
+	b.Status = CompletedBatchStatus
+	b.FinishedAt = &now
+	_, err := r.batches.UpdateOne(
+		ctx,
+		bson.M{"_id": b.ID, "status": RunningBatchStatus},
+		bson.M{"$set": bson.M{
+			"status":      b.Status,
+			"paid":        b.Paid,
+			"failed":      b.Failed,
+			"skipped":     b.Skipped,
+			"finished_at": now,
+		}},
+	)
+	return err
+}
+
+func (r *PayoutRepository) ListBatches(ctx context.Context, page, pageSize int) ([]Batch, int64, error) {
+	total, err := r.batches.CountDocuments(ctx, bson.M{})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "started_at", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.batches.Find(ctx, bson.M{}, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	batches := []Batch{}
+	if err := cursor.All(ctx, &batches); err != nil {
+		return nil, 0, err
+	}
+	return batches, total, nil
+}
@@ -0,0 +1,31 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+)
+
+// RunScheduler starts a payout batch every interval until ctx is cancelled.
+func RunScheduler(ctx context.Context, s Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			batch, err := s.RunBatch(ctx)
+			switch {
+			case errors.Is(err, ErrBatchRunning):
+				log.Printf("⏭️ payout batch skipped: previous batch still running")
+			case err != nil:
+				log.Printf("⚠️ payout batch failed: %v", err)
+			default:
+				log.Printf("✅ payout batch %s: %d paid, %d failed, %d skipped", batch.ID, batch.Paid, batch.Failed, batch.Skipped)
+			}
+		}
+	}
+}
@@ -0,0 +1,480 @@
+package payout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/techrook/23-market/internal/notification"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/money"
+	"github.com/techrook/23-market/pkg/secretbox"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// staleBatchAfter is how long a batch may stay "running" before the next run
+// assumes it crashed and takes over.
+const staleBatchAfter = time.Hour
+
+var (
+	ErrAccountNotFound = errors.New("payout account not found")
+	ErrDuplicateEntry  = errors.New("ledger entry already recorded")
+	ErrBatchRunning    = errors.New("a payout batch is already running")
+	ErrInvalidAmount   = errors.New("amount must be positive")
+	ErrNoProvider      = errors.New("no payout provider configured")
+)
+
+// Config controls when earnings become payable.
+type Config struct {
+	// HoldPeriod delays earnings (and their fees) from becoming available so
+	// refunds and disputes can land first.
+	HoldPeriod time.Duration
+	// Minimums is the smallest available balance worth paying out in each
+	// currency, in that currency's minor units. Currencies without an entry
+	// use defaultMinimum whole units.
+	Minimums map[string]int64
+}
+
+// defaultMinimum is the payout threshold, in whole currency units, for
+// currencies Config.Minimums doesn't list.
+const defaultMinimum = 10
+
+// minimum returns the payout threshold for currency in its minor units.
+func (c Config) minimum(currency string) int64 {
+	if m, ok := c.Minimums[currency]; ok {
+		return m
+	}
+	m := int64(defaultMinimum)
+	if cur, err := money.LookupCurrency(currency); err == nil {
+		for i := 0; i < cur.Digits; i++ {
+			m *= 10
+		}
+	}
+	return m
+}
+
+// ParseMinimums reads per-currency payout thresholds written as
+// "USD:10.00,JPY:1500" into minor units.
+func ParseMinimums(spec string) (map[string]int64, error) {
+	minimums := make(map[string]int64)
+	for _, field := range strings.Split(spec, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		code, amount, ok := strings.Cut(field, ":")
+		if !ok {
+			return nil, fmt.Errorf("payout minimum %q: expected CURRENCY:AMOUNT", field)
+		}
+		m, err := money.Parse(strings.TrimSpace(amount), strings.TrimSpace(code))
+		if err != nil {
+			return nil, fmt.Errorf("payout minimum %q: %w", field, err)
+		}
+		minimums[m.Currency()] = m.Amount()
+	}
+	return minimums, nil
+}
+
+// Ledger is the hook the order and refund flows use to move money on a vendor
+// balance. Recording is idempotent on the reference.
+type Ledger interface {
+	RecordEarning(ctx context.Context, vendorID primitive.ObjectID, orderRef string, gross, fee int64, currency string) error
+	RecordRefund(ctx context.Context, vendorID primitive.ObjectID, refundRef string, amount int64, currency string) error
+}
+
+type Service interface {
+	Ledger
+
+	AddAccount(ctx context.Context, userID primitive.ObjectID, req CreateAccountRequest) (*AccountResponse, error)
+	ListAccounts(ctx context.Context, userID primitive.ObjectID) ([]AccountResponse, error)
+	DeleteAccount(ctx context.Context, userID, accountID primitive.ObjectID) error
+	SetDefaultAccount(ctx context.Context, userID, accountID primitive.ObjectID) error
+
+	GetBalances(ctx context.Context, userID primitive.ObjectID) ([]BalanceResponse, error)
+	ListLedger(ctx context.Context, userID primitive.ObjectID, page, pageSize int) ([]LedgerEntryResponse, int64, error)
+	ListPayouts(ctx context.Context, userID primitive.ObjectID, page, pageSize int) ([]PayoutResponse, int64, error)
+
+	AdjustBalance(ctx context.Context, vendorID, actorID primitive.ObjectID, req AdjustmentRequest) (*LedgerEntryResponse, error)
+	RunBatch(ctx context.Context) (*BatchResponse, error)
+	ListBatches(ctx context.Context, page, pageSize int) ([]BatchResponse, int64, error)
+}
+
+type service struct {
+	payoutRepo Repository
+	vendorRepo vendor.Repository
+	box        *secretbox.Box
+	provider   Provider
+	notifier   notification.Notifier
+	cfg        Config
+}
+
+// NewService wires the payout service. provider may be nil when no payment
+// rail is configured; balances still accrue but RunBatch refuses to run.
+func NewService(payoutRepo Repository, vendorRepo vendor.Repository, box *secretbox.Box, provider Provider, notifier notification.Notifier, cfg Config) Service {
+	return &service{
+		payoutRepo: payoutRepo,
+		vendorRepo: vendorRepo,
+		box:        box,
+		provider:   provider,
+		notifier:   notifier,
+		cfg:        cfg,
+	}
+}
+
+func (s *service) AddAccount(ctx context.Context, userID primitive.ObjectID, req CreateAccountRequest) (*AccountResponse, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if v.Status == vendor.ClosedVendorStatus {
+		return nil, vendor.ErrVendorClosed
+	}
+
+	existing, err := s.payoutRepo.ListAccounts(ctx, v.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	accountNumber, err := s.box.Seal(req.AccountNumber)
+	if err != nil {
+		return nil, err
+	}
+	var routingNumber string
+	if req.RoutingNumber != "" {
+		if routingNumber, err = s.box.Seal(req.RoutingNumber); err != nil {
+			return nil, err
+		}
+	}
+
+	now := time.Now()
+	account := &Account{
+		ID:                     primitive.NewObjectID(),
+		VendorID:               v.ID,
+		Type:                   BankAccountType,
+		HolderName:             req.HolderName,
+		BankName:               req.BankName,
+		Country:                req.Country,
+		Currency:               req.Currency,
+		EncryptedAccountNumber: accountNumber,
+		EncryptedRoutingNumber: routingNumber,
+		Last4:                  last4(req.AccountNumber),
+		IsDefault:              req.IsDefault || len(existing) == 0,
+		CreatedAt:              now,
+		UpdatedAt:              now,
+	}
+	if err := s.payoutRepo.CreateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+
+	resp := account.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) ListAccounts(ctx context.Context, userID primitive.ObjectID) ([]AccountResponse, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	accounts, err := s.payoutRepo.ListAccounts(ctx, v.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]AccountResponse, 0, len(accounts))
+	for i := range accounts {
+		resp = append(resp, accounts[i].ToResponse())
+	}
+	return resp, nil
+}
+
+func (s *service) DeleteAccount(ctx context.Context, userID, accountID primitive.ObjectID) error {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.payoutRepo.DeleteAccount(ctx, v.ID, accountID)
+}
+
+func (s *service) SetDefaultAccount(ctx context.Context, userID, accountID primitive.ObjectID) error {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.payoutRepo.SetDefaultAccount(ctx, v.ID, accountID)
+}
+
+func (s *service) GetBalances(ctx context.Context, userID primitive.ObjectID) ([]BalanceResponse, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	balances, err := s.payoutRepo.Balances(ctx, v.ID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]BalanceResponse, 0, len(balances))
+	for _, b := range balances {
+		resp = append(resp, BalanceResponse{Currency: b.Currency, Available: b.Available, Pending: b.Pending})
+	}
+	return resp, nil
+}
+
+func (s *service) ListLedger(ctx context.Context, userID primitive.ObjectID, page, pageSize int) ([]LedgerEntryResponse, int64, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	entries, total, err := s.payoutRepo.ListEntries(ctx, v.ID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]LedgerEntryResponse, 0, len(entries))
+	for i := range entries {
+		resp = append(resp, entries[i].ToResponse())
+	}
+	return resp, total, nil
+}
+
+func (s *service) ListPayouts(ctx context.Context, userID primitive.ObjectID, page, pageSize int) ([]PayoutResponse, int64, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	payouts, total, err := s.payoutRepo.ListPayouts(ctx, v.ID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]PayoutResponse, 0, len(payouts))
+	for i := range payouts {
+		resp = append(resp, payouts[i].ToResponse())
+	}
+	return resp, total, nil
+}
+
+func (s *service) RecordEarning(ctx context.Context, vendorID primitive.ObjectID, orderRef string, gross, fee int64, currency string) error {
+	if gross <= 0 || fee < 0 {
+		return ErrInvalidAmount
+	}
+	availableAt := time.Now().Add(s.cfg.HoldPeriod)
+	if err := s.record(ctx, vendorID, EarningEntry, gross, currency, orderRef, "Order earnings", availableAt); err != nil {
+		return err
+	}
+	if fee == 0 {
+		return nil
+	}
+	return s.record(ctx, vendorID, FeeEntry, -fee, currency, orderRef, "Marketplace fee", availableAt)
+}
+
+// Refunds hit the available balance immediately so they are recovered from
+// the next payout rather than after the hold period.
+func (s *service) RecordRefund(ctx context.Context, vendorID primitive.ObjectID, refundRef string, amount int64, currency string) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	return s.record(ctx, vendorID, RefundEntry, -amount, currency, refundRef, "Order refund", time.Now())
+}
+
+func (s *service) record(ctx context.Context, vendorID primitive.ObjectID, kind EntryType, amount int64, currency, reference, description string, availableAt time.Time) error {
+	err := s.payoutRepo.InsertEntry(ctx, &LedgerEntry{
+		ID:          primitive.NewObjectID(),
+		VendorID:    vendorID,
+		Type:        kind,
+		Amount:      amount,
+		Currency:    currency,
+		Reference:   reference,
+		Description: description,
+		AvailableAt: availableAt,
+		CreatedAt:   time.Now(),
+	})
+	if errors.Is(err, ErrDuplicateEntry) {
+		return nil
+	}
+	return err
+}
+
+func (s *service) AdjustBalance(ctx context.Context, vendorID, actorID primitive.ObjectID, req AdjustmentRequest) (*LedgerEntryResponse, error) {
+	if _, err := s.vendorRepo.GetVendorByID(ctx, vendorID); err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	entry := &LedgerEntry{
+		ID:          primitive.NewObjectID(),
+		VendorID:    vendorID,
+		Type:        AdjustmentEntry,
+		Amount:      req.Amount,
+		Currency:    req.Currency,
+		Description: req.Description,
+		ActorID:     actorID,
+		AvailableAt: now,
+		CreatedAt:   now,
+	}
+	entry.Reference = entry.ID.Hex()
+	if err := s.payoutRepo.InsertEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	resp := entry.ToResponse()
+	return &resp, nil
+}
+
+// RunBatch pays out every vendor balance that has cleared the hold period and
+// reaches its currency's minimum. Each payout debits the ledger before the provider is
+// called; a failed transfer is credited back with a reversal entry.
+func (s *service) RunBatch(ctx context.Context) (*BatchResponse, error) {
+	if s.provider == nil {
+		return nil, ErrNoProvider
+	}
+	batch, err := s.payoutRepo.StartBatch(ctx, staleBatchAfter)
+	if err != nil {
+		return nil, err
+	}
+
+	balances, err := s.payoutRepo.PayableBalances(ctx, time.Now())
+	if err != nil {
+		// Release the batch slot so the next run doesn't wait for it to go stale.
+		_ = s.payoutRepo.FinishBatch(ctx, batch)
+		return nil, err
+	}
+	for _, balance := range balances {
+		if balance.Available < s.cfg.minimum(balance.Currency) {
+			continue
+		}
+		paid, err := s.payVendor(ctx, batch.ID, balance)
+		switch {
+		case err != nil:
+			log.Printf("⚠️ payout to vendor %s failed: %v", balance.VendorID.Hex(), err)
+			batch.Failed++
+		case paid:
+			batch.Paid++
+		default:
+			batch.Skipped++
+		}
+	}
+
+	if err := s.payoutRepo.FinishBatch(ctx, batch); err != nil {
+		return nil, err
+	}
+	resp := batch.ToResponse()
+	return &resp, nil
+}
+
+// payVendor reports false without error when the vendor can't be paid yet
+// (suspended, or no account in that currency); the balance carries over.
+func (s *service) payVendor(ctx context.Context, batchID primitive.ObjectID, balance VendorBalance) (bool, error) {
+	v, err := s.vendorRepo.GetVendorByID(ctx, balance.VendorID)
+	if err != nil {
+		return false, err
+	}
+	if v.Status == vendor.SuspendedVendorStatus {
+		return false, nil
+	}
+	account, err := s.payoutRepo.PayoutAccount(ctx, v.ID, balance.Currency)
+	if errors.Is(err, ErrAccountNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	transfer, err := s.transferFor(account)
+	if err != nil {
+		return false, err
+	}
+
+	now := time.Now()
+	payout := &Payout{
+		ID:        primitive.NewObjectID(),
+		VendorID:  v.ID,
+		AccountID: account.ID,
+		BatchID:   batchID,
+		Amount:    balance.Available,
+		Currency:  balance.Currency,
+		Status:    ProcessingStatus,
+		Provider:  s.provider.Name(),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := s.payoutRepo.CreatePayout(ctx, payout); err != nil {
+		return false, err
+	}
+	if err := s.record(ctx, v.ID, PayoutEntry, -payout.Amount, payout.Currency, payout.ID.Hex(), "Payout to ****"+account.Last4, now); err != nil {
+		_ = s.payoutRepo.CompletePayout(ctx, payout.ID, FailedStatus, "", "ledger debit failed")
+		return false, err
+	}
+
+	transfer.PayoutID = payout.ID.Hex()
+	transfer.Amount = payout.Amount
+	transfer.Currency = payout.Currency
+	reference, sendErr := s.provider.Send(ctx, transfer)
+	if sendErr != nil {
+		if err := s.payoutRepo.CompletePayout(ctx, payout.ID, FailedStatus, "", sendErr.Error()); err != nil {
+			return false, err
+		}
+		if err := s.record(ctx, v.ID, PayoutReversalEntry, payout.Amount, payout.Currency, payout.ID.Hex(), "Failed payout returned to balance", time.Now()); err != nil {
+			return false, err
+		}
+		s.notify(ctx, v.UserID, notification.PayoutFailedType, "Payout failed",
+			fmt.Sprintf("We could not send %s to your account ending %s. The funds are back in your balance.", formatAmount(payout.Amount, payout.Currency), account.Last4),
+			payout)
+		return false, sendErr
+	}
+
+	if err := s.payoutRepo.CompletePayout(ctx, payout.ID, PaidStatus, reference, ""); err != nil {
+		return false, err
+	}
+	s.notify(ctx, v.UserID, notification.PayoutSentType, "Payout sent",
+		fmt.Sprintf("%s is on its way to your account ending %s.", formatAmount(payout.Amount, payout.Currency), account.Last4),
+		payout)
+	return true, nil
+}
+
+func (s *service) transferFor(a *Account) (Transfer, error) {
+	accountNumber, err := s.box.Open(a.EncryptedAccountNumber)
+	if err != nil {
+		return Transfer{}, fmt.Errorf("decrypt account %s: %w", a.ID.Hex(), err)
+	}
+	var routingNumber string
+	if a.EncryptedRoutingNumber != "" {
+		if routingNumber, err = s.box.Open(a.EncryptedRoutingNumber); err != nil {
+			return Transfer{}, fmt.Errorf("decrypt account %s: %w", a.ID.Hex(), err)
+		}
+	}
+	return Transfer{
+		HolderName:    a.HolderName,
+		BankName:      a.BankName,
+		Country:       a.Country,
+		AccountNumber: accountNumber,
+		RoutingNumber: routingNumber,
+	}, nil
+}
+
+func (s *service) ListBatches(ctx context.Context, page, pageSize int) ([]BatchResponse, int64, error) {
+	batches, total, err := s.payoutRepo.ListBatches(ctx, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]BatchResponse, 0, len(batches))
+	for i := range batches {
+		resp = append(resp, batches[i].ToResponse())
+	}
+	return resp, total, nil
+}
+
+// notify is best effort: the payout already happened whether or not the
+// vendor hears about it.
+func (s *service) notify(ctx context.Context, userID primitive.ObjectID, kind notification.Type, title, body string, p *Payout) {
+	data := map[string]string{"payout_id": p.ID.Hex(), "status": string(p.Status)}
+	if err := s.notifier.Notify(ctx, userID, kind, title, body, data); err != nil {
+		log.Printf("⚠️ failed to notify vendor %s about payout %s: %v", userID.Hex(), p.ID.Hex(), err)
+	}
+}
+
+// formatAmount renders minor units with the currency's own decimal places.
+func formatAmount(minor int64, currency string) string {
+	m, err := money.New(minor, currency)
+	if err != nil {
+		return fmt.Sprintf("%d %s", minor, currency)
+	}
+	return m.String()
+}
@@ -0,0 +1,46 @@
+package pricing
+
+import "time"
+
+// CreateScheduleRequest without ends_at schedules a permanent price change;
+// with it, a sale.
+type CreateScheduleRequest struct {
+	VariantID      string     `json:"variant_id" binding:"omitempty,len=24,hexadecimal"`
+	Price          *int64     `json:"price" binding:"required,min=0"`
+	CompareAtPrice *int64     `json:"compare_at_price,omitempty" binding:"omitempty,min=0"`
+	StartsAt       time.Time  `json:"starts_at" binding:"required"`
+	EndsAt         *time.Time `json:"ends_at,omitempty"`
+}
+
+type ListSchedulesQuery struct {
+	Page     int            `form:"page" binding:"omitempty,min=1"`
+	PageSize int            `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Status   ScheduleStatus `form:"status" binding:"omitempty,oneof=scheduled active completed cancelled"`
+}
+
+type PriceHistoryQuery struct {
+	VariantID string `form:"variant_id" binding:"omitempty,len=24,hexadecimal"`
+	Days      int    `form:"days" binding:"omitempty,min=1,max=365"`
+}
+
+type ScheduleResponse struct {
+	ID             string `json:"id"`
+	ProductID      string `json:"product_id"`
+	VariantID      string `json:"variant_id,omitempty"`
+	Kind           string `json:"kind"`
+	Price          int64  `json:"price"`
+	CompareAtPrice *int64 `json:"compare_at_price,omitempty"`
+	StartsAt       string `json:"starts_at"`
+	EndsAt         string `json:"ends_at,omitempty"`
+	Status         string `json:"status"`
+	StartedAt      string `json:"started_at,omitempty"`
+	EndedAt        string `json:"ended_at,omitempty"`
+	CreatedAt      string `json:"created_at"`
+}
+
+type PricePointResponse struct {
+	VariantID  string `json:"variant_id"`
+	Price      int64  `json:"price"`
+	Currency   string `json:"currency"`
+	RecordedAt string `json:"recorded_at"`
+}
@@ -0,0 +1,166 @@
+package pricing
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	pricingService Service
+}
+
+func NewHandler(pricingService Service) *Handler {
+	return &Handler{
+		pricingService: pricingService,
+	}
+}
+
+func (h *Handler) CreateSchedule(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := idParam(c, "productID", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	var req CreateScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	schedule, err := h.pricingService.CreateSchedule(c.Request.Context(), userID, productID, req)
+	if err != nil {
+		handleError(c, err, "Failed to create price schedule")
+		return
+	}
+	response.Created(c, schedule, "Price schedule created successfully")
+}
+
+func (h *Handler) ListSchedules(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := idParam(c, "productID", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	var query ListSchedulesQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+
+	schedules, total, err := h.pricingService.ListSchedules(c.Request.Context(), userID, productID, query)
+	if err != nil {
+		handleError(c, err, "Failed to list price schedules")
+		return
+	}
+	response.Paginated(c, schedules, query.Page, query.PageSize, int(total), "Price schedules retrieved successfully")
+}
+
+func (h *Handler) CancelSchedule(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := idParam(c, "productID", "Invalid product ID")
+	if !ok {
+		return
+	}
+	scheduleID, ok := idParam(c, "scheduleID", "Invalid schedule ID")
+	if !ok {
+		return
+	}
+
+	schedule, err := h.pricingService.CancelSchedule(c.Request.Context(), userID, productID, scheduleID)
+	if err != nil {
+		handleError(c, err, "Failed to cancel price schedule")
+		return
+	}
+	response.OK(c, schedule, "Price schedule cancelled")
+}
+
+func (h *Handler) PriceHistory(c *gin.Context) {
+	productID, ok := idParam(c, "productID", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	var query PriceHistoryQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Days == 0 {
+		query.Days = 30
+	}
+
+	points, err := h.pricingService.PriceHistory(c.Request.Context(), productID, query)
+	if err != nil {
+		handleError(c, err, "Failed to get price history")
+		return
+	}
+	response.OK(c, points, "Price history retrieved successfully")
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrScheduleNotFound):
+		response.NotFound(c, "Price schedule", response.IsProduction(c))
+	case errors.Is(err, product.ErrProductNotFound):
+		response.NotFound(c, "Product", response.IsProduction(c))
+	case errors.Is(err, product.ErrVariantNotFound):
+		response.NotFound(c, "Variant", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotFound):
+		response.NotFound(c, "Vendor", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotApproved):
+		response.Forbidden(c, "Only approved vendors can schedule prices", response.IsProduction(c))
+	case errors.Is(err, ErrInvalidSchedule):
+		response.BadRequest(c, err.Error(), nil, response.IsProduction(c))
+	case errors.Is(err, ErrScheduleOverlap):
+		response.Conflict(c, err.Error(), nil, response.IsProduction(c))
+	case errors.Is(err, ErrScheduleFinished):
+		response.Conflict(c, "Price schedule has already finished", nil, response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func idParam(c *gin.Context, name, message string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param(name))
+	if err != nil {
+		response.BadRequest(c, message, nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func callerID(c *gin.Context) (primitive.ObjectID, bool) {
+	val, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	userID, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
@@ -0,0 +1,94 @@
+package pricing
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// DisclosureWindow is how far back the lowest-price disclosure looks from
+// the moment a price takes effect.
+const DisclosureWindow = 30 * 24 * time.Hour
+
+type ScheduleStatus string
+
+const (
+	ScheduledStatus ScheduleStatus = "scheduled"
+	ActiveStatus    ScheduleStatus = "active"
+	CompletedStatus ScheduleStatus = "completed"
+	CancelledStatus ScheduleStatus = "cancelled"
+)
+
+// Schedule is a future price for a product or one of its variants. With an
+// end time it is a sale that runs between StartsAt and EndsAt and then
+// lapses; without one it permanently replaces the regular price at StartsAt.
+type Schedule struct {
+	ID       primitive.ObjectID `bson:"_id,omitempty"`
+	VendorID primitive.ObjectID `bson:"vendor_id"`
+	// VariantID is nil when the schedule applies to the whole product.
+	ProductID      primitive.ObjectID  `bson:"product_id"`
+	VariantID      *primitive.ObjectID `bson:"variant_id"`
+	Price          int64               `bson:"price"`
+	CompareAtPrice *int64              `bson:"compare_at_price,omitempty"`
+	StartsAt       time.Time           `bson:"starts_at"`
+	EndsAt         *time.Time          `bson:"ends_at"`
+	Status         ScheduleStatus      `bson:"status"`
+	CreatedBy      primitive.ObjectID  `bson:"created_by"`
+	CreatedAt      time.Time           `bson:"created_at"`
+	StartedAt      *time.Time          `bson:"started_at,omitempty"`
+	EndedAt        *time.Time          `bson:"ended_at,omitempty"`
+}
+
+func (s *Schedule) IsSale() bool {
+	return s.EndsAt != nil
+}
+
+func (s *Schedule) ToResponse() ScheduleResponse {
+	resp := ScheduleResponse{
+		ID:             s.ID.Hex(),
+		ProductID:      s.ProductID.Hex(),
+		Kind:           "price_change",
+		Price:          s.Price,
+		CompareAtPrice: s.CompareAtPrice,
+		StartsAt:       s.StartsAt.Format(time.RFC3339),
+		Status:         string(s.Status),
+		CreatedAt:      s.CreatedAt.Format(time.RFC3339),
+	}
+	if s.VariantID != nil {
+		resp.VariantID = s.VariantID.Hex()
+	}
+	if s.IsSale() {
+		resp.Kind = "sale"
+		resp.EndsAt = s.EndsAt.Format(time.RFC3339)
+	}
+	if s.StartedAt != nil {
+		resp.StartedAt = s.StartedAt.Format(time.RFC3339)
+	}
+	if s.EndedAt != nil {
+		resp.EndedAt = s.EndedAt.Format(time.RFC3339)
+	}
+	return resp
+}
+
+// PricePoint records a variant's effective price from RecordedAt until the
+// next point. LowestPrior is the lowest price in the DisclosureWindow before
+// this one took effect, or nil with no earlier history in the same currency.
+type PricePoint struct {
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	VendorID    primitive.ObjectID `bson:"vendor_id"`
+	ProductID   primitive.ObjectID `bson:"product_id"`
+	VariantID   primitive.ObjectID `bson:"variant_id"`
+	Price       int64              `bson:"price"`
+	Currency    string             `bson:"currency"`
+	LowestPrior *int64             `bson:"lowest_prior,omitempty"`
+	RecordedAt  time.Time          `bson:"recorded_at"`
+}
+
+func (p *PricePoint) ToResponse() PricePointResponse {
+	return PricePointResponse{
+		VariantID:  p.VariantID.Hex(),
+		Price:      p.Price,
+		Currency:   p.Currency,
+		RecordedAt: p.RecordedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,265 @@
+package pricing
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// maxHistoryPoints caps one price history response.
+const maxHistoryPoints = 1000
+
+type Repository interface {
+	CreateSchedule(ctx context.Context, s *Schedule) error
+	GetScheduleForVendor(ctx context.Context, vendorID, id primitive.ObjectID) (*Schedule, error)
+	ListSchedules(ctx context.Context, vendorID, productID primitive.ObjectID, status ScheduleStatus, page, pageSize int) ([]Schedule, int64, error)
+	// SaleOverlaps reports whether another pending or running sale on the
+	// same product or variant overlaps [startsAt, endsAt).
+	SaleOverlaps(ctx context.Context, productID primitive.ObjectID, variantID *primitive.ObjectID, startsAt, endsAt time.Time) (bool, error)
+	// ClaimDue moves the earliest schedule due by now to active, or returns
+	// nil when none is due.
+	ClaimDue(ctx context.Context, now time.Time) (*Schedule, error)
+	// ClaimEnded moves a running sale whose end has passed to completed, or
+	// returns nil when none has.
+	ClaimEnded(ctx context.Context, now time.Time) (*Schedule, error)
+	// TransitionSchedule moves a schedule in one of the from statuses to
+	// status and returns it as it was before, or ErrScheduleFinished.
+	TransitionSchedule(ctx context.Context, id primitive.ObjectID, from []ScheduleStatus, status ScheduleStatus) (*Schedule, error)
+
+	InsertPoints(ctx context.Context, points []PricePoint) error
+	// LatestPoints returns each variant's most recent point.
+	LatestPoints(ctx context.Context, variantIDs []primitive.ObjectID) (map[primitive.ObjectID]PricePoint, error)
+	// LowestSince is the lowest price each variant had in currency from since
+	// on, counting the price already in effect at since.
+	LowestSince(ctx context.Context, variantIDs []primitive.ObjectID, currency string, since time.Time) (map[primitive.ObjectID]int64, error)
+	ListPoints(ctx context.Context, productID primitive.ObjectID, variantID *primitive.ObjectID, since time.Time) ([]PricePoint, error)
+}
+
+type PricingRepository struct {
+	schedules *mongo.Collection
+	history   *mongo.Collection
+}
+
+func NewPricingRepository(db *mongo.Database) Repository {
+	return &PricingRepository{
+		schedules: db.Collection("price_schedules"),
+		history:   db.Collection("price_history"),
+	}
+}
+
+func (r *PricingRepository) CreateSchedule(ctx context.Context, s *Schedule) error {
+	_, err := r.schedules.InsertOne(ctx, s)
+	return err
+}
+
+func (r *PricingRepository) GetScheduleForVendor(ctx context.Context, vendorID, id primitive.ObjectID) (*Schedule, error) {
+	var s Schedule
+	err := r.schedules.FindOne(ctx, bson.M{"_id": id, "vendor_id": vendorID}).Decode(&s)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrScheduleNotFound
+	}
+	return &s, err
+}
+
+func (r *PricingRepository) ListSchedules(ctx context.Context, vendorID, productID primitive.ObjectID, status ScheduleStatus, page, pageSize int) ([]Schedule, int64, error) {
+	filter := bson.M{"vendor_id": vendorID, "product_id": productID}
+	if status != "" {
+		filter["status"] = status
+	}
+
+	total, err := r.schedules.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "starts_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.schedules.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	schedules := []Schedule{}
+	if err := cursor.All(ctx, &schedules); err != nil {
+		return nil, 0, err
+	}
+	return schedules, total, nil
+}
+
+func (r *PricingRepository) SaleOverlaps(ctx context.Context, productID primitive.ObjectID, variantID *primitive.ObjectID, startsAt, endsAt time.Time) (bool, error) {
+	filter := bson.M{
+		"product_id": productID,
+		"variant_id": variantID,
+		"status":     bson.M{"$in": bson.A{ScheduledStatus, ActiveStatus}},
+		"starts_at":  bson.M{"$lt": endsAt},
+		"ends_at":    bson.M{"$gt": startsAt},
+	}
+	n, err := r.schedules.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+func (r *PricingRepository) ClaimDue(ctx context.Context, now time.Time) (*Schedule, error) {
+	return r.claim(ctx,
+		bson.M{"status": ScheduledStatus, "starts_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"status": ActiveStatus, "started_at": now}},
+		"starts_at",
+	)
+}
+
+func (r *PricingRepository) ClaimEnded(ctx context.Context, now time.Time) (*Schedule, error) {
+	return r.claim(ctx,
+		bson.M{"status": ActiveStatus, "ends_at": bson.M{"$lte": now}},
+		bson.M{"$set": bson.M{"status": CompletedStatus, "ended_at": now}},
+		"ends_at",
+	)
+}
+
+func (r *PricingRepository) claim(ctx context.Context, filter, update bson.M, sortKey string) (*Schedule, error) {
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: sortKey, Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var s Schedule
+	err := r.schedules.FindOneAndUpdate(ctx, filter, update, opts).Decode(&s)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (r *PricingRepository) TransitionSchedule(ctx context.Context, id primitive.ObjectID, from []ScheduleStatus, status ScheduleStatus) (*Schedule, error) {
+	set := bson.M{"status": status}
+	if status == CompletedStatus || status == CancelledStatus {
+		set["ended_at"] = time.Now()
+	}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.Before)
+
+	var s Schedule
+	err := r.schedules.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "status": bson.M{"$in": from}},
+		bson.M{"$set": set},
+		opts,
+	).Decode(&s)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrScheduleFinished
+	}
+	return &s, err
+}
+
+func (r *PricingRepository) InsertPoints(ctx context.Context, points []PricePoint) error {
+	if len(points) == 0 {
+		return nil
+	}
+	docs := make([]interface{}, 0, len(points))
+	for i := range points {
+		docs = append(docs, &points[i])
+	}
+	_, err := r.history.InsertMany(ctx, docs)
+	return err
+}
+
+func (r *PricingRepository) LatestPoints(ctx context.Context, variantIDs []primitive.ObjectID) (map[primitive.ObjectID]PricePoint, error) {
+	latest := make(map[primitive.ObjectID]PricePoint, len(variantIDs))
+	if len(variantIDs) == 0 {
+		return latest, nil
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"variant_id": bson.M{"$in": variantIDs}}}},
+		{{Key: "$sort", Value: bson.D{{Key: "variant_id", Value: 1}, {Key: "recorded_at", Value: -1}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$variant_id", "point": bson.M{"$first": "$$ROOT"}}}},
+	}
+	cursor, err := r.history.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Point PricePoint `bson:"point"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		latest[row.Point.VariantID] = row.Point
+	}
+	return latest, nil
+}
+
+func (r *PricingRepository) LowestSince(ctx context.Context, variantIDs []primitive.ObjectID, currency string, since time.Time) (map[primitive.ObjectID]int64, error) {
+	lowest := make(map[primitive.ObjectID]int64, len(variantIDs))
+	if len(variantIDs) == 0 {
+		return lowest, nil
+	}
+	match := bson.M{"variant_id": bson.M{"$in": variantIDs}, "currency": currency}
+
+	// Prices recorded inside the window.
+	within := mongo.Pipeline{
+		{{Key: "$match", Value: merge(match, bson.M{"recorded_at": bson.M{"$gte": since}})}},
+		{{Key: "$group", Value: bson.M{"_id": "$variant_id", "price": bson.M{"$min": "$price"}}}},
+	}
+	// The price each variant already had when the window opened.
+	before := mongo.Pipeline{
+		{{Key: "$match", Value: merge(match, bson.M{"recorded_at": bson.M{"$lt": since}})}},
+		{{Key: "$sort", Value: bson.D{{Key: "variant_id", Value: 1}, {Key: "recorded_at", Value: -1}}}},
+		{{Key: "$group", Value: bson.M{"_id": "$variant_id", "price": bson.M{"$first": "$price"}}}},
+	}
+
+	for _, pipeline := range []mongo.Pipeline{within, before} {
+		cursor, err := r.history.Aggregate(ctx, pipeline)
+		if err != nil {
+			return nil, err
+		}
+		var rows []struct {
+			VariantID primitive.ObjectID `bson:"_id"`
+			Price     int64              `bson:"price"`
+		}
+		if err := cursor.All(ctx, &rows); err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			if current, ok := lowest[row.VariantID]; !ok || row.Price < current {
+				lowest[row.VariantID] = row.Price
+			}
+		}
+	}
+	return lowest, nil
+}
+
+func (r *PricingRepository) ListPoints(ctx context.Context, productID primitive.ObjectID, variantID *primitive.ObjectID, since time.Time) ([]PricePoint, error) {
+	filter := bson.M{"product_id": productID, "recorded_at": bson.M{"$gte": since}}
+	if variantID != nil {
+		filter["variant_id"] = *variantID
+	}
+	opts := options.Find().
+		SetSort(bson.D{{Key: "recorded_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetLimit(maxHistoryPoints)
+
+	cursor, err := r.history.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	points := []PricePoint{}
+	if err := cursor.All(ctx, &points); err != nil {
+		return nil, err
+	}
+	return points, nil
+}
+
+func merge(a, b bson.M) bson.M {
+	m := make(bson.M, len(a)+len(b))
+	for k, v := range a {
+		m[k] = v
+	}
+	for k, v := range b {
+		m[k] = v
+	}
+	return m
+}
@@ -0,0 +1,29 @@
+package pricing
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunScheduler starts and ends price schedules every interval until ctx is
+// cancelled.
+func RunScheduler(ctx context.Context, s Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			started, ended, err := s.RunDue(ctx)
+			if err != nil {
+				log.Printf("⚠️ price scheduler failed: %v", err)
+			}
+			if started > 0 || ended > 0 {
+				log.Printf("✅ started %d and ended %d price schedules", started, ended)
+			}
+		}
+	}
+}
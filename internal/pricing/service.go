@@ -0,0 +1,386 @@
+package pricing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/vendor"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrScheduleNotFound = errors.New("price schedule not found")
+	ErrScheduleFinished = errors.New("price schedule has already finished")
+	ErrInvalidSchedule  = errors.New("invalid price schedule")
+	ErrScheduleOverlap  = errors.New("another sale on this product overlaps that period")
+)
+
+// startGrace lets a schedule start "now" despite clock skew and request
+// latency.
+const startGrace = time.Minute
+
+type Service interface {
+	CreateSchedule(ctx context.Context, userID, productID primitive.ObjectID, req CreateScheduleRequest) (*ScheduleResponse, error)
+	ListSchedules(ctx context.Context, userID, productID primitive.ObjectID, query ListSchedulesQuery) ([]ScheduleResponse, int64, error)
+	// CancelSchedule stops a pending schedule, or ends a running sale early.
+	CancelSchedule(ctx context.Context, userID, productID, scheduleID primitive.ObjectID) (*ScheduleResponse, error)
+
+	PriceHistory(ctx context.Context, productID primitive.ObjectID, query PriceHistoryQuery) ([]PricePointResponse, error)
+
+	// RecordPrices and LowestPrices implement product.PriceHistory.
+	RecordPrices(ctx context.Context, p *product.Product) error
+	LowestPrices(ctx context.Context, variantIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error)
+
+	// RunDue starts schedules whose time has come and ends sales that are
+	// over, returning how many of each it handled.
+	RunDue(ctx context.Context) (started, ended int, err error)
+}
+
+type service struct {
+	pricingRepo Repository
+	productRepo product.Repository
+	vendorRepo  vendor.Repository
+	indexer     product.Indexer
+	moderator   product.Moderator
+}
+
+func NewService(pricingRepo Repository, productRepo product.Repository, vendorRepo vendor.Repository, indexer product.Indexer, moderator product.Moderator) Service {
+	return &service{
+		pricingRepo: pricingRepo,
+		productRepo: productRepo,
+		vendorRepo:  vendorRepo,
+		indexer:     indexer,
+		moderator:   moderator,
+	}
+}
+
+func (s *service) CreateSchedule(ctx context.Context, userID, productID primitive.ObjectID, req CreateScheduleRequest) (*ScheduleResponse, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !v.CanSell() {
+		return nil, vendor.ErrVendorNotApproved
+	}
+	p, err := s.productRepo.GetForVendor(ctx, v.ID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	schedule := &Schedule{
+		ID:             primitive.NewObjectID(),
+		VendorID:       v.ID,
+		ProductID:      p.ID,
+		Price:          *req.Price,
+		CompareAtPrice: req.CompareAtPrice,
+		StartsAt:       req.StartsAt.UTC(),
+		Status:         ScheduledStatus,
+		CreatedBy:      userID,
+		CreatedAt:      time.Now(),
+	}
+	// The regular price a sale is measured against.
+	regular := p.Price
+	if req.VariantID != "" {
+		id, err := primitive.ObjectIDFromHex(req.VariantID)
+		if err != nil {
+			return nil, product.ErrVariantNotFound
+		}
+		variant := p.Variant(id)
+		if variant == nil {
+			return nil, product.ErrVariantNotFound
+		}
+		schedule.VariantID = &id
+		regular = p.RegularPrice(variant)
+	}
+
+	if schedule.StartsAt.Before(time.Now().Add(-startGrace)) {
+		return nil, fmt.Errorf("%w: starts_at is in the past", ErrInvalidSchedule)
+	}
+	if req.EndsAt != nil {
+		endsAt := req.EndsAt.UTC()
+		if !endsAt.After(schedule.StartsAt) {
+			return nil, fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidSchedule)
+		}
+		if schedule.Price >= regular {
+			return nil, fmt.Errorf("%w: a sale price must be below the regular price of %d", ErrInvalidSchedule, regular)
+		}
+		schedule.EndsAt = &endsAt
+
+		overlaps, err := s.pricingRepo.SaleOverlaps(ctx, p.ID, schedule.VariantID, schedule.StartsAt, endsAt)
+		if err != nil {
+			return nil, err
+		}
+		if overlaps {
+			return nil, ErrScheduleOverlap
+		}
+	} else if req.CompareAtPrice != nil {
+		return nil, fmt.Errorf("%w: compare_at_price only applies to sales", ErrInvalidSchedule)
+	}
+
+	if err := s.pricingRepo.CreateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+	resp := schedule.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) ListSchedules(ctx context.Context, userID, productID primitive.ObjectID, query ListSchedulesQuery) ([]ScheduleResponse, int64, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	schedules, total, err := s.pricingRepo.ListSchedules(ctx, v.ID, productID, query.Status, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]ScheduleResponse, 0, len(schedules))
+	for i := range schedules {
+		resp = append(resp, schedules[i].ToResponse())
+	}
+	return resp, total, nil
+}
+
+func (s *service) CancelSchedule(ctx context.Context, userID, productID, scheduleID primitive.ObjectID) (*ScheduleResponse, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	schedule, err := s.pricingRepo.GetScheduleForVendor(ctx, v.ID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if schedule.ProductID != productID {
+		return nil, ErrScheduleNotFound
+	}
+
+	previous, err := s.pricingRepo.TransitionSchedule(ctx, scheduleID, []ScheduleStatus{ScheduledStatus, ActiveStatus}, CancelledStatus)
+	if err != nil {
+		return nil, err
+	}
+	if previous.Status == ActiveStatus && previous.IsSale() {
+		p, err := s.productRepo.ClearSale(ctx, previous.ProductID, previous.VariantID, previous.ID)
+		if err != nil && !isGone(err) {
+			return nil, err
+		}
+		if p != nil {
+			s.refresh(ctx, p)
+		}
+	}
+
+	now := time.Now()
+	schedule.Status = CancelledStatus
+	schedule.EndedAt = &now
+	resp := schedule.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) PriceHistory(ctx context.Context, productID primitive.ObjectID, query PriceHistoryQuery) ([]PricePointResponse, error) {
+	p, err := s.productRepo.GetPublic(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	var variantID *primitive.ObjectID
+	if query.VariantID != "" {
+		id, err := primitive.ObjectIDFromHex(query.VariantID)
+		if err != nil || p.Variant(id) == nil {
+			return nil, product.ErrVariantNotFound
+		}
+		variantID = &id
+	}
+
+	since := time.Now().AddDate(0, 0, -query.Days)
+	points, err := s.pricingRepo.ListPoints(ctx, p.ID, variantID, since)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]PricePointResponse, 0, len(points))
+	for i := range points {
+		resp = append(resp, points[i].ToResponse())
+	}
+	return resp, nil
+}
+
+func (s *service) RecordPrices(ctx context.Context, p *product.Product) error {
+	ids := make([]primitive.ObjectID, 0, len(p.Variants))
+	for _, v := range p.Variants {
+		ids = append(ids, v.ID)
+	}
+	latest, err := s.pricingRepo.LatestPoints(ctx, ids)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var changed []primitive.ObjectID
+	points := make(map[primitive.ObjectID]*PricePoint)
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		price := p.EffectivePrice(v)
+		if last, ok := latest[v.ID]; ok && last.Price == price && last.Currency == p.Currency {
+			continue
+		}
+		changed = append(changed, v.ID)
+		points[v.ID] = &PricePoint{
+			VendorID:   p.VendorID,
+			ProductID:  p.ID,
+			VariantID:  v.ID,
+			Price:      price,
+			Currency:   p.Currency,
+			RecordedAt: now,
+		}
+	}
+	if len(changed) == 0 {
+		return nil
+	}
+
+	lowest, err := s.pricingRepo.LowestSince(ctx, changed, p.Currency, now.Add(-DisclosureWindow))
+	if err != nil {
+		return err
+	}
+	batch := make([]PricePoint, 0, len(changed))
+	for _, id := range changed {
+		point := points[id]
+		if price, ok := lowest[id]; ok {
+			point.LowestPrior = &price
+		}
+		batch = append(batch, *point)
+	}
+	return s.pricingRepo.InsertPoints(ctx, batch)
+}
+
+func (s *service) LowestPrices(ctx context.Context, variantIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error) {
+	latest, err := s.pricingRepo.LatestPoints(ctx, variantIDs)
+	if err != nil {
+		return nil, err
+	}
+	lowest := make(map[primitive.ObjectID]int64, len(latest))
+	for id, point := range latest {
+		if point.LowestPrior != nil {
+			lowest[id] = *point.LowestPrior
+		}
+	}
+	return lowest, nil
+}
+
+func (s *service) RunDue(ctx context.Context) (int, int, error) {
+	started, ended := 0, 0
+	for {
+		schedule, err := s.pricingRepo.ClaimDue(ctx, time.Now())
+		if err != nil {
+			return started, ended, err
+		}
+		if schedule == nil {
+			break
+		}
+		if err := s.start(ctx, schedule); err != nil {
+			return started, ended, err
+		}
+		started++
+	}
+	for {
+		schedule, err := s.pricingRepo.ClaimEnded(ctx, time.Now())
+		if err != nil {
+			return started, ended, err
+		}
+		if schedule == nil {
+			break
+		}
+		p, err := s.productRepo.ClearSale(ctx, schedule.ProductID, schedule.VariantID, schedule.ID)
+		if err != nil && !isGone(err) {
+			return started, ended, err
+		}
+		if p != nil {
+			s.refresh(ctx, p)
+		}
+		ended++
+	}
+	return started, ended, nil
+}
+
+// start applies a schedule that has just been claimed. A sale that was due
+// to end before the scheduler got to it is closed without being applied.
+func (s *service) start(ctx context.Context, schedule *Schedule) error {
+	var p *product.Product
+	var err error
+	switch {
+	case schedule.IsSale() && !schedule.EndsAt.After(time.Now()):
+		_, err = s.pricingRepo.TransitionSchedule(ctx, schedule.ID, []ScheduleStatus{ActiveStatus}, CompletedStatus)
+		return ignoreFinished(err)
+	case schedule.IsSale():
+		p, err = s.productRepo.SetSale(ctx, schedule.ProductID, schedule.VariantID, &product.Sale{
+			ScheduleID:     schedule.ID,
+			Price:          schedule.Price,
+			CompareAtPrice: schedule.CompareAtPrice,
+			EndsAt:         *schedule.EndsAt,
+		})
+	default:
+		p, err = s.productRepo.SetPrice(ctx, schedule.ProductID, schedule.VariantID, schedule.Price)
+		if err == nil {
+			_, err = s.pricingRepo.TransitionSchedule(ctx, schedule.ID, []ScheduleStatus{ActiveStatus}, CompletedStatus)
+			err = ignoreFinished(err)
+		}
+	}
+
+	if isGone(err) {
+		log.Printf("⚠️ price schedule %s cancelled: %v", schedule.ID.Hex(), err)
+		_, err = s.pricingRepo.TransitionSchedule(ctx, schedule.ID, []ScheduleStatus{ActiveStatus}, CancelledStatus)
+		return ignoreFinished(err)
+	}
+	if err != nil {
+		return err
+	}
+	s.refresh(ctx, s.screen(ctx, p))
+	return nil
+}
+
+// screen runs moderation over a live product whose price a schedule just
+// changed, as product.Service does for a vendor's own edits, and returns the
+// product as saved. Failures are logged and leave p as it was.
+func (s *service) screen(ctx context.Context, p *product.Product) *product.Product {
+	if p.Status != product.ActiveStatus {
+		return p
+	}
+	screened := *p
+	if err := s.moderator.Screen(ctx, &screened); err != nil {
+		log.Printf("⚠️ failed to screen product %s: %v", p.ID.Hex(), err)
+		return p
+	}
+	saved, err := s.productRepo.SetModeration(ctx, p.ID, p.UpdatedAt, *screened.Moderation)
+	if err != nil {
+		// A later edit was screened when it was saved.
+		if !errors.Is(err, product.ErrProductChanged) {
+			log.Printf("⚠️ failed to save moderation of product %s: %v", p.ID.Hex(), err)
+		}
+		return p
+	}
+	s.moderator.Screened(ctx, saved)
+	return saved
+}
+
+// refresh logs the product's new prices and updates its search entry. Like
+// product.Service, failures here are logged; a reindex catches up.
+func (s *service) refresh(ctx context.Context, p *product.Product) {
+	if err := s.RecordPrices(ctx, p); err != nil {
+		log.Printf("⚠️ failed to record prices of product %s: %v", p.ID.Hex(), err)
+	}
+	if err := s.indexer.IndexProduct(ctx, p); err != nil {
+		log.Printf("⚠️ failed to index product %s: %v", p.ID.Hex(), err)
+	}
+}
+
+// isGone reports whether the product or variant a schedule targets no longer
+// exists.
+func isGone(err error) bool {
+	return errors.Is(err, product.ErrProductNotFound) || errors.Is(err, product.ErrVariantNotFound)
+}
+
+func ignoreFinished(err error) error {
+	if errors.Is(err, ErrScheduleFinished) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,157 @@
+package product
+
+import "github.com/techrook/23-market/pkg/money"
+
+type CreateProductRequest struct {
+	Title       string `json:"title" binding:"required,min=2,max=200"`
+	Description string `json:"description" binding:"omitempty,max=10000"`
+	Price       int64  `json:"price" binding:"min=0"`
+	Currency    string `json:"currency" binding:"required,len=3,uppercase"`
+	CategoryID  string `json:"category_id" binding:"omitempty,len=24,hexadecimal"`
+	// Type defaults to physical.
+	Type Type `json:"type" binding:"omitempty,oneof=physical digital"`
+	// Attributes are checked against the category's attribute schema.
+	Attributes map[string]string `json:"attributes" binding:"omitempty,max=50"`
+}
+
+type UpdateProductRequest struct {
+	Title       *string `json:"title,omitempty" binding:"omitempty,min=2,max=200"`
+	Description *string `json:"description,omitempty" binding:"omitempty,max=10000"`
+	Price       *int64  `json:"price,omitempty" binding:"omitempty,min=0"`
+	Currency    *string `json:"currency,omitempty" binding:"omitempty,len=3,uppercase"`
+	Type        *Type   `json:"type,omitempty" binding:"omitempty,oneof=physical digital"`
+	// CompareAtPrice is only shown to buyers while it's above the price.
+	CompareAtPrice      *int64 `json:"compare_at_price,omitempty" binding:"omitempty,min=0"`
+	ClearCompareAtPrice bool   `json:"clear_compare_at_price"`
+	// CategoryID set to "" removes the product from its category.
+	CategoryID *string `json:"category_id,omitempty" binding:"omitempty,max=24"`
+	// Attributes replace all of the product's attributes when given.
+	Attributes map[string]string `json:"attributes,omitempty" binding:"omitempty,max=50"`
+}
+
+type UpdateStatusRequest struct {
+	Status Status `json:"status" binding:"required,oneof=draft active archived"`
+}
+
+type OptionInput struct {
+	Name   string   `json:"name" binding:"required,min=1,max=50"`
+	Values []string `json:"values" binding:"required,min=1,max=20,dive,required,max=50"`
+}
+
+// SetOptionsRequest with no options turns the product back into a single
+// default variant.
+type SetOptionsRequest struct {
+	Options []OptionInput `json:"options" binding:"max=3,dive"`
+}
+
+type UpdateVariantRequest struct {
+	SKU        *string `json:"sku,omitempty" binding:"omitempty,min=1,max=64,printascii"`
+	Price      *int64  `json:"price,omitempty" binding:"omitempty,min=0"`
+	ClearPrice bool    `json:"clear_price"`
+	// CompareAtPrice overrides the product's compare-at price for this variant.
+	CompareAtPrice      *int64  `json:"compare_at_price,omitempty" binding:"omitempty,min=0"`
+	ClearCompareAtPrice bool    `json:"clear_compare_at_price"`
+	WeightGrams         *int64  `json:"weight_grams,omitempty" binding:"omitempty,min=0"`
+	Barcode             *string `json:"barcode,omitempty" binding:"omitempty,max=64,alphanum"`
+}
+
+type ListVendorProductsQuery struct {
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Status   Status `form:"status" binding:"omitempty,oneof=draft active archived"`
+	// Moderation narrows the list to listings in one moderation state.
+	Moderation ModerationState `form:"moderation" binding:"omitempty,oneof=pending approved rejected"`
+}
+
+type ListProductsQuery struct {
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+	VendorID string `form:"vendor_id" binding:"omitempty,len=24,hexadecimal"`
+	// Category is a category slug; products in its subcategories match too.
+	Category string `form:"category" binding:"omitempty,max=100"`
+	// Currency adds display prices converted into it.
+	Currency string `form:"currency" binding:"omitempty,len=3,uppercase"`
+}
+
+type GetProductQuery struct {
+	Currency string `form:"currency" binding:"omitempty,len=3,uppercase"`
+}
+
+type VendorSummaryResponse struct {
+	ID           string `json:"id"`
+	BusinessName string `json:"business_name"`
+	Slug         string `json:"slug"`
+}
+
+type OptionValueResponse struct {
+	Value     string `json:"value"`
+	Available bool   `json:"available"`
+}
+
+type OptionResponse struct {
+	Name   string                `json:"name"`
+	Values []OptionValueResponse `json:"values"`
+}
+
+type VariantResponse struct {
+	ID            string            `json:"id"`
+	SKU           string            `json:"sku"`
+	Options       map[string]string `json:"options"`
+	Price         int64             `json:"price"`
+	PriceOverride *int64            `json:"price_override,omitempty"`
+	// CompareAtPrice is set when the price is a reduction: during a sale it
+	// defaults to the regular price.
+	CompareAtPrice    *int64 `json:"compare_at_price,omitempty"`
+	CompareAtOverride *int64 `json:"compare_at_override,omitempty"`
+	SaleEndsAt        string `json:"sale_ends_at,omitempty"`
+	// LowestPrice30d is the lowest price in the 30 days before the current
+	// price took effect, for price reduction disclosures.
+	LowestPrice30d *int64 `json:"lowest_price_30d,omitempty"`
+	// Display prices are in the currency the buyer asked for; checkout still
+	// charges Price in the product's currency.
+	DisplayPrice          *money.Money `json:"display_price,omitempty"`
+	DisplayCompareAtPrice *money.Money `json:"display_compare_at_price,omitempty"`
+	WeightGrams           int64        `json:"weight_grams"`
+	Barcode               string       `json:"barcode,omitempty"`
+	Stock                 *int64       `json:"stock,omitempty"`
+	Available             bool         `json:"available"`
+}
+
+type ImageResponse struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+}
+
+type ModerationResponse struct {
+	State     string `json:"state"`
+	Reason    string `json:"reason,omitempty"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type ProductResponse struct {
+	ID          string                 `json:"id"`
+	VendorID    string                 `json:"vendor_id"`
+	Vendor      *VendorSummaryResponse `json:"vendor,omitempty"`
+	Title       string                 `json:"title"`
+	Description string                 `json:"description"`
+	Price       int64                  `json:"price"`
+	Currency    string                 `json:"currency"`
+	// CompareAtPrice is the vendor's product-level setting; buyers see the
+	// per-variant value instead.
+	CompareAtPrice *int64            `json:"compare_at_price,omitempty"`
+	DisplayPrice   *money.Money      `json:"display_price,omitempty"`
+	Status         string            `json:"status"`
+	Type           string            `json:"type"`
+	CategoryID     string            `json:"category_id,omitempty"`
+	Attributes     map[string]string `json:"attributes"`
+	Images         []ImageResponse   `json:"images"`
+	Available      bool              `json:"available"`
+	Options        []OptionResponse  `json:"options"`
+	Variants       []VariantResponse `json:"variants"`
+	PublishedAt    string            `json:"published_at,omitempty"`
+	CreatedAt      string            `json:"created_at"`
+	UpdatedAt      string            `json:"updated_at"`
+	// Moderation is only shown to the vendor.
+	Moderation *ModerationResponse `json:"moderation,omitempty"`
+}
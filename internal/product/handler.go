@@ -0,0 +1,425 @@
+package product
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/category"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	productService Service
+}
+
+func NewHandler(productService Service) *Handler {
+	return &Handler{
+		productService: productService,
+	}
+}
+
+func (h *Handler) CreateProduct(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	product, err := h.productService.CreateProduct(c.Request.Context(), userID, req)
+	if err != nil {
+		handleError(c, err, "Failed to create product")
+		return
+	}
+	response.Created(c, product, "Product created successfully")
+}
+
+func (h *Handler) ListVendorProducts(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var query ListVendorProductsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+
+	products, total, err := h.productService.ListVendorProducts(c.Request.Context(), userID, query)
+	if err != nil {
+		handleError(c, err, "Failed to list products")
+		return
+	}
+	response.Paginated(c, products, query.Page, query.PageSize, int(total), "Products retrieved successfully")
+}
+
+func (h *Handler) GetVendorProduct(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := productIDParam(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.productService.GetVendorProduct(c.Request.Context(), userID, productID)
+	if err != nil {
+		handleError(c, err, "Failed to get product")
+		return
+	}
+	response.OK(c, product, "Product retrieved successfully")
+}
+
+func (h *Handler) UpdateProduct(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := productIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	product, err := h.productService.UpdateProduct(c.Request.Context(), userID, productID, req)
+	if err != nil {
+		handleError(c, err, "Failed to update product")
+		return
+	}
+	response.OK(c, product, "Product updated successfully")
+}
+
+func (h *Handler) UpdateStatus(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := productIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	product, err := h.productService.UpdateStatus(c.Request.Context(), userID, productID, req.Status)
+	if err != nil {
+		handleError(c, err, "Failed to update product status")
+		return
+	}
+	response.OK(c, product, "Product status updated successfully")
+}
+
+func (h *Handler) DeleteProduct(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := productIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := h.productService.DeleteProduct(c.Request.Context(), userID, productID); err != nil {
+		handleError(c, err, "Failed to delete product")
+		return
+	}
+	response.OK(c, nil, "Product deleted successfully")
+}
+
+func (h *Handler) SetOptions(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := productIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req SetOptionsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	product, err := h.productService.SetOptions(c.Request.Context(), userID, productID, req)
+	if err != nil {
+		handleError(c, err, "Failed to update product options")
+		return
+	}
+	response.OK(c, product, "Product options updated successfully")
+}
+
+func (h *Handler) UpdateVariant(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := productIDParam(c)
+	if !ok {
+		return
+	}
+	variantID, err := primitive.ObjectIDFromHex(c.Param("variantID"))
+	if err != nil {
+		response.BadRequest(c, "Invalid variant ID", nil, response.IsProduction(c))
+		return
+	}
+
+	var req UpdateVariantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	product, err := h.productService.UpdateVariant(c.Request.Context(), userID, productID, variantID, req)
+	if err != nil {
+		handleError(c, err, "Failed to update variant")
+		return
+	}
+	response.OK(c, product, "Variant updated successfully")
+}
+
+func (h *Handler) AddImage(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := productIDParam(c)
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart envelope around the file itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxImageSize+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			handleError(c, err, "Failed to upload image")
+			return
+		}
+		response.BadRequest(c, "A file is required", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.BadRequest(c, "Could not read uploaded file", nil, response.IsProduction(c))
+		return
+	}
+	defer file.Close()
+
+	product, err := h.productService.AddImage(c.Request.Context(), userID, productID, file)
+	if err != nil {
+		handleError(c, err, "Failed to upload image")
+		return
+	}
+	response.Created(c, product, "Image uploaded successfully")
+}
+
+func (h *Handler) DeleteImage(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := productIDParam(c)
+	if !ok {
+		return
+	}
+	imageID, ok := imageIDParam(c)
+	if !ok {
+		return
+	}
+
+	product, err := h.productService.DeleteImage(c.Request.Context(), userID, productID, imageID)
+	if err != nil {
+		handleError(c, err, "Failed to delete image")
+		return
+	}
+	response.OK(c, product, "Image deleted successfully")
+}
+
+func (h *Handler) GetVendorImage(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := productIDParam(c)
+	if !ok {
+		return
+	}
+	imageID, ok := imageIDParam(c)
+	if !ok {
+		return
+	}
+
+	img, blob, err := h.productService.OpenVendorImage(c.Request.Context(), userID, productID, imageID)
+	if err != nil {
+		handleError(c, err, "Failed to get image")
+		return
+	}
+	defer blob.Close()
+	c.DataFromReader(http.StatusOK, img.Size, img.ContentType, blob, map[string]string{
+		"Cache-Control": "private, max-age=3600",
+	})
+}
+
+func (h *Handler) GetImage(c *gin.Context) {
+	productID, ok := productIDParam(c)
+	if !ok {
+		return
+	}
+	imageID, ok := imageIDParam(c)
+	if !ok {
+		return
+	}
+
+	img, blob, err := h.productService.OpenImage(c.Request.Context(), productID, imageID)
+	if err != nil {
+		handleError(c, err, "Failed to get image")
+		return
+	}
+	defer blob.Close()
+	c.DataFromReader(http.StatusOK, img.Size, img.ContentType, blob, map[string]string{
+		"Cache-Control": "public, max-age=86400",
+	})
+}
+
+func (h *Handler) GetProduct(c *gin.Context) {
+	productID, ok := productIDParam(c)
+	if !ok {
+		return
+	}
+
+	var query GetProductQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	product, err := h.productService.GetProduct(c.Request.Context(), productID, query)
+	if err != nil {
+		handleError(c, err, "Failed to get product")
+		return
+	}
+	response.OK(c, product, "Product retrieved successfully")
+}
+
+func (h *Handler) ListProducts(c *gin.Context) {
+	var query ListProductsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+
+	products, total, err := h.productService.ListProducts(c.Request.Context(), query)
+	if err != nil {
+		handleError(c, err, "Failed to list products")
+		return
+	}
+	response.Paginated(c, products, query.Page, query.PageSize, int(total), "Products retrieved successfully")
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, ErrProductNotFound):
+		response.NotFound(c, "Product", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotFound):
+		response.NotFound(c, "Vendor", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotApproved):
+		response.Forbidden(c, "Only approved vendors can manage products", response.IsProduction(c))
+	case errors.Is(err, ErrVariantNotFound):
+		response.NotFound(c, "Variant", response.IsProduction(c))
+	case errors.Is(err, ErrInvalidVariants):
+		response.BadRequest(c, err.Error(), nil, response.IsProduction(c))
+	case errors.Is(err, ErrUnknownCurrency):
+		response.BadRequest(c, "Unknown currency code", nil, response.IsProduction(c))
+	case errors.Is(err, ErrCurrencyNotShown):
+		response.BadRequest(c, "Prices can't be shown in that currency", nil, response.IsProduction(c))
+	case errors.Is(err, ErrProductChanged):
+		response.Conflict(c, "The product was changed in the meantime; reload it and try again", nil, response.IsProduction(c))
+	case errors.Is(err, ErrSKUTaken):
+		response.Conflict(c, "SKU is already used by another of your variants", nil, response.IsProduction(c))
+	case errors.Is(err, category.ErrCategoryNotFound):
+		response.NotFound(c, "Category", response.IsProduction(c))
+	case errors.Is(err, category.ErrInvalidAttributes):
+		response.BadRequest(c, err.Error(), nil, response.IsProduction(c))
+	case errors.Is(err, category.ErrCategoryNotLeaf):
+		response.BadRequest(c, "Products can only be assigned to categories without subcategories", nil, response.IsProduction(c))
+	case errors.Is(err, ErrProductNotDeletable):
+		response.Conflict(c, "Only draft products can be deleted; archive it instead", nil, response.IsProduction(c))
+	case errors.Is(err, ErrImageNotFound):
+		response.NotFound(c, "Image", response.IsProduction(c))
+	case errors.Is(err, ErrTooManyImages):
+		response.Conflict(c, "A product can have at most 10 images", nil, response.IsProduction(c))
+	case errors.Is(err, ErrUnsupportedFileType):
+		response.BadRequest(c, "Only JPEG, PNG and WebP images are accepted", nil, response.IsProduction(c))
+	case errors.Is(err, ErrFileTooLarge), errors.As(err, &tooLarge):
+		response.Error(c, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", "Image exceeds the 5MB limit", nil, response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func imageIDParam(c *gin.Context) (primitive.ObjectID, bool) {
+	imageID, err := primitive.ObjectIDFromHex(c.Param("imageID"))
+	if err != nil {
+		response.BadRequest(c, "Invalid image ID", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return imageID, true
+}
+
+func productIDParam(c *gin.Context) (primitive.ObjectID, bool) {
+	productID, err := primitive.ObjectIDFromHex(c.Param("productID"))
+	if err != nil {
+		response.BadRequest(c, "Invalid product ID", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return productID, true
+}
+
+func callerID(c *gin.Context) (primitive.ObjectID, bool) {
+	val, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	userID, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
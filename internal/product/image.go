@@ -0,0 +1,75 @@
+package product
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MaxImages    = 10
+	MaxImageSize = 5 << 20 // 5 MB
+)
+
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// Image is a listing photo kept in blob storage. Images are shown in the
+// order they were added.
+type Image struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	BlobKey     string             `json:"-" bson:"blob_key"`
+	ContentType string             `json:"content_type" bson:"content_type"`
+	Size        int64              `json:"size" bson:"size"`
+	CreatedAt   time.Time          `json:"created_at" bson:"created_at"`
+}
+
+func NewImage(productID primitive.ObjectID, contentType string) Image {
+	id := primitive.NewObjectID()
+	return Image{
+		ID:          id,
+		BlobKey:     "products/" + productID.Hex() + "/" + id.Hex(),
+		ContentType: contentType,
+		CreatedAt:   time.Now(),
+	}
+}
+
+func (p *Product) Image(id primitive.ObjectID) *Image {
+	for i := range p.Images {
+		if p.Images[i].ID == id {
+			return &p.Images[i]
+		}
+	}
+	return nil
+}
+
+func (p *Product) removeImage(id primitive.ObjectID) {
+	kept := p.Images[:0]
+	for _, img := range p.Images {
+		if img.ID != id {
+			kept = append(kept, img)
+		}
+	}
+	p.Images = kept
+}
+
+// imageResponses links images through the public route, or through the
+// vendor's own route so they show before the product is listed.
+func (p *Product) imageResponses(public bool) []ImageResponse {
+	prefix := "/vendors/products/"
+	if public {
+		prefix = "/products/"
+	}
+	resp := make([]ImageResponse, 0, len(p.Images))
+	for _, img := range p.Images {
+		resp = append(resp, ImageResponse{
+			ID:          img.ID.Hex(),
+			URL:         prefix + p.ID.Hex() + "/images/" + img.ID.Hex(),
+			ContentType: img.ContentType,
+		})
+	}
+	return resp
+}
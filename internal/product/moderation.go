@@ -0,0 +1,68 @@
+package product
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ModerationState is where a listing stands in moderation. Only approved
+// listings are shown to buyers; products moderated before this existed have
+// no state and count as approved.
+type ModerationState string
+
+const (
+	PendingModeration  ModerationState = "pending"
+	ApprovedModeration ModerationState = "approved"
+	RejectedModeration ModerationState = "rejected"
+)
+
+// Moderation is the outcome of the latest check of a listing, by the
+// automatic rules or by an admin.
+type Moderation struct {
+	State  ModerationState `json:"state" bson:"state"`
+	Reason string          `json:"reason,omitempty" bson:"reason,omitempty"`
+	// ReviewedBy is the admin who decided; empty for automatic decisions.
+	ReviewedBy *primitive.ObjectID `json:"reviewed_by,omitempty" bson:"reviewed_by,omitempty"`
+	UpdatedAt  time.Time           `json:"updated_at" bson:"updated_at"`
+}
+
+// Moderator screens listings when they go live or change while live. The
+// moderation package implements it.
+type Moderator interface {
+	// Screen runs the automatic rules and sets p.Moderation without saving.
+	Screen(ctx context.Context, p *Product) error
+	// Screened is told once a screened product has been saved.
+	Screened(ctx context.Context, p *Product)
+}
+
+func (p *Product) ModerationState() ModerationState {
+	if p.Moderation == nil {
+		return ApprovedModeration
+	}
+	return p.Moderation.State
+}
+
+// IsListed reports whether buyers can see and buy the product.
+func (p *Product) IsListed() bool {
+	return p.Status == ActiveStatus && p.ModerationState() == ApprovedModeration
+}
+
+// notHeldBack matches products whose moderation doesn't keep them from
+// buyers, including those with no moderation state at all.
+func notHeldBack() bson.M {
+	return bson.M{"$nin": bson.A{PendingModeration, RejectedModeration}}
+}
+
+func (m *Moderation) ToResponse() *ModerationResponse {
+	if m == nil {
+		return nil
+	}
+	return &ModerationResponse{
+		State:     string(m.State),
+		Reason:    m.Reason,
+		UpdatedAt: m.UpdatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,206 @@
+package product
+
+import (
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Status string
+
+const (
+	DraftStatus    Status = "draft"
+	ActiveStatus   Status = "active"
+	ArchivedStatus Status = "archived"
+)
+
+// Type is what a buyer receives: physical goods are shipped, digital ones
+// are files the buyer downloads once the order is paid.
+type Type string
+
+const (
+	PhysicalType Type = "physical"
+	DigitalType  Type = "digital"
+)
+
+// Product prices are in minor units of Currency.
+type Product struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	VendorID    primitive.ObjectID `json:"vendor_id" bson:"vendor_id"`
+	Title       string             `json:"title" bson:"title"`
+	Description string             `json:"description" bson:"description"`
+	Price       int64              `json:"price" bson:"price"`
+	Currency    string             `json:"currency" bson:"currency"`
+	// CompareAtPrice is the vendor's "was" price shown against a lower price.
+	CompareAtPrice *int64 `json:"compare_at_price,omitempty" bson:"compare_at_price,omitempty"`
+	// Sale applies to every variant without a sale of its own.
+	Sale     *Sale     `json:"sale,omitempty" bson:"sale,omitempty"`
+	Status   Status    `json:"status" bson:"status"`
+	Type     Type      `json:"type,omitempty" bson:"type,omitempty"`
+	Options  []Option  `json:"options" bson:"options"`
+	Variants []Variant `json:"variants" bson:"variants"`
+	// CategoryPath holds the IDs from the root category down to CategoryID,
+	// which is always a leaf.
+	CategoryID   *primitive.ObjectID  `json:"category_id,omitempty" bson:"category_id,omitempty"`
+	CategoryPath []primitive.ObjectID `json:"category_path,omitempty" bson:"category_path,omitempty"`
+	PublishedAt  *time.Time           `json:"published_at,omitempty" bson:"published_at,omitempty"`
+	CreatedAt    time.Time            `json:"created_at" bson:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at" bson:"updated_at"`
+	// Attributes follow the attribute schema of the product's category.
+	Attributes map[string]string `json:"attributes,omitempty" bson:"attributes,omitempty"`
+	Images     []Image           `json:"images,omitempty" bson:"images,omitempty"`
+	Moderation *Moderation       `json:"moderation,omitempty" bson:"moderation,omitempty"`
+}
+
+// VendorSummary is the part of the owning vendor shown with public products.
+type VendorSummary struct {
+	ID           primitive.ObjectID `bson:"_id"`
+	BusinessName string             `bson:"business_name"`
+	Slug         string             `bson:"slug"`
+}
+
+// PublicProduct is a listed product joined with its vendor.
+type PublicProduct struct {
+	Product `bson:",inline"`
+	Vendor  VendorSummary `bson:"vendor"`
+}
+
+func NewProduct(vendorID primitive.ObjectID, req CreateProductRequest) *Product {
+	if req.Type == "" {
+		req.Type = PhysicalType
+	}
+	now := time.Now()
+	id := primitive.NewObjectID()
+	return &Product{
+		ID:          id,
+		VendorID:    vendorID,
+		Title:       strings.TrimSpace(req.Title),
+		Description: strings.TrimSpace(req.Description),
+		Price:       req.Price,
+		Currency:    req.Currency,
+		Status:      DraftStatus,
+		Type:        req.Type,
+		Options:     []Option{},
+		Variants:    []Variant{NewDefaultVariant(id)},
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+}
+
+func (p *Product) ApplyUpdate(req UpdateProductRequest) {
+	if req.Title != nil {
+		p.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Description != nil {
+		p.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Price != nil {
+		p.Price = *req.Price
+	}
+	if req.Currency != nil {
+		p.Currency = *req.Currency
+	}
+	if req.Type != nil {
+		p.Type = *req.Type
+	}
+	if req.ClearCompareAtPrice {
+		p.CompareAtPrice = nil
+	} else if req.CompareAtPrice != nil {
+		compareAt := *req.CompareAtPrice
+		p.CompareAtPrice = &compareAt
+	}
+	p.UpdatedAt = time.Now()
+}
+
+// ProductType treats products created before digital products existed, which
+// have no type stored, as physical.
+func (p *Product) ProductType() Type {
+	if p.Type == "" {
+		return PhysicalType
+	}
+	return p.Type
+}
+
+func (p *Product) IsDigital() bool {
+	return p.Type == DigitalType
+}
+
+// SetCategory assigns the product to the last category in path, or clears
+// the assignment when path is empty.
+func (p *Product) SetCategory(path []primitive.ObjectID) {
+	if len(path) == 0 {
+		p.CategoryID = nil
+		p.CategoryPath = nil
+		return
+	}
+	leaf := path[len(path)-1]
+	p.CategoryID = &leaf
+	p.CategoryPath = path
+}
+
+// SetStatus records the first time a product goes live.
+func (p *Product) SetStatus(status Status) {
+	if status == ActiveStatus && p.PublishedAt == nil {
+		now := time.Now()
+		p.PublishedAt = &now
+	}
+	p.Status = status
+	p.UpdatedAt = time.Now()
+}
+
+func (p *Product) ToResponse(stock Stock) ProductResponse {
+	return p.toResponse(false, stock)
+}
+
+// toResponse builds the product view; the public one hides stock counts and
+// raw price overrides.
+func (p *Product) toResponse(public bool, stock Stock) ProductResponse {
+	variants := p.variantResponses(public, stock)
+	available := false
+	for _, v := range variants {
+		available = available || v.Available
+	}
+
+	resp := ProductResponse{
+		ID:          p.ID.Hex(),
+		VendorID:    p.VendorID.Hex(),
+		Title:       p.Title,
+		Description: p.Description,
+		Price:       p.Price,
+		Currency:    p.Currency,
+		Status:      string(p.Status),
+		Type:        string(p.ProductType()),
+		Available:   available,
+		Options:     p.optionResponses(stock),
+		Variants:    variants,
+		CreatedAt:   p.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:   p.UpdatedAt.Format(time.RFC3339),
+	}
+	if p.PublishedAt != nil {
+		resp.PublishedAt = p.PublishedAt.Format(time.RFC3339)
+	}
+	if p.CategoryID != nil {
+		resp.CategoryID = p.CategoryID.Hex()
+	}
+	resp.Attributes = p.Attributes
+	if resp.Attributes == nil {
+		resp.Attributes = map[string]string{}
+	}
+	resp.Images = p.imageResponses(public)
+	if !public {
+		resp.CompareAtPrice = p.CompareAtPrice
+		resp.Moderation = p.Moderation.ToResponse()
+	}
+	return resp
+}
+
+func (p *PublicProduct) ToResponse(stock Stock) ProductResponse {
+	resp := p.Product.toResponse(true, stock)
+	resp.Vendor = &VendorSummaryResponse{
+		ID:           p.Vendor.ID.Hex(),
+		BusinessName: p.Vendor.BusinessName,
+		Slug:         p.Vendor.Slug,
+	}
+	return resp
+}
@@ -0,0 +1,430 @@
+package product
+
+import (
+	"context"
+	"time"
+
+	"github.com/techrook/23-market/internal/vendor"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	Create(ctx context.Context, p *Product) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*Product, error)
+	GetForVendor(ctx context.Context, vendorID, id primitive.ObjectID) (*Product, error)
+	ListByVendor(ctx context.Context, vendorID primitive.ObjectID, status Status, moderation ModerationState, page, pageSize int) ([]Product, int64, error)
+	// Update replaces the product unless it was changed after updatedAt, the
+	// UpdatedAt it was read with, in which case it returns ErrProductChanged.
+	Update(ctx context.Context, p *Product, updatedAt time.Time) error
+	DeleteDraft(ctx context.Context, vendorID, id primitive.ObjectID) error
+	// GetBySKU matches the vendor's SKUs case-insensitively.
+	GetBySKU(ctx context.Context, vendorID primitive.ObjectID, sku string) (*Product, error)
+	GetByVariantID(ctx context.Context, variantID primitive.ObjectID) (*Product, error)
+	// ListAfter walks every product in ID order, for batch jobs.
+	ListAfter(ctx context.Context, after primitive.ObjectID, limit int) ([]Product, error)
+	// ListByVendorAfter does the same for one vendor's products.
+	ListByVendorAfter(ctx context.Context, vendorID, after primitive.ObjectID, limit int) ([]Product, error)
+
+	// GetPublic and ListPublic only return listed products whose vendor
+	// storefront is visible.
+	GetPublic(ctx context.Context, id primitive.ObjectID) (*PublicProduct, error)
+	// A categoryID matches products anywhere in that category's subtree.
+	ListPublic(ctx context.Context, vendorID, categoryID *primitive.ObjectID, page, pageSize int) ([]PublicProduct, int64, error)
+	// ListPublicByIDs returns the public products among ids, in no
+	// particular order.
+	ListPublicByIDs(ctx context.Context, ids []primitive.ObjectID) ([]PublicProduct, error)
+
+	// SetPrice, SetSale and ClearSale change one price field in place for the
+	// pricing scheduler, leaving concurrent edits to other fields alone. A nil
+	// variantID targets the product itself.
+	SetPrice(ctx context.Context, productID primitive.ObjectID, variantID *primitive.ObjectID, price int64) (*Product, error)
+	SetSale(ctx context.Context, productID primitive.ObjectID, variantID *primitive.ObjectID, sale *Sale) (*Product, error)
+	// ClearSale only removes the sale if scheduleID put it there.
+	ClearSale(ctx context.Context, productID primitive.ObjectID, variantID *primitive.ObjectID, scheduleID primitive.ObjectID) (*Product, error)
+
+	// ListForModeration is the admin review queue: non-draft products in the
+	// given moderation state, longest waiting first.
+	ListForModeration(ctx context.Context, state ModerationState, page, pageSize int) ([]Product, int64, error)
+	// SetModeration records a moderation decision, unless the product was
+	// edited after updatedAt, in which case it returns ErrProductChanged.
+	SetModeration(ctx context.Context, id primitive.ObjectID, updatedAt time.Time, m Moderation) (*Product, error)
+
+	// These keep products in step with the category tree; see category.Products.
+	CountByCategory(ctx context.Context) (map[primitive.ObjectID]int64, error)
+	CategoryInUse(ctx context.Context, categoryID primitive.ObjectID) (bool, error)
+	RebaseCategoryPath(ctx context.Context, categoryID primitive.ObjectID, path []primitive.ObjectID) error
+}
+
+type ProductRepository struct {
+	collection *mongo.Collection
+	vendors    string
+}
+
+func NewProductRepository(db *mongo.Database) Repository {
+	return &ProductRepository{
+		collection: db.Collection("products"),
+		vendors:    "vendors",
+	}
+}
+
+func (r *ProductRepository) Create(ctx context.Context, p *Product) error {
+	if _, err := r.collection.InsertOne(ctx, p); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrSKUTaken
+		}
+		return err
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*Product, error) {
+	var p Product
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&p)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrProductNotFound
+	}
+	return &p, err
+}
+
+func (r *ProductRepository) GetForVendor(ctx context.Context, vendorID, id primitive.ObjectID) (*Product, error) {
+	var p Product
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "vendor_id": vendorID}).Decode(&p)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrProductNotFound
+	}
+	return &p, err
+}
+
+func (r *ProductRepository) ListByVendor(ctx context.Context, vendorID primitive.ObjectID, status Status, moderation ModerationState, page, pageSize int) ([]Product, int64, error) {
+	filter := bson.M{"vendor_id": vendorID}
+	if status != "" {
+		filter["status"] = status
+	}
+	switch moderation {
+	case "":
+	case ApprovedModeration:
+		filter["moderation.state"] = notHeldBack()
+	default:
+		filter["moderation.state"] = moderation
+	}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "updated_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	products := []Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+func (r *ProductRepository) Update(ctx context.Context, p *Product, updatedAt time.Time) error {
+	res, err := r.collection.ReplaceOne(ctx, bson.M{"_id": p.ID, "vendor_id": p.VendorID, "updated_at": updatedAt}, p)
+	if err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrSKUTaken
+		}
+		return err
+	}
+	if res.MatchedCount == 0 {
+		if _, err := r.GetForVendor(ctx, p.VendorID, p.ID); err != nil {
+			return err
+		}
+		return ErrProductChanged
+	}
+	return nil
+}
+
+func (r *ProductRepository) ListForModeration(ctx context.Context, state ModerationState, page, pageSize int) ([]Product, int64, error) {
+	filter := bson.M{"moderation.state": state, "status": bson.M{"$ne": DraftStatus}}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "moderation.updated_at", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	products := []Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, 0, err
+	}
+	return products, total, nil
+}
+
+func (r *ProductRepository) SetModeration(ctx context.Context, id primitive.ObjectID, updatedAt time.Time, m Moderation) (*Product, error) {
+	var p Product
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx,
+		bson.M{"_id": id, "updated_at": updatedAt},
+		bson.M{"$set": bson.M{"moderation": m}},
+		opts,
+	).Decode(&p)
+	if err == mongo.ErrNoDocuments {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrProductChanged
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+func (r *ProductRepository) DeleteDraft(ctx context.Context, vendorID, id primitive.ObjectID) error {
+	res, err := r.collection.DeleteOne(ctx, bson.M{"_id": id, "vendor_id": vendorID, "status": DraftStatus})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		if _, err := r.GetForVendor(ctx, vendorID, id); err != nil {
+			return err
+		}
+		return ErrProductNotDeletable
+	}
+	return nil
+}
+
+func (r *ProductRepository) GetBySKU(ctx context.Context, vendorID primitive.ObjectID, sku string) (*Product, error) {
+	var p Product
+	opts := options.FindOne().SetCollation(&options.Collation{Locale: "en", Strength: 2})
+	err := r.collection.FindOne(ctx, bson.M{"vendor_id": vendorID, "variants.sku": sku}, opts).Decode(&p)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrVariantNotFound
+	}
+	return &p, err
+}
+
+func (r *ProductRepository) GetByVariantID(ctx context.Context, variantID primitive.ObjectID) (*Product, error) {
+	var p Product
+	err := r.collection.FindOne(ctx, bson.M{"variants._id": variantID}).Decode(&p)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrVariantNotFound
+	}
+	return &p, err
+}
+
+func (r *ProductRepository) ListAfter(ctx context.Context, after primitive.ObjectID, limit int) ([]Product, error) {
+	return r.listAfter(ctx, bson.M{"_id": bson.M{"$gt": after}}, limit)
+}
+
+func (r *ProductRepository) ListByVendorAfter(ctx context.Context, vendorID, after primitive.ObjectID, limit int) ([]Product, error) {
+	return r.listAfter(ctx, bson.M{"vendor_id": vendorID, "_id": bson.M{"$gt": after}}, limit)
+}
+
+func (r *ProductRepository) listAfter(ctx context.Context, filter bson.M, limit int) ([]Product, error) {
+	opts := options.Find().
+		SetSort(bson.D{{Key: "_id", Value: 1}}).
+		SetLimit(int64(limit))
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, err
+	}
+	products := []Product{}
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *ProductRepository) SetPrice(ctx context.Context, productID primitive.ObjectID, variantID *primitive.ObjectID, price int64) (*Product, error) {
+	filter, field := bson.M{"_id": productID}, "price"
+	if variantID != nil {
+		filter["variants._id"] = *variantID
+		field = "variants.$.price"
+	}
+	return r.setField(ctx, productID, filter, bson.M{"$set": bson.M{field: price, "updated_at": time.Now()}})
+}
+
+func (r *ProductRepository) SetSale(ctx context.Context, productID primitive.ObjectID, variantID *primitive.ObjectID, sale *Sale) (*Product, error) {
+	filter, field := bson.M{"_id": productID}, "sale"
+	if variantID != nil {
+		filter["variants._id"] = *variantID
+		field = "variants.$.sale"
+	}
+	return r.setField(ctx, productID, filter, bson.M{"$set": bson.M{field: sale, "updated_at": time.Now()}})
+}
+
+func (r *ProductRepository) ClearSale(ctx context.Context, productID primitive.ObjectID, variantID *primitive.ObjectID, scheduleID primitive.ObjectID) (*Product, error) {
+	filter, field := bson.M{"_id": productID, "sale.schedule_id": scheduleID}, "sale"
+	if variantID != nil {
+		filter = bson.M{"_id": productID, "variants": bson.M{"$elemMatch": bson.M{"_id": *variantID, "sale.schedule_id": scheduleID}}}
+		field = "variants.$.sale"
+	}
+	p, err := r.setField(ctx, productID, filter, bson.M{"$unset": bson.M{field: ""}, "$set": bson.M{"updated_at": time.Now()}})
+	if err == ErrVariantNotFound {
+		// Some other sale, or none, is in place; nothing of ours to clear.
+		return r.GetByID(ctx, productID)
+	}
+	return p, err
+}
+
+// setField applies update to the product matched by filter and returns the
+// result. A miss is ErrProductNotFound if the product is gone and
+// ErrVariantNotFound otherwise.
+func (r *ProductRepository) setField(ctx context.Context, productID primitive.ObjectID, filter, update bson.M) (*Product, error) {
+	var p Product
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&p)
+	if err == mongo.ErrNoDocuments {
+		if _, err := r.GetByID(ctx, productID); err != nil {
+			return nil, err
+		}
+		return nil, ErrVariantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// publicPipeline joins each listed product with its vendor and drops those
+// whose storefront isn't visible.
+func (r *ProductRepository) publicPipeline(match bson.M) mongo.Pipeline {
+	match["status"] = ActiveStatus
+	match["moderation.state"] = notHeldBack()
+	return mongo.Pipeline{
+		{{Key: "$match", Value: match}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         r.vendors,
+			"localField":   "vendor_id",
+			"foreignField": "_id",
+			"pipeline": bson.A{
+				bson.M{"$match": vendor.VisibleStorefrontFilter(time.Now())},
+				bson.M{"$project": bson.M{"business_name": 1, "slug": 1}},
+			},
+			"as": "vendor",
+		}}},
+		{{Key: "$unwind", Value: "$vendor"}},
+	}
+}
+
+func (r *ProductRepository) GetPublic(ctx context.Context, id primitive.ObjectID) (*PublicProduct, error) {
+	cursor, err := r.collection.Aggregate(ctx, r.publicPipeline(bson.M{"_id": id}))
+	if err != nil {
+		return nil, err
+	}
+	var products []PublicProduct
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	if len(products) == 0 {
+		return nil, ErrProductNotFound
+	}
+	return &products[0], nil
+}
+
+func (r *ProductRepository) ListPublic(ctx context.Context, vendorID, categoryID *primitive.ObjectID, page, pageSize int) ([]PublicProduct, int64, error) {
+	match := bson.M{}
+	if vendorID != nil {
+		match["vendor_id"] = *vendorID
+	}
+	if categoryID != nil {
+		match["category_path"] = *categoryID
+	}
+
+	pipeline := append(r.publicPipeline(match), bson.D{{Key: "$facet", Value: bson.M{
+		"total": bson.A{bson.M{"$count": "count"}},
+		"items": bson.A{
+			bson.M{"$sort": bson.D{{Key: "published_at", Value: -1}, {Key: "_id", Value: -1}}},
+			bson.M{"$skip": (page - 1) * pageSize},
+			bson.M{"$limit": pageSize},
+		},
+	}}})
+
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, 0, err
+	}
+	var result []struct {
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+		Items []PublicProduct `bson:"items"`
+	}
+	if err := cursor.All(ctx, &result); err != nil {
+		return nil, 0, err
+	}
+	if len(result) == 0 || len(result[0].Total) == 0 {
+		return []PublicProduct{}, 0, nil
+	}
+	return result[0].Items, result[0].Total[0].Count, nil
+}
+
+func (r *ProductRepository) ListPublicByIDs(ctx context.Context, ids []primitive.ObjectID) ([]PublicProduct, error) {
+	if len(ids) == 0 {
+		return []PublicProduct{}, nil
+	}
+	cursor, err := r.collection.Aggregate(ctx, r.publicPipeline(bson.M{"_id": bson.M{"$in": ids}}))
+	if err != nil {
+		return nil, err
+	}
+	var products []PublicProduct
+	if err := cursor.All(ctx, &products); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+func (r *ProductRepository) CountByCategory(ctx context.Context) (map[primitive.ObjectID]int64, error) {
+	pipeline := append(r.publicPipeline(bson.M{"category_id": bson.M{"$exists": true}}),
+		bson.D{{Key: "$group", Value: bson.M{"_id": "$category_id", "count": bson.M{"$sum": 1}}}},
+	)
+	cursor, err := r.collection.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		ID    primitive.ObjectID `bson:"_id"`
+		Count int64              `bson:"count"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	counts := make(map[primitive.ObjectID]int64, len(rows))
+	for _, row := range rows {
+		counts[row.ID] = row.Count
+	}
+	return counts, nil
+}
+
+func (r *ProductRepository) CategoryInUse(ctx context.Context, categoryID primitive.ObjectID) (bool, error) {
+	n, err := r.collection.CountDocuments(ctx, bson.M{"category_id": categoryID}, options.Count().SetLimit(1))
+	return n > 0, err
+}
+
+func (r *ProductRepository) RebaseCategoryPath(ctx context.Context, categoryID primitive.ObjectID, path []primitive.ObjectID) error {
+	// Keep the part of the path below categoryID and swap in the new prefix.
+	below := bson.M{"$slice": bson.A{
+		"$category_path",
+		bson.M{"$add": bson.A{bson.M{"$indexOfArray": bson.A{"$category_path", categoryID}}, 1}},
+		bson.M{"$max": bson.A{bson.M{"$size": "$category_path"}, 1}},
+	}}
+	update := mongo.Pipeline{
+		{{Key: "$set", Value: bson.M{"category_path": bson.M{"$concatArrays": bson.A{path, below}}}}},
+	}
+	_, err := r.collection.UpdateMany(ctx, bson.M{"category_path": categoryID}, update)
+	return err
+}
@@ -0,0 +1,56 @@
+package product
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Sale is a temporary price put in place by a running price schedule. The
+// pricing scheduler sets and clears it; it stops applying at EndsAt even if
+// the scheduler hasn't caught up yet.
+type Sale struct {
+	ScheduleID primitive.ObjectID `json:"schedule_id" bson:"schedule_id"`
+	Price      int64              `json:"price" bson:"price"`
+	// CompareAtPrice replaces the regular price as the "was" price.
+	CompareAtPrice *int64    `json:"compare_at_price,omitempty" bson:"compare_at_price,omitempty"`
+	EndsAt         time.Time `json:"ends_at" bson:"ends_at"`
+}
+
+func (s *Sale) activeAt(t time.Time) bool {
+	return s != nil && t.Before(s.EndsAt)
+}
+
+// activeSale returns the sale setting the variant's price at t, if any.
+func (p *Product) activeSale(v *Variant, t time.Time) *Sale {
+	if v.Sale.activeAt(t) {
+		return v.Sale
+	}
+	if p.Sale.activeAt(t) {
+		return p.Sale
+	}
+	return nil
+}
+
+// ReferencePrice is the compare-at price shown struck through next to the
+// variant's price, or nil when the price isn't a reduction.
+func (p *Product) ReferencePrice(v *Variant) *int64 {
+	var compareAt *int64
+	if sale := p.activeSale(v, time.Now()); sale != nil {
+		regular := p.RegularPrice(v)
+		compareAt = &regular
+		if sale.CompareAtPrice != nil {
+			compareAt = sale.CompareAtPrice
+		}
+	} else if v.CompareAtPrice != nil {
+		compareAt = v.CompareAtPrice
+	} else {
+		compareAt = p.CompareAtPrice
+	}
+
+	if compareAt == nil || *compareAt <= p.EffectivePrice(v) {
+		return nil
+	}
+	price := *compareAt
+	return &price
+}
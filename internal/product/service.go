@@ -0,0 +1,647 @@
+package product
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/techrook/23-market/internal/category"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/money"
+	"github.com/techrook/23-market/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrProductNotFound     = errors.New("product not found")
+	ErrProductNotDeletable = errors.New("only draft products can be deleted")
+	ErrVariantNotFound     = errors.New("variant not found")
+	ErrInvalidVariants     = errors.New("invalid product options")
+	ErrSKUTaken            = errors.New("sku already used by another variant")
+	ErrUnknownCurrency     = errors.New("unknown currency code")
+	ErrCurrencyNotShown    = errors.New("prices can't be shown in that currency")
+	ErrProductChanged      = errors.New("product was changed in the meantime")
+	ErrImageNotFound       = errors.New("image not found")
+	ErrTooManyImages       = errors.New("product already has the maximum number of images")
+	ErrUnsupportedFileType = errors.New("images must be JPEG, PNG or WebP")
+	ErrFileTooLarge        = errors.New("image exceeds the 5MB limit")
+)
+
+// Indexer keeps the search index in step with product changes.
+type Indexer interface {
+	IndexProduct(ctx context.Context, p *Product) error
+}
+
+// PriceHistory logs price changes for lowest-price disclosures. The pricing
+// package implements it.
+type PriceHistory interface {
+	// RecordPrices logs every variant whose effective price changed since it
+	// was last recorded.
+	RecordPrices(ctx context.Context, p *Product) error
+	// LowestPrices returns, per variant, the lowest price in the 30 days
+	// before its current price took effect.
+	LowestPrices(ctx context.Context, variantIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error)
+}
+
+// Converter shows prices in a buyer's currency. Orders still settle in the
+// product's own currency; converted prices are only for display. The
+// exchange package implements it.
+type Converter interface {
+	Supports(currency string) bool
+	Convert(m money.Money, to string) (money.Money, error)
+}
+
+type Service interface {
+	CreateProduct(ctx context.Context, userID primitive.ObjectID, req CreateProductRequest) (*ProductResponse, error)
+	GetVendorProduct(ctx context.Context, userID, productID primitive.ObjectID) (*ProductResponse, error)
+	ListVendorProducts(ctx context.Context, userID primitive.ObjectID, query ListVendorProductsQuery) ([]ProductResponse, int64, error)
+	UpdateProduct(ctx context.Context, userID, productID primitive.ObjectID, req UpdateProductRequest) (*ProductResponse, error)
+	UpdateStatus(ctx context.Context, userID, productID primitive.ObjectID, status Status) (*ProductResponse, error)
+	DeleteProduct(ctx context.Context, userID, productID primitive.ObjectID) error
+	SetOptions(ctx context.Context, userID, productID primitive.ObjectID, req SetOptionsRequest) (*ProductResponse, error)
+	UpdateVariant(ctx context.Context, userID, productID, variantID primitive.ObjectID, req UpdateVariantRequest) (*ProductResponse, error)
+	AddImage(ctx context.Context, userID, productID primitive.ObjectID, file io.Reader) (*ProductResponse, error)
+	DeleteImage(ctx context.Context, userID, productID, imageID primitive.ObjectID) (*ProductResponse, error)
+	// OpenVendorImage streams an image of one of the vendor's own products,
+	// listed or not. The caller must close the reader.
+	OpenVendorImage(ctx context.Context, userID, productID, imageID primitive.ObjectID) (*Image, io.ReadCloser, error)
+
+	GetProduct(ctx context.Context, productID primitive.ObjectID, query GetProductQuery) (*ProductResponse, error)
+	ListProducts(ctx context.Context, query ListProductsQuery) ([]ProductResponse, int64, error)
+	// ListProductsByID returns the public products among ids in the order
+	// given, leaving out any that are inactive or whose vendor is hidden.
+	ListProductsByID(ctx context.Context, ids []primitive.ObjectID, currency string) ([]ProductResponse, error)
+	// OpenImage streams an image of a listed product. The caller must close
+	// the reader.
+	OpenImage(ctx context.Context, productID, imageID primitive.ObjectID) (*Image, io.ReadCloser, error)
+}
+
+type service struct {
+	productRepo Repository
+	vendorRepo  vendor.Repository
+	stock       StockReader
+	categories  category.Service
+	indexer     Indexer
+	prices      PriceHistory
+	converter   Converter
+	moderator   Moderator
+	blobs       storage.BlobStore
+}
+
+func NewService(productRepo Repository, vendorRepo vendor.Repository, stock StockReader, categories category.Service, indexer Indexer, prices PriceHistory, converter Converter, moderator Moderator, blobs storage.BlobStore) Service {
+	return &service{
+		productRepo: productRepo,
+		vendorRepo:  vendorRepo,
+		stock:       stock,
+		categories:  categories,
+		indexer:     indexer,
+		prices:      prices,
+		converter:   converter,
+		moderator:   moderator,
+		blobs:       blobs,
+	}
+}
+
+func (s *service) CreateProduct(ctx context.Context, userID primitive.ObjectID, req CreateProductRequest) (*ProductResponse, error) {
+	v, err := s.sellingVendor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !money.IsCurrency(req.Currency) {
+		return nil, ErrUnknownCurrency
+	}
+	p := NewProduct(v.ID, req)
+	if err := s.assignCategory(ctx, p, req.CategoryID); err != nil {
+		return nil, err
+	}
+	if err := s.setAttributes(ctx, p, req.Attributes); err != nil {
+		return nil, err
+	}
+	if err := s.productRepo.Create(ctx, p); err != nil {
+		return nil, err
+	}
+	if err := s.prices.RecordPrices(ctx, p); err != nil {
+		log.Printf("⚠️ failed to record prices of product %s: %v", p.ID.Hex(), err)
+	}
+	return s.respond(ctx, p)
+}
+
+func (s *service) GetVendorProduct(ctx context.Context, userID, productID primitive.ObjectID) (*ProductResponse, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.productRepo.GetForVendor(ctx, v.ID, productID)
+	if err != nil {
+		return nil, err
+	}
+	return s.respond(ctx, p)
+}
+
+func (s *service) ListVendorProducts(ctx context.Context, userID primitive.ObjectID, query ListVendorProductsQuery) ([]ProductResponse, int64, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	products, total, err := s.productRepo.ListByVendor(ctx, v.ID, query.Status, query.Moderation, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var ids []primitive.ObjectID
+	for i := range products {
+		ids = append(ids, products[i].variantIDs()...)
+	}
+	stock, err := s.stock.Available(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := make([]ProductResponse, 0, len(products))
+	for i := range products {
+		resp = append(resp, products[i].ToResponse(stock))
+	}
+	return resp, total, nil
+}
+
+func (s *service) UpdateProduct(ctx context.Context, userID, productID primitive.ObjectID, req UpdateProductRequest) (*ProductResponse, error) {
+	v, err := s.sellingVendor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.productRepo.GetForVendor(ctx, v.ID, productID)
+	if err != nil {
+		return nil, err
+	}
+	readAt := p.UpdatedAt
+
+	if req.Currency != nil && !money.IsCurrency(*req.Currency) {
+		return nil, ErrUnknownCurrency
+	}
+	p.ApplyUpdate(req)
+	if req.CategoryID != nil {
+		if err := s.assignCategory(ctx, p, *req.CategoryID); err != nil {
+			return nil, err
+		}
+	}
+	// Recheck attributes whenever they or the schema they follow change.
+	if req.Attributes != nil || req.CategoryID != nil {
+		attributes := p.Attributes
+		if req.Attributes != nil {
+			attributes = req.Attributes
+		}
+		if err := s.setAttributes(ctx, p, attributes); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.saveListing(ctx, p, readAt); err != nil {
+		return nil, err
+	}
+	return s.respond(ctx, p)
+}
+
+func (s *service) UpdateStatus(ctx context.Context, userID, productID primitive.ObjectID, status Status) (*ProductResponse, error) {
+	v, err := s.sellingVendor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.productRepo.GetForVendor(ctx, v.ID, productID)
+	if err != nil {
+		return nil, err
+	}
+	readAt := p.UpdatedAt
+
+	// Going live is when a listing is first screened; changes to a live
+	// listing are screened again as they're made.
+	save := s.save
+	if status == ActiveStatus && p.Status != ActiveStatus {
+		save = s.saveListing
+	}
+	p.SetStatus(status)
+	if err := save(ctx, p, readAt); err != nil {
+		return nil, err
+	}
+	return s.respond(ctx, p)
+}
+
+func (s *service) DeleteProduct(ctx context.Context, userID, productID primitive.ObjectID) error {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return s.productRepo.DeleteDraft(ctx, v.ID, productID)
+}
+
+func (s *service) SetOptions(ctx context.Context, userID, productID primitive.ObjectID, req SetOptionsRequest) (*ProductResponse, error) {
+	v, err := s.sellingVendor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.productRepo.GetForVendor(ctx, v.ID, productID)
+	if err != nil {
+		return nil, err
+	}
+	readAt := p.UpdatedAt
+
+	options := make([]Option, 0, len(req.Options))
+	for _, o := range req.Options {
+		options = append(options, Option{Name: o.Name, Values: o.Values})
+	}
+	if err := p.SetOptions(options); err != nil {
+		return nil, err
+	}
+	p.UpdatedAt = time.Now()
+
+	if err := s.saveListing(ctx, p, readAt); err != nil {
+		return nil, err
+	}
+	return s.respond(ctx, p)
+}
+
+func (s *service) UpdateVariant(ctx context.Context, userID, productID, variantID primitive.ObjectID, req UpdateVariantRequest) (*ProductResponse, error) {
+	v, err := s.sellingVendor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.productRepo.GetForVendor(ctx, v.ID, productID)
+	if err != nil {
+		return nil, err
+	}
+	readAt := p.UpdatedAt
+	variant := p.Variant(variantID)
+	if variant == nil {
+		return nil, ErrVariantNotFound
+	}
+
+	if err := p.ApplyVariantUpdate(variant, req); err != nil {
+		return nil, err
+	}
+	p.UpdatedAt = time.Now()
+
+	if err := s.saveListing(ctx, p, readAt); err != nil {
+		return nil, err
+	}
+	return s.respond(ctx, p)
+}
+
+func (s *service) AddImage(ctx context.Context, userID, productID primitive.ObjectID, file io.Reader) (*ProductResponse, error) {
+	v, err := s.sellingVendor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.productRepo.GetForVendor(ctx, v.ID, productID)
+	if err != nil {
+		return nil, err
+	}
+	readAt := p.UpdatedAt
+	if len(p.Images) >= MaxImages {
+		return nil, ErrTooManyImages
+	}
+
+	// Trust the file's bytes, not the client's Content-Type header.
+	buffered := bufio.NewReader(file)
+	head, _ := buffered.Peek(512)
+	contentType := http.DetectContentType(head)
+	if !allowedImageTypes[contentType] {
+		return nil, ErrUnsupportedFileType
+	}
+
+	img := NewImage(p.ID, contentType)
+	size, err := s.blobs.Put(ctx, img.BlobKey, io.LimitReader(buffered, MaxImageSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store product image: %w", err)
+	}
+	if size > MaxImageSize {
+		_ = s.blobs.Delete(ctx, img.BlobKey)
+		return nil, ErrFileTooLarge
+	}
+	img.Size = size
+
+	p.Images = append(p.Images, img)
+	p.UpdatedAt = time.Now()
+	if err := s.saveListing(ctx, p, readAt); err != nil {
+		_ = s.blobs.Delete(ctx, img.BlobKey)
+		return nil, err
+	}
+	return s.respond(ctx, p)
+}
+
+func (s *service) DeleteImage(ctx context.Context, userID, productID, imageID primitive.ObjectID) (*ProductResponse, error) {
+	v, err := s.sellingVendor(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.productRepo.GetForVendor(ctx, v.ID, productID)
+	if err != nil {
+		return nil, err
+	}
+	readAt := p.UpdatedAt
+	img := p.Image(imageID)
+	if img == nil {
+		return nil, ErrImageNotFound
+	}
+	blobKey := img.BlobKey
+
+	p.removeImage(imageID)
+	p.UpdatedAt = time.Now()
+	if err := s.saveListing(ctx, p, readAt); err != nil {
+		return nil, err
+	}
+	if err := s.blobs.Delete(ctx, blobKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("⚠️ failed to delete image %s of product %s: %v", imageID.Hex(), p.ID.Hex(), err)
+	}
+	return s.respond(ctx, p)
+}
+
+func (s *service) OpenVendorImage(ctx context.Context, userID, productID, imageID primitive.ObjectID) (*Image, io.ReadCloser, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := s.productRepo.GetForVendor(ctx, v.ID, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.openImage(ctx, p, imageID)
+}
+
+func (s *service) OpenImage(ctx context.Context, productID, imageID primitive.ObjectID) (*Image, io.ReadCloser, error) {
+	p, err := s.productRepo.GetPublic(ctx, productID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.openImage(ctx, &p.Product, imageID)
+}
+
+func (s *service) openImage(ctx context.Context, p *Product, imageID primitive.ObjectID) (*Image, io.ReadCloser, error) {
+	img := p.Image(imageID)
+	if img == nil {
+		return nil, nil, ErrImageNotFound
+	}
+	blob, err := s.blobs.Open(ctx, img.BlobKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrImageNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return img, blob, nil
+}
+
+func (s *service) GetProduct(ctx context.Context, productID primitive.ObjectID, query GetProductQuery) (*ProductResponse, error) {
+	if err := s.checkDisplayCurrency(query.Currency); err != nil {
+		return nil, err
+	}
+	p, err := s.productRepo.GetPublic(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	stock, err := s.stock.Available(ctx, p.variantIDs())
+	if err != nil {
+		return nil, err
+	}
+	resp := p.ToResponse(stock)
+	if err := s.addLowestPrices(ctx, p.variantIDs(), &resp); err != nil {
+		return nil, err
+	}
+	s.addDisplayPrices(&resp, query.Currency)
+	return &resp, nil
+}
+
+func (s *service) ListProducts(ctx context.Context, query ListProductsQuery) ([]ProductResponse, int64, error) {
+	if err := s.checkDisplayCurrency(query.Currency); err != nil {
+		return nil, 0, err
+	}
+	var vendorID *primitive.ObjectID
+	if query.VendorID != "" {
+		id, err := primitive.ObjectIDFromHex(query.VendorID)
+		if err != nil {
+			return nil, 0, vendor.ErrVendorNotFound
+		}
+		vendorID = &id
+	}
+	var categoryID *primitive.ObjectID
+	if query.Category != "" {
+		c, err := s.categories.Resolve(ctx, query.Category)
+		if err != nil {
+			return nil, 0, err
+		}
+		categoryID = &c.ID
+	}
+
+	products, total, err := s.productRepo.ListPublic(ctx, vendorID, categoryID, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var ids []primitive.ObjectID
+	for i := range products {
+		ids = append(ids, products[i].variantIDs()...)
+	}
+	stock, err := s.stock.Available(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := make([]ProductResponse, 0, len(products))
+	for i := range products {
+		resp = append(resp, products[i].ToResponse(stock))
+		s.addDisplayPrices(&resp[i], query.Currency)
+	}
+	return resp, total, nil
+}
+
+func (s *service) ListProductsByID(ctx context.Context, ids []primitive.ObjectID, currency string) ([]ProductResponse, error) {
+	if err := s.checkDisplayCurrency(currency); err != nil {
+		return nil, err
+	}
+	products, err := s.productRepo.ListPublicByIDs(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[primitive.ObjectID]*PublicProduct, len(products))
+	var variantIDs []primitive.ObjectID
+	for i := range products {
+		byID[products[i].ID] = &products[i]
+		variantIDs = append(variantIDs, products[i].variantIDs()...)
+	}
+	stock, err := s.stock.Available(ctx, variantIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := make([]ProductResponse, 0, len(products))
+	for _, id := range ids {
+		p, ok := byID[id]
+		if !ok {
+			continue
+		}
+		r := p.ToResponse(stock)
+		s.addDisplayPrices(&r, currency)
+		resp = append(resp, r)
+	}
+	return resp, nil
+}
+
+// sellingVendor returns the caller's vendor if it may manage listings; only
+// approved vendors can create or change products.
+func (s *service) sellingVendor(ctx context.Context, userID primitive.ObjectID) (*vendor.Vendor, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !v.CanSell() {
+		return nil, vendor.ErrVendorNotApproved
+	}
+	return v, nil
+}
+
+// save stores a changed product, logs any price change and refreshes its
+// search entry. readAt is the UpdatedAt the product was read with; if it has
+// been changed since, save fails with ErrProductChanged rather than
+// overwriting that change. Failures after the update are logged rather than
+// returned; the next change or a reindex catches the product up.
+func (s *service) save(ctx context.Context, p *Product, readAt time.Time) error {
+	if err := s.productRepo.Update(ctx, p, readAt); err != nil {
+		return err
+	}
+	if err := s.prices.RecordPrices(ctx, p); err != nil {
+		log.Printf("⚠️ failed to record prices of product %s: %v", p.ID.Hex(), err)
+	}
+	if err := s.indexer.IndexProduct(ctx, p); err != nil {
+		log.Printf("⚠️ failed to index product %s: %v", p.ID.Hex(), err)
+	}
+	return nil
+}
+
+// saveListing saves a product edited in a way buyers would see, screening
+// it first if it's live.
+func (s *service) saveListing(ctx context.Context, p *Product, readAt time.Time) error {
+	live := p.Status == ActiveStatus
+	if live {
+		if err := s.moderator.Screen(ctx, p); err != nil {
+			return err
+		}
+	}
+	if err := s.save(ctx, p, readAt); err != nil {
+		return err
+	}
+	if live {
+		s.moderator.Screened(ctx, p)
+	}
+	return nil
+}
+
+// assignCategory puts the product in the leaf category with the given hex ID;
+// an empty ID removes it from its category.
+func (s *service) assignCategory(ctx context.Context, p *Product, hexID string) error {
+	if hexID == "" {
+		p.SetCategory(nil)
+		return nil
+	}
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return category.ErrCategoryNotFound
+	}
+	path, err := s.categories.LeafPath(ctx, id)
+	if err != nil {
+		return err
+	}
+	p.SetCategory(path)
+	return nil
+}
+
+// setAttributes checks attributes against the schema of the product's
+// category and stores them normalised.
+func (s *service) setAttributes(ctx context.Context, p *Product, attributes map[string]string) error {
+	schema, err := s.categories.Schema(ctx, p.CategoryID)
+	if err != nil {
+		return err
+	}
+	clean, err := schema.Validate(attributes)
+	if err != nil {
+		return err
+	}
+	p.Attributes = nil
+	if len(clean) > 0 {
+		p.Attributes = clean
+	}
+	return nil
+}
+
+// respond renders a product for its vendor with current stock levels.
+func (s *service) respond(ctx context.Context, p *Product) (*ProductResponse, error) {
+	stock, err := s.stock.Available(ctx, p.variantIDs())
+	if err != nil {
+		return nil, err
+	}
+	resp := p.ToResponse(stock)
+	if err := s.addLowestPrices(ctx, p.variantIDs(), &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// addLowestPrices fills in the 30-day lowest price of each variant; ids are
+// in the same order as resp.Variants. List views leave it out to save a
+// lookup per page.
+func (s *service) addLowestPrices(ctx context.Context, ids []primitive.ObjectID, resp *ProductResponse) error {
+	lowest, err := s.prices.LowestPrices(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i, id := range ids {
+		if price, ok := lowest[id]; ok {
+			resp.Variants[i].LowestPrice30d = &price
+		}
+	}
+	return nil
+}
+
+func (s *service) checkDisplayCurrency(currency string) error {
+	if currency == "" {
+		return nil
+	}
+	if !money.IsCurrency(currency) {
+		return ErrUnknownCurrency
+	}
+	if !s.converter.Supports(currency) {
+		return ErrCurrencyNotShown
+	}
+	return nil
+}
+
+// addDisplayPrices adds prices in the buyer's currency next to the vendor's.
+// A product whose own currency has no rate just keeps its original prices.
+func (s *service) addDisplayPrices(resp *ProductResponse, currency string) {
+	if currency == "" || currency == resp.Currency {
+		return
+	}
+	convert := func(amount int64) *money.Money {
+		m, err := money.New(amount, resp.Currency)
+		if err != nil {
+			return nil
+		}
+		shown, err := s.converter.Convert(m, currency)
+		if err != nil {
+			return nil
+		}
+		return &shown
+	}
+
+	resp.DisplayPrice = convert(resp.Price)
+	for i := range resp.Variants {
+		v := &resp.Variants[i]
+		v.DisplayPrice = convert(v.Price)
+		if v.CompareAtPrice != nil {
+			v.DisplayCompareAtPrice = convert(*v.CompareAtPrice)
+		}
+	}
+}
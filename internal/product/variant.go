@@ -0,0 +1,304 @@
+package product
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MaxOptions      = 3
+	MaxOptionValues = 20
+	MaxVariants     = 100
+)
+
+// Option is a variant axis such as size or colour.
+type Option struct {
+	Name   string   `json:"name" bson:"name"`
+	Values []string `json:"values" bson:"values"`
+}
+
+// Variant is one purchasable combination of option values. A product without
+// options has a single default variant with no option values.
+type Variant struct {
+	ID  primitive.ObjectID `json:"id" bson:"_id"`
+	SKU string             `json:"sku" bson:"sku"`
+	// OptionValues holds one value per product option, in option order.
+	OptionValues []string `json:"option_values" bson:"option_values"`
+	// Price and CompareAtPrice override the product's when set.
+	Price          *int64 `json:"price,omitempty" bson:"price,omitempty"`
+	CompareAtPrice *int64 `json:"compare_at_price,omitempty" bson:"compare_at_price,omitempty"`
+	// Sale takes precedence over a sale on the whole product.
+	Sale        *Sale  `json:"sale,omitempty" bson:"sale,omitempty"`
+	WeightGrams int64  `json:"weight_grams" bson:"weight_grams"`
+	Barcode     string `json:"barcode,omitempty" bson:"barcode,omitempty"`
+}
+
+var skuUnsafe = regexp.MustCompile(`[^A-Z0-9]+`)
+
+// baseSKU derives a readable SKU prefix from the product ID's unique tail.
+func baseSKU(productID primitive.ObjectID) string {
+	return strings.ToUpper(productID.Hex()[14:])
+}
+
+func generatedSKU(productID primitive.ObjectID, values []string) string {
+	parts := []string{baseSKU(productID)}
+	for _, v := range values {
+		if token := strings.Trim(skuUnsafe.ReplaceAllString(strings.ToUpper(v), "-"), "-"); token != "" {
+			parts = append(parts, token)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+func NewDefaultVariant(productID primitive.ObjectID) Variant {
+	return Variant{
+		ID:           primitive.NewObjectID(),
+		SKU:          baseSKU(productID),
+		OptionValues: []string{},
+	}
+}
+
+// RegularPrice is the variant's price outside of any sale.
+func (p *Product) RegularPrice(v *Variant) int64 {
+	if v.Price != nil {
+		return *v.Price
+	}
+	return p.Price
+}
+
+// EffectivePrice is what a buyer pays for the variant right now.
+func (p *Product) EffectivePrice(v *Variant) int64 {
+	if sale := p.activeSale(v, time.Now()); sale != nil {
+		return sale.Price
+	}
+	return p.RegularPrice(v)
+}
+
+func (p *Product) Variant(id primitive.ObjectID) *Variant {
+	for i := range p.Variants {
+		if p.Variants[i].ID == id {
+			return &p.Variants[i]
+		}
+	}
+	return nil
+}
+
+func (p *Product) VariantBySKU(sku string) *Variant {
+	for i := range p.Variants {
+		if strings.EqualFold(p.Variants[i].SKU, sku) {
+			return &p.Variants[i]
+		}
+	}
+	return nil
+}
+
+// SetOptions replaces the option axes and regenerates the variant matrix.
+// Variants whose combination survives keep their ID, SKU and settings; new
+// combinations get a generated SKU.
+func (p *Product) SetOptions(options []Option) error {
+	cleaned, err := cleanOptions(options)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]Variant, len(p.Variants))
+	for _, v := range p.Variants {
+		existing[comboKey(v.OptionValues)] = v
+	}
+
+	combos := [][]string{{}}
+	for _, o := range cleaned {
+		next := make([][]string, 0, len(combos)*len(o.Values))
+		for _, combo := range combos {
+			for _, value := range o.Values {
+				c := append(append([]string{}, combo...), value)
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+	if len(combos) > MaxVariants {
+		return fmt.Errorf("%w: %d combinations exceed the limit of %d variants", ErrInvalidVariants, len(combos), MaxVariants)
+	}
+
+	variants := make([]Variant, 0, len(combos))
+	for _, combo := range combos {
+		if v, ok := existing[comboKey(combo)]; ok {
+			v.OptionValues = combo
+			variants = append(variants, v)
+			continue
+		}
+		variants = append(variants, Variant{
+			ID:           primitive.NewObjectID(),
+			SKU:          generatedSKU(p.ID, combo),
+			OptionValues: combo,
+		})
+	}
+
+	p.Options = cleaned
+	p.Variants = variants
+	return p.validateSKUs()
+}
+
+func cleanOptions(options []Option) ([]Option, error) {
+	if len(options) > MaxOptions {
+		return nil, fmt.Errorf("%w: at most %d options", ErrInvalidVariants, MaxOptions)
+	}
+	cleaned := make([]Option, 0, len(options))
+	names := make(map[string]bool, len(options))
+	for _, o := range options {
+		name := strings.TrimSpace(o.Name)
+		if name == "" || names[strings.ToLower(name)] {
+			return nil, fmt.Errorf("%w: option names must be present and unique", ErrInvalidVariants)
+		}
+		names[strings.ToLower(name)] = true
+
+		if len(o.Values) == 0 || len(o.Values) > MaxOptionValues {
+			return nil, fmt.Errorf("%w: option %q needs 1 to %d values", ErrInvalidVariants, name, MaxOptionValues)
+		}
+		values := make([]string, 0, len(o.Values))
+		seen := make(map[string]bool, len(o.Values))
+		for _, v := range o.Values {
+			v = strings.TrimSpace(v)
+			if v == "" || seen[strings.ToLower(v)] {
+				return nil, fmt.Errorf("%w: option %q has empty or duplicate values", ErrInvalidVariants, name)
+			}
+			seen[strings.ToLower(v)] = true
+			values = append(values, v)
+		}
+		cleaned = append(cleaned, Option{Name: name, Values: values})
+	}
+	return cleaned, nil
+}
+
+// comboKey matches option combinations case-insensitively so renaming "red"
+// to "Red" keeps the variant.
+func comboKey(values []string) string {
+	return strings.ToLower(strings.Join(values, "\x00"))
+}
+
+// validateSKUs enforces SKU uniqueness within the product; the database
+// index enforces it across the vendor's other products.
+func (p *Product) validateSKUs() error {
+	seen := make(map[string]bool, len(p.Variants))
+	for _, v := range p.Variants {
+		key := strings.ToUpper(v.SKU)
+		if seen[key] {
+			return fmt.Errorf("%w: %s", ErrSKUTaken, v.SKU)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// ApplyVariantUpdate changes one variant's own settings.
+func (p *Product) ApplyVariantUpdate(v *Variant, req UpdateVariantRequest) error {
+	if req.SKU != nil {
+		v.SKU = strings.ToUpper(strings.TrimSpace(*req.SKU))
+		if v.SKU == "" {
+			return fmt.Errorf("%w: sku cannot be blank", ErrInvalidVariants)
+		}
+	}
+	if req.ClearPrice {
+		v.Price = nil
+	} else if req.Price != nil {
+		price := *req.Price
+		v.Price = &price
+	}
+	if req.ClearCompareAtPrice {
+		v.CompareAtPrice = nil
+	} else if req.CompareAtPrice != nil {
+		compareAt := *req.CompareAtPrice
+		v.CompareAtPrice = &compareAt
+	}
+	if req.WeightGrams != nil {
+		v.WeightGrams = *req.WeightGrams
+	}
+	if req.Barcode != nil {
+		v.Barcode = strings.TrimSpace(*req.Barcode)
+	}
+	return p.validateSKUs()
+}
+
+// Stock maps variant IDs to the quantity available to buy right now.
+type Stock map[primitive.ObjectID]int64
+
+// InStock reports whether a buyer can order the variant now. Digital
+// products never run out, so they don't track stock.
+func (p *Product) InStock(v *Variant, stock Stock) bool {
+	return p.IsDigital() || stock[v.ID] > 0
+}
+
+// StockReader reports available quantities per variant. The inventory
+// package implements it; variants it doesn't know about have none.
+type StockReader interface {
+	Available(ctx context.Context, variantIDs []primitive.ObjectID) (Stock, error)
+}
+
+func (p *Product) variantIDs() []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(p.Variants))
+	for _, v := range p.Variants {
+		ids = append(ids, v.ID)
+	}
+	return ids
+}
+
+func (p *Product) variantResponses(public bool, stock Stock) []VariantResponse {
+	variants := make([]VariantResponse, 0, len(p.Variants))
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		resp := VariantResponse{
+			ID:             v.ID.Hex(),
+			SKU:            v.SKU,
+			Options:        make(map[string]string, len(p.Options)),
+			Price:          p.EffectivePrice(v),
+			CompareAtPrice: p.ReferencePrice(v),
+			WeightGrams:    v.WeightGrams,
+			Barcode:        v.Barcode,
+			Available:      p.InStock(v, stock),
+		}
+		if sale := p.activeSale(v, time.Now()); sale != nil {
+			resp.SaleEndsAt = sale.EndsAt.Format(time.RFC3339)
+		}
+		for j, value := range v.OptionValues {
+			if j < len(p.Options) {
+				resp.Options[p.Options[j].Name] = value
+			}
+		}
+		if !public {
+			available := stock[v.ID]
+			resp.Stock = &available
+			resp.PriceOverride = v.Price
+			resp.CompareAtOverride = v.CompareAtPrice
+		}
+		variants = append(variants, resp)
+	}
+	return variants
+}
+
+// optionResponses reports, for every option value, whether any in-stock
+// variant carries it, so a storefront can grey out sold-out choices.
+func (p *Product) optionResponses(stock Stock) []OptionResponse {
+	options := make([]OptionResponse, 0, len(p.Options))
+	for i, o := range p.Options {
+		values := make([]OptionValueResponse, 0, len(o.Values))
+		for _, value := range o.Values {
+			available := false
+			for j := range p.Variants {
+				v := &p.Variants[j]
+				if i < len(v.OptionValues) && v.OptionValues[i] == value && p.InStock(v, stock) {
+					available = true
+					break
+				}
+			}
+			values = append(values, OptionValueResponse{Value: value, Available: available})
+		}
+		options = append(options, OptionResponse{Name: o.Name, Values: values})
+	}
+	return options
+}
@@ -0,0 +1,38 @@
+package productio
+
+type UploadImportRequest struct {
+	// Format defaults to the file extension.
+	Format Format `form:"format" binding:"omitempty,oneof=csv jsonl"`
+}
+
+type ExportQuery struct {
+	Format Format `form:"format" binding:"omitempty,oneof=csv jsonl"`
+}
+
+type ListJobsQuery struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type RowErrorResponse struct {
+	Row     int    `json:"row"`
+	SKU     string `json:"sku,omitempty"`
+	Message string `json:"message"`
+}
+
+type JobResponse struct {
+	ID              string             `json:"id"`
+	Format          string             `json:"format"`
+	FileName        string             `json:"file_name"`
+	Status          string             `json:"status"`
+	Failure         string             `json:"failure,omitempty"`
+	TotalRows       int                `json:"total_rows"`
+	CreatedProducts int                `json:"created_products"`
+	UpdatedProducts int                `json:"updated_products"`
+	FailedRows      int                `json:"failed_rows"`
+	Errors          []RowErrorResponse `json:"errors"`
+	ResultsReady    bool               `json:"results_ready"`
+	CreatedAt       string             `json:"created_at"`
+	StartedAt       string             `json:"started_at,omitempty"`
+	FinishedAt      string             `json:"finished_at,omitempty"`
+}
@@ -0,0 +1,295 @@
+package productio
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+type Format string
+
+const (
+	CSVFormat   Format = "csv"
+	JSONLFormat Format = "jsonl"
+)
+
+var ErrUnknownFormat = errors.New("file must be csv or jsonl")
+
+// Columns is the catalogue file layout shared by import and export. Each row
+// is one variant; the product-level columns repeat on every row of a product
+// and are read from its first row.
+var Columns = []string{
+	"handle", "title", "description", "status", "type", "currency", "price", "compare_at_price", "category", "attributes",
+	"option1_name", "option1_value", "option2_name", "option2_value", "option3_name", "option3_value",
+	"sku", "variant_price", "variant_compare_at_price", "weight_grams", "barcode", "stock",
+}
+
+var resultColumns = []string{"row", "handle", "sku", "status", "product_id", "error"}
+
+// numericColumns are written to JSONL as numbers. Import accepts numbers or
+// numeric strings for them.
+var numericColumns = map[string]bool{
+	"price": true, "compare_at_price": true, "variant_price": true, "variant_compare_at_price": true,
+	"weight_grams": true, "stock": true, "row": true,
+}
+
+// objectColumns hold a JSON object. CSV carries the object's text; JSONL
+// writes the object itself and accepts either.
+var objectColumns = map[string]bool{
+	"attributes": true,
+}
+
+// maxLineBytes bounds one JSONL line; a description alone may be 10k runes.
+const maxLineBytes = 1 << 20
+
+func FormatFromFileName(name string) (Format, bool) {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".csv":
+		return CSVFormat, true
+	case ".jsonl", ".ndjson":
+		return JSONLFormat, true
+	}
+	return "", false
+}
+
+func (f Format) ContentType() string {
+	if f == JSONLFormat {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// Record is one row keyed by column. A column missing from the record leaves
+// that field alone on import; a present but blank value clears it.
+type Record map[string]string
+
+// line is a parsed row with its position in the file. Err is set when this
+// row couldn't be read but the rest of the file could.
+type line struct {
+	Row    int
+	Record Record
+	Err    error
+}
+
+// readLines parses a whole import file. An error return means the file as a
+// whole is unusable; problems confined to one row are reported on the line.
+func readLines(format Format, r io.Reader) ([]line, error) {
+	switch format {
+	case CSVFormat:
+		return readCSV(r)
+	case JSONLFormat:
+		return readJSONL(r)
+	}
+	return nil, ErrUnknownFormat
+}
+
+func readCSV(r io.Reader) ([]line, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []line{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unreadable header: %w", err)
+	}
+	columns, err := headerColumns(header)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := []line{}
+	for {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) || parseErr.Err != csv.ErrFieldCount {
+				return nil, err
+			}
+			lines = append(lines, line{Row: parseErr.StartLine, Err: fmt.Errorf("expected %d columns, found %d", len(columns), len(fields))})
+			continue
+		}
+		row, _ := reader.FieldPos(0)
+		record := make(Record, len(columns))
+		for i, name := range columns {
+			record[name] = strings.TrimSpace(fields[i])
+		}
+		lines = append(lines, line{Row: row, Record: record})
+	}
+}
+
+func headerColumns(header []string) ([]string, error) {
+	known := make(map[string]bool, len(Columns))
+	for _, c := range Columns {
+		known[c] = true
+	}
+	columns := make([]string, 0, len(header))
+	seen := make(map[string]bool, len(header))
+	for i, h := range header {
+		name := strings.ToLower(strings.TrimSpace(h))
+		if i == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("unknown column %q", h)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("column %q appears twice", h)
+		}
+		seen[name] = true
+		columns = append(columns, name)
+	}
+	return columns, nil
+}
+
+func readJSONL(r io.Reader) ([]line, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxLineBytes)
+
+	lines := []line{}
+	for row := 1; scanner.Scan(); row++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		record, err := decodeRecord(text)
+		lines = append(lines, line{Row: row, Record: record, Err: err})
+	}
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, fmt.Errorf("line %d is longer than %d bytes", len(lines)+1, maxLineBytes)
+		}
+		return nil, err
+	}
+	return lines, nil
+}
+
+func decodeRecord(text []byte) (Record, error) {
+	decoder := json.NewDecoder(bytes.NewReader(text))
+	decoder.UseNumber()
+	var fields map[string]any
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+
+	known := make(map[string]bool, len(Columns))
+	for _, c := range Columns {
+		known[c] = true
+	}
+	record := make(Record, len(fields))
+	for key, value := range fields {
+		if !known[key] {
+			return nil, fmt.Errorf("unknown field %q", key)
+		}
+		switch v := value.(type) {
+		case nil:
+			record[key] = ""
+		case string:
+			record[key] = strings.TrimSpace(v)
+		case json.Number:
+			if !numericColumns[key] {
+				return nil, fmt.Errorf("%s must be a string", key)
+			}
+			record[key] = v.String()
+		case map[string]any:
+			if !objectColumns[key] {
+				return nil, fmt.Errorf("%s must be a string", key)
+			}
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %v", key, err)
+			}
+			record[key] = string(encoded)
+		default:
+			return nil, fmt.Errorf("%s must be a string or number", key)
+		}
+	}
+	return record, nil
+}
+
+// recordWriter writes rows with a fixed set of columns.
+type recordWriter interface {
+	Write(record Record) error
+	Flush() error
+}
+
+func newRecordWriter(format Format, w io.Writer, columns []string) (recordWriter, error) {
+	switch format {
+	case CSVFormat:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(columns); err != nil {
+			return nil, err
+		}
+		return &csvWriter{writer: writer, columns: columns}, nil
+	case JSONLFormat:
+		return &jsonlWriter{writer: bufio.NewWriter(w), columns: columns}, nil
+	}
+	return nil, ErrUnknownFormat
+}
+
+type csvWriter struct {
+	writer  *csv.Writer
+	columns []string
+}
+
+func (w *csvWriter) Write(record Record) error {
+	fields := make([]string, len(w.columns))
+	for i, name := range w.columns {
+		fields[i] = record[name]
+	}
+	return w.writer.Write(fields)
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+	return w.writer.Error()
+}
+
+type jsonlWriter struct {
+	writer  *bufio.Writer
+	columns []string
+}
+
+// Write emits every column in order so exported lines are stable and
+// diffable; blank numbers and objects become null.
+func (w *jsonlWriter) Write(record Record) error {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, name := range w.columns {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(name)
+		buf.Write(key)
+		buf.WriteByte(':')
+
+		value := record[name]
+		switch {
+		case (numericColumns[name] || objectColumns[name]) && value == "":
+			buf.WriteString("null")
+		case numericColumns[name], objectColumns[name]:
+			buf.WriteString(value)
+		default:
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			buf.Write(encoded)
+		}
+	}
+	buf.WriteString("}\n")
+	_, err := w.writer.Write(buf.Bytes())
+	return err
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.writer.Flush()
+}
@@ -0,0 +1,209 @@
+package productio
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/response"
+	"github.com/techrook/23-market/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	productioService Service
+}
+
+func NewHandler(productioService Service) *Handler {
+	return &Handler{
+		productioService: productioService,
+	}
+}
+
+func (h *Handler) StartImport(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart envelope around the file itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxImportSize+1<<20)
+
+	var req UploadImportRequest
+	if err := c.ShouldBind(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		response.BadRequest(c, "A file is required", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	fileName := filepath.Base(fileHeader.Filename)
+	format := req.Format
+	if format == "" {
+		if format, ok = FormatFromFileName(fileName); !ok {
+			handleError(c, ErrUnknownFormat, "Failed to start import")
+			return
+		}
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.BadRequest(c, "Could not read uploaded file", nil, response.IsProduction(c))
+		return
+	}
+	defer file.Close()
+
+	job, err := h.productioService.StartImport(c.Request.Context(), userID, format, fileName, file)
+	if err != nil {
+		handleError(c, err, "Failed to start import")
+		return
+	}
+	response.Created(c, job, "Import queued")
+}
+
+func (h *Handler) ListImports(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var query ListJobsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+
+	jobs, total, err := h.productioService.ListImports(c.Request.Context(), userID, query)
+	if err != nil {
+		handleError(c, err, "Failed to list imports")
+		return
+	}
+	response.Paginated(c, jobs, query.Page, query.PageSize, int(total), "Imports retrieved successfully")
+}
+
+func (h *Handler) GetImport(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	jobID, ok := jobIDParam(c)
+	if !ok {
+		return
+	}
+
+	job, err := h.productioService.GetImport(c.Request.Context(), userID, jobID)
+	if err != nil {
+		handleError(c, err, "Failed to get import")
+		return
+	}
+	response.OK(c, job, "Import retrieved successfully")
+}
+
+func (h *Handler) DownloadResults(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	jobID, ok := jobIDParam(c)
+	if !ok {
+		return
+	}
+
+	job, blob, err := h.productioService.OpenResults(c.Request.Context(), userID, jobID)
+	if err != nil {
+		handleError(c, err, "Failed to download import results")
+		return
+	}
+	defer blob.Close()
+	fileName := fmt.Sprintf("import-%s-results.%s", job.ID.Hex(), job.Format)
+	c.DataFromReader(http.StatusOK, -1, job.Format.ContentType(), blob, map[string]string{
+		"Content-Disposition": fmt.Sprintf("attachment; filename=%q", fileName),
+	})
+}
+
+// Export streams the catalogue as it is read, so a failure part-way through
+// can only be logged; the client sees a truncated file.
+func (h *Handler) Export(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var query ExportQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Format == "" {
+		query.Format = CSVFormat
+	}
+
+	fileName := fmt.Sprintf("catalog-%s.%s", time.Now().Format("20060102"), query.Format)
+	c.Header("Content-Type", query.Format.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+
+	err := h.productioService.Export(c.Request.Context(), userID, query.Format, c.Writer)
+	if err != nil {
+		if c.Writer.Written() {
+			log.Printf("⚠️ catalogue export for user %s failed part-way: %v", userID.Hex(), err)
+			return
+		}
+		c.Header("Content-Disposition", "")
+		handleError(c, err, "Failed to export catalogue")
+	}
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, ErrJobNotFound):
+		response.NotFound(c, "Import", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotFound):
+		response.NotFound(c, "Vendor", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotApproved):
+		response.Forbidden(c, "Only approved vendors can import products", response.IsProduction(c))
+	case errors.Is(err, ErrUnknownFormat):
+		response.BadRequest(c, "File must be .csv or .jsonl, or set format", nil, response.IsProduction(c))
+	case errors.Is(err, ErrResultsNotReady), errors.Is(err, storage.ErrNotFound):
+		response.Conflict(c, "Import results are not available yet", nil, response.IsProduction(c))
+	case errors.Is(err, ErrFileTooLarge), errors.As(err, &tooLarge):
+		response.Error(c, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", "File exceeds the 20MB limit", nil, response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func jobIDParam(c *gin.Context) (primitive.ObjectID, bool) {
+	jobID, err := primitive.ObjectIDFromHex(c.Param("jobID"))
+	if err != nil {
+		response.BadRequest(c, "Invalid import ID", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return jobID, true
+}
+
+func callerID(c *gin.Context) (primitive.ObjectID, bool) {
+	val, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	userID, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
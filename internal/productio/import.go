@@ -0,0 +1,730 @@
+package productio
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/techrook/23-market/internal/category"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/pkg/money"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type rowStatus string
+
+const (
+	createdRow rowStatus = "created"
+	updatedRow rowStatus = "updated"
+	failedRow  rowStatus = "failed"
+	// skippedRow marks the other rows of a product whose import failed.
+	skippedRow rowStatus = "skipped"
+)
+
+type rowResult struct {
+	Row       int
+	Handle    string
+	SKU       string
+	Status    rowStatus
+	ProductID string
+	Error     string
+}
+
+func (r rowResult) record() Record {
+	return Record{
+		"row":        strconv.Itoa(r.Row),
+		"handle":     r.Handle,
+		"sku":        r.SKU,
+		"status":     string(r.Status),
+		"product_id": r.ProductID,
+		"error":      r.Error,
+	}
+}
+
+// rowError pins a failure to one row of a product group.
+type rowError struct {
+	row int
+	err error
+}
+
+func (e *rowError) Error() string { return e.err.Error() }
+func (e *rowError) Unwrap() error { return e.err }
+
+func rowErrorf(row int, format string, args ...any) error {
+	return &rowError{row: row, err: fmt.Errorf(format, args...)}
+}
+
+// group is the rows of one product: those sharing a handle, or a single row
+// identified by SKU alone.
+type group struct {
+	handle string
+	lines  []line
+}
+
+// variantPlan is what one row asks of its variant, validated before anything
+// is written.
+type variantPlan struct {
+	line   line
+	values []string
+	sku    string
+	update product.UpdateVariantRequest
+	stock  *int64
+
+	variant  primitive.ObjectID
+	stockErr error
+}
+
+type importer struct {
+	s       *service
+	job     *Job
+	touched map[primitive.ObjectID]bool
+	created int
+	updated int
+}
+
+func newImporter(s *service, job *Job) *importer {
+	return &importer{s: s, job: job, touched: make(map[primitive.ObjectID]bool)}
+}
+
+// run imports each product group independently; a failure in one row fails
+// its whole product and leaves the rest of the file unaffected.
+func (im *importer) run(ctx context.Context, lines []line) []rowResult {
+	results := make(map[int]rowResult, len(lines))
+	groups := im.group(lines, results)
+
+	for _, g := range groups {
+		p, created, plans, err := im.importGroup(ctx, g)
+		for i, l := range g.lines {
+			r := rowResult{Row: l.Row, Handle: g.handle, SKU: l.Record["sku"]}
+			var re *rowError
+			switch {
+			case err == nil:
+				r.ProductID = p.ID.Hex()
+				r.SKU = p.Variant(plans[i].variant).SKU
+				r.Status = updatedRow
+				if created {
+					r.Status = createdRow
+				}
+				if plans[i].stockErr != nil {
+					r.Status = failedRow
+					r.Error = "product saved but stock not set: " + plans[i].stockErr.Error()
+				}
+			case errors.As(err, &re) && re.row != l.Row:
+				r.Status = skippedRow
+				r.Error = fmt.Sprintf("row %d of this product failed", re.row)
+			default:
+				r.Status = failedRow
+				r.Error = err.Error()
+			}
+			results[l.Row] = r
+		}
+	}
+
+	ordered := make([]rowResult, 0, len(lines))
+	for _, l := range lines {
+		ordered = append(ordered, results[l.Row])
+	}
+	return ordered
+}
+
+// group collects rows into products in file order. Rows that can't be placed
+// are failed straight away.
+func (im *importer) group(lines []line, results map[int]rowResult) []*group {
+	var groups []*group
+	byKey := make(map[string]*group)
+	skuGroup := make(map[string]*group)
+
+	for _, l := range lines {
+		fail := func(err error) {
+			results[l.Row] = rowResult{Row: l.Row, Handle: l.Record["handle"], SKU: l.Record["sku"], Status: failedRow, Error: err.Error()}
+		}
+		if l.Err != nil {
+			fail(l.Err)
+			continue
+		}
+
+		handle := l.Record["handle"]
+		sku := normalizeSKU(l.Record["sku"])
+		var key string
+		switch {
+		case handle != "":
+			key = "handle:" + strings.ToLower(handle)
+		case sku != "":
+			key = "sku:" + sku
+		default:
+			fail(errors.New("handle or sku is required"))
+			continue
+		}
+
+		g, ok := byKey[key]
+		if sku != "" {
+			if other, seen := skuGroup[sku]; seen && other != g {
+				fail(fmt.Errorf("sku %s appears under more than one product", sku))
+				continue
+			}
+		}
+		if !ok {
+			g = &group{handle: handle}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		if sku != "" {
+			skuGroup[sku] = g
+		}
+		g.lines = append(g.lines, l)
+	}
+	return groups
+}
+
+// importGroup creates or updates one product from its rows. The returned
+// plans line up with g.lines.
+func (im *importer) importGroup(ctx context.Context, g *group) (*product.Product, bool, []variantPlan, error) {
+	p, err := im.existing(ctx, g)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	created := p == nil
+	first := g.lines[0]
+	var readAt time.Time
+	if !created {
+		readAt = p.UpdatedAt
+	}
+
+	if created {
+		req, err := createRequest(first)
+		if err != nil {
+			return nil, false, nil, err
+		}
+		p = product.NewProduct(im.job.VendorID, req)
+		if value := first.Record["compare_at_price"]; value != "" {
+			compareAt, err := parseAmount(first.Row, "compare_at_price", value)
+			if err != nil {
+				return nil, false, nil, err
+			}
+			p.CompareAtPrice = &compareAt
+		}
+	} else {
+		req, err := updateRequest(first)
+		if err != nil {
+			return nil, false, nil, err
+		}
+		p.ApplyUpdate(req)
+	}
+
+	if status, ok := first.Record["status"]; ok && status != "" {
+		switch s := product.Status(strings.ToLower(status)); s {
+		case product.DraftStatus, product.ActiveStatus, product.ArchivedStatus:
+			p.SetStatus(s)
+		default:
+			return nil, false, nil, rowErrorf(first.Row, "status must be draft, active or archived")
+		}
+	}
+	slug, hasCategory := first.Record["category"]
+	if hasCategory {
+		if err := im.assignCategory(ctx, p, first.Row, slug); err != nil {
+			return nil, false, nil, err
+		}
+	}
+	// Recheck attributes whenever they or the schema they follow change, as
+	// the product API does.
+	value, hasAttributes := first.Record["attributes"]
+	if hasAttributes || hasCategory {
+		attributes := p.Attributes
+		if hasAttributes {
+			if attributes, err = parseAttributes(first.Row, value); err != nil {
+				return nil, false, nil, err
+			}
+		}
+		if err := im.setAttributes(ctx, p, first.Row, attributes); err != nil {
+			return nil, false, nil, err
+		}
+	}
+
+	plans, err := im.applyVariants(p, g)
+	if err != nil {
+		return nil, false, nil, err
+	}
+	// Live products pass moderation just like edits made through the API.
+	live := p.Status == product.ActiveStatus
+	if live {
+		if err := im.s.moderator.Screen(ctx, p); err != nil {
+			return nil, false, nil, err
+		}
+	}
+
+	if created {
+		err = im.s.productRepo.Create(ctx, p)
+	} else {
+		err = im.s.productRepo.Update(ctx, p, readAt)
+	}
+	if errors.Is(err, product.ErrSKUTaken) {
+		return nil, false, nil, errors.New("a sku is already used by another of your products")
+	}
+	if errors.Is(err, product.ErrProductChanged) {
+		return nil, false, nil, errors.New("the product was changed while importing; import it again")
+	}
+	if err != nil {
+		return nil, false, nil, err
+	}
+	im.touched[p.ID] = true
+	if created {
+		im.created++
+	} else {
+		im.updated++
+	}
+	im.s.refresh(ctx, p)
+	if live {
+		im.s.moderator.Screened(ctx, p)
+	}
+
+	im.setStock(ctx, p, plans)
+	return p, created, plans, nil
+}
+
+// setStock applies stock counts once the product is saved. A failure here
+// fails only that row; the product itself has already been imported.
+func (im *importer) setStock(ctx context.Context, p *product.Product, plans []variantPlan) {
+	reference := "import:" + im.job.ID.Hex()
+	for i := range plans {
+		if plans[i].stock != nil {
+			plans[i].stockErr = im.s.inventory.SetOnHand(ctx, im.job.UserID, p, plans[i].variant, *plans[i].stock, reference)
+		}
+	}
+}
+
+// existing finds the product a group updates: the one whose ID is the
+// handle, or the one already holding the group's SKUs.
+func (im *importer) existing(ctx context.Context, g *group) (*product.Product, error) {
+	var found *product.Product
+
+	if id, err := primitive.ObjectIDFromHex(g.handle); err == nil {
+		p, err := im.s.productRepo.GetForVendor(ctx, im.job.VendorID, id)
+		switch {
+		case err == nil:
+			found = p
+		case !errors.Is(err, product.ErrProductNotFound):
+			return nil, err
+		}
+	}
+	for _, l := range g.lines {
+		sku := strings.TrimSpace(l.Record["sku"])
+		if sku == "" || (found != nil && found.VariantBySKU(sku) != nil) {
+			continue
+		}
+		p, err := im.s.productRepo.GetBySKU(ctx, im.job.VendorID, sku)
+		if errors.Is(err, product.ErrVariantNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if found != nil && found.ID != p.ID {
+			return nil, rowErrorf(l.Row, "sku %s belongs to a different product than the rest of its rows", sku)
+		}
+		found = p
+	}
+
+	if found != nil && im.touched[found.ID] {
+		return nil, rowErrorf(g.lines[0].Row, "product %s already appeared earlier in the file under another handle", found.ID.Hex())
+	}
+	return found, nil
+}
+
+func (im *importer) assignCategory(ctx context.Context, p *product.Product, row int, slug string) error {
+	if slug == "" {
+		p.SetCategory(nil)
+		return nil
+	}
+	c, err := im.s.categories.Resolve(ctx, slug)
+	if errors.Is(err, category.ErrCategoryNotFound) {
+		return rowErrorf(row, "category %q not found", slug)
+	}
+	if err != nil {
+		return err
+	}
+	path, err := im.s.categories.LeafPath(ctx, c.ID)
+	if errors.Is(err, category.ErrCategoryNotLeaf) {
+		return rowErrorf(row, "category %q has subcategories; use one of those", slug)
+	}
+	if err != nil {
+		return err
+	}
+	p.SetCategory(path)
+	return nil
+}
+
+// setAttributes checks attributes against the schema of the product's
+// category and stores them normalised.
+func (im *importer) setAttributes(ctx context.Context, p *product.Product, row int, attributes map[string]string) error {
+	schema, err := im.s.categories.Schema(ctx, p.CategoryID)
+	if err != nil {
+		return err
+	}
+	clean, err := schema.Validate(attributes)
+	if errors.Is(err, category.ErrInvalidAttributes) {
+		return rowErrorf(row, "%v", err)
+	}
+	if err != nil {
+		return err
+	}
+	p.Attributes = nil
+	if len(clean) > 0 {
+		p.Attributes = clean
+	}
+	return nil
+}
+
+// applyVariants sets the option axes from the group's rows, when the file
+// has option columns, then applies each row to its variant. Rows are matched
+// by option values, then by SKU, then to the only variant.
+func (im *importer) applyVariants(p *product.Product, g *group) ([]variantPlan, error) {
+	names, hasOptions, err := optionNames(g.lines[0])
+	if err != nil {
+		return nil, err
+	}
+
+	plans := make([]variantPlan, 0, len(g.lines))
+	for _, l := range g.lines {
+		plan, err := rowPlan(l, len(names), hasOptions)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, plan)
+	}
+
+	if hasOptions {
+		options := make([]product.Option, len(names))
+		for i, name := range names {
+			options[i].Name = name
+			for _, plan := range plans {
+				if !containsFold(options[i].Values, plan.values[i]) {
+					options[i].Values = append(options[i].Values, plan.values[i])
+				}
+			}
+		}
+		if err := p.SetOptions(options); err != nil {
+			return nil, rowErrorf(g.lines[0].Row, "%v", err)
+		}
+	}
+
+	claimed := make(map[primitive.ObjectID]int, len(plans))
+	for i := range plans {
+		plan := &plans[i]
+		var variant *product.Variant
+		switch {
+		case hasOptions:
+			variant = variantByValues(p, plan.values)
+		case plan.sku != "":
+			variant = p.VariantBySKU(plan.sku)
+			if variant == nil && len(p.Variants) == 1 && len(plans) == 1 {
+				variant = &p.Variants[0]
+			}
+		case len(p.Variants) == 1:
+			variant = &p.Variants[0]
+		}
+		if variant == nil {
+			return nil, rowErrorf(plan.line.Row, "row doesn't match any variant of this product; give its sku or option values")
+		}
+		if row, ok := claimed[variant.ID]; ok {
+			return nil, rowErrorf(plan.line.Row, "row %d already describes this variant", row)
+		}
+		claimed[variant.ID] = plan.line.Row
+		plan.variant = variant.ID
+
+		if err := p.ApplyVariantUpdate(variant, plan.update); err != nil {
+			return nil, rowErrorf(plan.line.Row, "%v", err)
+		}
+	}
+	return plans, nil
+}
+
+// optionNames reads the option axes from a product's first row. hasOptions
+// is false when the file has no option columns, which leaves the product's
+// options as they are.
+func optionNames(l line) ([]string, bool, error) {
+	var names []string
+	hasOptions := false
+	for i := 0; i < product.MaxOptions; i++ {
+		name, ok := l.Record[optionNameColumn(i)]
+		hasOptions = hasOptions || ok
+		if name == "" {
+			continue
+		}
+		if len(names) != i {
+			return nil, false, rowErrorf(l.Row, "%s is set but an earlier option name is blank", optionNameColumn(i))
+		}
+		if utf8.RuneCountInString(name) > 50 {
+			return nil, false, rowErrorf(l.Row, "%s must be at most 50 characters", optionNameColumn(i))
+		}
+		names = append(names, name)
+	}
+	return names, hasOptions, nil
+}
+
+func rowPlan(l line, optionCount int, hasOptions bool) (variantPlan, error) {
+	rec := l.Record
+	plan := variantPlan{line: l, sku: normalizeSKU(rec["sku"])}
+
+	if hasOptions {
+		for i := 0; i < product.MaxOptions; i++ {
+			value := rec[optionValueColumn(i)]
+			switch {
+			case i < optionCount && value == "":
+				return plan, rowErrorf(l.Row, "%s is required", optionValueColumn(i))
+			case i >= optionCount && value != "":
+				return plan, rowErrorf(l.Row, "%s is set but its option has no name", optionValueColumn(i))
+			case utf8.RuneCountInString(value) > 50:
+				return plan, rowErrorf(l.Row, "%s must be at most 50 characters", optionValueColumn(i))
+			}
+			if i < optionCount {
+				plan.values = append(plan.values, value)
+			}
+		}
+	}
+
+	if plan.sku != "" {
+		if len(plan.sku) > 64 || !isPrintableASCII(plan.sku) {
+			return plan, rowErrorf(l.Row, "sku must be at most 64 printable ASCII characters")
+		}
+		sku := plan.sku
+		plan.update.SKU = &sku
+	}
+	if value, ok := rec["variant_price"]; ok {
+		if value == "" {
+			plan.update.ClearPrice = true
+		} else {
+			price, err := parseAmount(l.Row, "variant_price", value)
+			if err != nil {
+				return plan, err
+			}
+			plan.update.Price = &price
+		}
+	}
+	if value, ok := rec["variant_compare_at_price"]; ok {
+		if value == "" {
+			plan.update.ClearCompareAtPrice = true
+		} else {
+			compareAt, err := parseAmount(l.Row, "variant_compare_at_price", value)
+			if err != nil {
+				return plan, err
+			}
+			plan.update.CompareAtPrice = &compareAt
+		}
+	}
+	if value, ok := rec["weight_grams"]; ok {
+		weight := int64(0)
+		if value != "" {
+			var err error
+			if weight, err = parseAmount(l.Row, "weight_grams", value); err != nil {
+				return plan, err
+			}
+		}
+		plan.update.WeightGrams = &weight
+	}
+	if value, ok := rec["barcode"]; ok {
+		if len(value) > 64 || !isAlphanumeric(value) {
+			return plan, rowErrorf(l.Row, "barcode must be at most 64 letters and digits")
+		}
+		barcode := value
+		plan.update.Barcode = &barcode
+	}
+	// A blank stock column leaves stock alone; stock is never cleared.
+	if value := rec["stock"]; value != "" {
+		stock, err := parseAmount(l.Row, "stock", value)
+		if err != nil {
+			return plan, err
+		}
+		plan.stock = &stock
+	}
+	return plan, nil
+}
+
+func createRequest(l line) (product.CreateProductRequest, error) {
+	rec := l.Record
+	req := product.CreateProductRequest{
+		Title:       rec["title"],
+		Description: rec["description"],
+		Currency:    strings.ToUpper(rec["currency"]),
+	}
+	if value := rec["type"]; value != "" {
+		t, err := parseType(l.Row, value)
+		if err != nil {
+			return req, err
+		}
+		req.Type = t
+	}
+	if req.Title == "" {
+		return req, rowErrorf(l.Row, "title is required for a new product")
+	}
+	if req.Currency == "" {
+		return req, rowErrorf(l.Row, "currency is required for a new product")
+	}
+	if value := rec["price"]; value != "" {
+		price, err := parseAmount(l.Row, "price", value)
+		if err != nil {
+			return req, err
+		}
+		req.Price = price
+	}
+	if err := validateTitle(l.Row, req.Title); err != nil {
+		return req, err
+	}
+	if err := validateDescription(l.Row, req.Description); err != nil {
+		return req, err
+	}
+	return req, validateCurrency(l.Row, req.Currency)
+}
+
+// updateRequest changes the fields present in the row. Title, type, currency
+// and price can't be cleared, so blanks leave them as they are.
+func updateRequest(l line) (product.UpdateProductRequest, error) {
+	rec := l.Record
+	var req product.UpdateProductRequest
+	if title := rec["title"]; title != "" {
+		if err := validateTitle(l.Row, title); err != nil {
+			return req, err
+		}
+		req.Title = &title
+	}
+	if description, ok := rec["description"]; ok {
+		if err := validateDescription(l.Row, description); err != nil {
+			return req, err
+		}
+		req.Description = &description
+	}
+	if currency := strings.ToUpper(rec["currency"]); currency != "" {
+		if err := validateCurrency(l.Row, currency); err != nil {
+			return req, err
+		}
+		req.Currency = &currency
+	}
+	if value := rec["price"]; value != "" {
+		price, err := parseAmount(l.Row, "price", value)
+		if err != nil {
+			return req, err
+		}
+		req.Price = &price
+	}
+	if value := rec["type"]; value != "" {
+		t, err := parseType(l.Row, value)
+		if err != nil {
+			return req, err
+		}
+		req.Type = &t
+	}
+	if value, ok := rec["compare_at_price"]; ok {
+		if value == "" {
+			req.ClearCompareAtPrice = true
+		} else {
+			compareAt, err := parseAmount(l.Row, "compare_at_price", value)
+			if err != nil {
+				return req, err
+			}
+			req.CompareAtPrice = &compareAt
+		}
+	}
+	return req, nil
+}
+
+// The validators below apply the same limits as the product API.
+
+func validateTitle(row int, title string) error {
+	if n := utf8.RuneCountInString(title); n < 2 || n > 200 {
+		return rowErrorf(row, "title must be 2 to 200 characters")
+	}
+	return nil
+}
+
+func validateDescription(row int, description string) error {
+	if utf8.RuneCountInString(description) > 10000 {
+		return rowErrorf(row, "description must be at most 10000 characters")
+	}
+	return nil
+}
+
+func validateCurrency(row int, currency string) error {
+	if !money.IsCurrency(currency) {
+		return rowErrorf(row, "currency must be an ISO 4217 code")
+	}
+	return nil
+}
+
+func parseType(row int, value string) (product.Type, error) {
+	switch t := product.Type(strings.ToLower(value)); t {
+	case product.PhysicalType, product.DigitalType:
+		return t, nil
+	}
+	return "", rowErrorf(row, "type must be physical or digital")
+}
+
+// parseAttributes reads a JSON object of attribute names to values. A blank
+// value clears the attributes.
+func parseAttributes(row int, value string) (map[string]string, error) {
+	attributes := map[string]string{}
+	if value == "" {
+		return attributes, nil
+	}
+	if err := json.Unmarshal([]byte(value), &attributes); err != nil {
+		return nil, rowErrorf(row, "attributes must be a JSON object of names to text values")
+	}
+	return attributes, nil
+}
+
+// parseAmount reads a non-negative whole number, such as a price in minor
+// units.
+func parseAmount(row int, column, value string) (int64, error) {
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, rowErrorf(row, "%s must be a whole number of at least 0", column)
+	}
+	return n, nil
+}
+
+func variantByValues(p *product.Product, values []string) *product.Variant {
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		if len(v.OptionValues) != len(values) {
+			continue
+		}
+		match := true
+		for j := range values {
+			match = match && strings.EqualFold(v.OptionValues[j], values[j])
+		}
+		if match {
+			return v
+		}
+	}
+	return nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func isAlphanumeric(s string) bool {
+	for _, r := range s {
+		if !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,97 @@
+package productio
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MaxImportSize = 20 << 20
+	MaxImportRows = 20000
+	// maxInlineErrors is how many row errors the job keeps for the API; the
+	// full list is in the results file.
+	maxInlineErrors = 100
+)
+
+type JobStatus string
+
+const (
+	QueuedJobStatus    JobStatus = "queued"
+	RunningJobStatus   JobStatus = "running"
+	CompletedJobStatus JobStatus = "completed"
+	FailedJobStatus    JobStatus = "failed"
+)
+
+// RowError is a problem with one row of an import file. Row numbers count
+// from 1 at the first line of the file, so a CSV header is row 1.
+type RowError struct {
+	Row     int    `bson:"row"`
+	SKU     string `bson:"sku,omitempty"`
+	Message string `bson:"message"`
+}
+
+// Job is one uploaded import file and its outcome.
+type Job struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	VendorID  primitive.ObjectID `bson:"vendor_id"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Format    Format             `bson:"format"`
+	FileName  string             `bson:"file_name"`
+	BlobKey   string             `bson:"blob_key"`
+	ResultKey string             `bson:"result_key,omitempty"`
+	Status    JobStatus          `bson:"status"`
+	// Failure is set when the file as a whole couldn't be processed.
+	Failure         string     `bson:"failure,omitempty"`
+	TotalRows       int        `bson:"total_rows"`
+	CreatedProducts int        `bson:"created_products"`
+	UpdatedProducts int        `bson:"updated_products"`
+	FailedRows      int        `bson:"failed_rows"`
+	Errors          []RowError `bson:"errors"`
+	CreatedAt       time.Time  `bson:"created_at"`
+	StartedAt       *time.Time `bson:"started_at,omitempty"`
+	FinishedAt      *time.Time `bson:"finished_at,omitempty"`
+}
+
+func NewJob(vendorID, userID primitive.ObjectID, format Format, fileName string) *Job {
+	id := primitive.NewObjectID()
+	return &Job{
+		ID:        id,
+		VendorID:  vendorID,
+		UserID:    userID,
+		Format:    format,
+		FileName:  fileName,
+		BlobKey:   "imports/" + vendorID.Hex() + "/" + id.Hex(),
+		Status:    QueuedJobStatus,
+		Errors:    []RowError{},
+		CreatedAt: time.Now(),
+	}
+}
+
+func (j *Job) ToResponse() JobResponse {
+	errors := make([]RowErrorResponse, 0, len(j.Errors))
+	for _, e := range j.Errors {
+		errors = append(errors, RowErrorResponse{Row: e.Row, SKU: e.SKU, Message: e.Message})
+	}
+	resp := JobResponse{
+		ID:              j.ID.Hex(),
+		Format:          string(j.Format),
+		FileName:        j.FileName,
+		Status:          string(j.Status),
+		Failure:         j.Failure,
+		TotalRows:       j.TotalRows,
+		CreatedProducts: j.CreatedProducts,
+		UpdatedProducts: j.UpdatedProducts,
+		FailedRows:      j.FailedRows,
+		Errors:          errors,
+		ResultsReady:    j.ResultKey != "",
+		CreatedAt:       j.CreatedAt.Format(time.RFC3339),
+	}
+	if j.StartedAt != nil {
+		resp.StartedAt = j.StartedAt.Format(time.RFC3339)
+	}
+	if j.FinishedAt != nil {
+		resp.FinishedAt = j.FinishedAt.Format(time.RFC3339)
+	}
+	return resp
+}
@@ -0,0 +1,115 @@
+package productio
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	Create(ctx context.Context, job *Job) error
+	GetForVendor(ctx context.Context, vendorID, id primitive.ObjectID) (*Job, error)
+	ListByVendor(ctx context.Context, vendorID primitive.ObjectID, page, pageSize int) ([]Job, int64, error)
+	// Claim marks the oldest queued job running and returns it, or nil when
+	// there is none. Jobs left running since before staleBefore belong to a
+	// worker that died and are claimed again.
+	Claim(ctx context.Context, staleBefore time.Time) (*Job, error)
+	Finish(ctx context.Context, job *Job) error
+}
+
+type JobRepository struct {
+	collection *mongo.Collection
+}
+
+func NewJobRepository(db *mongo.Database) Repository {
+	return &JobRepository{
+		collection: db.Collection("import_jobs"),
+	}
+}
+
+func (r *JobRepository) Create(ctx context.Context, job *Job) error {
+	_, err := r.collection.InsertOne(ctx, job)
+	return err
+}
+
+func (r *JobRepository) GetForVendor(ctx context.Context, vendorID, id primitive.ObjectID) (*Job, error) {
+	var job Job
+	err := r.collection.FindOne(ctx, bson.M{"_id": id, "vendor_id": vendorID}).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrJobNotFound
+	}
+	return &job, err
+}
+
+func (r *JobRepository) ListByVendor(ctx context.Context, vendorID primitive.ObjectID, page, pageSize int) ([]Job, int64, error) {
+	filter := bson.M{"vendor_id": vendorID}
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	jobs := []Job{}
+	if err := cursor.All(ctx, &jobs); err != nil {
+		return nil, 0, err
+	}
+	return jobs, total, nil
+}
+
+func (r *JobRepository) Claim(ctx context.Context, staleBefore time.Time) (*Job, error) {
+	filter := bson.M{"$or": bson.A{
+		bson.M{"status": QueuedJobStatus},
+		bson.M{"status": RunningJobStatus, "started_at": bson.M{"$lt": staleBefore}},
+	}}
+	update := bson.M{"$set": bson.M{"status": RunningJobStatus, "started_at": time.Now()}}
+	opts := options.FindOneAndUpdate().
+		SetSort(bson.D{{Key: "created_at", Value: 1}}).
+		SetReturnDocument(options.After)
+
+	var job Job
+	err := r.collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(&job)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// Finish only applies while the job is still running under the claim that
+// started it, so a stale worker can't overwrite a newer run's outcome.
+func (r *JobRepository) Finish(ctx context.Context, job *Job) error {
+	filter := bson.M{"_id": job.ID, "status": RunningJobStatus, "started_at": job.StartedAt}
+	update := bson.M{"$set": bson.M{
+		"status":           job.Status,
+		"failure":          job.Failure,
+		"result_key":       job.ResultKey,
+		"total_rows":       job.TotalRows,
+		"created_products": job.CreatedProducts,
+		"updated_products": job.UpdatedProducts,
+		"failed_rows":      job.FailedRows,
+		"errors":           job.Errors,
+		"finished_at":      job.FinishedAt,
+	}}
+	res, err := r.collection.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrJobNotRunning
+	}
+	return nil
+}
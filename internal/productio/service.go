@@ -0,0 +1,381 @@
+package productio
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/techrook/23-market/internal/category"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrJobNotFound     = errors.New("import job not found")
+	ErrJobNotRunning   = errors.New("import job is no longer running")
+	ErrResultsNotReady = errors.New("import results are not available yet")
+	ErrFileTooLarge    = errors.New("import file is too large")
+)
+
+// staleJobAfter is how long a job may stay running before another worker
+// assumes the first one died and takes it over.
+const staleJobAfter = 30 * time.Minute
+
+const exportBatchSize = 200
+
+// Inventory reads and sets on-hand counts. The inventory package implements
+// it.
+type Inventory interface {
+	OnHand(ctx context.Context, variantIDs []primitive.ObjectID) (map[primitive.ObjectID]int64, error)
+	SetOnHand(ctx context.Context, actorID primitive.ObjectID, p *product.Product, variantID primitive.ObjectID, onHand int64, reference string) error
+}
+
+type Service interface {
+	// StartImport stores the file and queues it; RunWorker picks it up.
+	StartImport(ctx context.Context, userID primitive.ObjectID, format Format, fileName string, file io.Reader) (*JobResponse, error)
+	ListImports(ctx context.Context, userID primitive.ObjectID, query ListJobsQuery) ([]JobResponse, int64, error)
+	GetImport(ctx context.Context, userID, jobID primitive.ObjectID) (*JobResponse, error)
+	// OpenResults returns the per-row outcome file of a finished import, in
+	// the same format as the upload.
+	OpenResults(ctx context.Context, userID, jobID primitive.ObjectID) (*Job, io.ReadCloser, error)
+	// RunNext processes the oldest queued import and reports whether there
+	// was one.
+	RunNext(ctx context.Context) (bool, error)
+
+	// Export writes the caller's whole catalogue to w in a layout StartImport
+	// accepts back unchanged.
+	Export(ctx context.Context, userID primitive.ObjectID, format Format, w io.Writer) error
+}
+
+type service struct {
+	jobRepo     Repository
+	productRepo product.Repository
+	vendorRepo  vendor.Repository
+	categories  category.Service
+	indexer     product.Indexer
+	prices      product.PriceHistory
+	moderator   product.Moderator
+	inventory   Inventory
+	blobs       storage.BlobStore
+}
+
+func NewService(jobRepo Repository, productRepo product.Repository, vendorRepo vendor.Repository, categories category.Service, indexer product.Indexer, prices product.PriceHistory, moderator product.Moderator, inventory Inventory, blobs storage.BlobStore) Service {
+	return &service{
+		jobRepo:     jobRepo,
+		productRepo: productRepo,
+		vendorRepo:  vendorRepo,
+		categories:  categories,
+		indexer:     indexer,
+		prices:      prices,
+		moderator:   moderator,
+		inventory:   inventory,
+		blobs:       blobs,
+	}
+}
+
+func (s *service) StartImport(ctx context.Context, userID primitive.ObjectID, format Format, fileName string, file io.Reader) (*JobResponse, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !v.CanSell() {
+		return nil, vendor.ErrVendorNotApproved
+	}
+
+	job := NewJob(v.ID, userID, format, fileName)
+	size, err := s.blobs.Put(ctx, job.BlobKey, io.LimitReader(file, MaxImportSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store import file: %w", err)
+	}
+	if size > MaxImportSize {
+		_ = s.blobs.Delete(ctx, job.BlobKey)
+		return nil, ErrFileTooLarge
+	}
+
+	if err := s.jobRepo.Create(ctx, job); err != nil {
+		_ = s.blobs.Delete(ctx, job.BlobKey)
+		return nil, err
+	}
+	resp := job.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) ListImports(ctx context.Context, userID primitive.ObjectID, query ListJobsQuery) ([]JobResponse, int64, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	jobs, total, err := s.jobRepo.ListByVendor(ctx, v.ID, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]JobResponse, 0, len(jobs))
+	for i := range jobs {
+		resp = append(resp, jobs[i].ToResponse())
+	}
+	return resp, total, nil
+}
+
+func (s *service) GetImport(ctx context.Context, userID, jobID primitive.ObjectID) (*JobResponse, error) {
+	job, err := s.vendorJob(ctx, userID, jobID)
+	if err != nil {
+		return nil, err
+	}
+	resp := job.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) OpenResults(ctx context.Context, userID, jobID primitive.ObjectID) (*Job, io.ReadCloser, error) {
+	job, err := s.vendorJob(ctx, userID, jobID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if job.ResultKey == "" {
+		return nil, nil, ErrResultsNotReady
+	}
+	blob, err := s.blobs.Open(ctx, job.ResultKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return job, blob, nil
+}
+
+func (s *service) RunNext(ctx context.Context) (bool, error) {
+	job, err := s.jobRepo.Claim(ctx, time.Now().Add(-staleJobAfter))
+	if err != nil || job == nil {
+		return false, err
+	}
+
+	if err := s.process(ctx, job); err != nil {
+		job.Status = FailedJobStatus
+		job.Failure = err.Error()
+	}
+	finished := time.Now()
+	job.FinishedAt = &finished
+	return true, s.jobRepo.Finish(ctx, job)
+}
+
+// process runs the import and stores its results file. An error means the
+// file as a whole couldn't be imported.
+func (s *service) process(ctx context.Context, job *Job) error {
+	v, err := s.vendorRepo.GetVendorByID(ctx, job.VendorID)
+	if err != nil {
+		return err
+	}
+	if !v.CanSell() {
+		return vendor.ErrVendorNotApproved
+	}
+
+	blob, err := s.blobs.Open(ctx, job.BlobKey)
+	if err != nil {
+		return err
+	}
+	lines, err := readLines(job.Format, blob)
+	blob.Close()
+	if err != nil {
+		return err
+	}
+	if len(lines) > MaxImportRows {
+		return fmt.Errorf("file has %d rows; the limit is %d", len(lines), MaxImportRows)
+	}
+
+	im := newImporter(s, job)
+	results := im.run(ctx, lines)
+
+	var buf bytes.Buffer
+	writer, err := newRecordWriter(job.Format, &buf, resultColumns)
+	if err != nil {
+		return err
+	}
+	for _, r := range results {
+		if err := writer.Write(r.record()); err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	resultKey := job.BlobKey + "-results"
+	if _, err := s.blobs.Put(ctx, resultKey, &buf); err != nil {
+		return fmt.Errorf("failed to store import results: %w", err)
+	}
+
+	job.ResultKey = resultKey
+	job.Status = CompletedJobStatus
+	job.TotalRows = len(lines)
+	job.CreatedProducts = im.created
+	job.UpdatedProducts = im.updated
+	job.Errors = []RowError{}
+	job.FailedRows = 0
+	for _, r := range results {
+		if r.Status != failedRow && r.Status != skippedRow {
+			continue
+		}
+		job.FailedRows++
+		if r.Status == failedRow && len(job.Errors) < maxInlineErrors {
+			job.Errors = append(job.Errors, RowError{Row: r.Row, SKU: r.SKU, Message: r.Error})
+		}
+	}
+	return nil
+}
+
+func (s *service) Export(ctx context.Context, userID primitive.ObjectID, format Format, w io.Writer) error {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	writer, err := newRecordWriter(format, w, Columns)
+	if err != nil {
+		return err
+	}
+
+	after := primitive.NilObjectID
+	for {
+		products, err := s.productRepo.ListByVendorAfter(ctx, v.ID, after, exportBatchSize)
+		if err != nil {
+			return err
+		}
+
+		var categoryIDs, variantIDs []primitive.ObjectID
+		for i := range products {
+			if products[i].CategoryID != nil {
+				categoryIDs = append(categoryIDs, *products[i].CategoryID)
+			}
+			for _, variant := range products[i].Variants {
+				variantIDs = append(variantIDs, variant.ID)
+			}
+		}
+		categories, err := s.categories.Lookup(ctx, categoryIDs)
+		if err != nil {
+			return err
+		}
+		onHand, err := s.inventory.OnHand(ctx, variantIDs)
+		if err != nil {
+			return err
+		}
+
+		for i := range products {
+			for _, record := range exportRecords(&products[i], categories, onHand) {
+				if err := writer.Write(record); err != nil {
+					return err
+				}
+			}
+		}
+
+		if len(products) < exportBatchSize {
+			break
+		}
+		after = products[len(products)-1].ID
+	}
+	return writer.Flush()
+}
+
+func (s *service) vendorJob(ctx context.Context, userID, jobID primitive.ObjectID) (*Job, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.jobRepo.GetForVendor(ctx, v.ID, jobID)
+}
+
+// exportRecords renders one row per variant. The handle is the product ID,
+// so re-importing the file updates the same products even if SKUs change.
+func exportRecords(p *product.Product, categories map[primitive.ObjectID]category.Category, onHand map[primitive.ObjectID]int64) []Record {
+	base := Record{
+		"handle":           p.ID.Hex(),
+		"title":            p.Title,
+		"description":      p.Description,
+		"status":           string(p.Status),
+		"type":             string(p.ProductType()),
+		"currency":         p.Currency,
+		"price":            strconv.FormatInt(p.Price, 10),
+		"compare_at_price": "",
+		"category":         "",
+		"attributes":       encodeAttributes(p.Attributes),
+	}
+	if p.CompareAtPrice != nil {
+		base["compare_at_price"] = strconv.FormatInt(*p.CompareAtPrice, 10)
+	}
+	if p.CategoryID != nil {
+		if c, ok := categories[*p.CategoryID]; ok {
+			base["category"] = c.Slug
+		}
+	}
+	for i := 0; i < product.MaxOptions; i++ {
+		name := ""
+		if i < len(p.Options) {
+			name = p.Options[i].Name
+		}
+		base[optionNameColumn(i)] = name
+	}
+
+	records := make([]Record, 0, len(p.Variants))
+	for _, variant := range p.Variants {
+		record := make(Record, len(Columns))
+		for k, v := range base {
+			record[k] = v
+		}
+		for i := 0; i < product.MaxOptions; i++ {
+			value := ""
+			if i < len(variant.OptionValues) {
+				value = variant.OptionValues[i]
+			}
+			record[optionValueColumn(i)] = value
+		}
+		record["sku"] = variant.SKU
+		record["variant_price"] = ""
+		if variant.Price != nil {
+			record["variant_price"] = strconv.FormatInt(*variant.Price, 10)
+		}
+		record["variant_compare_at_price"] = ""
+		if variant.CompareAtPrice != nil {
+			record["variant_compare_at_price"] = strconv.FormatInt(*variant.CompareAtPrice, 10)
+		}
+		record["weight_grams"] = strconv.FormatInt(variant.WeightGrams, 10)
+		record["barcode"] = variant.Barcode
+		record["stock"] = strconv.FormatInt(onHand[variant.ID], 10)
+		records = append(records, record)
+	}
+	return records
+}
+
+// encodeAttributes writes attributes as a JSON object with sorted keys.
+func encodeAttributes(attributes map[string]string) string {
+	if attributes == nil {
+		attributes = map[string]string{}
+	}
+	encoded, _ := json.Marshal(attributes)
+	return string(encoded)
+}
+
+func optionNameColumn(i int) string {
+	return "option" + strconv.Itoa(i+1) + "_name"
+}
+
+func optionValueColumn(i int) string {
+	return "option" + strconv.Itoa(i+1) + "_value"
+}
+
+// refresh logs a saved product's prices and updates its search entry. As in
+// product.Service, failures are only logged; the product is saved either way.
+func (s *service) refresh(ctx context.Context, p *product.Product) {
+	if err := s.prices.RecordPrices(ctx, p); err != nil {
+		log.Printf("⚠️ failed to record prices of product %s: %v", p.ID.Hex(), err)
+	}
+	if err := s.indexer.IndexProduct(ctx, p); err != nil {
+		log.Printf("⚠️ failed to index product %s: %v", p.ID.Hex(), err)
+	}
+}
+
+func normalizeSKU(sku string) string {
+	return strings.ToUpper(strings.TrimSpace(sku))
+}
@@ -0,0 +1,33 @@
+package productio
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunWorker processes queued imports every interval until ctx is cancelled.
+// Each tick drains the queue, one job at a time.
+func RunWorker(ctx context.Context, s Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for ctx.Err() == nil {
+				ran, err := s.RunNext(ctx)
+				if err != nil {
+					log.Printf("⚠️ catalogue import failed: %v", err)
+					break
+				}
+				if !ran {
+					break
+				}
+				log.Printf("✅ processed a catalogue import")
+			}
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package productqa
+
+type AskQuestionRequest struct {
+	Body string `json:"body" binding:"required,min=5,max=1000"`
+}
+
+type AnswerQuestionRequest struct {
+	Body string `json:"body" binding:"required,min=2,max=2000"`
+}
+
+// VoteRequest sets the caller's vote; 0 takes it back.
+type VoteRequest struct {
+	Value *int `json:"value" binding:"required,oneof=-1 0 1"`
+}
+
+type ReportRequest struct {
+	Reason string `json:"reason" binding:"required,min=3,max=500"`
+}
+
+type ModerateRequest struct {
+	Status string `json:"status" binding:"required,oneof=published hidden"`
+	Note   string `json:"note" binding:"omitempty,max=500"`
+}
+
+type ListQuestionsQuery struct {
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Sort     string `form:"sort" binding:"omitempty,oneof=newest top"`
+}
+
+type ListAnswersQuery struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type ListVendorQuestionsQuery struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+	// Unanswered leaves out questions the vendor has already answered.
+	Unanswered bool `form:"unanswered"`
+}
+
+type ModerationQueueQuery struct {
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Status   string `form:"status" binding:"omitempty,oneof=published flagged hidden"`
+}
+
+type ModerationResponse struct {
+	ModeratedBy string `json:"moderated_by,omitempty"`
+	ModeratedAt string `json:"moderated_at"`
+	Note        string `json:"note,omitempty"`
+}
+
+type AnswerResponse struct {
+	ID          string              `json:"id"`
+	QuestionID  string              `json:"question_id"`
+	ProductID   string              `json:"product_id"`
+	AuthorID    string              `json:"author_id"`
+	Body        string              `json:"body"`
+	Official    bool                `json:"official"`
+	Status      string              `json:"status"`
+	Upvotes     int64               `json:"upvotes"`
+	Downvotes   int64               `json:"downvotes"`
+	Score       int64               `json:"score"`
+	ReportCount *int64              `json:"report_count,omitempty"`
+	Moderation  *ModerationResponse `json:"moderation,omitempty"`
+	CreatedAt   string              `json:"created_at"`
+}
+
+type QuestionResponse struct {
+	ID                string `json:"id"`
+	ProductID         string `json:"product_id"`
+	AuthorID          string `json:"author_id"`
+	Body              string `json:"body"`
+	Status            string `json:"status"`
+	AnswerCount       int64  `json:"answer_count"`
+	HasOfficialAnswer bool   `json:"has_official_answer"`
+	Upvotes           int64  `json:"upvotes"`
+	Downvotes         int64  `json:"downvotes"`
+	Score             int64  `json:"score"`
+	// TopAnswers is a preview on the product page: official answers first,
+	// then the best voted.
+	TopAnswers  []AnswerResponse    `json:"top_answers,omitempty"`
+	ReportCount *int64              `json:"report_count,omitempty"`
+	Moderation  *ModerationResponse `json:"moderation,omitempty"`
+	CreatedAt   string              `json:"created_at"`
+}
@@ -0,0 +1,386 @@
+package productqa
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/user"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	qaService Service
+}
+
+func NewHandler(qaService Service) *Handler {
+	return &Handler{
+		qaService: qaService,
+	}
+}
+
+func (h *Handler) AskQuestion(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := idParam(c, "productID", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	var req AskQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	question, err := h.qaService.AskQuestion(c.Request.Context(), userID, productID, req)
+	if err != nil {
+		handleError(c, err, "Failed to ask question")
+		return
+	}
+	response.Created(c, question, "Question posted successfully")
+}
+
+func (h *Handler) ListQuestions(c *gin.Context) {
+	productID, ok := idParam(c, "productID", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	var query ListQuestionsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	query.Page, query.PageSize = pageDefaults(query.Page, query.PageSize)
+
+	questions, total, err := h.qaService.ListQuestions(c.Request.Context(), productID, query)
+	if err != nil {
+		handleError(c, err, "Failed to list questions")
+		return
+	}
+	response.Paginated(c, questions, query.Page, query.PageSize, int(total), "Questions retrieved successfully")
+}
+
+func (h *Handler) DeleteQuestion(c *gin.Context) {
+	actorID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	questionID, ok := idParam(c, "questionID", "Invalid question ID")
+	if !ok {
+		return
+	}
+	role, _ := c.Get("userRole")
+
+	if err := h.qaService.DeleteQuestion(c.Request.Context(), actorID, questionID, role == user.RoleAdmin); err != nil {
+		handleError(c, err, "Failed to delete question")
+		return
+	}
+	response.OK(c, nil, "Question deleted successfully")
+}
+
+func (h *Handler) AnswerQuestion(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	questionID, ok := idParam(c, "questionID", "Invalid question ID")
+	if !ok {
+		return
+	}
+
+	role, _ := c.Get("userRole")
+
+	var req AnswerQuestionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	answer, err := h.qaService.AnswerQuestion(c.Request.Context(), userID, questionID, role == user.RoleUser, req)
+	if err != nil {
+		handleError(c, err, "Failed to answer question")
+		return
+	}
+	response.Created(c, answer, "Answer posted successfully")
+}
+
+func (h *Handler) ListAnswers(c *gin.Context) {
+	questionID, ok := idParam(c, "questionID", "Invalid question ID")
+	if !ok {
+		return
+	}
+
+	var query ListAnswersQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	query.Page, query.PageSize = pageDefaults(query.Page, query.PageSize)
+
+	answers, total, err := h.qaService.ListAnswers(c.Request.Context(), questionID, query)
+	if err != nil {
+		handleError(c, err, "Failed to list answers")
+		return
+	}
+	response.Paginated(c, answers, query.Page, query.PageSize, int(total), "Answers retrieved successfully")
+}
+
+func (h *Handler) DeleteAnswer(c *gin.Context) {
+	actorID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	answerID, ok := idParam(c, "answerID", "Invalid answer ID")
+	if !ok {
+		return
+	}
+	role, _ := c.Get("userRole")
+
+	if err := h.qaService.DeleteAnswer(c.Request.Context(), actorID, answerID, role == user.RoleAdmin); err != nil {
+		handleError(c, err, "Failed to delete answer")
+		return
+	}
+	response.OK(c, nil, "Answer deleted successfully")
+}
+
+func (h *Handler) VoteQuestion(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	questionID, ok := idParam(c, "questionID", "Invalid question ID")
+	if !ok {
+		return
+	}
+
+	var req VoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	question, err := h.qaService.VoteQuestion(c.Request.Context(), userID, questionID, *req.Value)
+	if err != nil {
+		handleError(c, err, "Failed to vote on question")
+		return
+	}
+	response.OK(c, question, "Vote saved successfully")
+}
+
+func (h *Handler) VoteAnswer(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	answerID, ok := idParam(c, "answerID", "Invalid answer ID")
+	if !ok {
+		return
+	}
+
+	var req VoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	answer, err := h.qaService.VoteAnswer(c.Request.Context(), userID, answerID, *req.Value)
+	if err != nil {
+		handleError(c, err, "Failed to vote on answer")
+		return
+	}
+	response.OK(c, answer, "Vote saved successfully")
+}
+
+func (h *Handler) ReportQuestion(c *gin.Context) {
+	h.report(c, QuestionKind, "questionID", "Invalid question ID")
+}
+
+func (h *Handler) ReportAnswer(c *gin.Context) {
+	h.report(c, AnswerKind, "answerID", "Invalid answer ID")
+}
+
+func (h *Handler) report(c *gin.Context, kind Kind, param, invalidMessage string) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	targetID, ok := idParam(c, param, invalidMessage)
+	if !ok {
+		return
+	}
+
+	var req ReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	if err := h.qaService.Report(c.Request.Context(), userID, kind, targetID, req); err != nil {
+		handleError(c, err, "Failed to report "+string(kind))
+		return
+	}
+	response.OK(c, nil, "Report received; a moderator will review it")
+}
+
+func (h *Handler) ListVendorQuestions(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var query ListVendorQuestionsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	query.Page, query.PageSize = pageDefaults(query.Page, query.PageSize)
+
+	questions, total, err := h.qaService.ListVendorQuestions(c.Request.Context(), userID, query)
+	if err != nil {
+		handleError(c, err, "Failed to list questions")
+		return
+	}
+	response.Paginated(c, questions, query.Page, query.PageSize, int(total), "Questions retrieved successfully")
+}
+
+func (h *Handler) ListQuestionQueue(c *gin.Context) {
+	var query ModerationQueueQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	query.Page, query.PageSize = pageDefaults(query.Page, query.PageSize)
+
+	questions, total, err := h.qaService.ListQuestionQueue(c.Request.Context(), query)
+	if err != nil {
+		handleError(c, err, "Failed to list questions")
+		return
+	}
+	response.Paginated(c, questions, query.Page, query.PageSize, int(total), "Questions retrieved successfully")
+}
+
+func (h *Handler) ListAnswerQueue(c *gin.Context) {
+	var query ModerationQueueQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	query.Page, query.PageSize = pageDefaults(query.Page, query.PageSize)
+
+	answers, total, err := h.qaService.ListAnswerQueue(c.Request.Context(), query)
+	if err != nil {
+		handleError(c, err, "Failed to list answers")
+		return
+	}
+	response.Paginated(c, answers, query.Page, query.PageSize, int(total), "Answers retrieved successfully")
+}
+
+func (h *Handler) ModerateQuestion(c *gin.Context) {
+	adminID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	questionID, ok := idParam(c, "questionID", "Invalid question ID")
+	if !ok {
+		return
+	}
+
+	var req ModerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	question, err := h.qaService.ModerateQuestion(c.Request.Context(), adminID, questionID, req)
+	if err != nil {
+		handleError(c, err, "Failed to moderate question")
+		return
+	}
+	response.OK(c, question, "Question moderated successfully")
+}
+
+func (h *Handler) ModerateAnswer(c *gin.Context) {
+	adminID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	answerID, ok := idParam(c, "answerID", "Invalid answer ID")
+	if !ok {
+		return
+	}
+
+	var req ModerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	answer, err := h.qaService.ModerateAnswer(c.Request.Context(), adminID, answerID, req)
+	if err != nil {
+		handleError(c, err, "Failed to moderate answer")
+		return
+	}
+	response.OK(c, answer, "Answer moderated successfully")
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrQuestionNotFound):
+		response.NotFound(c, "Question", response.IsProduction(c))
+	case errors.Is(err, ErrAnswerNotFound):
+		response.NotFound(c, "Answer", response.IsProduction(c))
+	case errors.Is(err, product.ErrProductNotFound):
+		response.NotFound(c, "Product", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotFound):
+		response.NotFound(c, "Vendor", response.IsProduction(c))
+	case errors.Is(err, ErrNotAuthor):
+		response.Forbidden(c, "Only the author can delete this", response.IsProduction(c))
+	case errors.Is(err, ErrOwnPost):
+		response.Forbidden(c, "You can't vote on or report your own post", response.IsProduction(c))
+	case errors.Is(err, ErrCannotAnswer):
+		response.Forbidden(c, "Only shoppers and the product's seller can answer questions", response.IsProduction(c))
+	case errors.Is(err, ErrAlreadyReported):
+		response.Conflict(c, "You have already reported this", nil, response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func pageDefaults(page, pageSize int) (int, int) {
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = 20
+	}
+	return page, pageSize
+}
+
+func idParam(c *gin.Context, name, message string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param(name))
+	if err != nil {
+		response.BadRequest(c, message, nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func callerID(c *gin.Context) (primitive.ObjectID, bool) {
+	val, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	userID, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
@@ -0,0 +1,182 @@
+package productqa
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Status is where a question or answer stands in moderation. Posts go live
+// straight away; a report flags them for an admin, who can hide them.
+type Status string
+
+const (
+	PublishedStatus Status = "published"
+	// FlaggedStatus posts stay visible until an admin looks at them.
+	FlaggedStatus Status = "flagged"
+	HiddenStatus  Status = "hidden"
+)
+
+// visibleStatuses are shown on the product page.
+var visibleStatuses = []Status{PublishedStatus, FlaggedStatus}
+
+func (s Status) IsVisible() bool {
+	return s == PublishedStatus || s == FlaggedStatus
+}
+
+// Kind tells questions and answers apart where votes, reports and moderation
+// treat them alike.
+type Kind string
+
+const (
+	QuestionKind Kind = "question"
+	AnswerKind   Kind = "answer"
+)
+
+// Post holds what questions and answers have in common. The field names are
+// shared so votes, reports and moderation can update either collection.
+type Post struct {
+	ID             primitive.ObjectID  `bson:"_id,omitempty"`
+	ProductID      primitive.ObjectID  `bson:"product_id"`
+	VendorID       primitive.ObjectID  `bson:"vendor_id"`
+	AuthorID       primitive.ObjectID  `bson:"author_id"`
+	Body           string              `bson:"body"`
+	Status         Status              `bson:"status"`
+	Upvotes        int64               `bson:"upvotes"`
+	Downvotes      int64               `bson:"downvotes"`
+	Score          int64               `bson:"score"`
+	ReportCount    int64               `bson:"report_count"`
+	ModeratedBy    *primitive.ObjectID `bson:"moderated_by,omitempty"`
+	ModeratedAt    *time.Time          `bson:"moderated_at,omitempty"`
+	ModerationNote string              `bson:"moderation_note,omitempty"`
+	CreatedAt      time.Time           `bson:"created_at"`
+	UpdatedAt      time.Time           `bson:"updated_at"`
+}
+
+type Question struct {
+	Post `bson:",inline"`
+	// AnswerCount and OfficialAnswerCount only count visible answers.
+	AnswerCount         int64 `bson:"answer_count"`
+	OfficialAnswerCount int64 `bson:"official_answer_count"`
+}
+
+type Answer struct {
+	Post       `bson:",inline"`
+	QuestionID primitive.ObjectID `bson:"question_id"`
+	// Official is set when the vendor selling the product wrote the answer.
+	Official bool `bson:"official"`
+}
+
+// Vote is one user's up (+1) or down (-1) vote on a question or answer.
+type Vote struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Kind      Kind               `bson:"kind"`
+	TargetID  primitive.ObjectID `bson:"target_id"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Value     int                `bson:"value"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// Report records that a user flagged a post; a user can report a post once.
+type Report struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	Kind      Kind               `bson:"kind"`
+	TargetID  primitive.ObjectID `bson:"target_id"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Reason    string             `bson:"reason"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+func newPost(productID, vendorID, authorID primitive.ObjectID, body string) Post {
+	now := time.Now()
+	return Post{
+		ID:        primitive.NewObjectID(),
+		ProductID: productID,
+		VendorID:  vendorID,
+		AuthorID:  authorID,
+		Body:      body,
+		Status:    PublishedStatus,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func NewQuestion(productID, vendorID, authorID primitive.ObjectID, body string) *Question {
+	return &Question{Post: newPost(productID, vendorID, authorID, body)}
+}
+
+func NewAnswer(q *Question, authorID primitive.ObjectID, body string, official bool) *Answer {
+	return &Answer{
+		Post:       newPost(q.ProductID, q.VendorID, authorID, body),
+		QuestionID: q.ID,
+		Official:   official,
+	}
+}
+
+func (p *Post) moderationResponse() *ModerationResponse {
+	if p.ModeratedAt == nil {
+		return nil
+	}
+	resp := &ModerationResponse{
+		ModeratedAt: p.ModeratedAt.Format(time.RFC3339),
+		Note:        p.ModerationNote,
+	}
+	if p.ModeratedBy != nil {
+		resp.ModeratedBy = p.ModeratedBy.Hex()
+	}
+	return resp
+}
+
+// ToResponse leaves moderation details to the admin view.
+func (q *Question) ToResponse(topAnswers []Answer) QuestionResponse {
+	resp := QuestionResponse{
+		ID:                q.ID.Hex(),
+		ProductID:         q.ProductID.Hex(),
+		AuthorID:          q.AuthorID.Hex(),
+		Body:              q.Body,
+		Status:            string(q.Status),
+		AnswerCount:       q.AnswerCount,
+		HasOfficialAnswer: q.OfficialAnswerCount > 0,
+		Upvotes:           q.Upvotes,
+		Downvotes:         q.Downvotes,
+		Score:             q.Score,
+		CreatedAt:         q.CreatedAt.Format(time.RFC3339),
+	}
+	if topAnswers != nil {
+		resp.TopAnswers = make([]AnswerResponse, 0, len(topAnswers))
+		for i := range topAnswers {
+			resp.TopAnswers = append(resp.TopAnswers, topAnswers[i].ToResponse())
+		}
+	}
+	return resp
+}
+
+func (q *Question) ToAdminResponse() QuestionResponse {
+	resp := q.ToResponse(nil)
+	resp.ReportCount = &q.ReportCount
+	resp.Moderation = q.moderationResponse()
+	return resp
+}
+
+func (a *Answer) ToResponse() AnswerResponse {
+	return AnswerResponse{
+		ID:         a.ID.Hex(),
+		QuestionID: a.QuestionID.Hex(),
+		ProductID:  a.ProductID.Hex(),
+		AuthorID:   a.AuthorID.Hex(),
+		Body:       a.Body,
+		Official:   a.Official,
+		Status:     string(a.Status),
+		Upvotes:    a.Upvotes,
+		Downvotes:  a.Downvotes,
+		Score:      a.Score,
+		CreatedAt:  a.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (a *Answer) ToAdminResponse() AnswerResponse {
+	resp := a.ToResponse()
+	resp.ReportCount = &a.ReportCount
+	resp.Moderation = a.moderationResponse()
+	return resp
+}
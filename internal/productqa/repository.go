@@ -0,0 +1,319 @@
+package productqa
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	CreateQuestion(ctx context.Context, q *Question) error
+	GetQuestion(ctx context.Context, id primitive.ObjectID) (*Question, error)
+	// ListQuestions returns a product's visible questions, newest or top
+	// voted first.
+	ListQuestions(ctx context.Context, productID primitive.ObjectID, sort string, page, pageSize int) ([]Question, int64, error)
+	ListVendorQuestions(ctx context.Context, vendorID primitive.ObjectID, unanswered bool, page, pageSize int) ([]Question, int64, error)
+	ListQuestionsByStatus(ctx context.Context, status Status, page, pageSize int) ([]Question, int64, error)
+	// DeleteQuestion removes a question along with its answers and their
+	// votes and reports.
+	DeleteQuestion(ctx context.Context, id primitive.ObjectID) error
+	AdjustAnswerCounts(ctx context.Context, questionID primitive.ObjectID, answers, official int64) error
+
+	CreateAnswer(ctx context.Context, a *Answer) error
+	GetAnswer(ctx context.Context, id primitive.ObjectID) (*Answer, error)
+	ListAnswers(ctx context.Context, questionID primitive.ObjectID, page, pageSize int) ([]Answer, int64, error)
+	// TopAnswers returns up to perQuestion visible answers for each question,
+	// official ones first.
+	TopAnswers(ctx context.Context, questionIDs []primitive.ObjectID, perQuestion int) (map[primitive.ObjectID][]Answer, error)
+	ListAnswersByStatus(ctx context.Context, status Status, page, pageSize int) ([]Answer, int64, error)
+	DeleteAnswer(ctx context.Context, id primitive.ObjectID) (*Answer, error)
+
+	// SetVote records the user's vote on a post, 0 removing it, and returns
+	// the value it replaced.
+	SetVote(ctx context.Context, kind Kind, targetID, userID primitive.ObjectID, value int) (int, error)
+	AdjustVotes(ctx context.Context, kind Kind, targetID primitive.ObjectID, up, down int64) error
+	// AddReport records a report and flags the post if it was published. A
+	// second report by the same user fails with ErrAlreadyReported.
+	AddReport(ctx context.Context, report *Report) error
+	// SetStatus moderates a post and returns the status it had before.
+	SetStatus(ctx context.Context, kind Kind, id primitive.ObjectID, status Status, moderatorID primitive.ObjectID, note string) (Status, error)
+}
+
+type QARepository struct {
+	questions *mongo.Collection
+	answers   *mongo.Collection
+	votes     *mongo.Collection
+	reports   *mongo.Collection
+}
+
+func NewQARepository(db *mongo.Database) Repository {
+	return &QARepository{
+		questions: db.Collection("product_questions"),
+		answers:   db.Collection("product_answers"),
+		votes:     db.Collection("qa_votes"),
+		reports:   db.Collection("qa_reports"),
+	}
+}
+
+func (r *QARepository) CreateQuestion(ctx context.Context, q *Question) error {
+	_, err := r.questions.InsertOne(ctx, q)
+	return err
+}
+
+func (r *QARepository) GetQuestion(ctx context.Context, id primitive.ObjectID) (*Question, error) {
+	var q Question
+	err := r.questions.FindOne(ctx, bson.M{"_id": id}).Decode(&q)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrQuestionNotFound
+	}
+	return &q, err
+}
+
+func (r *QARepository) ListQuestions(ctx context.Context, productID primitive.ObjectID, sort string, page, pageSize int) ([]Question, int64, error) {
+	filter := bson.M{"product_id": productID, "status": bson.M{"$in": visibleStatuses}}
+	order := bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+	if sort == "top" {
+		order = bson.D{{Key: "score", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+	}
+	var questions []Question
+	total, err := findPage(ctx, r.questions, filter, order, page, pageSize, &questions)
+	return questions, total, err
+}
+
+func (r *QARepository) ListVendorQuestions(ctx context.Context, vendorID primitive.ObjectID, unanswered bool, page, pageSize int) ([]Question, int64, error) {
+	filter := bson.M{"vendor_id": vendorID, "status": bson.M{"$in": visibleStatuses}}
+	if unanswered {
+		filter["official_answer_count"] = bson.M{"$lte": 0}
+	}
+	var questions []Question
+	total, err := findPage(ctx, r.questions, filter, bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}, page, pageSize, &questions)
+	return questions, total, err
+}
+
+func (r *QARepository) ListQuestionsByStatus(ctx context.Context, status Status, page, pageSize int) ([]Question, int64, error) {
+	var questions []Question
+	total, err := findPage(ctx, r.questions, bson.M{"status": status}, queueOrder, page, pageSize, &questions)
+	return questions, total, err
+}
+
+func (r *QARepository) DeleteQuestion(ctx context.Context, id primitive.ObjectID) error {
+	res, err := r.questions.DeleteOne(ctx, bson.M{"_id": id})
+	if err != nil {
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return ErrQuestionNotFound
+	}
+
+	cursor, err := r.answers.Find(ctx, bson.M{"question_id": id}, options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return err
+	}
+	var answers []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &answers); err != nil {
+		return err
+	}
+	targets := []primitive.ObjectID{id}
+	for _, a := range answers {
+		targets = append(targets, a.ID)
+	}
+	if _, err := r.answers.DeleteMany(ctx, bson.M{"question_id": id}); err != nil {
+		return err
+	}
+	return r.deleteFeedback(ctx, targets)
+}
+
+func (r *QARepository) AdjustAnswerCounts(ctx context.Context, questionID primitive.ObjectID, answers, official int64) error {
+	_, err := r.questions.UpdateOne(ctx,
+		bson.M{"_id": questionID},
+		bson.M{"$inc": bson.M{"answer_count": answers, "official_answer_count": official}},
+	)
+	return err
+}
+
+func (r *QARepository) CreateAnswer(ctx context.Context, a *Answer) error {
+	_, err := r.answers.InsertOne(ctx, a)
+	return err
+}
+
+func (r *QARepository) GetAnswer(ctx context.Context, id primitive.ObjectID) (*Answer, error) {
+	var a Answer
+	err := r.answers.FindOne(ctx, bson.M{"_id": id}).Decode(&a)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrAnswerNotFound
+	}
+	return &a, err
+}
+
+// answerOrder puts the vendor's official answers first, then the most
+// helpful, then the oldest.
+var answerOrder = bson.D{{Key: "official", Value: -1}, {Key: "score", Value: -1}, {Key: "created_at", Value: 1}, {Key: "_id", Value: 1}}
+
+// queueOrder lists the most reported posts first in the moderation queue.
+var queueOrder = bson.D{{Key: "report_count", Value: -1}, {Key: "updated_at", Value: 1}, {Key: "_id", Value: 1}}
+
+func (r *QARepository) ListAnswers(ctx context.Context, questionID primitive.ObjectID, page, pageSize int) ([]Answer, int64, error) {
+	filter := bson.M{"question_id": questionID, "status": bson.M{"$in": visibleStatuses}}
+	var answers []Answer
+	total, err := findPage(ctx, r.answers, filter, answerOrder, page, pageSize, &answers)
+	return answers, total, err
+}
+
+func (r *QARepository) TopAnswers(ctx context.Context, questionIDs []primitive.ObjectID, perQuestion int) (map[primitive.ObjectID][]Answer, error) {
+	top := make(map[primitive.ObjectID][]Answer, len(questionIDs))
+	if len(questionIDs) == 0 {
+		return top, nil
+	}
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"question_id": bson.M{"$in": questionIDs}, "status": bson.M{"$in": visibleStatuses}}}},
+		{{Key: "$sort", Value: answerOrder}},
+		{{Key: "$group", Value: bson.M{"_id": "$question_id", "answers": bson.M{"$push": "$$ROOT"}}}},
+		{{Key: "$project", Value: bson.M{"answers": bson.M{"$slice": bson.A{"$answers", perQuestion}}}}},
+	}
+	cursor, err := r.answers.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		QuestionID primitive.ObjectID `bson:"_id"`
+		Answers    []Answer           `bson:"answers"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		top[row.QuestionID] = row.Answers
+	}
+	return top, nil
+}
+
+func (r *QARepository) ListAnswersByStatus(ctx context.Context, status Status, page, pageSize int) ([]Answer, int64, error) {
+	var answers []Answer
+	total, err := findPage(ctx, r.answers, bson.M{"status": status}, queueOrder, page, pageSize, &answers)
+	return answers, total, err
+}
+
+func (r *QARepository) DeleteAnswer(ctx context.Context, id primitive.ObjectID) (*Answer, error) {
+	var a Answer
+	err := r.answers.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&a)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrAnswerNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, r.deleteFeedback(ctx, []primitive.ObjectID{id})
+}
+
+func (r *QARepository) SetVote(ctx context.Context, kind Kind, targetID, userID primitive.ObjectID, value int) (int, error) {
+	filter := bson.M{"kind": kind, "target_id": targetID, "user_id": userID}
+
+	var previous Vote
+	var err error
+	if value == 0 {
+		err = r.votes.FindOneAndDelete(ctx, filter).Decode(&previous)
+	} else {
+		err = r.votes.FindOneAndUpdate(ctx, filter,
+			bson.M{
+				"$set":         bson.M{"value": value},
+				"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": time.Now()},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before),
+		).Decode(&previous)
+	}
+	if err == mongo.ErrNoDocuments {
+		return 0, nil
+	}
+	return previous.Value, err
+}
+
+func (r *QARepository) AdjustVotes(ctx context.Context, kind Kind, targetID primitive.ObjectID, up, down int64) error {
+	_, err := r.collection(kind).UpdateOne(ctx,
+		bson.M{"_id": targetID},
+		bson.M{"$inc": bson.M{"upvotes": up, "downvotes": down, "score": up - down}},
+	)
+	return err
+}
+
+func (r *QARepository) AddReport(ctx context.Context, report *Report) error {
+	if _, err := r.reports.InsertOne(ctx, report); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrAlreadyReported
+		}
+		return err
+	}
+	_, err := r.collection(report.Kind).UpdateOne(ctx,
+		bson.M{"_id": report.TargetID},
+		bson.A{bson.M{"$set": bson.M{
+			"report_count": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$report_count", 0}}, 1}},
+			"status": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$status", PublishedStatus}}, FlaggedStatus, "$status",
+			}},
+		}}},
+	)
+	return err
+}
+
+func (r *QARepository) SetStatus(ctx context.Context, kind Kind, id primitive.ObjectID, status Status, moderatorID primitive.ObjectID, note string) (Status, error) {
+	now := time.Now()
+	var previous Post
+	err := r.collection(kind).FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"status":          status,
+			"moderated_by":    moderatorID,
+			"moderated_at":    now,
+			"moderation_note": note,
+			"updated_at":      now,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		if kind == QuestionKind {
+			return "", ErrQuestionNotFound
+		}
+		return "", ErrAnswerNotFound
+	}
+	return previous.Status, err
+}
+
+func (r *QARepository) collection(kind Kind) *mongo.Collection {
+	if kind == QuestionKind {
+		return r.questions
+	}
+	return r.answers
+}
+
+// deleteFeedback removes the votes and reports left on deleted posts.
+func (r *QARepository) deleteFeedback(ctx context.Context, targets []primitive.ObjectID) error {
+	filter := bson.M{"target_id": bson.M{"$in": targets}}
+	if _, err := r.votes.DeleteMany(ctx, filter); err != nil {
+		return err
+	}
+	_, err := r.reports.DeleteMany(ctx, filter)
+	return err
+}
+
+// findPage runs a counted, skip/limit paged find into out.
+func findPage(ctx context.Context, collection *mongo.Collection, filter bson.M, order bson.D, page, pageSize int, out interface{}) (int64, error) {
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	opts := options.Find().
+		SetSort(order).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, err
+	}
+	return total, cursor.All(ctx, out)
+}
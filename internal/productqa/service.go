@@ -0,0 +1,417 @@
+package productqa
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/techrook/23-market/internal/notification"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/vendor"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrQuestionNotFound = errors.New("question not found")
+	ErrAnswerNotFound   = errors.New("answer not found")
+	ErrNotAuthor        = errors.New("only the author can delete this")
+	ErrOwnPost          = errors.New("you can't vote on or report your own post")
+	ErrAlreadyReported  = errors.New("you have already reported this")
+	ErrCannotAnswer     = errors.New("only shoppers and the product's seller can answer")
+)
+
+// topAnswersPerQuestion is how many answers the product page shows under
+// each question.
+const topAnswersPerQuestion = 3
+
+type Service interface {
+	AskQuestion(ctx context.Context, userID, productID primitive.ObjectID, req AskQuestionRequest) (*QuestionResponse, error)
+	ListQuestions(ctx context.Context, productID primitive.ObjectID, query ListQuestionsQuery) ([]QuestionResponse, int64, error)
+	DeleteQuestion(ctx context.Context, actorID, questionID primitive.ObjectID, isAdmin bool) error
+
+	// AnswerQuestion marks the answer official when the caller is the vendor
+	// selling the product. Apart from that vendor only shoppers may answer,
+	// so other sellers can't answer for a competitor.
+	AnswerQuestion(ctx context.Context, userID, questionID primitive.ObjectID, isShopper bool, req AnswerQuestionRequest) (*AnswerResponse, error)
+	ListAnswers(ctx context.Context, questionID primitive.ObjectID, query ListAnswersQuery) ([]AnswerResponse, int64, error)
+	DeleteAnswer(ctx context.Context, actorID, answerID primitive.ObjectID, isAdmin bool) error
+
+	VoteQuestion(ctx context.Context, userID, questionID primitive.ObjectID, value int) (*QuestionResponse, error)
+	VoteAnswer(ctx context.Context, userID, answerID primitive.ObjectID, value int) (*AnswerResponse, error)
+	Report(ctx context.Context, userID primitive.ObjectID, kind Kind, targetID primitive.ObjectID, req ReportRequest) error
+
+	// ListVendorQuestions is the vendor's inbox of questions on their
+	// products.
+	ListVendorQuestions(ctx context.Context, userID primitive.ObjectID, query ListVendorQuestionsQuery) ([]QuestionResponse, int64, error)
+
+	ListQuestionQueue(ctx context.Context, query ModerationQueueQuery) ([]QuestionResponse, int64, error)
+	ListAnswerQueue(ctx context.Context, query ModerationQueueQuery) ([]AnswerResponse, int64, error)
+	ModerateQuestion(ctx context.Context, adminID, questionID primitive.ObjectID, req ModerateRequest) (*QuestionResponse, error)
+	ModerateAnswer(ctx context.Context, adminID, answerID primitive.ObjectID, req ModerateRequest) (*AnswerResponse, error)
+}
+
+type service struct {
+	qaRepo      Repository
+	productRepo product.Repository
+	vendorRepo  vendor.Repository
+	notifier    notification.Notifier
+}
+
+func NewService(qaRepo Repository, productRepo product.Repository, vendorRepo vendor.Repository, notifier notification.Notifier) Service {
+	return &service{
+		qaRepo:      qaRepo,
+		productRepo: productRepo,
+		vendorRepo:  vendorRepo,
+		notifier:    notifier,
+	}
+}
+
+func (s *service) AskQuestion(ctx context.Context, userID, productID primitive.ObjectID, req AskQuestionRequest) (*QuestionResponse, error) {
+	p, err := s.productRepo.GetPublic(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	q := NewQuestion(p.ID, p.VendorID, userID, req.Body)
+	if err := s.qaRepo.CreateQuestion(ctx, q); err != nil {
+		return nil, err
+	}
+
+	v, err := s.vendorRepo.GetVendorByID(ctx, p.VendorID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load vendor %s to notify about question %s: %v", p.VendorID.Hex(), q.ID.Hex(), err)
+	} else if v.UserID != userID {
+		s.notify(ctx, v.UserID, notification.ProductQuestionAskedType,
+			"New question about "+p.Title,
+			q.Body,
+			map[string]string{"product_id": p.ID.Hex(), "question_id": q.ID.Hex()},
+		)
+	}
+
+	resp := q.ToResponse(nil)
+	return &resp, nil
+}
+
+func (s *service) ListQuestions(ctx context.Context, productID primitive.ObjectID, query ListQuestionsQuery) ([]QuestionResponse, int64, error) {
+	if _, err := s.productRepo.GetPublic(ctx, productID); err != nil {
+		return nil, 0, err
+	}
+	questions, total, err := s.qaRepo.ListQuestions(ctx, productID, query.Sort, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(questions))
+	for i := range questions {
+		ids = append(ids, questions[i].ID)
+	}
+	top, err := s.qaRepo.TopAnswers(ctx, ids, topAnswersPerQuestion)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := make([]QuestionResponse, 0, len(questions))
+	for i := range questions {
+		answers := top[questions[i].ID]
+		if answers == nil {
+			answers = []Answer{}
+		}
+		resp = append(resp, questions[i].ToResponse(answers))
+	}
+	return resp, total, nil
+}
+
+func (s *service) DeleteQuestion(ctx context.Context, actorID, questionID primitive.ObjectID, isAdmin bool) error {
+	q, err := s.qaRepo.GetQuestion(ctx, questionID)
+	if err != nil {
+		return err
+	}
+	if q.AuthorID != actorID && !isAdmin {
+		return ErrNotAuthor
+	}
+	return s.qaRepo.DeleteQuestion(ctx, questionID)
+}
+
+func (s *service) AnswerQuestion(ctx context.Context, userID, questionID primitive.ObjectID, isShopper bool, req AnswerQuestionRequest) (*AnswerResponse, error) {
+	q, err := s.visibleQuestion(ctx, questionID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.productRepo.GetPublic(ctx, q.ProductID)
+	if err != nil {
+		return nil, err
+	}
+
+	official := false
+	if !isShopper {
+		v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+		if err != nil && !errors.Is(err, vendor.ErrVendorNotFound) {
+			return nil, err
+		}
+		if err != nil || v.ID != q.VendorID {
+			return nil, ErrCannotAnswer
+		}
+		official = true
+	}
+
+	a := NewAnswer(q, userID, req.Body, official)
+	if err := s.qaRepo.CreateAnswer(ctx, a); err != nil {
+		return nil, err
+	}
+	s.adjustAnswerCounts(ctx, a, 1)
+
+	if q.AuthorID != userID {
+		title := "Your question about " + p.Title + " was answered"
+		if official {
+			title = p.Title + ": the seller answered your question"
+		}
+		s.notify(ctx, q.AuthorID, notification.ProductQuestionAnsweredType, title, a.Body,
+			map[string]string{"product_id": p.ID.Hex(), "question_id": q.ID.Hex(), "answer_id": a.ID.Hex()},
+		)
+	}
+
+	resp := a.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) ListAnswers(ctx context.Context, questionID primitive.ObjectID, query ListAnswersQuery) ([]AnswerResponse, int64, error) {
+	if _, err := s.visibleQuestion(ctx, questionID); err != nil {
+		return nil, 0, err
+	}
+	answers, total, err := s.qaRepo.ListAnswers(ctx, questionID, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]AnswerResponse, 0, len(answers))
+	for i := range answers {
+		resp = append(resp, answers[i].ToResponse())
+	}
+	return resp, total, nil
+}
+
+func (s *service) DeleteAnswer(ctx context.Context, actorID, answerID primitive.ObjectID, isAdmin bool) error {
+	a, err := s.qaRepo.GetAnswer(ctx, answerID)
+	if err != nil {
+		return err
+	}
+	if a.AuthorID != actorID && !isAdmin {
+		return ErrNotAuthor
+	}
+
+	deleted, err := s.qaRepo.DeleteAnswer(ctx, answerID)
+	if err != nil {
+		return err
+	}
+	if deleted.Status.IsVisible() {
+		s.adjustAnswerCounts(ctx, deleted, -1)
+	}
+	return nil
+}
+
+func (s *service) VoteQuestion(ctx context.Context, userID, questionID primitive.ObjectID, value int) (*QuestionResponse, error) {
+	q, err := s.visibleQuestion(ctx, questionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.vote(ctx, QuestionKind, &q.Post, userID, value); err != nil {
+		return nil, err
+	}
+	if q, err = s.qaRepo.GetQuestion(ctx, questionID); err != nil {
+		return nil, err
+	}
+	resp := q.ToResponse(nil)
+	return &resp, nil
+}
+
+func (s *service) VoteAnswer(ctx context.Context, userID, answerID primitive.ObjectID, value int) (*AnswerResponse, error) {
+	a, err := s.visibleAnswer(ctx, answerID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.vote(ctx, AnswerKind, &a.Post, userID, value); err != nil {
+		return nil, err
+	}
+	if a, err = s.qaRepo.GetAnswer(ctx, answerID); err != nil {
+		return nil, err
+	}
+	resp := a.ToResponse()
+	return &resp, nil
+}
+
+// vote records the vote and moves the post's tallies by the difference from
+// the user's previous vote, so changing or repeating a vote counts once.
+func (s *service) vote(ctx context.Context, kind Kind, post *Post, userID primitive.ObjectID, value int) error {
+	if post.AuthorID == userID {
+		return ErrOwnPost
+	}
+	previous, err := s.qaRepo.SetVote(ctx, kind, post.ID, userID, value)
+	if err != nil {
+		return err
+	}
+	if previous == value {
+		return nil
+	}
+	up := countIf(value == 1) - countIf(previous == 1)
+	down := countIf(value == -1) - countIf(previous == -1)
+	return s.qaRepo.AdjustVotes(ctx, kind, post.ID, up, down)
+}
+
+func (s *service) Report(ctx context.Context, userID primitive.ObjectID, kind Kind, targetID primitive.ObjectID, req ReportRequest) error {
+	var post *Post
+	switch kind {
+	case QuestionKind:
+		q, err := s.visibleQuestion(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		post = &q.Post
+	case AnswerKind:
+		a, err := s.visibleAnswer(ctx, targetID)
+		if err != nil {
+			return err
+		}
+		post = &a.Post
+	default:
+		return fmt.Errorf("unknown post kind %q", kind)
+	}
+	if post.AuthorID == userID {
+		return ErrOwnPost
+	}
+
+	return s.qaRepo.AddReport(ctx, &Report{
+		ID:        primitive.NewObjectID(),
+		Kind:      kind,
+		TargetID:  targetID,
+		UserID:    userID,
+		Reason:    req.Reason,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *service) ListVendorQuestions(ctx context.Context, userID primitive.ObjectID, query ListVendorQuestionsQuery) ([]QuestionResponse, int64, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	questions, total, err := s.qaRepo.ListVendorQuestions(ctx, v.ID, query.Unanswered, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]QuestionResponse, 0, len(questions))
+	for i := range questions {
+		resp = append(resp, questions[i].ToResponse(nil))
+	}
+	return resp, total, nil
+}
+
+func (s *service) ListQuestionQueue(ctx context.Context, query ModerationQueueQuery) ([]QuestionResponse, int64, error) {
+	questions, total, err := s.qaRepo.ListQuestionsByStatus(ctx, queueStatus(query), query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]QuestionResponse, 0, len(questions))
+	for i := range questions {
+		resp = append(resp, questions[i].ToAdminResponse())
+	}
+	return resp, total, nil
+}
+
+func (s *service) ListAnswerQueue(ctx context.Context, query ModerationQueueQuery) ([]AnswerResponse, int64, error) {
+	answers, total, err := s.qaRepo.ListAnswersByStatus(ctx, queueStatus(query), query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	resp := make([]AnswerResponse, 0, len(answers))
+	for i := range answers {
+		resp = append(resp, answers[i].ToAdminResponse())
+	}
+	return resp, total, nil
+}
+
+func (s *service) ModerateQuestion(ctx context.Context, adminID, questionID primitive.ObjectID, req ModerateRequest) (*QuestionResponse, error) {
+	if _, err := s.qaRepo.SetStatus(ctx, QuestionKind, questionID, Status(req.Status), adminID, req.Note); err != nil {
+		return nil, err
+	}
+	q, err := s.qaRepo.GetQuestion(ctx, questionID)
+	if err != nil {
+		return nil, err
+	}
+	resp := q.ToAdminResponse()
+	return &resp, nil
+}
+
+func (s *service) ModerateAnswer(ctx context.Context, adminID, answerID primitive.ObjectID, req ModerateRequest) (*AnswerResponse, error) {
+	previous, err := s.qaRepo.SetStatus(ctx, AnswerKind, answerID, Status(req.Status), adminID, req.Note)
+	if err != nil {
+		return nil, err
+	}
+	a, err := s.qaRepo.GetAnswer(ctx, answerID)
+	if err != nil {
+		return nil, err
+	}
+	// Hiding or restoring an answer changes what its question shows.
+	switch {
+	case previous.IsVisible() && !a.Status.IsVisible():
+		s.adjustAnswerCounts(ctx, a, -1)
+	case !previous.IsVisible() && a.Status.IsVisible():
+		s.adjustAnswerCounts(ctx, a, 1)
+	}
+	resp := a.ToAdminResponse()
+	return &resp, nil
+}
+
+func (s *service) visibleQuestion(ctx context.Context, id primitive.ObjectID) (*Question, error) {
+	q, err := s.qaRepo.GetQuestion(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !q.Status.IsVisible() {
+		return nil, ErrQuestionNotFound
+	}
+	return q, nil
+}
+
+func (s *service) visibleAnswer(ctx context.Context, id primitive.ObjectID) (*Answer, error) {
+	a, err := s.qaRepo.GetAnswer(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !a.Status.IsVisible() {
+		return nil, ErrAnswerNotFound
+	}
+	return a, nil
+}
+
+// adjustAnswerCounts is best effort: the answer itself has already been
+// written and the counts only drive previews and the vendor inbox.
+func (s *service) adjustAnswerCounts(ctx context.Context, a *Answer, delta int64) {
+	official := int64(0)
+	if a.Official {
+		official = delta
+	}
+	if err := s.qaRepo.AdjustAnswerCounts(ctx, a.QuestionID, delta, official); err != nil {
+		log.Printf("⚠️ Failed to update answer counts of question %s: %v", a.QuestionID.Hex(), err)
+	}
+}
+
+func (s *service) notify(ctx context.Context, userID primitive.ObjectID, kind notification.Type, title, body string, data map[string]string) {
+	if err := s.notifier.Notify(ctx, userID, kind, title, body, data); err != nil {
+		log.Printf("⚠️ Failed to notify user %s about %s: %v", userID.Hex(), kind, err)
+	}
+}
+
+func queueStatus(query ModerationQueueQuery) Status {
+	if query.Status == "" {
+		return FlaggedStatus
+	}
+	return Status(query.Status)
+}
+
+func countIf(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,141 @@
+package productreview
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type CreateReviewRequest struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Title  string `json:"title" binding:"omitempty,max=120"`
+	Body   string `json:"body" binding:"omitempty,max=5000"`
+}
+
+type UpdateReviewRequest struct {
+	Rating *int    `json:"rating,omitempty" binding:"omitempty,min=1,max=5"`
+	Title  *string `json:"title,omitempty" binding:"omitempty,max=120"`
+	Body   *string `json:"body,omitempty" binding:"omitempty,max=5000"`
+}
+
+type VoteRequest struct {
+	Helpful *bool `json:"helpful" binding:"required"`
+}
+
+type RespondRequest struct {
+	Body string `json:"body" binding:"required,min=1,max=2000"`
+}
+
+type ReportRequest struct {
+	Reason string `json:"reason" binding:"required,min=3,max=500"`
+}
+
+type ModerateRequest struct {
+	Status string `json:"status" binding:"required,oneof=published hidden"`
+	Note   string `json:"note" binding:"omitempty,max=500"`
+}
+
+type ListReviewsQuery struct {
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Sort     string `form:"sort" binding:"omitempty,oneof=newest helpful highest lowest"`
+	// Rating keeps only reviews with exactly this many stars.
+	Rating     int  `form:"rating" binding:"omitempty,min=1,max=5"`
+	WithPhotos bool `form:"with_photos"`
+}
+
+type ListVendorReviewsQuery struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+	// Unanswered leaves out reviews the vendor has already responded to.
+	Unanswered bool `form:"unanswered"`
+}
+
+type ModerationQueueQuery struct {
+	Page     int    `form:"page" binding:"omitempty,min=1"`
+	PageSize int    `form:"page_size" binding:"omitempty,min=1,max=100"`
+	Status   string `form:"status" binding:"omitempty,oneof=published flagged hidden"`
+}
+
+type PhotoResponse struct {
+	ID          string `json:"id"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+type VendorResponseResponse struct {
+	Body      string `json:"body"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type ModerationResponse struct {
+	ModeratedBy string `json:"moderated_by,omitempty"`
+	ModeratedAt string `json:"moderated_at"`
+	Note        string `json:"note,omitempty"`
+}
+
+type ReviewResponse struct {
+	ID               string                  `json:"id"`
+	ProductID        string                  `json:"product_id"`
+	VariantID        string                  `json:"variant_id"`
+	BuyerID          string                  `json:"buyer_id"`
+	Rating           int                     `json:"rating"`
+	Title            string                  `json:"title"`
+	Body             string                  `json:"body"`
+	VerifiedPurchase bool                    `json:"verified_purchase"`
+	Photos           []PhotoResponse         `json:"photos"`
+	VendorResponse   *VendorResponseResponse `json:"vendor_response,omitempty"`
+	Status           string                  `json:"status"`
+	HelpfulCount     int64                   `json:"helpful_count"`
+	UnhelpfulCount   int64                   `json:"unhelpful_count"`
+	ReportCount      *int64                  `json:"report_count,omitempty"`
+	Moderation       *ModerationResponse     `json:"moderation,omitempty"`
+	CreatedAt        string                  `json:"created_at"`
+	UpdatedAt        string                  `json:"updated_at"`
+}
+
+type RatingResponse struct {
+	ProductID string           `json:"product_id"`
+	Average   float64          `json:"average"`
+	Count     int64            `json:"count"`
+	Stars     map[string]int64 `json:"stars"`
+}
+
+type RecomputeResponse struct {
+	ProductsUpdated int   `json:"products_updated"`
+	ProductsReset   int64 `json:"products_reset"`
+}
+
+// RecordDeliveryRequest is one delivered order line, as reported by the order
+// flow. DeliveredAt defaults to now.
+type RecordDeliveryRequest struct {
+	LineID      string    `json:"line_id" binding:"required"`
+	OrderID     string    `json:"order_id" binding:"required"`
+	BuyerID     string    `json:"buyer_id" binding:"required"`
+	VendorID    string    `json:"vendor_id" binding:"required"`
+	ProductID   string    `json:"product_id" binding:"required"`
+	VariantID   string    `json:"variant_id" binding:"required"`
+	DeliveredAt time.Time `json:"delivered_at"`
+}
+
+func (r RecordDeliveryRequest) toLine() (DeliveredLine, error) {
+	var ids [6]primitive.ObjectID
+	for i, hex := range []string{r.LineID, r.OrderID, r.BuyerID, r.VendorID, r.ProductID, r.VariantID} {
+		id, err := primitive.ObjectIDFromHex(hex)
+		if err != nil {
+			return DeliveredLine{}, ErrInvalidDelivery
+		}
+		ids[i] = id
+	}
+	return DeliveredLine{
+		ID:          ids[0],
+		OrderID:     ids[1],
+		BuyerID:     ids[2],
+		VendorID:    ids[3],
+		ProductID:   ids[4],
+		VariantID:   ids[5],
+		DeliveredAt: r.DeliveredAt,
+	}, nil
+}
@@ -0,0 +1,442 @@
+package productreview
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/user"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	reviewService Service
+}
+
+func NewHandler(reviewService Service) *Handler {
+	return &Handler{
+		reviewService: reviewService,
+	}
+}
+
+func (h *Handler) CreateReview(c *gin.Context) {
+	buyerID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	productID, ok := idParam(c, "productID", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	var req CreateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	review, err := h.reviewService.CreateReview(c.Request.Context(), buyerID, productID, req)
+	if err != nil {
+		handleError(c, err, "Failed to create review")
+		return
+	}
+	response.Created(c, review, "Review posted successfully")
+}
+
+func (h *Handler) ListReviews(c *gin.Context) {
+	productID, ok := idParam(c, "productID", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	var query ListReviewsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	query.Page, query.PageSize = pageDefaults(query.Page, query.PageSize)
+
+	reviews, total, err := h.reviewService.ListReviews(c.Request.Context(), productID, query)
+	if err != nil {
+		handleError(c, err, "Failed to list reviews")
+		return
+	}
+	response.Paginated(c, reviews, query.Page, query.PageSize, int(total), "Reviews retrieved successfully")
+}
+
+func (h *Handler) GetRating(c *gin.Context) {
+	productID, ok := idParam(c, "productID", "Invalid product ID")
+	if !ok {
+		return
+	}
+
+	rating, err := h.reviewService.GetRating(c.Request.Context(), productID)
+	if err != nil {
+		handleError(c, err, "Failed to get rating")
+		return
+	}
+	response.OK(c, rating, "Rating retrieved successfully")
+}
+
+func (h *Handler) UpdateReview(c *gin.Context) {
+	buyerID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	reviewID, ok := idParam(c, "reviewID", "Invalid review ID")
+	if !ok {
+		return
+	}
+
+	var req UpdateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	review, err := h.reviewService.UpdateReview(c.Request.Context(), reviewID, buyerID, req)
+	if err != nil {
+		handleError(c, err, "Failed to update review")
+		return
+	}
+	response.OK(c, review, "Review updated successfully")
+}
+
+func (h *Handler) DeleteReview(c *gin.Context) {
+	actorID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	reviewID, ok := idParam(c, "reviewID", "Invalid review ID")
+	if !ok {
+		return
+	}
+	role, _ := c.Get("userRole")
+
+	if err := h.reviewService.DeleteReview(c.Request.Context(), reviewID, actorID, role == user.RoleAdmin); err != nil {
+		handleError(c, err, "Failed to delete review")
+		return
+	}
+	response.OK(c, nil, "Review deleted successfully")
+}
+
+func (h *Handler) AddPhoto(c *gin.Context) {
+	buyerID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	reviewID, ok := idParam(c, "reviewID", "Invalid review ID")
+	if !ok {
+		return
+	}
+
+	// Leave room for the multipart envelope around the file itself.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxPhotoSize+1<<20)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			handleError(c, err, "Failed to upload photo")
+			return
+		}
+		response.BadRequest(c, "A file is required", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		response.BadRequest(c, "Could not read uploaded file", nil, response.IsProduction(c))
+		return
+	}
+	defer file.Close()
+
+	review, err := h.reviewService.AddPhoto(c.Request.Context(), reviewID, buyerID, file)
+	if err != nil {
+		handleError(c, err, "Failed to upload photo")
+		return
+	}
+	response.Created(c, review, "Photo uploaded successfully")
+}
+
+func (h *Handler) DeletePhoto(c *gin.Context) {
+	actorID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	reviewID, ok := idParam(c, "reviewID", "Invalid review ID")
+	if !ok {
+		return
+	}
+	photoID, ok := idParam(c, "photoID", "Invalid photo ID")
+	if !ok {
+		return
+	}
+	role, _ := c.Get("userRole")
+
+	if err := h.reviewService.DeletePhoto(c.Request.Context(), reviewID, photoID, actorID, role == user.RoleAdmin); err != nil {
+		handleError(c, err, "Failed to delete photo")
+		return
+	}
+	response.OK(c, nil, "Photo deleted successfully")
+}
+
+func (h *Handler) GetPhoto(c *gin.Context) {
+	reviewID, ok := idParam(c, "reviewID", "Invalid review ID")
+	if !ok {
+		return
+	}
+	photoID, ok := idParam(c, "photoID", "Invalid photo ID")
+	if !ok {
+		return
+	}
+
+	photo, blob, err := h.reviewService.OpenPhoto(c.Request.Context(), reviewID, photoID)
+	if err != nil {
+		handleError(c, err, "Failed to get photo")
+		return
+	}
+	defer blob.Close()
+	c.DataFromReader(http.StatusOK, photo.Size, photo.ContentType, blob, map[string]string{
+		"Cache-Control": "public, max-age=86400",
+	})
+}
+
+func (h *Handler) Vote(c *gin.Context) {
+	var req VoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	h.vote(c, req.Helpful)
+}
+
+func (h *Handler) RemoveVote(c *gin.Context) {
+	h.vote(c, nil)
+}
+
+func (h *Handler) vote(c *gin.Context, helpful *bool) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	reviewID, ok := idParam(c, "reviewID", "Invalid review ID")
+	if !ok {
+		return
+	}
+
+	review, err := h.reviewService.Vote(c.Request.Context(), userID, reviewID, helpful)
+	if err != nil {
+		handleError(c, err, "Failed to vote on review")
+		return
+	}
+	response.OK(c, review, "Vote saved successfully")
+}
+
+func (h *Handler) Report(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	reviewID, ok := idParam(c, "reviewID", "Invalid review ID")
+	if !ok {
+		return
+	}
+
+	var req ReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	if err := h.reviewService.Report(c.Request.Context(), userID, reviewID, req); err != nil {
+		handleError(c, err, "Failed to report review")
+		return
+	}
+	response.OK(c, nil, "Report received; a moderator will review it")
+}
+
+func (h *Handler) Respond(c *gin.Context) {
+	vendorUserID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	reviewID, ok := idParam(c, "reviewID", "Invalid review ID")
+	if !ok {
+		return
+	}
+
+	var req RespondRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	review, err := h.reviewService.Respond(c.Request.Context(), vendorUserID, reviewID, req.Body)
+	if err != nil {
+		handleError(c, err, "Failed to respond to review")
+		return
+	}
+	response.OK(c, review, "Response saved successfully")
+}
+
+func (h *Handler) ListVendorReviews(c *gin.Context) {
+	userID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var query ListVendorReviewsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	query.Page, query.PageSize = pageDefaults(query.Page, query.PageSize)
+
+	reviews, total, err := h.reviewService.ListVendorReviews(c.Request.Context(), userID, query)
+	if err != nil {
+		handleError(c, err, "Failed to list reviews")
+		return
+	}
+	response.Paginated(c, reviews, query.Page, query.PageSize, int(total), "Reviews retrieved successfully")
+}
+
+func (h *Handler) ListQueue(c *gin.Context) {
+	var query ModerationQueueQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	query.Page, query.PageSize = pageDefaults(query.Page, query.PageSize)
+
+	reviews, total, err := h.reviewService.ListQueue(c.Request.Context(), query)
+	if err != nil {
+		handleError(c, err, "Failed to list reviews")
+		return
+	}
+	response.Paginated(c, reviews, query.Page, query.PageSize, int(total), "Reviews retrieved successfully")
+}
+
+func (h *Handler) Moderate(c *gin.Context) {
+	adminID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	reviewID, ok := idParam(c, "reviewID", "Invalid review ID")
+	if !ok {
+		return
+	}
+
+	var req ModerateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	review, err := h.reviewService.Moderate(c.Request.Context(), adminID, reviewID, req)
+	if err != nil {
+		handleError(c, err, "Failed to moderate review")
+		return
+	}
+	response.OK(c, review, "Review moderated successfully")
+}
+
+func (h *Handler) RecomputeRatings(c *gin.Context) {
+	result, err := h.reviewService.RecomputeRatings(c.Request.Context())
+	if err != nil {
+		handleError(c, err, "Failed to recompute ratings")
+		return
+	}
+	response.OK(c, result, "Ratings recomputed successfully")
+}
+
+// RecordDelivery takes a delivered order line from the order flow.
+func (h *Handler) RecordDelivery(c *gin.Context) {
+	var req RecordDeliveryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	line, err := req.toLine()
+	if err == nil {
+		err = h.reviewService.RecordDelivery(c.Request.Context(), line)
+	}
+	if err != nil {
+		handleError(c, err, "Failed to record delivery")
+		return
+	}
+	response.OK(c, nil, "Delivery recorded")
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.Is(err, ErrReviewNotFound):
+		response.NotFound(c, "Review", response.IsProduction(c))
+	case errors.Is(err, ErrPhotoNotFound):
+		response.NotFound(c, "Photo", response.IsProduction(c))
+	case errors.Is(err, product.ErrProductNotFound):
+		response.NotFound(c, "Product", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotFound):
+		response.NotFound(c, "Vendor", response.IsProduction(c))
+	case errors.Is(err, ErrNotVerifiedPurchase):
+		response.Forbidden(c, "Only buyers who received this product can review it", response.IsProduction(c))
+	case errors.Is(err, ErrNotReviewAuthor):
+		response.Forbidden(c, "Only the author can change this review", response.IsProduction(c))
+	case errors.Is(err, ErrNotReviewVendor):
+		response.Forbidden(c, "Only the product's vendor can respond", response.IsProduction(c))
+	case errors.Is(err, ErrOwnReview):
+		response.Forbidden(c, "You can't vote on or report your own review", response.IsProduction(c))
+	case errors.Is(err, ErrInvalidDelivery):
+		response.BadRequest(c, err.Error(), nil, response.IsProduction(c))
+	case errors.Is(err, ErrAlreadyReviewed):
+		response.Conflict(c, "You have already reviewed this product", nil, response.IsProduction(c))
+	case errors.Is(err, ErrAlreadyReported):
+		response.Conflict(c, "You have already reported this review", nil, response.IsProduction(c))
+	case errors.Is(err, ErrConcurrentEdit):
+		response.Conflict(c, "Review was changed concurrently, please retry", nil, response.IsProduction(c))
+	case errors.Is(err, ErrTooManyPhotos):
+		response.Conflict(c, "A review can have at most 5 photos", nil, response.IsProduction(c))
+	case errors.Is(err, ErrUnsupportedFileType):
+		response.BadRequest(c, "Only JPEG, PNG and WebP photos are accepted", nil, response.IsProduction(c))
+	case errors.Is(err, ErrFileTooLarge), errors.As(err, &tooLarge):
+		response.Error(c, http.StatusRequestEntityTooLarge, "FILE_TOO_LARGE", "Photo exceeds the 5MB limit", nil, response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func pageDefaults(page, pageSize int) (int, int) {
+	if page == 0 {
+		page = 1
+	}
+	if pageSize == 0 {
+		pageSize = 20
+	}
+	return page, pageSize
+}
+
+func idParam(c *gin.Context, name, message string) (primitive.ObjectID, bool) {
+	id, err := primitive.ObjectIDFromHex(c.Param(name))
+	if err != nil {
+		response.BadRequest(c, message, nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
+
+func callerID(c *gin.Context) (primitive.ObjectID, bool) {
+	val, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	userID, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
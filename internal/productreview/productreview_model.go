@@ -0,0 +1,227 @@
+package productreview
+
+import (
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	MaxPhotos    = 5
+	MaxPhotoSize = 5 << 20 // 5 MB
+)
+
+var allowedPhotoTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// Status is where a review stands in moderation. Reviews go live straight
+// away; an abuse report flags them for an admin, who can hide them. Hidden
+// reviews don't count towards the product's rating.
+type Status string
+
+const (
+	PublishedStatus Status = "published"
+	FlaggedStatus   Status = "flagged"
+	HiddenStatus    Status = "hidden"
+)
+
+var visibleStatuses = []Status{PublishedStatus, FlaggedStatus}
+
+func (s Status) IsVisible() bool {
+	return s == PublishedStatus || s == FlaggedStatus
+}
+
+// DeliveredLine is an order line that reached the buyer. Only buyers with a
+// delivered line for a product may review it.
+type DeliveredLine struct {
+	// ID is the order line's ID, which makes recording it idempotent.
+	ID          primitive.ObjectID `bson:"_id"`
+	OrderID     primitive.ObjectID `bson:"order_id"`
+	BuyerID     primitive.ObjectID `bson:"buyer_id"`
+	VendorID    primitive.ObjectID `bson:"vendor_id"`
+	ProductID   primitive.ObjectID `bson:"product_id"`
+	VariantID   primitive.ObjectID `bson:"variant_id"`
+	DeliveredAt time.Time          `bson:"delivered_at"`
+}
+
+type Photo struct {
+	ID          primitive.ObjectID `bson:"_id"`
+	BlobKey     string             `bson:"blob_key"`
+	ContentType string             `bson:"content_type"`
+	Size        int64              `bson:"size"`
+	CreatedAt   time.Time          `bson:"created_at"`
+}
+
+type VendorResponse struct {
+	Body      string    `bson:"body"`
+	CreatedAt time.Time `bson:"created_at"`
+	UpdatedAt time.Time `bson:"updated_at"`
+}
+
+type Review struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	ProductID primitive.ObjectID `bson:"product_id"`
+	VariantID primitive.ObjectID `bson:"variant_id"`
+	VendorID  primitive.ObjectID `bson:"vendor_id"`
+	BuyerID   primitive.ObjectID `bson:"buyer_id"`
+	// OrderID and LineID are the delivered purchase that verifies the review.
+	OrderID        primitive.ObjectID  `bson:"order_id"`
+	LineID         primitive.ObjectID  `bson:"line_id"`
+	Rating         int                 `bson:"rating"`
+	Title          string              `bson:"title"`
+	Body           string              `bson:"body"`
+	Photos         []Photo             `bson:"photos"`
+	Response       *VendorResponse     `bson:"response,omitempty"`
+	Status         Status              `bson:"status"`
+	HelpfulCount   int64               `bson:"helpful_count"`
+	UnhelpfulCount int64               `bson:"unhelpful_count"`
+	ReportCount    int64               `bson:"report_count"`
+	ModeratedBy    *primitive.ObjectID `bson:"moderated_by,omitempty"`
+	ModeratedAt    *time.Time          `bson:"moderated_at,omitempty"`
+	ModerationNote string              `bson:"moderation_note,omitempty"`
+	CreatedAt      time.Time           `bson:"created_at"`
+	UpdatedAt      time.Time           `bson:"updated_at"`
+}
+
+// Vote is one user's verdict on whether a review helped.
+type Vote struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	ReviewID  primitive.ObjectID `bson:"review_id"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Helpful   bool               `bson:"helpful"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// Report records that a user reported a review as abusive; a user can report
+// a review once.
+type Report struct {
+	ID        primitive.ObjectID `bson:"_id,omitempty"`
+	ReviewID  primitive.ObjectID `bson:"review_id"`
+	UserID    primitive.ObjectID `bson:"user_id"`
+	Reason    string             `bson:"reason"`
+	CreatedAt time.Time          `bson:"created_at"`
+}
+
+// Rating is a product's running rating totals over its visible reviews.
+// Stars counts reviews per star, keyed "1" to "5".
+type Rating struct {
+	ProductID primitive.ObjectID `bson:"_id"`
+	Count     int64              `bson:"count"`
+	Sum       int64              `bson:"sum"`
+	Stars     map[string]int64   `bson:"stars"`
+}
+
+func NewReview(line *DeliveredLine, req CreateReviewRequest) *Review {
+	now := time.Now()
+	return &Review{
+		ID:        primitive.NewObjectID(),
+		ProductID: line.ProductID,
+		VariantID: line.VariantID,
+		VendorID:  line.VendorID,
+		BuyerID:   line.BuyerID,
+		OrderID:   line.OrderID,
+		LineID:    line.ID,
+		Rating:    req.Rating,
+		Title:     req.Title,
+		Body:      req.Body,
+		Photos:    []Photo{},
+		Status:    PublishedStatus,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func NewPhoto(reviewID primitive.ObjectID, contentType string) Photo {
+	id := primitive.NewObjectID()
+	return Photo{
+		ID:          id,
+		BlobKey:     "reviews/" + reviewID.Hex() + "/" + id.Hex(),
+		ContentType: contentType,
+		CreatedAt:   time.Now(),
+	}
+}
+
+func (r *Review) Photo(id primitive.ObjectID) *Photo {
+	for i := range r.Photos {
+		if r.Photos[i].ID == id {
+			return &r.Photos[i]
+		}
+	}
+	return nil
+}
+
+func (r *Review) ToResponse() ReviewResponse {
+	resp := ReviewResponse{
+		ID:               r.ID.Hex(),
+		ProductID:        r.ProductID.Hex(),
+		VariantID:        r.VariantID.Hex(),
+		BuyerID:          r.BuyerID.Hex(),
+		Rating:           r.Rating,
+		Title:            r.Title,
+		Body:             r.Body,
+		VerifiedPurchase: true,
+		Photos:           make([]PhotoResponse, 0, len(r.Photos)),
+		Status:           string(r.Status),
+		HelpfulCount:     r.HelpfulCount,
+		UnhelpfulCount:   r.UnhelpfulCount,
+		CreatedAt:        r.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:        r.UpdatedAt.Format(time.RFC3339),
+	}
+	for _, p := range r.Photos {
+		resp.Photos = append(resp.Photos, PhotoResponse{
+			ID:          p.ID.Hex(),
+			URL:         "/product-reviews/" + r.ID.Hex() + "/photos/" + p.ID.Hex(),
+			ContentType: p.ContentType,
+			Size:        p.Size,
+		})
+	}
+	if r.Response != nil {
+		resp.VendorResponse = &VendorResponseResponse{
+			Body:      r.Response.Body,
+			CreatedAt: r.Response.CreatedAt.Format(time.RFC3339),
+			UpdatedAt: r.Response.UpdatedAt.Format(time.RFC3339),
+		}
+	}
+	return resp
+}
+
+func (r *Review) ToAdminResponse() ReviewResponse {
+	resp := r.ToResponse()
+	resp.ReportCount = &r.ReportCount
+	if r.ModeratedAt != nil {
+		resp.Moderation = &ModerationResponse{
+			ModeratedAt: r.ModeratedAt.Format(time.RFC3339),
+			Note:        r.ModerationNote,
+		}
+		if r.ModeratedBy != nil {
+			resp.Moderation.ModeratedBy = r.ModeratedBy.Hex()
+		}
+	}
+	return resp
+}
+
+func (r *Rating) ToResponse() RatingResponse {
+	resp := RatingResponse{
+		ProductID: r.ProductID.Hex(),
+		Count:     r.Count,
+		Stars:     make(map[string]int64, 5),
+	}
+	for star := 1; star <= 5; star++ {
+		key := strconv.Itoa(star)
+		resp.Stars[key] = r.Stars[key]
+	}
+	resp.Average = r.Average()
+	return resp
+}
+
+// Average is the mean star rating, 0 for a product without reviews.
+func (r *Rating) Average() float64 {
+	if r.Count <= 0 {
+		return 0
+	}
+	return float64(r.Sum) / float64(r.Count)
+}
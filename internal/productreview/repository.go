@@ -0,0 +1,442 @@
+package productreview
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	// RecordDelivery stores a delivered order line; recording a line that is
+	// already stored leaves it unchanged.
+	RecordDelivery(ctx context.Context, line *DeliveredLine) error
+	// FindDelivery returns the buyer's earliest delivered line for the
+	// product.
+	FindDelivery(ctx context.Context, buyerID, productID primitive.ObjectID) (*DeliveredLine, error)
+
+	Create(ctx context.Context, review *Review) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*Review, error)
+	ListByProduct(ctx context.Context, productID primitive.ObjectID, query ListReviewsQuery) ([]Review, int64, error)
+	ListByVendor(ctx context.Context, vendorID primitive.ObjectID, unanswered bool, page, pageSize int) ([]Review, int64, error)
+	ListByStatus(ctx context.Context, status Status, page, pageSize int) ([]Review, int64, error)
+	// Update applies set only if the rating is still expectedRating, so the
+	// caller's aggregate adjustment matches what was written.
+	Update(ctx context.Context, id primitive.ObjectID, expectedRating int, set bson.M) (*Review, error)
+	// Delete removes a review along with its votes and reports.
+	Delete(ctx context.Context, id primitive.ObjectID) (*Review, error)
+	SetResponse(ctx context.Context, id primitive.ObjectID, body string) (*Review, error)
+	// AddPhoto attaches a photo unless the review already has MaxPhotos.
+	AddPhoto(ctx context.Context, id primitive.ObjectID, photo Photo) (*Review, error)
+	RemovePhoto(ctx context.Context, id, photoID primitive.ObjectID) (*Review, error)
+
+	// SetVote records the user's vote, nil removing it, and returns the vote
+	// it replaced.
+	SetVote(ctx context.Context, reviewID, userID primitive.ObjectID, helpful *bool) (*bool, error)
+	AdjustVotes(ctx context.Context, reviewID primitive.ObjectID, helpful, unhelpful int64) error
+	// AddReport records a report and flags the review if it was published. A
+	// second report by the same user fails with ErrAlreadyReported.
+	AddReport(ctx context.Context, report *Report) error
+	// SetStatus moderates a review and returns the status it had before.
+	SetStatus(ctx context.Context, id primitive.ObjectID, status Status, moderatorID primitive.ObjectID, note string) (Status, error)
+
+	GetRating(ctx context.Context, productID primitive.ObjectID) (*Rating, error)
+	// AdjustRating adds delta reviews with the given star rating to a
+	// product's totals; a negative delta takes them away.
+	AdjustRating(ctx context.Context, productID primitive.ObjectID, rating int, delta int64) error
+	// AggregateRatings computes every product's totals from its visible
+	// reviews.
+	AggregateRatings(ctx context.Context) ([]Rating, error)
+	SetRating(ctx context.Context, rating Rating) error
+	// DeleteRatingsExcept removes the totals of every product not in ids and
+	// returns the products whose totals were removed.
+	DeleteRatingsExcept(ctx context.Context, ids []primitive.ObjectID) ([]primitive.ObjectID, error)
+	// Averages returns the average rating of each product that has reviews;
+	// search indexes it.
+	Averages(ctx context.Context, productIDs []primitive.ObjectID) (map[primitive.ObjectID]float64, error)
+}
+
+type ReviewRepository struct {
+	deliveries *mongo.Collection
+	reviews    *mongo.Collection
+	votes      *mongo.Collection
+	reports    *mongo.Collection
+	ratings    *mongo.Collection
+}
+
+func NewReviewRepository(db *mongo.Database) Repository {
+	return &ReviewRepository{
+		deliveries: db.Collection("delivered_order_lines"),
+		reviews:    db.Collection("product_reviews"),
+		votes:      db.Collection("product_review_votes"),
+		reports:    db.Collection("product_review_reports"),
+		ratings:    db.Collection("product_ratings"),
+	}
+}
+
+func (r *ReviewRepository) RecordDelivery(ctx context.Context, line *DeliveredLine) error {
+	_, err := r.deliveries.UpdateOne(ctx,
+		bson.M{"_id": line.ID},
+		bson.M{"$setOnInsert": line},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *ReviewRepository) FindDelivery(ctx context.Context, buyerID, productID primitive.ObjectID) (*DeliveredLine, error) {
+	var line DeliveredLine
+	err := r.deliveries.FindOne(ctx,
+		bson.M{"buyer_id": buyerID, "product_id": productID},
+		options.FindOne().SetSort(bson.D{{Key: "delivered_at", Value: 1}}),
+	).Decode(&line)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrNotVerifiedPurchase
+	}
+	return &line, err
+}
+
+func (r *ReviewRepository) Create(ctx context.Context, review *Review) error {
+	_, err := r.reviews.InsertOne(ctx, review)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrAlreadyReviewed
+	}
+	return err
+}
+
+func (r *ReviewRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*Review, error) {
+	var review Review
+	err := r.reviews.FindOne(ctx, bson.M{"_id": id}).Decode(&review)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReviewNotFound
+	}
+	return &review, err
+}
+
+var newestFirst = bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}
+
+// reviewOrders are the public sort options; ties fall back to newest first.
+var reviewOrders = map[string]bson.D{
+	"newest":  newestFirst,
+	"helpful": {{Key: "helpful_count", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+	"highest": {{Key: "rating", Value: -1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+	"lowest":  {{Key: "rating", Value: 1}, {Key: "created_at", Value: -1}, {Key: "_id", Value: -1}},
+}
+
+// queueOrder lists the most reported reviews first in the moderation queue.
+var queueOrder = bson.D{{Key: "report_count", Value: -1}, {Key: "updated_at", Value: 1}, {Key: "_id", Value: 1}}
+
+func (r *ReviewRepository) ListByProduct(ctx context.Context, productID primitive.ObjectID, query ListReviewsQuery) ([]Review, int64, error) {
+	filter := bson.M{"product_id": productID, "status": bson.M{"$in": visibleStatuses}}
+	if query.Rating != 0 {
+		filter["rating"] = query.Rating
+	}
+	if query.WithPhotos {
+		filter["photos.0"] = bson.M{"$exists": true}
+	}
+	order, ok := reviewOrders[query.Sort]
+	if !ok {
+		order = newestFirst
+	}
+	var reviews []Review
+	total, err := findPage(ctx, r.reviews, filter, order, query.Page, query.PageSize, &reviews)
+	return reviews, total, err
+}
+
+func (r *ReviewRepository) ListByVendor(ctx context.Context, vendorID primitive.ObjectID, unanswered bool, page, pageSize int) ([]Review, int64, error) {
+	filter := bson.M{"vendor_id": vendorID, "status": bson.M{"$in": visibleStatuses}}
+	if unanswered {
+		filter["response"] = bson.M{"$exists": false}
+	}
+	var reviews []Review
+	total, err := findPage(ctx, r.reviews, filter, newestFirst, page, pageSize, &reviews)
+	return reviews, total, err
+}
+
+func (r *ReviewRepository) ListByStatus(ctx context.Context, status Status, page, pageSize int) ([]Review, int64, error) {
+	var reviews []Review
+	total, err := findPage(ctx, r.reviews, bson.M{"status": status}, queueOrder, page, pageSize, &reviews)
+	return reviews, total, err
+}
+
+func (r *ReviewRepository) Update(ctx context.Context, id primitive.ObjectID, expectedRating int, set bson.M) (*Review, error) {
+	set["updated_at"] = time.Now()
+
+	var review Review
+	err := r.reviews.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id, "rating": expectedRating},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&review)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrConcurrentEdit
+	}
+	return &review, err
+}
+
+func (r *ReviewRepository) Delete(ctx context.Context, id primitive.ObjectID) (*Review, error) {
+	var review Review
+	err := r.reviews.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&review)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReviewNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	filter := bson.M{"review_id": id}
+	if _, err := r.votes.DeleteMany(ctx, filter); err != nil {
+		return nil, err
+	}
+	_, err = r.reports.DeleteMany(ctx, filter)
+	return &review, err
+}
+
+func (r *ReviewRepository) SetResponse(ctx context.Context, id primitive.ObjectID, body string) (*Review, error) {
+	now := time.Now()
+	var review Review
+	err := r.reviews.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id},
+		bson.A{bson.M{"$set": bson.M{
+			"response": bson.M{
+				"body":       body,
+				"created_at": bson.M{"$ifNull": bson.A{"$response.created_at", now}},
+				"updated_at": now,
+			},
+		}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&review)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReviewNotFound
+	}
+	return &review, err
+}
+
+func (r *ReviewRepository) AddPhoto(ctx context.Context, id primitive.ObjectID, photo Photo) (*Review, error) {
+	var review Review
+	err := r.reviews.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id, "photos." + strconv.Itoa(MaxPhotos-1): bson.M{"$exists": false}},
+		bson.M{"$push": bson.M{"photos": photo}, "$set": bson.M{"updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&review)
+	if err == mongo.ErrNoDocuments {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, ErrTooManyPhotos
+	}
+	return &review, err
+}
+
+func (r *ReviewRepository) RemovePhoto(ctx context.Context, id, photoID primitive.ObjectID) (*Review, error) {
+	var review Review
+	err := r.reviews.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id, "photos._id": photoID},
+		bson.M{"$pull": bson.M{"photos": bson.M{"_id": photoID}}, "$set": bson.M{"updated_at": time.Now()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&review)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrPhotoNotFound
+	}
+	return &review, err
+}
+
+func (r *ReviewRepository) SetVote(ctx context.Context, reviewID, userID primitive.ObjectID, helpful *bool) (*bool, error) {
+	filter := bson.M{"review_id": reviewID, "user_id": userID}
+
+	var previous Vote
+	var err error
+	if helpful == nil {
+		err = r.votes.FindOneAndDelete(ctx, filter).Decode(&previous)
+	} else {
+		err = r.votes.FindOneAndUpdate(ctx, filter,
+			bson.M{
+				"$set":         bson.M{"helpful": *helpful},
+				"$setOnInsert": bson.M{"_id": primitive.NewObjectID(), "created_at": time.Now()},
+			},
+			options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before),
+		).Decode(&previous)
+	}
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &previous.Helpful, nil
+}
+
+func (r *ReviewRepository) AdjustVotes(ctx context.Context, reviewID primitive.ObjectID, helpful, unhelpful int64) error {
+	_, err := r.reviews.UpdateOne(ctx,
+		bson.M{"_id": reviewID},
+		bson.M{"$inc": bson.M{"helpful_count": helpful, "unhelpful_count": unhelpful}},
+	)
+	return err
+}
+
+func (r *ReviewRepository) AddReport(ctx context.Context, report *Report) error {
+	if _, err := r.reports.InsertOne(ctx, report); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return ErrAlreadyReported
+		}
+		return err
+	}
+	_, err := r.reviews.UpdateOne(ctx,
+		bson.M{"_id": report.ReviewID},
+		bson.A{bson.M{"$set": bson.M{
+			"report_count": bson.M{"$add": bson.A{bson.M{"$ifNull": bson.A{"$report_count", 0}}, 1}},
+			"status": bson.M{"$cond": bson.A{
+				bson.M{"$eq": bson.A{"$status", PublishedStatus}}, FlaggedStatus, "$status",
+			}},
+		}}},
+	)
+	return err
+}
+
+func (r *ReviewRepository) SetStatus(ctx context.Context, id primitive.ObjectID, status Status, moderatorID primitive.ObjectID, note string) (Status, error) {
+	now := time.Now()
+	var previous Review
+	err := r.reviews.FindOneAndUpdate(ctx,
+		bson.M{"_id": id},
+		bson.M{"$set": bson.M{
+			"status":          status,
+			"moderated_by":    moderatorID,
+			"moderated_at":    now,
+			"moderation_note": note,
+			"updated_at":      now,
+		}},
+		options.FindOneAndUpdate().SetReturnDocument(options.Before),
+	).Decode(&previous)
+	if err == mongo.ErrNoDocuments {
+		return "", ErrReviewNotFound
+	}
+	return previous.Status, err
+}
+
+func (r *ReviewRepository) GetRating(ctx context.Context, productID primitive.ObjectID) (*Rating, error) {
+	var rating Rating
+	err := r.ratings.FindOne(ctx, bson.M{"_id": productID}).Decode(&rating)
+	if err == mongo.ErrNoDocuments {
+		return &Rating{ProductID: productID}, nil
+	}
+	return &rating, err
+}
+
+func (r *ReviewRepository) AdjustRating(ctx context.Context, productID primitive.ObjectID, rating int, delta int64) error {
+	_, err := r.ratings.UpdateOne(ctx,
+		bson.M{"_id": productID},
+		bson.M{"$inc": bson.M{
+			"count":                         delta,
+			"sum":                           delta * int64(rating),
+			"stars." + strconv.Itoa(rating): delta,
+		}},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *ReviewRepository) AggregateRatings(ctx context.Context) ([]Rating, error) {
+	group := bson.M{
+		"_id":   "$product_id",
+		"count": bson.M{"$sum": 1},
+		"sum":   bson.M{"$sum": "$rating"},
+	}
+	stars := bson.M{}
+	for star := 1; star <= 5; star++ {
+		key := strconv.Itoa(star)
+		group["s"+key] = bson.M{"$sum": bson.M{"$cond": bson.A{bson.M{"$eq": bson.A{"$rating", star}}, 1, 0}}}
+		stars[key] = "$s" + key
+	}
+
+	cursor, err := r.reviews.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"status": bson.M{"$in": visibleStatuses}}}},
+		{{Key: "$group", Value: group}},
+		{{Key: "$project", Value: bson.M{"count": 1, "sum": 1, "stars": stars}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	var ratings []Rating
+	if err := cursor.All(ctx, &ratings); err != nil {
+		return nil, err
+	}
+	return ratings, nil
+}
+
+func (r *ReviewRepository) SetRating(ctx context.Context, rating Rating) error {
+	_, err := r.ratings.ReplaceOne(ctx,
+		bson.M{"_id": rating.ProductID},
+		rating,
+		options.Replace().SetUpsert(true),
+	)
+	return err
+}
+
+func (r *ReviewRepository) DeleteRatingsExcept(ctx context.Context, ids []primitive.ObjectID) ([]primitive.ObjectID, error) {
+	cursor, err := r.ratings.Find(ctx,
+		bson.M{"_id": bson.M{"$nin": ids}},
+		options.Find().SetProjection(bson.M{"_id": 1}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var stale []Rating
+	if err := cursor.All(ctx, &stale); err != nil {
+		return nil, err
+	}
+	removed := make([]primitive.ObjectID, 0, len(stale))
+	for _, s := range stale {
+		removed = append(removed, s.ProductID)
+	}
+	if len(removed) == 0 {
+		return removed, nil
+	}
+	if _, err := r.ratings.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": removed}}); err != nil {
+		return nil, err
+	}
+	return removed, nil
+}
+
+func (r *ReviewRepository) Averages(ctx context.Context, productIDs []primitive.ObjectID) (map[primitive.ObjectID]float64, error) {
+	averages := make(map[primitive.ObjectID]float64, len(productIDs))
+	if len(productIDs) == 0 {
+		return averages, nil
+	}
+	cursor, err := r.ratings.Find(ctx, bson.M{"_id": bson.M{"$in": productIDs}})
+	if err != nil {
+		return nil, err
+	}
+	var ratings []Rating
+	if err := cursor.All(ctx, &ratings); err != nil {
+		return nil, err
+	}
+	for i := range ratings {
+		averages[ratings[i].ProductID] = ratings[i].Average()
+	}
+	return averages, nil
+}
+
+// findPage runs a counted, skip/limit paged find into out.
+func findPage(ctx context.Context, collection *mongo.Collection, filter bson.M, order bson.D, page, pageSize int, out interface{}) (int64, error) {
+	total, err := collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	opts := options.Find().
+		SetSort(order).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := collection.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, err
+	}
+	return total, cursor.All(ctx, out)
+}
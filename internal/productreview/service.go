@@ -0,0 +1,502 @@
+package productreview
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/techrook/23-market/internal/notification"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/storage"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrReviewNotFound      = errors.New("review not found")
+	ErrPhotoNotFound       = errors.New("photo not found")
+	ErrNotVerifiedPurchase = errors.New("only buyers who received this product can review it")
+	ErrAlreadyReviewed     = errors.New("product already reviewed by this buyer")
+	ErrConcurrentEdit      = errors.New("review was changed concurrently")
+	ErrNotReviewAuthor     = errors.New("only the author can change this review")
+	ErrNotReviewVendor     = errors.New("only the product's vendor can respond")
+	ErrOwnReview           = errors.New("you can't vote on or report your own review")
+	ErrAlreadyReported     = errors.New("you have already reported this review")
+	ErrTooManyPhotos       = errors.New("review already has the maximum number of photos")
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+	ErrFileTooLarge        = errors.New("file too large")
+	ErrInvalidDelivery     = errors.New("delivered line IDs must be valid object IDs")
+)
+
+// DeliveryRecorder is the hook the order flow uses once an order line has
+// been delivered, making the buyer eligible to review the product. The order
+// flow reaches it through POST /internal/deliveries. Recording the same line
+// twice is a no-op.
+type DeliveryRecorder interface {
+	RecordDelivery(ctx context.Context, line DeliveredLine) error
+}
+
+type Service interface {
+	DeliveryRecorder
+
+	CreateReview(ctx context.Context, buyerID, productID primitive.ObjectID, req CreateReviewRequest) (*ReviewResponse, error)
+	ListReviews(ctx context.Context, productID primitive.ObjectID, query ListReviewsQuery) ([]ReviewResponse, int64, error)
+	GetRating(ctx context.Context, productID primitive.ObjectID) (*RatingResponse, error)
+	UpdateReview(ctx context.Context, reviewID, buyerID primitive.ObjectID, req UpdateReviewRequest) (*ReviewResponse, error)
+	DeleteReview(ctx context.Context, reviewID, actorID primitive.ObjectID, isAdmin bool) error
+
+	AddPhoto(ctx context.Context, reviewID, buyerID primitive.ObjectID, file io.Reader) (*ReviewResponse, error)
+	DeletePhoto(ctx context.Context, reviewID, photoID, actorID primitive.ObjectID, isAdmin bool) error
+	// OpenPhoto streams a photo of a visible review. The caller must close
+	// the reader.
+	OpenPhoto(ctx context.Context, reviewID, photoID primitive.ObjectID) (*Photo, io.ReadCloser, error)
+
+	// Vote records whether the user found the review helpful; nil removes
+	// their vote.
+	Vote(ctx context.Context, userID, reviewID primitive.ObjectID, helpful *bool) (*ReviewResponse, error)
+	Report(ctx context.Context, userID, reviewID primitive.ObjectID, req ReportRequest) error
+
+	Respond(ctx context.Context, vendorUserID, reviewID primitive.ObjectID, body string) (*ReviewResponse, error)
+	// ListVendorReviews is the vendor's inbox of reviews on their products.
+	ListVendorReviews(ctx context.Context, userID primitive.ObjectID, query ListVendorReviewsQuery) ([]ReviewResponse, int64, error)
+
+	ListQueue(ctx context.Context, query ModerationQueueQuery) ([]ReviewResponse, int64, error)
+	Moderate(ctx context.Context, adminID, reviewID primitive.ObjectID, req ModerateRequest) (*ReviewResponse, error)
+	RecomputeRatings(ctx context.Context) (*RecomputeResponse, error)
+}
+
+type service struct {
+	reviewRepo  Repository
+	productRepo product.Repository
+	vendorRepo  vendor.Repository
+	indexer     product.Indexer
+	blobs       storage.BlobStore
+	notifier    notification.Notifier
+}
+
+func NewService(reviewRepo Repository, productRepo product.Repository, vendorRepo vendor.Repository, indexer product.Indexer, blobs storage.BlobStore, notifier notification.Notifier) Service {
+	return &service{
+		reviewRepo:  reviewRepo,
+		productRepo: productRepo,
+		vendorRepo:  vendorRepo,
+		indexer:     indexer,
+		blobs:       blobs,
+		notifier:    notifier,
+	}
+}
+
+func (s *service) RecordDelivery(ctx context.Context, line DeliveredLine) error {
+	if line.DeliveredAt.IsZero() {
+		line.DeliveredAt = time.Now()
+	}
+	return s.reviewRepo.RecordDelivery(ctx, &line)
+}
+
+func (s *service) CreateReview(ctx context.Context, buyerID, productID primitive.ObjectID, req CreateReviewRequest) (*ReviewResponse, error) {
+	p, err := s.productRepo.GetPublic(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	line, err := s.reviewRepo.FindDelivery(ctx, buyerID, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	review := NewReview(line, req)
+	if err := s.reviewRepo.Create(ctx, review); err != nil {
+		return nil, err
+	}
+	s.adjustRating(ctx, review.ProductID, review.Rating, 1)
+	s.reindex(ctx, review.ProductID)
+
+	v, err := s.vendorRepo.GetVendorByID(ctx, review.VendorID)
+	if err != nil {
+		log.Printf("⚠️ Failed to load vendor %s to notify about review %s: %v", review.VendorID.Hex(), review.ID.Hex(), err)
+	} else {
+		s.notify(ctx, v.UserID, notification.ProductReviewPostedType,
+			fmt.Sprintf("New %d-star review of %s", review.Rating, p.Title),
+			review.Title,
+			map[string]string{"product_id": p.ID.Hex(), "review_id": review.ID.Hex()},
+		)
+	}
+
+	resp := review.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) ListReviews(ctx context.Context, productID primitive.ObjectID, query ListReviewsQuery) ([]ReviewResponse, int64, error) {
+	if _, err := s.productRepo.GetPublic(ctx, productID); err != nil {
+		return nil, 0, err
+	}
+	reviews, total, err := s.reviewRepo.ListByProduct(ctx, productID, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	return toResponses(reviews, (*Review).ToResponse), total, nil
+}
+
+func (s *service) GetRating(ctx context.Context, productID primitive.ObjectID) (*RatingResponse, error) {
+	if _, err := s.productRepo.GetPublic(ctx, productID); err != nil {
+		return nil, err
+	}
+	rating, err := s.reviewRepo.GetRating(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	resp := rating.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) UpdateReview(ctx context.Context, reviewID, buyerID primitive.ObjectID, req UpdateReviewRequest) (*ReviewResponse, error) {
+	review, err := s.reviewRepo.GetByID(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.BuyerID != buyerID {
+		return nil, ErrNotReviewAuthor
+	}
+
+	set := bson.M{}
+	if req.Rating != nil {
+		set["rating"] = *req.Rating
+	}
+	if req.Title != nil {
+		set["title"] = *req.Title
+	}
+	if req.Body != nil {
+		set["body"] = *req.Body
+	}
+
+	updated, err := s.reviewRepo.Update(ctx, reviewID, review.Rating, set)
+	if err != nil {
+		return nil, err
+	}
+	if updated.Rating != review.Rating && updated.Status.IsVisible() {
+		s.adjustRating(ctx, updated.ProductID, review.Rating, -1)
+		s.adjustRating(ctx, updated.ProductID, updated.Rating, 1)
+		s.reindex(ctx, updated.ProductID)
+	}
+
+	resp := updated.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) DeleteReview(ctx context.Context, reviewID, actorID primitive.ObjectID, isAdmin bool) error {
+	review, err := s.reviewRepo.GetByID(ctx, reviewID)
+	if err != nil {
+		return err
+	}
+	if review.BuyerID != actorID && !isAdmin {
+		return ErrNotReviewAuthor
+	}
+
+	deleted, err := s.reviewRepo.Delete(ctx, reviewID)
+	if err != nil {
+		return err
+	}
+	if deleted.Status.IsVisible() {
+		s.adjustRating(ctx, deleted.ProductID, deleted.Rating, -1)
+		s.reindex(ctx, deleted.ProductID)
+	}
+	for _, photo := range deleted.Photos {
+		s.deleteBlob(ctx, photo.BlobKey)
+	}
+	return nil
+}
+
+func (s *service) AddPhoto(ctx context.Context, reviewID, buyerID primitive.ObjectID, file io.Reader) (*ReviewResponse, error) {
+	review, err := s.reviewRepo.GetByID(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.BuyerID != buyerID {
+		return nil, ErrNotReviewAuthor
+	}
+	if len(review.Photos) >= MaxPhotos {
+		return nil, ErrTooManyPhotos
+	}
+
+	// Trust the file's bytes, not the client's Content-Type header.
+	buffered := bufio.NewReader(file)
+	head, _ := buffered.Peek(512)
+	contentType := http.DetectContentType(head)
+	if !allowedPhotoTypes[contentType] {
+		return nil, ErrUnsupportedFileType
+	}
+
+	photo := NewPhoto(review.ID, contentType)
+	size, err := s.blobs.Put(ctx, photo.BlobKey, io.LimitReader(buffered, MaxPhotoSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to store review photo: %w", err)
+	}
+	if size > MaxPhotoSize {
+		_ = s.blobs.Delete(ctx, photo.BlobKey)
+		return nil, ErrFileTooLarge
+	}
+	photo.Size = size
+
+	updated, err := s.reviewRepo.AddPhoto(ctx, review.ID, photo)
+	if err != nil {
+		_ = s.blobs.Delete(ctx, photo.BlobKey)
+		return nil, err
+	}
+	resp := updated.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) DeletePhoto(ctx context.Context, reviewID, photoID, actorID primitive.ObjectID, isAdmin bool) error {
+	review, err := s.reviewRepo.GetByID(ctx, reviewID)
+	if err != nil {
+		return err
+	}
+	if review.BuyerID != actorID && !isAdmin {
+		return ErrNotReviewAuthor
+	}
+	photo := review.Photo(photoID)
+	if photo == nil {
+		return ErrPhotoNotFound
+	}
+
+	if _, err := s.reviewRepo.RemovePhoto(ctx, reviewID, photoID); err != nil {
+		return err
+	}
+	s.deleteBlob(ctx, photo.BlobKey)
+	return nil
+}
+
+func (s *service) OpenPhoto(ctx context.Context, reviewID, photoID primitive.ObjectID) (*Photo, io.ReadCloser, error) {
+	review, err := s.visibleReview(ctx, reviewID)
+	if err != nil {
+		return nil, nil, err
+	}
+	photo := review.Photo(photoID)
+	if photo == nil {
+		return nil, nil, ErrPhotoNotFound
+	}
+	blob, err := s.blobs.Open(ctx, photo.BlobKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrPhotoNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return photo, blob, nil
+}
+
+// Vote moves the review's tallies by the difference from the user's previous
+// vote, so changing or repeating a vote counts once.
+func (s *service) Vote(ctx context.Context, userID, reviewID primitive.ObjectID, helpful *bool) (*ReviewResponse, error) {
+	review, err := s.visibleReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.BuyerID == userID {
+		return nil, ErrOwnReview
+	}
+
+	previous, err := s.reviewRepo.SetVote(ctx, reviewID, userID, helpful)
+	if err != nil {
+		return nil, err
+	}
+	up := countIf(helpful != nil && *helpful) - countIf(previous != nil && *previous)
+	down := countIf(helpful != nil && !*helpful) - countIf(previous != nil && !*previous)
+	if up != 0 || down != 0 {
+		if err := s.reviewRepo.AdjustVotes(ctx, reviewID, up, down); err != nil {
+			return nil, err
+		}
+	}
+
+	if review, err = s.reviewRepo.GetByID(ctx, reviewID); err != nil {
+		return nil, err
+	}
+	resp := review.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) Report(ctx context.Context, userID, reviewID primitive.ObjectID, req ReportRequest) error {
+	review, err := s.visibleReview(ctx, reviewID)
+	if err != nil {
+		return err
+	}
+	if review.BuyerID == userID {
+		return ErrOwnReview
+	}
+
+	return s.reviewRepo.AddReport(ctx, &Report{
+		ID:        primitive.NewObjectID(),
+		ReviewID:  reviewID,
+		UserID:    userID,
+		Reason:    req.Reason,
+		CreatedAt: time.Now(),
+	})
+}
+
+func (s *service) Respond(ctx context.Context, vendorUserID, reviewID primitive.ObjectID, body string) (*ReviewResponse, error) {
+	review, err := s.visibleReview(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, vendorUserID)
+	if err != nil {
+		return nil, err
+	}
+	if review.VendorID != v.ID {
+		return nil, ErrNotReviewVendor
+	}
+
+	updated, err := s.reviewRepo.SetResponse(ctx, reviewID, body)
+	if err != nil {
+		return nil, err
+	}
+	s.notify(ctx, updated.BuyerID, notification.ProductReviewRespondedType,
+		v.BusinessName+" responded to your review",
+		body,
+		map[string]string{"product_id": updated.ProductID.Hex(), "review_id": updated.ID.Hex()},
+	)
+
+	resp := updated.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) ListVendorReviews(ctx context.Context, userID primitive.ObjectID, query ListVendorReviewsQuery) ([]ReviewResponse, int64, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	reviews, total, err := s.reviewRepo.ListByVendor(ctx, v.ID, query.Unanswered, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	return toResponses(reviews, (*Review).ToResponse), total, nil
+}
+
+func (s *service) ListQueue(ctx context.Context, query ModerationQueueQuery) ([]ReviewResponse, int64, error) {
+	status := FlaggedStatus
+	if query.Status != "" {
+		status = Status(query.Status)
+	}
+	reviews, total, err := s.reviewRepo.ListByStatus(ctx, status, query.Page, query.PageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	return toResponses(reviews, (*Review).ToAdminResponse), total, nil
+}
+
+func (s *service) Moderate(ctx context.Context, adminID, reviewID primitive.ObjectID, req ModerateRequest) (*ReviewResponse, error) {
+	previous, err := s.reviewRepo.SetStatus(ctx, reviewID, Status(req.Status), adminID, req.Note)
+	if err != nil {
+		return nil, err
+	}
+	review, err := s.reviewRepo.GetByID(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	// Hidden reviews don't count towards the product's rating.
+	switch {
+	case previous.IsVisible() && !review.Status.IsVisible():
+		s.adjustRating(ctx, review.ProductID, review.Rating, -1)
+		s.reindex(ctx, review.ProductID)
+	case !previous.IsVisible() && review.Status.IsVisible():
+		s.adjustRating(ctx, review.ProductID, review.Rating, 1)
+		s.reindex(ctx, review.ProductID)
+	}
+	resp := review.ToAdminResponse()
+	return &resp, nil
+}
+
+// RecomputeRatings rebuilds every product's rating from its visible reviews,
+// repairing any drift left by failed incremental updates.
+func (s *service) RecomputeRatings(ctx context.Context) (*RecomputeResponse, error) {
+	ratings, err := s.reviewRepo.AggregateRatings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(ratings))
+	for _, r := range ratings {
+		previous, err := s.reviewRepo.GetRating(ctx, r.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.reviewRepo.SetRating(ctx, r); err != nil {
+			return nil, err
+		}
+		if previous.Average() != r.Average() {
+			s.reindex(ctx, r.ProductID)
+		}
+		ids = append(ids, r.ProductID)
+	}
+
+	reset, err := s.reviewRepo.DeleteRatingsExcept(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range reset {
+		s.reindex(ctx, id)
+	}
+	return &RecomputeResponse{ProductsUpdated: len(ratings), ProductsReset: int64(len(reset))}, nil
+}
+
+func (s *service) visibleReview(ctx context.Context, id primitive.ObjectID) (*Review, error) {
+	review, err := s.reviewRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !review.Status.IsVisible() {
+		return nil, ErrReviewNotFound
+	}
+	return review, nil
+}
+
+// adjustRating is best effort: the review write has already succeeded and
+// RecomputeRatings repairs the totals if this fails.
+func (s *service) adjustRating(ctx context.Context, productID primitive.ObjectID, rating int, delta int64) {
+	if err := s.reviewRepo.AdjustRating(ctx, productID, rating, delta); err != nil {
+		log.Printf("⚠️ Failed to update rating for product %s: %v", productID.Hex(), err)
+	}
+}
+
+// reindex refreshes the product's search document after its rating changed.
+// Like adjustRating it is best effort; a search reindex catches up.
+func (s *service) reindex(ctx context.Context, productID primitive.ObjectID) {
+	p, err := s.productRepo.GetByID(ctx, productID)
+	if err == nil {
+		err = s.indexer.IndexProduct(ctx, p)
+	}
+	if err != nil && !errors.Is(err, product.ErrProductNotFound) {
+		log.Printf("⚠️ Failed to reindex product %s after a rating change: %v", productID.Hex(), err)
+	}
+}
+
+func (s *service) deleteBlob(ctx context.Context, key string) {
+	if err := s.blobs.Delete(ctx, key); err != nil && !errors.Is(err, storage.ErrNotFound) {
+		log.Printf("⚠️ Failed to delete review photo blob %s: %v", key, err)
+	}
+}
+
+func (s *service) notify(ctx context.Context, userID primitive.ObjectID, kind notification.Type, title, body string, data map[string]string) {
+	if err := s.notifier.Notify(ctx, userID, kind, title, body, data); err != nil {
+		log.Printf("⚠️ Failed to notify user %s about %s: %v", userID.Hex(), kind, err)
+	}
+}
+
+func toResponses(reviews []Review, convert func(*Review) ReviewResponse) []ReviewResponse {
+	resp := make([]ReviewResponse, 0, len(reviews))
+	for i := range reviews {
+		resp = append(resp, convert(&reviews[i]))
+	}
+	return resp
+}
+
+func countIf(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
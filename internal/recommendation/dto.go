@@ -0,0 +1,21 @@
+package recommendation
+
+import "github.com/techrook/23-market/internal/product"
+
+type RecommendationsQuery struct {
+	Limit    int    `form:"limit" binding:"omitempty,min=1,max=20"`
+	Currency string `form:"currency" binding:"omitempty,len=3,uppercase"`
+}
+
+type RecommendationsResponse struct {
+	BoughtTogether []product.ProductResponse `json:"bought_together"`
+	Similar        []product.ProductResponse `json:"similar"`
+	ComputedAt     string                    `json:"computed_at,omitempty"`
+}
+
+type RecomputeResponse struct {
+	Products       int   `json:"products"`
+	BoughtTogether int   `json:"bought_together"`
+	Similar        int   `json:"similar"`
+	Removed        int64 `json:"removed"`
+}
@@ -0,0 +1,69 @@
+package recommendation
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultLimit is how many products of each kind a page shows unless asked.
+const defaultLimit = 8
+
+type Handler struct {
+	recommendationService Service
+}
+
+func NewHandler(recommendationService Service) *Handler {
+	return &Handler{
+		recommendationService: recommendationService,
+	}
+}
+
+func (h *Handler) Recommendations(c *gin.Context) {
+	productID, err := primitive.ObjectIDFromHex(c.Param("productID"))
+	if err != nil {
+		response.BadRequest(c, "Invalid product ID", nil, response.IsProduction(c))
+		return
+	}
+
+	var query RecommendationsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Limit == 0 {
+		query.Limit = defaultLimit
+	}
+
+	recommendations, err := h.recommendationService.Recommendations(c.Request.Context(), productID, query)
+	if err != nil {
+		handleError(c, err, "Failed to get recommendations")
+		return
+	}
+	response.OK(c, recommendations, "Recommendations retrieved successfully")
+}
+
+func (h *Handler) Recompute(c *gin.Context) {
+	result, err := h.recommendationService.Recompute(c.Request.Context())
+	if err != nil {
+		handleError(c, err, "Failed to recompute recommendations")
+		return
+	}
+	response.OK(c, result, "Recommendations recomputed successfully")
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, product.ErrProductNotFound):
+		response.NotFound(c, "Product", response.IsProduction(c))
+	case errors.Is(err, product.ErrUnknownCurrency):
+		response.BadRequest(c, "Unknown currency code", nil, response.IsProduction(c))
+	case errors.Is(err, product.ErrCurrencyNotShown):
+		response.BadRequest(c, "Prices can't be shown in that currency", nil, response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
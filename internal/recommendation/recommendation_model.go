@@ -0,0 +1,39 @@
+package recommendation
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Kind string
+
+const (
+	BoughtTogetherKind Kind = "bought_together"
+	SimilarKind        Kind = "similar"
+)
+
+// Neighbour is one recommended product. Score is the number of shared orders
+// for bought-together lists and a similarity between 0 and 1 for similar
+// items; either way higher is better.
+type Neighbour struct {
+	ProductID primitive.ObjectID `bson:"product_id"`
+	Score     float64            `bson:"score"`
+}
+
+// Neighbours are the precomputed recommendations for one product, best
+// first. Products that are inactive or whose vendor can't sell at compute
+// time get no document and appear in no list.
+type Neighbours struct {
+	ProductID      primitive.ObjectID `bson:"_id"`
+	BoughtTogether []Neighbour        `bson:"bought_together"`
+	Similar        []Neighbour        `bson:"similar"`
+	ComputedAt     time.Time          `bson:"computed_at"`
+}
+
+func (n *Neighbours) list(kind Kind) []Neighbour {
+	if kind == BoughtTogetherKind {
+		return n.BoughtTogether
+	}
+	return n.Similar
+}
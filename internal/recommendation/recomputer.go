@@ -0,0 +1,28 @@
+package recommendation
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunRecomputer rebuilds recommendations every interval until ctx is
+// cancelled.
+func RunRecomputer(ctx context.Context, s Service, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			result, err := s.Recompute(ctx)
+			if err != nil {
+				log.Printf("⚠️ recommendation recompute failed: %v", err)
+				continue
+			}
+			log.Printf("✅ recomputed recommendations for %d products", result.Products)
+		}
+	}
+}
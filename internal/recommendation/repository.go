@@ -0,0 +1,62 @@
+package recommendation
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	// Get returns an empty set when the product has no recommendations.
+	Get(ctx context.Context, productID primitive.ObjectID) (*Neighbours, error)
+	// SaveAll replaces the stored recommendations of each product given.
+	SaveAll(ctx context.Context, neighbours []Neighbours) error
+	// DeleteComputedBefore drops recommendations a recompute didn't refresh.
+	DeleteComputedBefore(ctx context.Context, t time.Time) (int64, error)
+}
+
+type NeighbourRepository struct {
+	collection *mongo.Collection
+}
+
+func NewNeighbourRepository(db *mongo.Database) Repository {
+	return &NeighbourRepository{
+		collection: db.Collection("product_neighbours"),
+	}
+}
+
+func (r *NeighbourRepository) Get(ctx context.Context, productID primitive.ObjectID) (*Neighbours, error) {
+	var n Neighbours
+	err := r.collection.FindOne(ctx, bson.M{"_id": productID}).Decode(&n)
+	if err == mongo.ErrNoDocuments {
+		return &Neighbours{ProductID: productID}, nil
+	}
+	return &n, err
+}
+
+func (r *NeighbourRepository) SaveAll(ctx context.Context, neighbours []Neighbours) error {
+	if len(neighbours) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(neighbours))
+	for i := range neighbours {
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": neighbours[i].ProductID}).
+			SetReplacement(neighbours[i]).
+			SetUpsert(true))
+	}
+	_, err := r.collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func (r *NeighbourRepository) DeleteComputedBefore(ctx context.Context, t time.Time) (int64, error) {
+	res, err := r.collection.DeleteMany(ctx, bson.M{"computed_at": bson.M{"$lt": t}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,230 @@
+package recommendation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/techrook/23-market/internal/analytics"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/vendor"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	// storedNeighbours is how many recommendations of each kind are kept per
+	// product; requests can ask for up to this many.
+	storedNeighbours = 20
+	// minSharedOrders keeps one-off coincidences out of bought-together
+	// lists.
+	minSharedOrders = 2
+	batchSize       = 500
+)
+
+type Config struct {
+	// Window is how far back order history counts towards bought-together
+	// lists.
+	Window time.Duration
+}
+
+type Service interface {
+	Recommendations(ctx context.Context, productID primitive.ObjectID, query RecommendationsQuery) (*RecommendationsResponse, error)
+	// Recompute rebuilds every product's recommendations from order history
+	// and the catalogue.
+	Recompute(ctx context.Context) (*RecomputeResponse, error)
+}
+
+// PurchaseHistory supplies products bought in the same order. The analytics
+// repository implements it from the sales the order flow reports through
+// POST /internal/sales; until sales arrive, bought-together lists stay empty
+// and only similar products are recommended.
+type PurchaseHistory interface {
+	CoPurchases(ctx context.Context, since time.Time, minOrders int64, perProduct int) ([]analytics.ProductCoPurchases, error)
+}
+
+type service struct {
+	neighbourRepo Repository
+	productRepo   product.Repository
+	vendorRepo    vendor.Repository
+	products      product.Service
+	purchases     PurchaseHistory
+	cfg           Config
+}
+
+func NewService(neighbourRepo Repository, productRepo product.Repository, vendorRepo vendor.Repository, products product.Service, purchases PurchaseHistory, cfg Config) Service {
+	return &service{
+		neighbourRepo: neighbourRepo,
+		productRepo:   productRepo,
+		vendorRepo:    vendorRepo,
+		products:      products,
+		purchases:     purchases,
+		cfg:           cfg,
+	}
+}
+
+func (s *service) Recommendations(ctx context.Context, productID primitive.ObjectID, query RecommendationsQuery) (*RecommendationsResponse, error) {
+	if _, err := s.productRepo.GetPublic(ctx, productID); err != nil {
+		return nil, err
+	}
+	n, err := s.neighbourRepo.Get(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load both lists in one go. Products that stopped being public since the
+	// last recompute drop out here.
+	var ids []primitive.ObjectID
+	for _, neighbour := range append(n.BoughtTogether, n.Similar...) {
+		ids = append(ids, neighbour.ProductID)
+	}
+	products, err := s.products.ListProductsByID(ctx, ids, query.Currency)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]product.ProductResponse, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+
+	resp := &RecommendationsResponse{
+		BoughtTogether: make([]product.ProductResponse, 0, query.Limit),
+		Similar:        make([]product.ProductResponse, 0, query.Limit),
+	}
+	if !n.ComputedAt.IsZero() {
+		resp.ComputedAt = n.ComputedAt.Format(time.RFC3339)
+	}
+	// Don't suggest the same product twice on one page.
+	shown := map[string]bool{productID.Hex(): true}
+	for _, kind := range []Kind{BoughtTogetherKind, SimilarKind} {
+		list := &resp.BoughtTogether
+		if kind == SimilarKind {
+			list = &resp.Similar
+		}
+		for _, neighbour := range n.list(kind) {
+			p, ok := byID[neighbour.ProductID.Hex()]
+			if !ok || shown[p.ID] {
+				continue
+			}
+			if len(*list) == query.Limit {
+				break
+			}
+			shown[p.ID] = true
+			*list = append(*list, p)
+		}
+	}
+	return resp, nil
+}
+
+func (s *service) Recompute(ctx context.Context) (*RecomputeResponse, error) {
+	started := time.Now()
+
+	profiles, err := s.sellableProfiles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sellable := make(map[primitive.ObjectID]bool, len(profiles))
+	for _, p := range profiles {
+		sellable[p.id] = true
+	}
+
+	// Ask for spare pairs: some partners may no longer be sellable.
+	copurchases, err := s.purchases.CoPurchases(ctx, started.Add(-s.cfg.Window), minSharedOrders, 2*storedNeighbours)
+	if err != nil {
+		return nil, err
+	}
+	bought := make(map[primitive.ObjectID][]Neighbour, len(copurchases))
+	for _, cp := range copurchases {
+		if !sellable[cp.ProductID] {
+			continue
+		}
+		var list []Neighbour
+		for _, with := range cp.With {
+			if sellable[with.ProductID] && len(list) < storedNeighbours {
+				list = append(list, Neighbour{ProductID: with.ProductID, Score: float64(with.Orders)})
+			}
+		}
+		if len(list) > 0 {
+			bought[cp.ProductID] = list
+		}
+	}
+
+	similar := similarNeighbours(profiles, storedNeighbours)
+
+	resp := &RecomputeResponse{}
+	batch := make([]Neighbours, 0, batchSize)
+	for _, p := range profiles {
+		n := Neighbours{
+			ProductID:      p.id,
+			BoughtTogether: orEmpty(bought[p.id]),
+			Similar:        orEmpty(similar[p.id]),
+			ComputedAt:     started,
+		}
+		if len(n.BoughtTogether) == 0 && len(n.Similar) == 0 {
+			continue
+		}
+		resp.Products++
+		resp.BoughtTogether += len(n.BoughtTogether)
+		resp.Similar += len(n.Similar)
+
+		batch = append(batch, n)
+		if len(batch) == batchSize {
+			if err := s.neighbourRepo.SaveAll(ctx, batch); err != nil {
+				return nil, err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := s.neighbourRepo.SaveAll(ctx, batch); err != nil {
+		return nil, err
+	}
+
+	// Anything not saved above belongs to a product that is no longer
+	// sellable or has nothing to recommend.
+	removed, err := s.neighbourRepo.DeleteComputedBefore(ctx, started)
+	if err != nil {
+		return nil, err
+	}
+	resp.Removed = removed
+	return resp, nil
+}
+
+// sellableProfiles walks the catalogue for listed products whose vendor can
+// sell.
+func (s *service) sellableProfiles(ctx context.Context) ([]*profile, error) {
+	var profiles []*profile
+	vendors := make(map[primitive.ObjectID]*vendor.Vendor)
+
+	after := primitive.NilObjectID
+	for {
+		products, err := s.productRepo.ListAfter(ctx, after, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		for i := range products {
+			p := &products[i]
+			v, ok := vendors[p.VendorID]
+			if !ok {
+				v, err = s.vendorRepo.GetVendorByID(ctx, p.VendorID)
+				if err != nil && !errors.Is(err, vendor.ErrVendorNotFound) {
+					return nil, err
+				}
+				vendors[p.VendorID] = v
+			}
+			if p.IsListed() && v != nil && v.CanSell() {
+				profiles = append(profiles, newProfile(p))
+			}
+		}
+
+		if len(products) < batchSize {
+			return profiles, nil
+		}
+		after = products[len(products)-1].ID
+	}
+}
+
+func orEmpty(list []Neighbour) []Neighbour {
+	if list == nil {
+		return []Neighbour{}
+	}
+	return list
+}
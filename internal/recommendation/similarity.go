@@ -0,0 +1,219 @@
+package recommendation
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/techrook/23-market/internal/product"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// How much each signal counts towards similarity. The category carries most
+// because that is how shoppers browse; shared title words and option values
+// tell items within a category apart; price keeps suggestions in range.
+const (
+	categoryWeight  = 0.5
+	attributeWeight = 0.35
+	priceWeight     = 0.15
+
+	// minSimilarity keeps weak matches out of the lists.
+	minSimilarity = 0.3
+
+	// Products are compared with every other product under the same parent
+	// category. A parent with more than maxBucketSize products is split into
+	// its leaf categories to keep the pairwise pass affordable.
+	maxBucketSize = 2000
+)
+
+var stopWords = map[string]bool{
+	"and": true, "for": true, "the": true, "with": true, "from": true, "new": true,
+}
+
+// profile is the part of a product similarity looks at.
+type profile struct {
+	id       primitive.ObjectID
+	leaf     primitive.ObjectID
+	category []primitive.ObjectID
+	terms    map[string]bool
+	price    int64
+	currency string
+}
+
+func newProfile(p *product.Product) *profile {
+	pr := &profile{
+		id:       p.ID,
+		category: p.CategoryPath,
+		terms:    make(map[string]bool),
+		price:    p.Price,
+		currency: p.Currency,
+	}
+	if p.CategoryID != nil {
+		pr.leaf = *p.CategoryID
+	}
+	for _, word := range strings.FieldsFunc(strings.ToLower(p.Title), isSeparator) {
+		if len(word) >= 3 && !stopWords[word] {
+			pr.terms[word] = true
+		}
+	}
+	for _, o := range p.Options {
+		name := strings.ToLower(strings.TrimSpace(o.Name))
+		for _, v := range o.Values {
+			pr.terms[name+"="+strings.ToLower(strings.TrimSpace(v))] = true
+		}
+	}
+	return pr
+}
+
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// similarity scores two products between 0 and 1.
+func similarity(a, b *profile) float64 {
+	return categoryWeight*categoryOverlap(a.category, b.category) +
+		attributeWeight*jaccard(a.terms, b.terms) +
+		priceWeight*priceCloseness(a, b)
+}
+
+// categoryOverlap is the share of the deeper path the two have in common, so
+// siblings under a deep category score higher than under a shallow one.
+func categoryOverlap(a, b []primitive.ObjectID) float64 {
+	deepest := len(a)
+	if len(b) > deepest {
+		deepest = len(b)
+	}
+	if deepest == 0 {
+		return 0
+	}
+	shared := 0
+	for shared < len(a) && shared < len(b) && a[shared] == b[shared] {
+		shared++
+	}
+	return float64(shared) / float64(deepest)
+}
+
+func jaccard(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for term := range a {
+		if b[term] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// priceCloseness is the ratio of the lower price to the higher one. Prices in
+// different currencies aren't compared.
+func priceCloseness(a, b *profile) float64 {
+	if a.currency != b.currency || a.price <= 0 || b.price <= 0 {
+		return 0
+	}
+	if a.price < b.price {
+		return float64(a.price) / float64(b.price)
+	}
+	return float64(b.price) / float64(a.price)
+}
+
+// similarNeighbours finds up to limit similar products for each profile.
+func similarNeighbours(profiles []*profile, limit int) map[primitive.ObjectID][]Neighbour {
+	tops := make(map[primitive.ObjectID]*topList, len(profiles))
+	for _, bucket := range buckets(profiles) {
+		for i, a := range bucket {
+			for _, b := range bucket[i+1:] {
+				score := similarity(a, b)
+				if score < minSimilarity {
+					continue
+				}
+				top(tops, a.id, limit).add(Neighbour{ProductID: b.id, Score: score})
+				top(tops, b.id, limit).add(Neighbour{ProductID: a.id, Score: score})
+			}
+		}
+	}
+
+	result := make(map[primitive.ObjectID][]Neighbour, len(tops))
+	for id, t := range tops {
+		result[id] = t.sorted()
+	}
+	return result
+}
+
+// buckets groups profiles by parent category, or by leaf where a parent is
+// too large. Uncategorised products have nothing to compare on.
+func buckets(profiles []*profile) [][]*profile {
+	byParent := make(map[primitive.ObjectID][]*profile)
+	for _, p := range profiles {
+		switch n := len(p.category); {
+		case n >= 2:
+			byParent[p.category[n-2]] = append(byParent[p.category[n-2]], p)
+		case n == 1:
+			byParent[p.category[0]] = append(byParent[p.category[0]], p)
+		}
+	}
+
+	var result [][]*profile
+	for _, bucket := range byParent {
+		if len(bucket) <= maxBucketSize {
+			result = append(result, bucket)
+			continue
+		}
+		byLeaf := make(map[primitive.ObjectID][]*profile)
+		for _, p := range bucket {
+			byLeaf[p.leaf] = append(byLeaf[p.leaf], p)
+		}
+		for _, leaf := range byLeaf {
+			result = append(result, leaf)
+		}
+	}
+	return result
+}
+
+// topList keeps the limit highest-scoring neighbours seen so far.
+type topList struct {
+	limit int
+	items []Neighbour
+}
+
+func top(tops map[primitive.ObjectID]*topList, id primitive.ObjectID, limit int) *topList {
+	t, ok := tops[id]
+	if !ok {
+		t = &topList{limit: limit}
+		tops[id] = t
+	}
+	return t
+}
+
+func (t *topList) add(n Neighbour) {
+	if len(t.items) < t.limit {
+		t.items = append(t.items, n)
+		return
+	}
+	lowest := 0
+	for i := range t.items {
+		if t.items[i].Score < t.items[lowest].Score {
+			lowest = i
+		}
+	}
+	if n.Score > t.items[lowest].Score {
+		t.items[lowest] = n
+	}
+}
+
+func (t *topList) sorted() []Neighbour {
+	sortNeighbours(t.items)
+	return t.items
+}
+
+// sortNeighbours orders by score, breaking ties by ID so recomputes are
+// stable.
+func sortNeighbours(items []Neighbour) {
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Score != items[j].Score {
+			return items[i].Score > items[j].Score
+		}
+		return items[i].ProductID.Hex() < items[j].ProductID.Hex()
+	})
+}
@@ -0,0 +1,82 @@
+package search
+
+import "github.com/techrook/23-market/internal/category"
+
+type SearchQuery struct {
+	Q         string  `form:"q" binding:"omitempty,max=200"`
+	Category  string  `form:"category" binding:"omitempty,max=100"`
+	VendorID  string  `form:"vendor_id" binding:"omitempty,len=24,hexadecimal"`
+	Currency  string  `form:"currency" binding:"omitempty,len=3,uppercase"`
+	MinPrice  *int64  `form:"min_price" binding:"omitempty,min=0"`
+	MaxPrice  *int64  `form:"max_price" binding:"omitempty,min=0"`
+	MinRating float64 `form:"min_rating" binding:"omitempty,min=0,max=5"`
+	Sort      Sort    `form:"sort" binding:"omitempty,oneof=relevance price_asc price_desc newest rating"`
+	Page      int     `form:"page" binding:"omitempty,min=1"`
+	PageSize  int     `form:"page_size" binding:"omitempty,min=1,max=100"`
+	// Attributes come from attr[name]=value query parameters.
+	Attributes map[string]string `form:"-"`
+}
+
+type VendorResponse struct {
+	ID           string `json:"id"`
+	BusinessName string `json:"business_name"`
+	Slug         string `json:"slug"`
+}
+
+type HitResponse struct {
+	ID         string         `json:"id"`
+	Title      string         `json:"title"`
+	Vendor     VendorResponse `json:"vendor"`
+	PriceMin   int64          `json:"price_min"`
+	PriceMax   int64          `json:"price_max"`
+	Currency   string         `json:"currency"`
+	CategoryID string         `json:"category_id,omitempty"`
+	Rating     float64        `json:"rating"`
+	Score      float64        `json:"score,omitempty"`
+	// Highlights holds HTML-escaped title and description snippets with
+	// matching terms wrapped in <em>.
+	Highlights map[string]string `json:"highlights,omitempty"`
+}
+
+type FacetValueResponse struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	Count int64  `json:"count"`
+}
+
+type PriceRangeResponse struct {
+	Min   int64  `json:"min"`
+	Max   *int64 `json:"max,omitempty"`
+	Count int64  `json:"count"`
+}
+
+type RatingFacetResponse struct {
+	MinRating int   `json:"min_rating"`
+	Count     int64 `json:"count"`
+}
+
+type FacetsResponse struct {
+	Categories  []FacetValueResponse            `json:"categories"`
+	Vendors     []FacetValueResponse            `json:"vendors"`
+	PriceRanges []PriceRangeResponse            `json:"price_ranges"`
+	Ratings     []RatingFacetResponse           `json:"ratings"`
+	Attributes  map[string][]FacetValueResponse `json:"attributes"`
+	// Schema describes the attributes of the category searched in, so their
+	// facets can be shown with labels and units.
+	Schema []category.AttributeResponse `json:"schema,omitempty"`
+}
+
+type SearchResponse struct {
+	Query string `json:"query"`
+	// CorrectedQuery is set when nothing matched the query as typed and the
+	// results are for a spelling correction instead.
+	CorrectedQuery string         `json:"corrected_query,omitempty"`
+	Hits           []HitResponse  `json:"hits"`
+	Facets         FacetsResponse `json:"facets"`
+}
+
+type ReindexResponse struct {
+	Indexed int   `json:"indexed"`
+	Removed int   `json:"removed"`
+	Pruned  int64 `json:"pruned"`
+}
@@ -0,0 +1,182 @@
+package search
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// Attribute is one facetable name/value pair, such as colour: red. Names and
+// values are stored lower-case.
+type Attribute struct {
+	Name  string `bson:"name"`
+	Value string `bson:"value"`
+}
+
+// Document is the flattened, searchable form of a listed product.
+type Document struct {
+	ID           primitive.ObjectID   `bson:"_id"`
+	VendorID     primitive.ObjectID   `bson:"vendor_id"`
+	VendorName   string               `bson:"vendor_name"`
+	VendorSlug   string               `bson:"vendor_slug"`
+	Title        string               `bson:"title"`
+	Description  string               `bson:"description"`
+	SKUs         []string             `bson:"skus"`
+	CategoryID   *primitive.ObjectID  `bson:"category_id,omitempty"`
+	CategoryPath []primitive.ObjectID `bson:"category_path"`
+	// PriceMin and PriceMax span the effective prices of all variants.
+	PriceMin    int64       `bson:"price_min"`
+	PriceMax    int64       `bson:"price_max"`
+	Currency    string      `bson:"currency"`
+	Rating      float64     `bson:"rating"`
+	Attributes  []Attribute `bson:"attributes"`
+	PublishedAt time.Time   `bson:"published_at"`
+	IndexedAt   time.Time   `bson:"indexed_at"`
+}
+
+// Field weights shared by both engines so they rank the same way.
+const (
+	titleWeight       = 10
+	skuWeight         = 5
+	vendorWeight      = 3
+	attributeWeight   = 2
+	descriptionWeight = 1
+)
+
+type Sort string
+
+const (
+	RelevanceSort Sort = "relevance"
+	PriceAscSort  Sort = "price_asc"
+	PriceDescSort Sort = "price_desc"
+	NewestSort    Sort = "newest"
+	RatingSort    Sort = "rating"
+)
+
+// Query is an engine-level search. Terms are already tokenised; with no
+// terms every document matching the filters is returned.
+type Query struct {
+	Terms      []string
+	CategoryID *primitive.ObjectID
+	VendorID   *primitive.ObjectID
+	// ExcludeVendors are left out of hits and facets alike.
+	ExcludeVendors []primitive.ObjectID
+	Currency       string
+	MinPrice       *int64
+	MaxPrice       *int64
+	MinRating      float64
+	// Attributes must all match.
+	Attributes []Attribute
+	Sort       Sort
+	Offset     int
+	Limit      int
+}
+
+type Hit struct {
+	Document `bson:",inline"`
+	Score    float64 `bson:"score"`
+}
+
+type FacetCount struct {
+	Value string
+	Label string
+	Count int64
+}
+
+// PriceRangeCount counts documents whose lowest price falls in [Min, Max);
+// the last range has no upper bound.
+type PriceRangeCount struct {
+	Min   int64
+	Max   *int64
+	Count int64
+}
+
+// RatingCount counts documents rated MinRating or better.
+type RatingCount struct {
+	MinRating int
+	Count     int64
+}
+
+type Facets struct {
+	Categories []FacetCount
+	Vendors    []FacetCount
+	// PriceRanges is only counted when the query names a currency; amounts
+	// in different currencies don't share buckets.
+	PriceRanges []PriceRangeCount
+	Ratings     []RatingCount
+	Attributes  map[string][]FacetCount
+}
+
+type Result struct {
+	Hits   []Hit
+	Total  int64
+	Facets Facets
+}
+
+// Engine indexes and queries documents. MongoEngine backs production;
+// MemoryEngine runs in-process for development and tests.
+type Engine interface {
+	Index(ctx context.Context, docs ...Document) error
+	Remove(ctx context.Context, ids ...primitive.ObjectID) error
+	// Prune removes documents last indexed before t, after a full reindex.
+	Prune(ctx context.Context, before time.Time) (int64, error)
+	Search(ctx context.Context, q Query) (*Result, error)
+	// Vocabulary returns indexed terms that could be a misspelling of term,
+	// for the typo-tolerant fallback.
+	Vocabulary(ctx context.Context, term string) ([]string, error)
+}
+
+// PriceBoundaries split the price facet, in minor units.
+var PriceBoundaries = []int64{0, 1000, 2500, 5000, 10000, 25000, 50000, 100000}
+
+const (
+	maxCategoryFacets  = 20
+	maxVendorFacets    = 20
+	maxAttributeFacets = 100
+)
+
+func priceRanges(counts map[int64]int64) []PriceRangeCount {
+	if counts == nil {
+		return nil
+	}
+	ranges := make([]PriceRangeCount, 0, len(PriceBoundaries))
+	for i, min := range PriceBoundaries {
+		r := PriceRangeCount{Min: min, Count: counts[min]}
+		if i+1 < len(PriceBoundaries) {
+			max := PriceBoundaries[i+1]
+			r.Max = &max
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges
+}
+
+// priceBucket returns the lower boundary of the range price falls in.
+func priceBucket(price int64) int64 {
+	bucket := PriceBoundaries[0]
+	for _, b := range PriceBoundaries {
+		if price >= b {
+			bucket = b
+		}
+	}
+	return bucket
+}
+
+// ratingCounts turns counts per whole star into "n stars and up" counts.
+func ratingCounts(perStar map[int]int64) []RatingCount {
+	counts := make([]RatingCount, 0, 4)
+	var running int64
+	for stars := 5; stars >= 1; stars-- {
+		running += perStar[stars]
+		if stars <= 4 {
+			counts = append(counts, RatingCount{MinRating: stars, Count: running})
+		}
+	}
+	return counts
+}
+
+func ratingStar(rating float64) int {
+	return int(math.Floor(rating))
+}
@@ -0,0 +1,64 @@
+package search
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/category"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/response"
+)
+
+type Handler struct {
+	searchService Service
+}
+
+func NewHandler(searchService Service) *Handler {
+	return &Handler{
+		searchService: searchService,
+	}
+}
+
+func (h *Handler) Search(c *gin.Context) {
+	var query SearchQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+	query.Attributes = c.QueryMap("attr")
+
+	results, total, err := h.searchService.Search(c.Request.Context(), query)
+	if err != nil {
+		handleError(c, err, "Failed to search products")
+		return
+	}
+	response.Paginated(c, results, query.Page, query.PageSize, int(total), "Search completed successfully")
+}
+
+func (h *Handler) Reindex(c *gin.Context) {
+	result, err := h.searchService.Reindex(c.Request.Context())
+	if err != nil {
+		handleError(c, err, "Failed to rebuild search index")
+		return
+	}
+	response.OK(c, result, "Search index rebuilt")
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, category.ErrCategoryNotFound):
+		response.NotFound(c, "Category", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotFound):
+		response.NotFound(c, "Vendor", response.IsProduction(c))
+	case errors.Is(err, ErrInvalidPriceRange), errors.Is(err, ErrCurrencyRequired):
+		response.BadRequest(c, err.Error(), nil, response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
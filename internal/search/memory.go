@@ -0,0 +1,306 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// MemoryEngine is an in-process engine with an inverted index. It ranks with
+// the same field weights as MongoEngine and is meant for development and
+// tests, not for catalogues that don't fit in memory.
+type MemoryEngine struct {
+	mu       sync.RWMutex
+	docs     map[primitive.ObjectID]*memoryDoc
+	postings map[string]map[primitive.ObjectID]bool
+}
+
+type memoryDoc struct {
+	Document
+	// weights holds the weighted frequency of each term in the document.
+	weights map[string]float64
+}
+
+func NewMemoryEngine() *MemoryEngine {
+	return &MemoryEngine{
+		docs:     make(map[primitive.ObjectID]*memoryDoc),
+		postings: make(map[string]map[primitive.ObjectID]bool),
+	}
+}
+
+func (e *MemoryEngine) Index(ctx context.Context, docs ...Document) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, d := range docs {
+		e.remove(d.ID)
+
+		md := &memoryDoc{Document: d, weights: make(map[string]float64)}
+		add := func(text string, weight float64) {
+			for _, t := range Tokenize(text) {
+				md.weights[t] += weight
+			}
+		}
+		add(d.Title, titleWeight)
+		add(d.VendorName, vendorWeight)
+		add(d.Description, descriptionWeight)
+		for _, sku := range d.SKUs {
+			add(sku, skuWeight)
+		}
+		for _, a := range d.Attributes {
+			add(a.Value, attributeWeight)
+		}
+
+		e.docs[d.ID] = md
+		for t := range md.weights {
+			if e.postings[t] == nil {
+				e.postings[t] = make(map[primitive.ObjectID]bool)
+			}
+			e.postings[t][d.ID] = true
+		}
+	}
+	return nil
+}
+
+func (e *MemoryEngine) Remove(ctx context.Context, ids ...primitive.ObjectID) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, id := range ids {
+		e.remove(id)
+	}
+	return nil
+}
+
+func (e *MemoryEngine) remove(id primitive.ObjectID) {
+	md, ok := e.docs[id]
+	if !ok {
+		return
+	}
+	for t := range md.weights {
+		delete(e.postings[t], id)
+		if len(e.postings[t]) == 0 {
+			delete(e.postings, t)
+		}
+	}
+	delete(e.docs, id)
+}
+
+func (e *MemoryEngine) Prune(ctx context.Context, before time.Time) (int64, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var n int64
+	for id, md := range e.docs {
+		if md.IndexedAt.Before(before) {
+			e.remove(id)
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (e *MemoryEngine) Vocabulary(ctx context.Context, term string) ([]string, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	var terms []string
+	for t := range e.postings {
+		terms = append(terms, t)
+	}
+	return terms, nil
+}
+
+func (e *MemoryEngine) Search(ctx context.Context, q Query) (*Result, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+
+	// Like a Mongo text search, any term matching is enough; more and
+	// heavier matches score higher.
+	var hits []Hit
+	for _, md := range e.candidates(q.Terms) {
+		if !matches(&md.Document, q) {
+			continue
+		}
+		var score float64
+		for _, t := range q.Terms {
+			score += md.weights[t]
+		}
+		hits = append(hits, Hit{Document: md.Document, Score: score})
+	}
+
+	res := &Result{Total: int64(len(hits)), Facets: facetsOf(hits, q.Currency != "")}
+	sortHits(hits, q.Sort, len(q.Terms) > 0)
+	if q.Offset < len(hits) {
+		hits = hits[q.Offset:]
+	} else {
+		hits = nil
+	}
+	if len(hits) > q.Limit {
+		hits = hits[:q.Limit]
+	}
+	res.Hits = append([]Hit{}, hits...)
+	return res, nil
+}
+
+func (e *MemoryEngine) candidates(terms []string) []*memoryDoc {
+	if len(terms) == 0 {
+		all := make([]*memoryDoc, 0, len(e.docs))
+		for _, md := range e.docs {
+			all = append(all, md)
+		}
+		return all
+	}
+	seen := make(map[primitive.ObjectID]bool)
+	var docs []*memoryDoc
+	for _, t := range terms {
+		for id := range e.postings[t] {
+			if !seen[id] {
+				seen[id] = true
+				docs = append(docs, e.docs[id])
+			}
+		}
+	}
+	return docs
+}
+
+func matches(d *Document, q Query) bool {
+	if q.VendorID != nil && d.VendorID != *q.VendorID {
+		return false
+	}
+	for _, id := range q.ExcludeVendors {
+		if d.VendorID == id {
+			return false
+		}
+	}
+	if q.CategoryID != nil {
+		found := false
+		for _, id := range d.CategoryPath {
+			found = found || id == *q.CategoryID
+		}
+		if !found {
+			return false
+		}
+	}
+	if q.Currency != "" && d.Currency != q.Currency {
+		return false
+	}
+	if q.MinPrice != nil && d.PriceMax < *q.MinPrice {
+		return false
+	}
+	if q.MaxPrice != nil && d.PriceMin > *q.MaxPrice {
+		return false
+	}
+	if d.Rating < q.MinRating {
+		return false
+	}
+	for _, want := range q.Attributes {
+		found := false
+		for _, a := range d.Attributes {
+			found = found || a == want
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// sortHits orders hits for q.Sort; relevance falls back to newest when there
+// was no text to score against. Ties break on ID, newest first.
+func sortHits(hits []Hit, s Sort, scored bool) {
+	if s == RelevanceSort && !scored {
+		s = NewestSort
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		a, b := &hits[i], &hits[j]
+		switch s {
+		case RelevanceSort:
+			if a.Score != b.Score {
+				return a.Score > b.Score
+			}
+		case PriceAscSort:
+			if a.PriceMin != b.PriceMin {
+				return a.PriceMin < b.PriceMin
+			}
+		case PriceDescSort:
+			if a.PriceMax != b.PriceMax {
+				return a.PriceMax > b.PriceMax
+			}
+		case RatingSort:
+			if a.Rating != b.Rating {
+				return a.Rating > b.Rating
+			}
+		default:
+			if !a.PublishedAt.Equal(b.PublishedAt) {
+				return a.PublishedAt.After(b.PublishedAt)
+			}
+		}
+		return a.ID.Hex() > b.ID.Hex()
+	})
+}
+
+func facetsOf(hits []Hit, pricedFacet bool) Facets {
+	categories := make(map[primitive.ObjectID]int64)
+	vendors := make(map[primitive.ObjectID]*FacetCount)
+	var prices map[int64]int64
+	if pricedFacet {
+		prices = make(map[int64]int64)
+	}
+	stars := make(map[int]int64)
+	attributes := make(map[Attribute]int64)
+
+	for i := range hits {
+		d := &hits[i].Document
+		for _, id := range d.CategoryPath {
+			categories[id]++
+		}
+		if vendors[d.VendorID] == nil {
+			vendors[d.VendorID] = &FacetCount{Value: d.VendorID.Hex(), Label: d.VendorName}
+		}
+		vendors[d.VendorID].Count++
+		if prices != nil {
+			prices[priceBucket(d.PriceMin)]++
+		}
+		stars[ratingStar(d.Rating)]++
+		for _, a := range d.Attributes {
+			attributes[a]++
+		}
+	}
+
+	facets := Facets{
+		PriceRanges: priceRanges(prices),
+		Ratings:     ratingCounts(stars),
+		Attributes:  make(map[string][]FacetCount),
+	}
+	for id, n := range categories {
+		facets.Categories = append(facets.Categories, FacetCount{Value: id.Hex(), Count: n})
+	}
+	for _, v := range vendors {
+		facets.Vendors = append(facets.Vendors, *v)
+	}
+	var attrs []FacetCount
+	for a, n := range attributes {
+		attrs = append(attrs, FacetCount{Value: a.Value, Label: a.Name, Count: n})
+	}
+
+	facets.Categories = topCounts(facets.Categories, maxCategoryFacets)
+	facets.Vendors = topCounts(facets.Vendors, maxVendorFacets)
+	for _, a := range topCounts(attrs, maxAttributeFacets) {
+		facets.Attributes[a.Label] = append(facets.Attributes[a.Label], FacetCount{Value: a.Value, Count: a.Count})
+	}
+	return facets
+}
+
+// topCounts keeps the n largest counts, ties broken by value.
+func topCounts(counts []FacetCount, n int) []FacetCount {
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Value < counts[j].Value
+	})
+	if len(counts) > n {
+		counts = counts[:n]
+	}
+	return counts
+}
@@ -0,0 +1,257 @@
+package search
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func testDocuments() (docs []Document, phone, charger, case_, shoe primitive.ObjectID) {
+	vendorA, vendorB := primitive.NewObjectID(), primitive.NewObjectID()
+	electronics, footwear := primitive.NewObjectID(), primitive.NewObjectID()
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	phone, charger, case_, shoe = primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	docs = []Document{
+		{
+			ID: phone, VendorID: vendorA, VendorName: "Volt",
+			Title: "Wireless phone", Description: "A phone with a wireless charger included",
+			CategoryPath: []primitive.ObjectID{electronics},
+			PriceMin:     49900, PriceMax: 59900, Currency: "USD", Rating: 4.6,
+			Attributes:  []Attribute{{Name: "colour", Value: "black"}},
+			PublishedAt: base,
+		},
+		{
+			ID: charger, VendorID: vendorA, VendorName: "Volt",
+			Title: "Wireless charger", Description: "Charges any phone",
+			CategoryPath: []primitive.ObjectID{electronics},
+			PriceMin:     2500, PriceMax: 2500, Currency: "USD", Rating: 3.9,
+			Attributes:  []Attribute{{Name: "colour", Value: "white"}},
+			PublishedAt: base.Add(time.Hour),
+		},
+		{
+			ID: case_, VendorID: vendorB, VendorName: "Shell",
+			Title: "Phone case", Description: "Leather case",
+			CategoryPath: []primitive.ObjectID{electronics},
+			PriceMin:     1500, PriceMax: 1500, Currency: "EUR", Rating: 4.1,
+			Attributes:  []Attribute{{Name: "colour", Value: "black"}},
+			PublishedAt: base.Add(2 * time.Hour),
+		},
+		{
+			ID: shoe, VendorID: vendorB, VendorName: "Shell",
+			Title: "Running shoe", Description: "Light trainers",
+			CategoryPath: []primitive.ObjectID{footwear},
+			PriceMin:     8000, PriceMax: 8000, Currency: "USD", Rating: 4.9,
+			PublishedAt: base.Add(3 * time.Hour),
+		},
+	}
+	return docs, phone, charger, case_, shoe
+}
+
+func newTestEngine(t *testing.T) (*MemoryEngine, primitive.ObjectID, primitive.ObjectID, primitive.ObjectID, primitive.ObjectID) {
+	t.Helper()
+	docs, phone, charger, case_, shoe := testDocuments()
+	e := NewMemoryEngine()
+	if err := e.Index(context.Background(), docs...); err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	return e, phone, charger, case_, shoe
+}
+
+func hitIDs(res *Result) []primitive.ObjectID {
+	ids := make([]primitive.ObjectID, 0, len(res.Hits))
+	for _, h := range res.Hits {
+		ids = append(ids, h.ID)
+	}
+	return ids
+}
+
+func TestMemoryEngineRanking(t *testing.T) {
+	e, phone, charger, case_, shoe := newTestEngine(t)
+	minPrice := int64(2000)
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []primitive.ObjectID
+	}{
+		{
+			// Both terms in the phone's title outweigh one in the charger's
+			// title plus one in its description.
+			name:  "relevance weights title over description",
+			query: Query{Terms: []string{"wireless", "phone"}, Sort: RelevanceSort},
+			want:  []primitive.ObjectID{phone, charger, case_},
+		},
+		{
+			name:  "relevance without terms falls back to newest",
+			query: Query{Sort: RelevanceSort},
+			want:  []primitive.ObjectID{shoe, case_, charger, phone},
+		},
+		{
+			name:  "price ascending within a currency",
+			query: Query{Currency: "USD", Sort: PriceAscSort},
+			want:  []primitive.ObjectID{charger, shoe, phone},
+		},
+		{
+			name:  "price descending within a currency",
+			query: Query{Currency: "USD", Sort: PriceDescSort},
+			want:  []primitive.ObjectID{phone, shoe, charger},
+		},
+		{
+			name:  "rating",
+			query: Query{Sort: RatingSort},
+			want:  []primitive.ObjectID{shoe, phone, case_, charger},
+		},
+		{
+			name:  "filters by currency, price and rating",
+			query: Query{Currency: "USD", MinPrice: &minPrice, MinRating: 4, Sort: PriceAscSort},
+			want:  []primitive.ObjectID{shoe, phone},
+		},
+		{
+			name:  "filters by attribute",
+			query: Query{Attributes: []Attribute{{Name: "colour", Value: "black"}}, Sort: NewestSort},
+			want:  []primitive.ObjectID{case_, phone},
+		},
+		{
+			name:  "pages after sorting",
+			query: Query{Sort: NewestSort, Offset: 1, Limit: 2},
+			want:  []primitive.ObjectID{case_, charger},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.query.Limit == 0 {
+				tt.query.Limit = 20
+			}
+			res, err := e.Search(context.Background(), tt.query)
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if got := hitIDs(res); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("hits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryEngineFacets(t *testing.T) {
+	e, _, _, _, _ := newTestEngine(t)
+
+	t.Run("counts every match, not just the page", func(t *testing.T) {
+		res, err := e.Search(context.Background(), Query{Sort: NewestSort, Limit: 1})
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		if res.Total != 4 || len(res.Hits) != 1 {
+			t.Fatalf("total = %d, hits = %d, want 4 and 1", res.Total, len(res.Hits))
+		}
+
+		if len(res.Facets.Categories) != 2 || res.Facets.Categories[0].Count != 3 || res.Facets.Categories[1].Count != 1 {
+			t.Errorf("categories = %+v, want counts 3 and 1", res.Facets.Categories)
+		}
+		vendors := map[string]int64{}
+		for _, v := range res.Facets.Vendors {
+			vendors[v.Label] = v.Count
+		}
+		if !reflect.DeepEqual(vendors, map[string]int64{"Volt": 2, "Shell": 2}) {
+			t.Errorf("vendors = %v", vendors)
+		}
+
+		wantRatings := []RatingCount{{MinRating: 4, Count: 3}, {MinRating: 3, Count: 4}, {MinRating: 2, Count: 4}, {MinRating: 1, Count: 4}}
+		if !reflect.DeepEqual(res.Facets.Ratings, wantRatings) {
+			t.Errorf("ratings = %+v, want %+v", res.Facets.Ratings, wantRatings)
+		}
+
+		colours := map[string]int64{}
+		for _, v := range res.Facets.Attributes["colour"] {
+			colours[v.Value] = v.Count
+		}
+		if !reflect.DeepEqual(colours, map[string]int64{"black": 2, "white": 1}) {
+			t.Errorf("colour facet = %v", colours)
+		}
+	})
+
+	t.Run("price ranges need a currency", func(t *testing.T) {
+		res, err := e.Search(context.Background(), Query{Sort: NewestSort, Limit: 20})
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		if res.Facets.PriceRanges != nil {
+			t.Errorf("price ranges without currency = %+v, want none", res.Facets.PriceRanges)
+		}
+	})
+
+	t.Run("price ranges bucket the lowest price", func(t *testing.T) {
+		res, err := e.Search(context.Background(), Query{Currency: "USD", Sort: NewestSort, Limit: 20})
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		got := map[int64]int64{}
+		for _, r := range res.Facets.PriceRanges {
+			got[r.Min] = r.Count
+		}
+		want := map[int64]int64{0: 0, 1000: 0, 2500: 1, 5000: 1, 10000: 0, 25000: 1, 50000: 0, 100000: 0}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("price ranges = %v, want %v", got, want)
+		}
+		last := res.Facets.PriceRanges[len(res.Facets.PriceRanges)-1]
+		if last.Max != nil {
+			t.Errorf("last range max = %d, want open-ended", *last.Max)
+		}
+	})
+}
+
+func TestTypoFallback(t *testing.T) {
+	e, _, charger, _, _ := newTestEngine(t)
+	s := &service{engine: e}
+
+	tests := []struct {
+		name      string
+		terms     []string
+		want      []string
+		corrected bool
+	}{
+		{name: "one typo in a short word", terms: []string{"chager"}, want: []string{"charger"}, corrected: true},
+		{name: "transposed letters", terms: []string{"wirelses", "chrager"}, want: []string{"wireless", "charger"}, corrected: true},
+		{name: "known words are kept", terms: []string{"phone", "chargr"}, want: []string{"phone", "charger"}, corrected: true},
+		{name: "short words are never corrected", terms: []string{"cse"}, want: []string{"cse"}},
+		{name: "too many typos", terms: []string{"chxxxr"}, want: []string{"chxxxr"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed, err := s.correct(context.Background(), tt.terms)
+			if err != nil {
+				t.Fatalf("correct: %v", err)
+			}
+			if changed != tt.corrected || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("correct(%v) = %v, %v; want %v, %v", tt.terms, got, changed, tt.want, tt.corrected)
+			}
+		})
+	}
+
+	t.Run("corrected query finds what the typo missed", func(t *testing.T) {
+		res, err := e.Search(context.Background(), Query{Terms: []string{"chager"}, Sort: RelevanceSort, Limit: 20})
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		if res.Total != 0 {
+			t.Fatalf("typo matched %d documents, want 0", res.Total)
+		}
+		corrected, _, err := s.correct(context.Background(), []string{"chager"})
+		if err != nil {
+			t.Fatalf("correct: %v", err)
+		}
+		res, err = e.Search(context.Background(), Query{Terms: corrected, Sort: RelevanceSort, Limit: 20})
+		if err != nil {
+			t.Fatalf("Search: %v", err)
+		}
+		if len(res.Hits) == 0 || res.Hits[0].ID != charger {
+			t.Errorf("corrected hits = %v, want the charger first", hitIDs(res))
+		}
+	})
+}
@@ -0,0 +1,307 @@
+package search
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MongoEngine searches with a weighted text index on search_documents (see
+// database.EnsureIndexes) and keeps the indexed terms in search_terms for
+// typo correction.
+type MongoEngine struct {
+	documents *mongo.Collection
+	terms     *mongo.Collection
+}
+
+func NewMongoEngine(db *mongo.Database) *MongoEngine {
+	return &MongoEngine{
+		documents: db.Collection("search_documents"),
+		terms:     db.Collection("search_terms"),
+	}
+}
+
+func (e *MongoEngine) Index(ctx context.Context, docs ...Document) error {
+	if len(docs) == 0 {
+		return nil
+	}
+	writes := make([]mongo.WriteModel, 0, len(docs))
+	vocabulary := make(map[string]bool)
+	for _, d := range docs {
+		writes = append(writes, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"_id": d.ID}).
+			SetReplacement(d).
+			SetUpsert(true))
+		for _, text := range append([]string{d.Title, d.VendorName}, attributeValues(d)...) {
+			for _, t := range Tokenize(text) {
+				vocabulary[t] = true
+			}
+		}
+	}
+	if _, err := e.documents.BulkWrite(ctx, writes, options.BulkWrite().SetOrdered(false)); err != nil {
+		return err
+	}
+
+	termWrites := make([]mongo.WriteModel, 0, len(vocabulary))
+	for t := range vocabulary {
+		termWrites = append(termWrites, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"_id": t}).
+			SetUpdate(bson.M{"$set": bson.M{"length": utf8.RuneCountInString(t)}}).
+			SetUpsert(true))
+	}
+	if len(termWrites) == 0 {
+		return nil
+	}
+	_, err := e.terms.BulkWrite(ctx, termWrites, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+func attributeValues(d Document) []string {
+	values := make([]string, 0, len(d.Attributes))
+	for _, a := range d.Attributes {
+		values = append(values, a.Value)
+	}
+	return values
+}
+
+func (e *MongoEngine) Remove(ctx context.Context, ids ...primitive.ObjectID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	_, err := e.documents.DeleteMany(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	return err
+}
+
+func (e *MongoEngine) Prune(ctx context.Context, before time.Time) (int64, error) {
+	res, err := e.documents.DeleteMany(ctx, bson.M{"indexed_at": bson.M{"$lt": before}})
+	if err != nil {
+		return 0, err
+	}
+	return res.DeletedCount, nil
+}
+
+// Vocabulary narrows candidates to terms that share the first letter and are
+// within the typo budget in length.
+func (e *MongoEngine) Vocabulary(ctx context.Context, term string) ([]string, error) {
+	budget := maxTypos(term)
+	first, _ := utf8.DecodeRuneInString(term)
+	n := utf8.RuneCountInString(term)
+	filter := bson.M{
+		"_id":    primitive.Regex{Pattern: "^" + regexp.QuoteMeta(string(first))},
+		"length": bson.M{"$gte": n - budget, "$lte": n + budget},
+	}
+	cursor, err := e.terms.Find(ctx, filter, options.Find().SetLimit(5000))
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Term string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	terms := make([]string, 0, len(rows))
+	for _, r := range rows {
+		terms = append(terms, r.Term)
+	}
+	return terms, nil
+}
+
+func (e *MongoEngine) Search(ctx context.Context, q Query) (*Result, error) {
+	scored := len(q.Terms) > 0
+	match := bson.M{}
+	if scored {
+		match["$text"] = bson.M{"$search": strings.Join(q.Terms, " ")}
+	}
+	if q.VendorID != nil || len(q.ExcludeVendors) > 0 {
+		vendorMatch := bson.M{}
+		if q.VendorID != nil {
+			vendorMatch["$eq"] = *q.VendorID
+		}
+		if len(q.ExcludeVendors) > 0 {
+			vendorMatch["$nin"] = q.ExcludeVendors
+		}
+		match["vendor_id"] = vendorMatch
+	}
+	if q.CategoryID != nil {
+		match["category_path"] = *q.CategoryID
+	}
+	if q.Currency != "" {
+		match["currency"] = q.Currency
+	}
+	if q.MinPrice != nil {
+		match["price_max"] = bson.M{"$gte": *q.MinPrice}
+	}
+	if q.MaxPrice != nil {
+		match["price_min"] = bson.M{"$lte": *q.MaxPrice}
+	}
+	if q.MinRating > 0 {
+		match["rating"] = bson.M{"$gte": q.MinRating}
+	}
+	if len(q.Attributes) > 0 {
+		all := make(bson.A, 0, len(q.Attributes))
+		for _, a := range q.Attributes {
+			all = append(all, bson.M{"$elemMatch": bson.M{"name": a.Name, "value": a.Value}})
+		}
+		match["attributes"] = bson.M{"$all": all}
+	}
+
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: match}}}
+	if scored {
+		pipeline = append(pipeline, bson.D{{Key: "$addFields", Value: bson.M{"score": bson.M{"$meta": "textScore"}}}})
+	}
+
+	facets := bson.M{
+		"hits": bson.A{
+			bson.M{"$sort": mongoSort(q.Sort, scored)},
+			bson.M{"$skip": q.Offset},
+			bson.M{"$limit": q.Limit},
+		},
+		"total": bson.A{bson.M{"$count": "count"}},
+		"categories": bson.A{
+			bson.M{"$unwind": "$category_path"},
+			bson.M{"$group": bson.M{"_id": "$category_path", "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+			bson.M{"$limit": maxCategoryFacets},
+		},
+		"vendors": bson.A{
+			bson.M{"$group": bson.M{"_id": "$vendor_id", "name": bson.M{"$first": "$vendor_name"}, "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id", Value: 1}}},
+			bson.M{"$limit": maxVendorFacets},
+		},
+		"ratings": bson.A{
+			bson.M{"$group": bson.M{"_id": bson.M{"$floor": "$rating"}, "count": bson.M{"$sum": 1}}},
+		},
+		"attributes": bson.A{
+			bson.M{"$unwind": "$attributes"},
+			bson.M{"$group": bson.M{"_id": "$attributes", "count": bson.M{"$sum": 1}}},
+			bson.M{"$sort": bson.D{{Key: "count", Value: -1}, {Key: "_id.value", Value: 1}}},
+			bson.M{"$limit": maxAttributeFacets},
+		},
+	}
+	if q.Currency != "" {
+		boundaries := make(bson.A, 0, len(PriceBoundaries)+1)
+		for _, b := range PriceBoundaries {
+			boundaries = append(boundaries, b)
+		}
+		boundaries = append(boundaries, int64(math.MaxInt64))
+		facets["prices"] = bson.A{
+			bson.M{"$bucket": bson.M{"groupBy": "$price_min", "boundaries": boundaries, "default": "other"}},
+		}
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$facet", Value: facets}})
+
+	cursor, err := e.documents.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, err
+	}
+	var rows []struct {
+		Hits  []Hit `bson:"hits"`
+		Total []struct {
+			Count int64 `bson:"count"`
+		} `bson:"total"`
+		Categories []struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Count int64              `bson:"count"`
+		} `bson:"categories"`
+		Vendors []struct {
+			ID    primitive.ObjectID `bson:"_id"`
+			Name  string             `bson:"name"`
+			Count int64              `bson:"count"`
+		} `bson:"vendors"`
+		Prices []struct {
+			ID    interface{} `bson:"_id"`
+			Count int64       `bson:"count"`
+		} `bson:"prices"`
+		Ratings []struct {
+			Stars float64 `bson:"_id"`
+			Count int64   `bson:"count"`
+		} `bson:"ratings"`
+		Attributes []struct {
+			Attribute Attribute `bson:"_id"`
+			Count     int64     `bson:"count"`
+		} `bson:"attributes"`
+	}
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return &Result{Hits: []Hit{}}, nil
+	}
+	row := rows[0]
+
+	res := &Result{Hits: row.Hits}
+	if len(row.Total) > 0 {
+		res.Total = row.Total[0].Count
+	}
+	for _, c := range row.Categories {
+		res.Facets.Categories = append(res.Facets.Categories, FacetCount{Value: c.ID.Hex(), Count: c.Count})
+	}
+	for _, v := range row.Vendors {
+		res.Facets.Vendors = append(res.Facets.Vendors, FacetCount{Value: v.ID.Hex(), Label: v.Name, Count: v.Count})
+	}
+	var prices map[int64]int64
+	if q.Currency != "" {
+		prices = make(map[int64]int64)
+	}
+	for _, p := range row.Prices {
+		if min, ok := bucketBoundary(p.ID); ok {
+			prices[min] = p.Count
+		}
+	}
+	res.Facets.PriceRanges = priceRanges(prices)
+	stars := make(map[int]int64)
+	for _, r := range row.Ratings {
+		stars[int(r.Stars)] += r.Count
+	}
+	res.Facets.Ratings = ratingCounts(stars)
+	res.Facets.Attributes = make(map[string][]FacetCount)
+	for _, a := range row.Attributes {
+		res.Facets.Attributes[a.Attribute.Name] = append(res.Facets.Attributes[a.Attribute.Name],
+			FacetCount{Value: a.Attribute.Value, Count: a.Count})
+	}
+	return res, nil
+}
+
+// bucketBoundary reads a $bucket _id, which comes back as whatever numeric
+// type the boundary was stored as.
+func bucketBoundary(id interface{}) (int64, bool) {
+	switch v := id.(type) {
+	case int64:
+		return v, true
+	case int32:
+		return int64(v), true
+	case float64:
+		return int64(v), true
+	default:
+		return 0, false
+	}
+}
+
+func mongoSort(s Sort, scored bool) bson.D {
+	if s == RelevanceSort && !scored {
+		s = NewestSort
+	}
+	var sort bson.D
+	switch s {
+	case RelevanceSort:
+		sort = bson.D{{Key: "score", Value: -1}}
+	case PriceAscSort:
+		sort = bson.D{{Key: "price_min", Value: 1}}
+	case PriceDescSort:
+		sort = bson.D{{Key: "price_max", Value: -1}}
+	case RatingSort:
+		sort = bson.D{{Key: "rating", Value: -1}}
+	default:
+		sort = bson.D{{Key: "published_at", Value: -1}}
+	}
+	return append(sort, bson.E{Key: "_id", Value: -1})
+}
@@ -0,0 +1,430 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/techrook/23-market/internal/category"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/vendor"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrInvalidPriceRange = errors.New("min_price cannot be greater than max_price")
+	// Prices are in minor units of each product's own currency, so they only
+	// compare within one currency.
+	ErrCurrencyRequired = errors.New("currency is required to filter or sort by price")
+)
+
+const reindexBatchSize = 200
+
+type Service interface {
+	Search(ctx context.Context, query SearchQuery) (*SearchResponse, int64, error)
+
+	// IndexProduct implements product.Indexer. Only listed products of
+	// vendors that can sell are searchable; anything else is removed.
+	IndexProduct(ctx context.Context, p *product.Product) error
+	RemoveProduct(ctx context.Context, productID primitive.ObjectID) error
+	// Reindex rebuilds the whole index from the catalogue, picking up
+	// category moves that don't touch products.
+	Reindex(ctx context.Context) (*ReindexResponse, error)
+	// ReindexVendor implements vendor.CatalogIndexer, adding or removing the
+	// vendor's products as it starts or stops selling.
+	ReindexVendor(ctx context.Context, vendorID primitive.ObjectID) error
+}
+
+// RatingSource supplies products' average review ratings. The productreview
+// repository implements it.
+type RatingSource interface {
+	Averages(ctx context.Context, productIDs []primitive.ObjectID) (map[primitive.ObjectID]float64, error)
+}
+
+type service struct {
+	engine      Engine
+	productRepo product.Repository
+	vendorRepo  vendor.Repository
+	categories  category.Service
+	ratings     RatingSource
+}
+
+func NewService(engine Engine, productRepo product.Repository, vendorRepo vendor.Repository, categories category.Service, ratings RatingSource) Service {
+	return &service{
+		engine:      engine,
+		productRepo: productRepo,
+		vendorRepo:  vendorRepo,
+		categories:  categories,
+		ratings:     ratings,
+	}
+}
+
+func (s *service) Search(ctx context.Context, query SearchQuery) (*SearchResponse, int64, error) {
+	if query.MinPrice != nil && query.MaxPrice != nil && *query.MinPrice > *query.MaxPrice {
+		return nil, 0, ErrInvalidPriceRange
+	}
+	priced := query.MinPrice != nil || query.MaxPrice != nil || query.Sort == PriceAscSort || query.Sort == PriceDescSort
+	if priced && query.Currency == "" {
+		return nil, 0, ErrCurrencyRequired
+	}
+
+	q := Query{
+		Terms:     queryTerms(query.Q),
+		Currency:  query.Currency,
+		MinPrice:  query.MinPrice,
+		MaxPrice:  query.MaxPrice,
+		MinRating: query.MinRating,
+		Sort:      query.Sort,
+		Offset:    (query.Page - 1) * query.PageSize,
+		Limit:     query.PageSize,
+	}
+	if q.Sort == "" {
+		q.Sort = RelevanceSort
+	}
+	if query.Category != "" {
+		c, err := s.categories.Resolve(ctx, query.Category)
+		if err != nil {
+			return nil, 0, err
+		}
+		q.CategoryID = &c.ID
+	}
+	if query.VendorID != "" {
+		id, err := primitive.ObjectIDFromHex(query.VendorID)
+		if err != nil {
+			return nil, 0, vendor.ErrVendorNotFound
+		}
+		q.VendorID = &id
+	}
+	// A vacation hides a storefront only until its return date, so hidden
+	// vendors are filtered at query time rather than dropped from the index.
+	hidden, err := s.vendorRepo.HiddenVendorIDs(ctx, time.Now())
+	if err != nil {
+		return nil, 0, err
+	}
+	q.ExcludeVendors = hidden
+	for name, value := range query.Attributes {
+		q.Attributes = append(q.Attributes, Attribute{
+			Name:  strings.ToLower(strings.TrimSpace(name)),
+			Value: strings.ToLower(strings.TrimSpace(value)),
+		})
+	}
+
+	res, err := s.engine.Search(ctx, q)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := &SearchResponse{Query: query.Q}
+	if res.Total == 0 && len(q.Terms) > 0 {
+		corrected, changed, err := s.correct(ctx, q.Terms)
+		if err != nil {
+			return nil, 0, err
+		}
+		if changed {
+			q.Terms = corrected
+			if res, err = s.engine.Search(ctx, q); err != nil {
+				return nil, 0, err
+			}
+			resp.CorrectedQuery = strings.Join(corrected, " ")
+		}
+	}
+
+	resp.Hits = make([]HitResponse, 0, len(res.Hits))
+	for i := range res.Hits {
+		resp.Hits = append(resp.Hits, hitResponse(&res.Hits[i], q.Terms))
+	}
+	if resp.Facets, err = s.facetsResponse(ctx, res.Facets); err != nil {
+		return nil, 0, err
+	}
+	if q.CategoryID != nil {
+		schema, err := s.categories.Schema(ctx, q.CategoryID)
+		if err != nil {
+			return nil, 0, err
+		}
+		resp.Facets.Schema = schema.ToResponse()
+	}
+	return resp, res.Total, nil
+}
+
+// correct swaps each term the index has never seen for its closest known
+// spelling.
+func (s *service) correct(ctx context.Context, terms []string) ([]string, bool, error) {
+	corrected := make([]string, 0, len(terms))
+	changed := false
+	for _, t := range terms {
+		vocabulary, err := s.engine.Vocabulary(ctx, t)
+		if err != nil {
+			return nil, false, err
+		}
+		known := false
+		for _, v := range vocabulary {
+			known = known || v == t
+		}
+		if fix, ok := correct(t, vocabulary); !known && ok {
+			corrected = append(corrected, fix)
+			changed = true
+			continue
+		}
+		corrected = append(corrected, t)
+	}
+	return corrected, changed, nil
+}
+
+func (s *service) IndexProduct(ctx context.Context, p *product.Product) error {
+	if !p.IsListed() {
+		return s.engine.Remove(ctx, p.ID)
+	}
+	v, err := s.vendorRepo.GetVendorByID(ctx, p.VendorID)
+	if err != nil {
+		return err
+	}
+	if !v.CanSell() {
+		return s.engine.Remove(ctx, p.ID)
+	}
+	ratings, err := s.ratings.Averages(ctx, []primitive.ObjectID{p.ID})
+	if err != nil {
+		return err
+	}
+	return s.engine.Index(ctx, NewDocument(p, v, ratings[p.ID], time.Now()))
+}
+
+func (s *service) RemoveProduct(ctx context.Context, productID primitive.ObjectID) error {
+	return s.engine.Remove(ctx, productID)
+}
+
+func (s *service) Reindex(ctx context.Context) (*ReindexResponse, error) {
+	started := time.Now()
+	resp := &ReindexResponse{}
+	vendors := make(map[primitive.ObjectID]*vendor.Vendor)
+
+	after := primitive.NilObjectID
+	for {
+		products, err := s.productRepo.ListAfter(ctx, after, reindexBatchSize)
+		if err != nil {
+			return nil, err
+		}
+
+		indexed, removed, err := s.indexBatch(ctx, products, vendors)
+		if err != nil {
+			return nil, err
+		}
+		resp.Indexed += indexed
+		resp.Removed += removed
+
+		if len(products) < reindexBatchSize {
+			break
+		}
+		after = products[len(products)-1].ID
+	}
+
+	// Anything not touched above belongs to a product that no longer exists.
+	pruned, err := s.engine.Prune(ctx, started)
+	if err != nil {
+		return nil, err
+	}
+	resp.Pruned = pruned
+	return resp, nil
+}
+
+func (s *service) ReindexVendor(ctx context.Context, vendorID primitive.ObjectID) error {
+	v, err := s.vendorRepo.GetVendorByID(ctx, vendorID)
+	if err != nil {
+		return err
+	}
+	vendors := map[primitive.ObjectID]*vendor.Vendor{vendorID: v}
+
+	after := primitive.NilObjectID
+	for {
+		products, err := s.productRepo.ListByVendorAfter(ctx, vendorID, after, reindexBatchSize)
+		if err != nil {
+			return err
+		}
+		if _, _, err := s.indexBatch(ctx, products, vendors); err != nil {
+			return err
+		}
+		if len(products) < reindexBatchSize {
+			return nil
+		}
+		after = products[len(products)-1].ID
+	}
+}
+
+// indexBatch indexes the searchable products of a batch and removes the
+// rest, looking vendors up once and caching them in vendors.
+func (s *service) indexBatch(ctx context.Context, products []product.Product, vendors map[primitive.ObjectID]*vendor.Vendor) (int, int, error) {
+	ids := make([]primitive.ObjectID, 0, len(products))
+	for i := range products {
+		ids = append(ids, products[i].ID)
+	}
+	ratings, err := s.ratings.Averages(ctx, ids)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var docs []Document
+	var removed []primitive.ObjectID
+	for i := range products {
+		p := &products[i]
+		v, ok := vendors[p.VendorID]
+		if !ok {
+			v, err = s.vendorRepo.GetVendorByID(ctx, p.VendorID)
+			if err != nil && !errors.Is(err, vendor.ErrVendorNotFound) {
+				return 0, 0, err
+			}
+			vendors[p.VendorID] = v
+		}
+		if !p.IsListed() || v == nil || !v.CanSell() {
+			removed = append(removed, p.ID)
+			continue
+		}
+		docs = append(docs, NewDocument(p, v, ratings[p.ID], time.Now()))
+	}
+	if err := s.engine.Index(ctx, docs...); err != nil {
+		return 0, 0, err
+	}
+	if err := s.engine.Remove(ctx, removed...); err != nil {
+		return 0, 0, err
+	}
+	return len(docs), len(removed), nil
+}
+
+// NewDocument flattens a listed product for indexing, with rating being the
+// product's average review rating. The product's category
+// attributes and its option values become facetable attributes; only option
+// values some variant actually carries are kept.
+func NewDocument(p *product.Product, v *vendor.Vendor, rating float64, indexedAt time.Time) Document {
+	d := Document{
+		ID:           p.ID,
+		VendorID:     p.VendorID,
+		VendorName:   v.BusinessName,
+		VendorSlug:   v.Slug,
+		Title:        p.Title,
+		Description:  p.Description,
+		SKUs:         make([]string, 0, len(p.Variants)),
+		CategoryID:   p.CategoryID,
+		CategoryPath: p.CategoryPath,
+		PriceMin:     p.Price,
+		PriceMax:     p.Price,
+		Currency:     p.Currency,
+		Rating:       rating,
+		Attributes:   []Attribute{},
+		IndexedAt:    indexedAt,
+	}
+	if p.PublishedAt != nil {
+		d.PublishedAt = *p.PublishedAt
+	}
+	if d.CategoryPath == nil {
+		d.CategoryPath = []primitive.ObjectID{}
+	}
+
+	seen := make(map[Attribute]bool)
+	names := make([]string, 0, len(p.Attributes))
+	for name := range p.Attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		a := Attribute{Name: strings.ToLower(name), Value: strings.ToLower(p.Attributes[name])}
+		seen[a] = true
+		d.Attributes = append(d.Attributes, a)
+	}
+	for i := range p.Variants {
+		variant := &p.Variants[i]
+		d.SKUs = append(d.SKUs, variant.SKU)
+		price := p.EffectivePrice(variant)
+		if i == 0 || price < d.PriceMin {
+			d.PriceMin = price
+		}
+		if i == 0 || price > d.PriceMax {
+			d.PriceMax = price
+		}
+		for j, value := range variant.OptionValues {
+			if j >= len(p.Options) {
+				break
+			}
+			a := Attribute{Name: strings.ToLower(p.Options[j].Name), Value: strings.ToLower(value)}
+			if !seen[a] {
+				seen[a] = true
+				d.Attributes = append(d.Attributes, a)
+			}
+		}
+	}
+	return d
+}
+
+func hitResponse(h *Hit, terms []string) HitResponse {
+	resp := HitResponse{
+		ID:    h.ID.Hex(),
+		Title: h.Title,
+		Vendor: VendorResponse{
+			ID:           h.VendorID.Hex(),
+			BusinessName: h.VendorName,
+			Slug:         h.VendorSlug,
+		},
+		PriceMin: h.PriceMin,
+		PriceMax: h.PriceMax,
+		Currency: h.Currency,
+		Rating:   h.Rating,
+		Score:    h.Score,
+	}
+	if h.CategoryID != nil {
+		resp.CategoryID = h.CategoryID.Hex()
+	}
+	if len(terms) > 0 {
+		resp.Highlights = make(map[string]string)
+		if title, ok := Highlight(h.Title, terms, 0); ok {
+			resp.Highlights["title"] = title
+		}
+		if description, ok := Highlight(h.Description, terms, snippetRunes); ok {
+			resp.Highlights["description"] = description
+		}
+	}
+	return resp
+}
+
+// facetsResponse labels category facets with their current names, dropping
+// categories deleted since the documents were indexed.
+func (s *service) facetsResponse(ctx context.Context, f Facets) (FacetsResponse, error) {
+	resp := FacetsResponse{
+		Categories:  []FacetValueResponse{},
+		Vendors:     make([]FacetValueResponse, 0, len(f.Vendors)),
+		PriceRanges: make([]PriceRangeResponse, 0, len(f.PriceRanges)),
+		Ratings:     make([]RatingFacetResponse, 0, len(f.Ratings)),
+		Attributes:  make(map[string][]FacetValueResponse, len(f.Attributes)),
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(f.Categories))
+	for _, c := range f.Categories {
+		if id, err := primitive.ObjectIDFromHex(c.Value); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	categories, err := s.categories.Lookup(ctx, ids)
+	if err != nil {
+		return resp, err
+	}
+	for _, c := range f.Categories {
+		id, _ := primitive.ObjectIDFromHex(c.Value)
+		if cat, ok := categories[id]; ok {
+			resp.Categories = append(resp.Categories, FacetValueResponse{Value: cat.Slug, Label: cat.Name, Count: c.Count})
+		}
+	}
+
+	for _, v := range f.Vendors {
+		resp.Vendors = append(resp.Vendors, FacetValueResponse{Value: v.Value, Label: v.Label, Count: v.Count})
+	}
+	for _, p := range f.PriceRanges {
+		resp.PriceRanges = append(resp.PriceRanges, PriceRangeResponse{Min: p.Min, Max: p.Max, Count: p.Count})
+	}
+	for _, r := range f.Ratings {
+		resp.Ratings = append(resp.Ratings, RatingFacetResponse{MinRating: r.MinRating, Count: r.Count})
+	}
+	for name, values := range f.Attributes {
+		for _, v := range values {
+			resp.Attributes[name] = append(resp.Attributes[name], FacetValueResponse{Value: v.Value, Count: v.Count})
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,183 @@
+package search
+
+import (
+	"html"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	maxQueryTerms  = 10
+	snippetRunes   = 160
+	highlightOpen  = "<em>"
+	highlightClose = "</em>"
+)
+
+// Tokenize lower-cases text and splits it into letter and digit runs.
+func Tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// queryTerms tokenises a search box entry, dropping repeats.
+func queryTerms(text string) []string {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, t := range Tokenize(text) {
+		if seen[t] || len(terms) == maxQueryTerms {
+			continue
+		}
+		seen[t] = true
+		terms = append(terms, t)
+	}
+	return terms
+}
+
+// maxTypos is how many edits a term of this length tolerates: none for short
+// words, where a single edit usually means a different word.
+func maxTypos(term string) int {
+	switch n := utf8.RuneCountInString(term); {
+	case n < 4:
+		return 0
+	case n < 8:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// correct picks the closest vocabulary term within the typo budget, preferring
+// fewer edits, then similar length, then alphabetical order.
+func correct(term string, vocabulary []string) (string, bool) {
+	budget := maxTypos(term)
+	if budget == 0 {
+		return "", false
+	}
+	type candidate struct {
+		term     string
+		distance int
+		lenDiff  int
+	}
+	var best []candidate
+	for _, v := range vocabulary {
+		if v == term {
+			continue
+		}
+		d := levenshtein(term, v)
+		if d > budget {
+			continue
+		}
+		diff := utf8.RuneCountInString(v) - utf8.RuneCountInString(term)
+		if diff < 0 {
+			diff = -diff
+		}
+		best = append(best, candidate{term: v, distance: d, lenDiff: diff})
+	}
+	if len(best) == 0 {
+		return "", false
+	}
+	sort.Slice(best, func(i, j int) bool {
+		if best[i].distance != best[j].distance {
+			return best[i].distance < best[j].distance
+		}
+		if best[i].lenDiff != best[j].lenDiff {
+			return best[i].lenDiff < best[j].lenDiff
+		}
+		return best[i].term < best[j].term
+	})
+	return best[0].term, true
+}
+
+// levenshtein counts single-rune insertions, deletions, substitutions and
+// adjacent transpositions between a and b.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
+
+// Highlight HTML-escapes text and wraps every token matching one of terms
+// in <em> tags. With maxRunes > 0 the result is cut to a snippet around the
+// first match.
+func Highlight(text string, terms []string, maxRunes int) (string, bool) {
+	want := make(map[string]bool, len(terms))
+	for _, t := range terms {
+		want[t] = true
+	}
+
+	runes := []rune(text)
+	type span struct{ start, end int }
+	var matches []span
+	start := -1
+	for i := 0; i <= len(runes); i++ {
+		inWord := i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]))
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			if want[strings.ToLower(string(runes[start:i]))] {
+				matches = append(matches, span{start, i})
+			}
+			start = -1
+		}
+	}
+	if len(matches) == 0 {
+		return "", false
+	}
+
+	from, to := 0, len(runes)
+	if maxRunes > 0 && len(runes) > maxRunes {
+		from = matches[0].start - maxRunes/4
+		if from < 0 {
+			from = 0
+		}
+		to = from + maxRunes
+		if to > len(runes) {
+			to = len(runes)
+			from = to - maxRunes
+		}
+	}
+
+	var b strings.Builder
+	if from > 0 {
+		b.WriteString("…")
+	}
+	pos := from
+	for _, m := range matches {
+		if m.start < from || m.end > to {
+			continue
+		}
+		b.WriteString(html.EscapeString(string(runes[pos:m.start])))
+		b.WriteString(highlightOpen)
+		b.WriteString(html.EscapeString(string(runes[m.start:m.end])))
+		b.WriteString(highlightClose)
+		pos = m.end
+	}
+	b.WriteString(html.EscapeString(string(runes[pos:to])))
+	if to < len(runes) {
+		b.WriteString("…")
+	}
+	return b.String(), true
+}
@@ -2,9 +2,28 @@ package server
 
 import (
 	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/admin"
+	"github.com/techrook/23-market/internal/analytics"
 	"github.com/techrook/23-market/internal/auth"
+	"github.com/techrook/23-market/internal/category"
+	"github.com/techrook/23-market/internal/digital"
+	"github.com/techrook/23-market/internal/exchange"
+	"github.com/techrook/23-market/internal/inventory"
+	"github.com/techrook/23-market/internal/kyc"
+	"github.com/techrook/23-market/internal/moderation"
+	"github.com/techrook/23-market/internal/notification"
+	"github.com/techrook/23-market/internal/payout"
+	"github.com/techrook/23-market/internal/pricing"
+	"github.com/techrook/23-market/internal/product"
+	"github.com/techrook/23-market/internal/productio"
+	"github.com/techrook/23-market/internal/productqa"
+	"github.com/techrook/23-market/internal/productreview"
+	"github.com/techrook/23-market/internal/recommendation"
+	"github.com/techrook/23-market/internal/search"
+	"github.com/techrook/23-market/internal/shipping"
 	"github.com/techrook/23-market/internal/user"
 	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/internal/vendorreview"
 )
 
 func SetupRoutes(
@@ -12,6 +31,25 @@ func SetupRoutes(
 	authHandler *auth.Handler,
 	userHandler *user.Handler,
 	vendorHandler *vendor.Handler,
+	adminHandler *admin.Handler,
+	kycHandler *kyc.Handler,
+	notificationHandler *notification.Handler,
+	vendorReviewHandler *vendorreview.Handler,
+	shippingHandler *shipping.Handler,
+	payoutHandler *payout.Handler,
+	analyticsHandler *analytics.Handler,
+	productHandler *product.Handler,
+	inventoryHandler *inventory.Handler,
+	categoryHandler *category.Handler,
+	searchHandler *search.Handler,
+	productioHandler *productio.Handler,
+	pricingHandler *pricing.Handler,
+	exchangeHandler *exchange.Handler,
+	qaHandler *productqa.Handler,
+	reviewHandler *productreview.Handler,
+	recommendationHandler *recommendation.Handler,
+	digitalHandler *digital.Handler,
+	moderationHandler *moderation.Handler,
 	userRepo user.Repository,
 ) {
 	authCfg := auth.LoadConfig()
@@ -24,7 +62,7 @@ func SetupRoutes(
 	}
 
 		protected := r.Group("/users")
-	protected.Use(auth.AuthMiddleware(authCfg))
+	protected.Use(auth.AuthMiddleware(authCfg), auth.RequireSelfOrAdmin("userID"))
 	{
 		protected.GET("/me", authHandler.Me)
 		protected.POST("/:userID", userHandler.CreateUserProfile)
@@ -34,12 +72,255 @@ func SetupRoutes(
 	}
 
 		vendorGroup := r.Group("/vendors")
-	vendorGroup.Use(auth.AuthMiddleware(authCfg))
+	vendorGroup.Use(auth.AuthMiddleware(authCfg), auth.RequireSelfOrAdmin("userID"))
 	{
 		vendorGroup.POST("/complete-profile", vendorHandler.CompleteVendorProfile)
 		vendorGroup.GET("/profile", vendorHandler.GetVendorProfile)
 		vendorGroup.PUT("/profile", vendorHandler.UpdateVendorProfile)
 		vendorGroup.DELETE("/profile", vendorHandler.DeactivateVendorProfile)
+		vendorGroup.PUT("/profile/hours", vendorHandler.UpdateBusinessHours)
+		vendorGroup.PUT("/profile/vacation", vendorHandler.UpdateVacation)
+		vendorGroup.GET("/:userID/profile", vendorHandler.GetVendorProfile)
+		vendorGroup.PUT("/:userID/profile", vendorHandler.UpdateVendorProfile)
+		vendorGroup.DELETE("/:userID/profile", vendorHandler.DeactivateVendorProfile)
+		vendorGroup.PUT("/:userID/profile/hours", vendorHandler.UpdateBusinessHours)
+		vendorGroup.PUT("/:userID/profile/vacation", vendorHandler.UpdateVacation)
+
+		vendorGroup.POST("/kyc/documents", kycHandler.UploadDocument)
+		vendorGroup.GET("/kyc/documents", kycHandler.ListVendorDocuments)
+		vendorGroup.GET("/kyc/documents/:documentID/file", kycHandler.DownloadVendorDocument)
+
+		vendorGroup.GET("/shipping", shippingHandler.GetProfile)
+		vendorGroup.PUT("/shipping", shippingHandler.UpdateProfile)
+		vendorGroup.GET("/:userID/shipping", shippingHandler.GetProfile)
+		vendorGroup.PUT("/:userID/shipping", shippingHandler.UpdateProfile)
+
+		vendorGroup.GET("/payouts", payoutHandler.ListPayouts)
+		vendorGroup.GET("/payouts/balance", payoutHandler.GetBalances)
+		vendorGroup.GET("/payouts/ledger", payoutHandler.ListLedger)
+		vendorGroup.GET("/payouts/accounts", payoutHandler.ListAccounts)
+		vendorGroup.POST("/payouts/accounts", payoutHandler.AddAccount)
+		vendorGroup.DELETE("/payouts/accounts/:accountID", payoutHandler.DeleteAccount)
+		vendorGroup.POST("/payouts/accounts/:accountID/default", payoutHandler.SetDefaultAccount)
+
+		vendorGroup.GET("/analytics", analyticsHandler.Summary)
+		vendorGroup.GET("/analytics/products", analyticsHandler.TopProducts)
+		vendorGroup.GET("/:userID/analytics", analyticsHandler.Summary)
+		vendorGroup.GET("/:userID/analytics/products", analyticsHandler.TopProducts)
+	}
+
+	vendorProductGroup := r.Group("/vendors/products")
+	vendorProductGroup.Use(auth.AuthMiddleware(authCfg), auth.RequireRole(user.RoleVendor))
+	{
+		vendorProductGroup.POST("", productHandler.CreateProduct)
+		vendorProductGroup.GET("", productHandler.ListVendorProducts)
+		vendorProductGroup.GET("/:productID", productHandler.GetVendorProduct)
+		vendorProductGroup.PUT("/:productID", productHandler.UpdateProduct)
+		vendorProductGroup.PUT("/:productID/status", productHandler.UpdateStatus)
+		vendorProductGroup.DELETE("/:productID", productHandler.DeleteProduct)
+		vendorProductGroup.PUT("/:productID/options", productHandler.SetOptions)
+		vendorProductGroup.PUT("/:productID/variants/:variantID", productHandler.UpdateVariant)
+		vendorProductGroup.POST("/:productID/images", productHandler.AddImage)
+		vendorProductGroup.GET("/:productID/images/:imageID", productHandler.GetVendorImage)
+		vendorProductGroup.DELETE("/:productID/images/:imageID", productHandler.DeleteImage)
+		vendorProductGroup.POST("/:productID/price-schedules", pricingHandler.CreateSchedule)
+		vendorProductGroup.GET("/:productID/price-schedules", pricingHandler.ListSchedules)
+		vendorProductGroup.DELETE("/:productID/price-schedules/:scheduleID", pricingHandler.CancelSchedule)
+		vendorProductGroup.POST("/:productID/assets", digitalHandler.UploadAsset)
+		vendorProductGroup.GET("/:productID/assets", digitalHandler.ListAssets)
+		vendorProductGroup.DELETE("/:productID/assets/:assetID", digitalHandler.DeleteAsset)
+	}
+
+	vendorInventoryGroup := r.Group("/vendors/inventory")
+	vendorInventoryGroup.Use(auth.AuthMiddleware(authCfg), auth.RequireRole(user.RoleVendor))
+	{
+		vendorInventoryGroup.GET("", inventoryHandler.ListItems)
+		vendorInventoryGroup.GET("/:sku", inventoryHandler.GetItem)
+		vendorInventoryGroup.POST("/:sku/adjustments", inventoryHandler.AdjustStock)
+		vendorInventoryGroup.GET("/:sku/adjustments", inventoryHandler.ListAdjustments)
+	}
+
+	vendorCatalogGroup := r.Group("/vendors/catalog")
+	vendorCatalogGroup.Use(auth.AuthMiddleware(authCfg), auth.RequireRole(user.RoleVendor))
+	{
+		vendorCatalogGroup.POST("/imports", productioHandler.StartImport)
+		vendorCatalogGroup.GET("/imports", productioHandler.ListImports)
+		vendorCatalogGroup.GET("/imports/:jobID", productioHandler.GetImport)
+		vendorCatalogGroup.GET("/imports/:jobID/results", productioHandler.DownloadResults)
+		vendorCatalogGroup.GET("/export", productioHandler.Export)
+	}
+
+	vendorQuestionGroup := r.Group("/vendors/questions")
+	vendorQuestionGroup.Use(auth.AuthMiddleware(authCfg), auth.RequireRole(user.RoleVendor))
+	{
+		vendorQuestionGroup.GET("", qaHandler.ListVendorQuestions)
+	}
+
+	vendorProductReviewGroup := r.Group("/vendors/product-reviews")
+	vendorProductReviewGroup.Use(auth.AuthMiddleware(authCfg), auth.RequireRole(user.RoleVendor))
+	{
+		vendorProductReviewGroup.GET("", reviewHandler.ListVendorReviews)
+	}
+
+	reservationGroup := r.Group("/inventory/reservations")
+	reservationGroup.Use(auth.AuthMiddleware(authCfg), auth.RequireRole(user.RoleUser))
+	{
+		reservationGroup.POST("", inventoryHandler.Reserve)
+		reservationGroup.DELETE("/:reservationID", inventoryHandler.Release)
+	}
+
+	categoryGroup := r.Group("/categories")
+	{
+		categoryGroup.GET("", categoryHandler.Tree)
+		categoryGroup.GET("/:slug", categoryHandler.GetCategory)
+	}
+
+	r.GET("/library", auth.AuthMiddleware(authCfg), digitalHandler.Library)
+	r.GET("/downloads/:entitlementID/:assetID", digitalHandler.Download)
+
+	r.GET("/search", searchHandler.Search)
+	r.GET("/exchange-rates", exchangeHandler.CurrentRates)
+
+	productGroup := r.Group("/products")
+	{
+		productGroup.GET("", productHandler.ListProducts)
+		productGroup.GET("/:productID", productHandler.GetProduct)
+		productGroup.GET("/:productID/images/:imageID", productHandler.GetImage)
+		productGroup.GET("/:productID/price-history", pricingHandler.PriceHistory)
+		productGroup.GET("/:productID/questions", qaHandler.ListQuestions)
+		productGroup.POST("/:productID/questions", auth.AuthMiddleware(authCfg), qaHandler.AskQuestion)
+		productGroup.GET("/:productID/reviews", reviewHandler.ListReviews)
+		productGroup.GET("/:productID/rating", reviewHandler.GetRating)
+		productGroup.GET("/:productID/recommendations", recommendationHandler.Recommendations)
+		productGroup.POST("/:productID/reviews", auth.AuthMiddleware(authCfg), auth.RequireRole(user.RoleUser), reviewHandler.CreateReview)
+	}
+
+	questionGroup := r.Group("/product-questions")
+	{
+		questionGroup.GET("/:questionID/answers", qaHandler.ListAnswers)
+		questionGroup.POST("/:questionID/answers", auth.AuthMiddleware(authCfg), qaHandler.AnswerQuestion)
+		questionGroup.DELETE("/:questionID", auth.AuthMiddleware(authCfg), qaHandler.DeleteQuestion)
+		questionGroup.PUT("/:questionID/vote", auth.AuthMiddleware(authCfg), qaHandler.VoteQuestion)
+		questionGroup.POST("/:questionID/report", auth.AuthMiddleware(authCfg), qaHandler.ReportQuestion)
+	}
+
+	answerGroup := r.Group("/product-answers")
+	answerGroup.Use(auth.AuthMiddleware(authCfg))
+	{
+		answerGroup.DELETE("/:answerID", qaHandler.DeleteAnswer)
+		answerGroup.PUT("/:answerID/vote", qaHandler.VoteAnswer)
+		answerGroup.POST("/:answerID/report", qaHandler.ReportAnswer)
+	}
+
+	productReviewGroup := r.Group("/product-reviews")
+	{
+		productReviewGroup.GET("/:reviewID/photos/:photoID", reviewHandler.GetPhoto)
+		productReviewGroup.PUT("/:reviewID", auth.AuthMiddleware(authCfg), reviewHandler.UpdateReview)
+		productReviewGroup.DELETE("/:reviewID", auth.AuthMiddleware(authCfg), reviewHandler.DeleteReview)
+		productReviewGroup.POST("/:reviewID/photos", auth.AuthMiddleware(authCfg), reviewHandler.AddPhoto)
+		productReviewGroup.DELETE("/:reviewID/photos/:photoID", auth.AuthMiddleware(authCfg), reviewHandler.DeletePhoto)
+		productReviewGroup.PUT("/:reviewID/vote", auth.AuthMiddleware(authCfg), reviewHandler.Vote)
+		productReviewGroup.DELETE("/:reviewID/vote", auth.AuthMiddleware(authCfg), reviewHandler.RemoveVote)
+		productReviewGroup.POST("/:reviewID/report", auth.AuthMiddleware(authCfg), reviewHandler.Report)
+		productReviewGroup.PUT("/:reviewID/response", auth.AuthMiddleware(authCfg), auth.RequireRole(user.RoleVendor), reviewHandler.Respond)
+	}
+
+	shippingGroup := r.Group("/shipping")
+	shippingGroup.Use(auth.AuthMiddleware(authCfg))
+	{
+		shippingGroup.POST("/quote", shippingHandler.Quote)
+	}
+
+	notificationGroup := r.Group("/notifications")
+	notificationGroup.Use(auth.AuthMiddleware(authCfg))
+	{
+		notificationGroup.GET("", notificationHandler.ListNotifications)
+		notificationGroup.POST("/:notificationID/read", notificationHandler.MarkRead)
+	}
+
+	storeGroup := r.Group("/stores")
+	{
+		storeGroup.GET("", vendorHandler.ListStorefronts)
+		storeGroup.GET("/:slug", analyticsHandler.TrackStorefrontViews(), vendorHandler.GetStorefront)
+		storeGroup.GET("/:slug/reviews", vendorReviewHandler.ListReviews)
+		storeGroup.POST("/:slug/reviews", auth.AuthMiddleware(authCfg), auth.RequireRole(user.RoleUser), vendorReviewHandler.CreateReview)
+	}
+
+	vendorReviewGroup := r.Group("/vendor-reviews")
+	vendorReviewGroup.Use(auth.AuthMiddleware(authCfg))
+	{
+		vendorReviewGroup.PUT("/:reviewID", vendorReviewHandler.UpdateReview)
+		vendorReviewGroup.DELETE("/:reviewID", vendorReviewHandler.DeleteReview)
+		vendorReviewGroup.PUT("/:reviewID/reply", auth.RequireRole(user.RoleVendor), vendorReviewHandler.ReplyToReview)
+	}
+
+	// The order flow reports what happens to an order here: once payment is
+	// confirmed it commits the checkout's reservation, records each vendor's
+	// share of the sale and fulfils digital products, and it reports each
+	// line once delivered.
+	internalGroup := r.Group("/internal")
+	internalGroup.Use(auth.RequireServiceToken(authCfg))
+	{
+		internalGroup.POST("/reservations/:reservationID/commit", inventoryHandler.Commit)
+		internalGroup.POST("/sales", analyticsHandler.RecordSale)
+		internalGroup.POST("/fulfilments", digitalHandler.FulfilOrder)
+		internalGroup.POST("/deliveries", reviewHandler.RecordDelivery)
+	}
+
+	adminGroup := r.Group("/admin")
+	adminGroup.Use(auth.AuthMiddleware(authCfg), auth.RequireRole(user.RoleAdmin))
+	{
+		adminGroup.GET("/users", adminHandler.ListUsers)
+		adminGroup.GET("/users/:userID", adminHandler.GetUser)
+		adminGroup.POST("/users/:userID/suspend", adminHandler.SuspendUser)
+		adminGroup.POST("/users/:userID/unsuspend", adminHandler.UnsuspendUser)
+		adminGroup.POST("/users/:userID/verify", adminHandler.VerifyUser)
+		adminGroup.POST("/users/:userID/logout", adminHandler.ForceLogout)
+
+		adminGroup.GET("/vendors", vendorHandler.ListVendorApplications)
+		adminGroup.GET("/vendors/:vendorID", vendorHandler.GetVendorApplication)
+		adminGroup.POST("/vendors/:vendorID/review", vendorHandler.StartVendorReview)
+		adminGroup.POST("/vendors/:vendorID/approve", vendorHandler.ApproveVendor)
+		adminGroup.POST("/vendors/:vendorID/reject", vendorHandler.RejectVendor)
+		adminGroup.POST("/vendors/:vendorID/suspend", vendorHandler.SuspendVendor)
+		adminGroup.POST("/vendors/:vendorID/reinstate", vendorHandler.ReinstateVendor)
+		adminGroup.POST("/vendor-reviews/recompute", vendorReviewHandler.RecomputeRatings)
+
+		adminGroup.GET("/kyc/documents", kycHandler.ListReviewQueue)
+		adminGroup.GET("/kyc/documents/:documentID/file", kycHandler.DownloadDocument)
+		adminGroup.POST("/kyc/documents/:documentID/approve", kycHandler.ApproveDocument)
+		adminGroup.POST("/kyc/documents/:documentID/reject", kycHandler.RejectDocument)
+
+		adminGroup.POST("/vendors/:vendorID/ledger", payoutHandler.AdjustBalance)
+		adminGroup.GET("/payouts/batches", payoutHandler.ListBatches)
+		adminGroup.POST("/payouts/batches", payoutHandler.RunBatch)
+		adminGroup.POST("/analytics/rebuild", analyticsHandler.RebuildRollups)
+
+		adminGroup.POST("/search/reindex", searchHandler.Reindex)
+		adminGroup.POST("/recommendations/recompute", recommendationHandler.Recompute)
+
+		adminGroup.PUT("/exchange-rates", exchangeHandler.PublishRates)
+
+		adminGroup.GET("/product-questions", qaHandler.ListQuestionQueue)
+		adminGroup.PUT("/product-questions/:questionID/moderation", qaHandler.ModerateQuestion)
+		adminGroup.GET("/product-answers", qaHandler.ListAnswerQueue)
+		adminGroup.PUT("/product-answers/:answerID/moderation", qaHandler.ModerateAnswer)
+
+		adminGroup.GET("/product-reviews", reviewHandler.ListQueue)
+		adminGroup.PUT("/product-reviews/:reviewID/moderation", reviewHandler.Moderate)
+		adminGroup.POST("/product-reviews/recompute", reviewHandler.RecomputeRatings)
+
+		adminGroup.GET("/moderation/rules", moderationHandler.GetRules)
+		adminGroup.PUT("/moderation/rules", moderationHandler.UpdateRules)
+		adminGroup.GET("/moderation/queue", moderationHandler.Queue)
+		adminGroup.POST("/moderation/decisions", moderationHandler.Decide)
+		adminGroup.GET("/moderation/products/:productID/images/:imageID", moderationHandler.GetImage)
+
+		adminGroup.POST("/categories", categoryHandler.CreateCategory)
+		adminGroup.PUT("/categories/:categoryID", categoryHandler.UpdateCategory)
+		adminGroup.POST("/categories/:categoryID/move", categoryHandler.MoveCategory)
+		adminGroup.PUT("/categories/:categoryID/attributes", categoryHandler.SetAttributes)
+		adminGroup.DELETE("/categories/:categoryID", categoryHandler.DeleteCategory)
 	}
 
 
@@ -0,0 +1,69 @@
+package shipping
+
+type TierInput struct {
+	UpTo   int64 `json:"up_to" binding:"min=0"`
+	Amount int64 `json:"amount" binding:"min=0"`
+}
+
+type RateInput struct {
+	ID       string      `json:"id" binding:"omitempty,len=24,hexadecimal"`
+	Name     string      `json:"name" binding:"required,min=2,max=100"`
+	Type     RateType    `json:"type" binding:"required,oneof=flat weight price_tier free_over"`
+	Amount   int64       `json:"amount" binding:"min=0"`
+	Tiers    []TierInput `json:"tiers" binding:"max=20,dive"`
+	FreeOver int64       `json:"free_over" binding:"min=0"`
+	MinDays  int         `json:"min_days" binding:"min=0,max=365"`
+	MaxDays  int         `json:"max_days" binding:"min=0,max=365"`
+}
+
+type ZoneInput struct {
+	ID             string      `json:"id" binding:"omitempty,len=24,hexadecimal"`
+	Name           string      `json:"name" binding:"required,min=2,max=100"`
+	Countries      []string    `json:"countries" binding:"required,min=1,max=250,dive,required,max=100"`
+	Regions        []string    `json:"regions" binding:"max=200,dive,required,max=100"`
+	PostalPrefixes []string    `json:"postal_prefixes" binding:"max=500,dive,required,max=20"`
+	Rates          []RateInput `json:"rates" binding:"required,min=1,max=20,dive"`
+}
+
+type UpdateProfileRequest struct {
+	Currency string      `json:"currency" binding:"required,len=3,uppercase"`
+	Zones    []ZoneInput `json:"zones" binding:"max=100,dive"`
+}
+
+// QuoteRequest identifies the vendor by ID or storefront slug. The destination
+// defaults to the caller's profile address; any field given here overrides it.
+type QuoteRequest struct {
+	VendorID    string `json:"vendor_id" binding:"required_without=VendorSlug,omitempty,len=24,hexadecimal"`
+	VendorSlug  string `json:"vendor_slug" binding:"required_without=VendorID,omitempty,max=100"`
+	Subtotal    int64  `json:"subtotal" binding:"min=0"`
+	WeightGrams int64  `json:"weight_grams" binding:"min=0"`
+	Country     string `json:"country" binding:"omitempty,max=100"`
+	Region      string `json:"region" binding:"omitempty,max=100"`
+	PostalCode  string `json:"postal_code" binding:"omitempty,max=20"`
+}
+
+type ProfileResponse struct {
+	ID        string `json:"id"`
+	VendorID  string `json:"vendor_id"`
+	Currency  string `json:"currency"`
+	Zones     []Zone `json:"zones"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type QuoteOption struct {
+	ZoneID   string `json:"zone_id"`
+	ZoneName string `json:"zone_name"`
+	RateID   string `json:"rate_id"`
+	Name     string `json:"name"`
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+	MinDays  int    `json:"min_days"`
+	MaxDays  int    `json:"max_days"`
+}
+
+type QuoteResponse struct {
+	Country    string        `json:"country"`
+	Region     string        `json:"region,omitempty"`
+	PostalCode string        `json:"postal_code,omitempty"`
+	Options    []QuoteOption `json:"options"`
+}
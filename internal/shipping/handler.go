@@ -0,0 +1,107 @@
+package shipping
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	shippingService Service
+}
+
+func NewHandler(shippingService Service) *Handler {
+	return &Handler{
+		shippingService: shippingService,
+	}
+}
+
+func (h *Handler) GetProfile(c *gin.Context) {
+	userID, ok := contextID(c, "subjectID")
+	if !ok {
+		return
+	}
+
+	profile, err := h.shippingService.GetProfile(c.Request.Context(), userID)
+	if err != nil {
+		handleError(c, err, "Failed to get shipping profile")
+		return
+	}
+	response.OK(c, profile, "Shipping profile retrieved successfully")
+}
+
+func (h *Handler) UpdateProfile(c *gin.Context) {
+	userID, ok := contextID(c, "subjectID")
+	if !ok {
+		return
+	}
+
+	var req UpdateProfileRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	profile, err := h.shippingService.UpdateProfile(c.Request.Context(), userID, req)
+	if err != nil {
+		handleError(c, err, "Failed to update shipping profile")
+		return
+	}
+	response.OK(c, profile, "Shipping profile updated successfully")
+}
+
+func (h *Handler) Quote(c *gin.Context) {
+	buyerID, ok := contextID(c, "userID")
+	if !ok {
+		return
+	}
+
+	var req QuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	quote, err := h.shippingService.Quote(c.Request.Context(), buyerID, req)
+	if err != nil {
+		handleError(c, err, "Failed to quote shipping")
+		return
+	}
+	response.OK(c, quote, "Shipping options retrieved successfully")
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrProfileNotFound):
+		response.NotFound(c, "Shipping profile", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotFound):
+		response.NotFound(c, "Vendor", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorClosed):
+		response.Forbidden(c, "Vendor account is closed", response.IsProduction(c))
+	case errors.Is(err, ErrInvalidProfile):
+		response.BadRequest(c, err.Error(), nil, response.IsProduction(c))
+	case errors.Is(err, ErrAddressRequired):
+		response.BadRequest(c, "Add a country to your profile or include one in the request", nil, response.IsProduction(c))
+	case errors.Is(err, ErrNoShippingToRegion):
+		response.NotFound(c, "Shipping option", response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func contextID(c *gin.Context, key string) (primitive.ObjectID, bool) {
+	val, exists := c.Get(key)
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	id, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return id, true
+}
@@ -0,0 +1,57 @@
+package shipping
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	GetByVendorID(ctx context.Context, vendorID primitive.ObjectID) (*Profile, error)
+	Upsert(ctx context.Context, profile *Profile) error
+}
+
+type ShippingRepository struct {
+	collection *mongo.Collection
+}
+
+func NewShippingRepository(db *mongo.Database) Repository {
+	return &ShippingRepository{
+		collection: db.Collection("shipping_profiles"),
+	}
+}
+
+func (r *ShippingRepository) GetByVendorID(ctx context.Context, vendorID primitive.ObjectID) (*Profile, error) {
+	var p Profile
+	err := r.collection.FindOne(ctx, bson.M{"vendor_id": vendorID}).Decode(&p)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrProfileNotFound
+	}
+	return &p, err
+}
+
+func (r *ShippingRepository) Upsert(ctx context.Context, profile *Profile) error {
+	now := time.Now()
+	profile.UpdatedAt = now
+	_, err := r.collection.UpdateOne(
+		ctx,
+		bson.M{"vendor_id": profile.VendorID},
+		bson.M{
+			"$set": bson.M{
+				"currency":   profile.Currency,
+				"zones":      profile.Zones,
+				"updated_at": now,
+			},
+			"$setOnInsert": bson.M{
+				"_id":        primitive.NewObjectID(),
+				"created_at": now,
+			},
+		},
+		options.Update().SetUpsert(true),
+	)
+	return err
+}
@@ -0,0 +1,251 @@
+package shipping
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/techrook/23-market/internal/user"
+	"github.com/techrook/23-market/internal/vendor"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrProfileNotFound    = errors.New("shipping profile not found")
+	ErrInvalidProfile     = errors.New("invalid shipping profile")
+	ErrAddressRequired    = errors.New("destination country is required")
+	ErrNoShippingToRegion = errors.New("vendor does not ship to this address")
+)
+
+type Service interface {
+	GetProfile(ctx context.Context, vendorUserID primitive.ObjectID) (*ProfileResponse, error)
+	UpdateProfile(ctx context.Context, vendorUserID primitive.ObjectID, req UpdateProfileRequest) (*ProfileResponse, error)
+	Quote(ctx context.Context, buyerID primitive.ObjectID, req QuoteRequest) (*QuoteResponse, error)
+}
+
+type service struct {
+	shippingRepo Repository
+	vendorRepo   vendor.Repository
+	userRepo     user.Repository
+}
+
+func NewService(shippingRepo Repository, vendorRepo vendor.Repository, userRepo user.Repository) Service {
+	return &service{
+		shippingRepo: shippingRepo,
+		vendorRepo:   vendorRepo,
+		userRepo:     userRepo,
+	}
+}
+
+func (s *service) GetProfile(ctx context.Context, vendorUserID primitive.ObjectID) (*ProfileResponse, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, vendorUserID)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := s.shippingRepo.GetByVendorID(ctx, v.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := profile.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) UpdateProfile(ctx context.Context, vendorUserID primitive.ObjectID, req UpdateProfileRequest) (*ProfileResponse, error) {
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, vendorUserID)
+	if err != nil {
+		return nil, err
+	}
+	if v.Status == vendor.ClosedVendorStatus {
+		return nil, vendor.ErrVendorClosed
+	}
+
+	zones, err := buildZones(req.Zones)
+	if err != nil {
+		return nil, err
+	}
+	profile := &Profile{
+		VendorID: v.ID,
+		Currency: req.Currency,
+		Zones:    zones,
+	}
+	if err := s.shippingRepo.Upsert(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.shippingRepo.GetByVendorID(ctx, v.ID)
+	if err != nil {
+		return nil, err
+	}
+	resp := saved.ToResponse()
+	return &resp, nil
+}
+
+// Quote prices every rate in the most specific zone covering the buyer's
+// address. Rates that cannot price the order (e.g. weight above the last
+// bounded tier) are left out.
+func (s *service) Quote(ctx context.Context, buyerID primitive.ObjectID, req QuoteRequest) (*QuoteResponse, error) {
+	v, err := s.quoteVendor(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	addr, err := s.destination(ctx, buyerID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	profile, err := s.shippingRepo.GetByVendorID(ctx, v.ID)
+	if errors.Is(err, ErrProfileNotFound) {
+		return nil, ErrNoShippingToRegion
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	zone := profile.MatchZone(addr)
+	if zone == nil {
+		return nil, ErrNoShippingToRegion
+	}
+
+	options := make([]QuoteOption, 0, len(zone.Rates))
+	for i := range zone.Rates {
+		rate := &zone.Rates[i]
+		amount, ok := rate.Price(req.Subtotal, req.WeightGrams)
+		if !ok {
+			continue
+		}
+		options = append(options, QuoteOption{
+			ZoneID:   zone.ID.Hex(),
+			ZoneName: zone.Name,
+			RateID:   rate.ID.Hex(),
+			Name:     rate.Name,
+			Amount:   amount,
+			Currency: profile.Currency,
+			MinDays:  rate.MinDays,
+			MaxDays:  rate.MaxDays,
+		})
+	}
+	if len(options) == 0 {
+		return nil, ErrNoShippingToRegion
+	}
+	sortOptions(options)
+
+	return &QuoteResponse{
+		Country:    addr.Country,
+		Region:     addr.Region,
+		PostalCode: addr.PostalCode,
+		Options:    options,
+	}, nil
+}
+
+func (s *service) quoteVendor(ctx context.Context, req QuoteRequest) (*vendor.Vendor, error) {
+	var v *vendor.Vendor
+	var err error
+	if req.VendorID != "" {
+		id, parseErr := primitive.ObjectIDFromHex(req.VendorID)
+		if parseErr != nil {
+			return nil, vendor.ErrVendorNotFound
+		}
+		v, err = s.vendorRepo.GetVendorByID(ctx, id)
+	} else {
+		v, err = s.vendorRepo.GetVendorBySlug(ctx, req.VendorSlug)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !v.CanSell() {
+		return nil, vendor.ErrVendorNotFound
+	}
+	return v, nil
+}
+
+// destination starts from the buyer's saved profile address and applies any
+// fields supplied on the request.
+func (s *service) destination(ctx context.Context, buyerID primitive.ObjectID, req QuoteRequest) (Address, error) {
+	var addr Address
+	profile, err := s.userRepo.GetProfileByUserID(ctx, buyerID)
+	switch {
+	case err == nil:
+		addr = Address{Country: profile.Country, Region: profile.Region, PostalCode: profile.PostalCode}
+	case !errors.Is(err, user.ErrProfileNotFound):
+		return Address{}, err
+	}
+
+	if req.Country != "" {
+		// A different country makes the saved region and postcode meaningless.
+		if !strings.EqualFold(req.Country, addr.Country) {
+			addr = Address{}
+		}
+		addr.Country = req.Country
+	}
+	if req.Region != "" {
+		addr.Region = req.Region
+	}
+	if req.PostalCode != "" {
+		addr.PostalCode = req.PostalCode
+	}
+
+	addr.Country = strings.TrimSpace(addr.Country)
+	if addr.Country == "" {
+		return Address{}, ErrAddressRequired
+	}
+	return addr, nil
+}
+
+// buildZones converts and validates the request, keeping IDs the client sent
+// back so rate references stay stable across edits.
+func buildZones(inputs []ZoneInput) ([]Zone, error) {
+	zones := make([]Zone, 0, len(inputs))
+	for _, zi := range inputs {
+		zone := Zone{
+			ID:             idOrNew(zi.ID),
+			Name:           strings.TrimSpace(zi.Name),
+			Countries:      trimAll(zi.Countries),
+			Regions:        trimAll(zi.Regions),
+			PostalPrefixes: trimAll(zi.PostalPrefixes),
+			Rates:          make([]Rate, 0, len(zi.Rates)),
+		}
+		for _, c := range zone.Countries {
+			if c == WildcardCountry && (len(zone.Regions) > 0 || len(zone.PostalPrefixes) > 0) {
+				return nil, fmt.Errorf("%w: zone %q cannot combine the wildcard country with regions or postal prefixes", ErrInvalidProfile, zone.Name)
+			}
+		}
+		for _, ri := range zi.Rates {
+			rate := Rate{
+				ID:       idOrNew(ri.ID),
+				Name:     strings.TrimSpace(ri.Name),
+				Type:     ri.Type,
+				Amount:   ri.Amount,
+				FreeOver: ri.FreeOver,
+				MinDays:  ri.MinDays,
+				MaxDays:  ri.MaxDays,
+			}
+			for _, t := range ri.Tiers {
+				rate.Tiers = append(rate.Tiers, Tier{UpTo: t.UpTo, Amount: t.Amount})
+			}
+			if err := rate.Validate(); err != nil {
+				return nil, err
+			}
+			zone.Rates = append(zone.Rates, rate)
+		}
+		zones = append(zones, zone)
+	}
+	return zones, nil
+}
+
+func idOrNew(hex string) primitive.ObjectID {
+	if id, err := primitive.ObjectIDFromHex(hex); err == nil {
+		return id
+	}
+	return primitive.NewObjectID()
+}
+
+func trimAll(values []string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
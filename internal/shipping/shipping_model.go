@@ -0,0 +1,219 @@
+package shipping
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type RateType string
+
+const (
+	FlatRate      RateType = "flat"
+	WeightRate    RateType = "weight"
+	PriceTierRate RateType = "price_tier"
+	FreeOverRate  RateType = "free_over"
+)
+
+// WildcardCountry matches any destination country ("rest of world").
+const WildcardCountry = "*"
+
+// Tier charges Amount while the order weight (grams) or subtotal (minor units)
+// is at most UpTo. UpTo of 0 means no upper bound and is only allowed last.
+type Tier struct {
+	UpTo   int64 `json:"up_to" bson:"up_to"`
+	Amount int64 `json:"amount" bson:"amount"`
+}
+
+// Rate amounts are in minor units of the profile currency.
+type Rate struct {
+	ID       primitive.ObjectID `json:"id" bson:"_id"`
+	Name     string             `json:"name" bson:"name"`
+	Type     RateType           `json:"type" bson:"type"`
+	Amount   int64              `json:"amount" bson:"amount"`
+	Tiers    []Tier             `json:"tiers,omitempty" bson:"tiers,omitempty"`
+	FreeOver int64              `json:"free_over,omitempty" bson:"free_over,omitempty"`
+	MinDays  int                `json:"min_days" bson:"min_days"`
+	MaxDays  int                `json:"max_days" bson:"max_days"`
+}
+
+type Zone struct {
+	ID             primitive.ObjectID `json:"id" bson:"_id"`
+	Name           string             `json:"name" bson:"name"`
+	Countries      []string           `json:"countries" bson:"countries"`
+	Regions        []string           `json:"regions,omitempty" bson:"regions,omitempty"`
+	PostalPrefixes []string           `json:"postal_prefixes,omitempty" bson:"postal_prefixes,omitempty"`
+	Rates          []Rate             `json:"rates" bson:"rates"`
+}
+
+type Profile struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	VendorID  primitive.ObjectID `json:"vendor_id" bson:"vendor_id"`
+	Currency  string             `json:"currency" bson:"currency"`
+	Zones     []Zone             `json:"zones" bson:"zones"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+type Address struct {
+	Country    string
+	Region     string
+	PostalCode string
+}
+
+// Validate checks tier ordering and rate parameters.
+func (r *Rate) Validate() error {
+	if r.MinDays < 0 || r.MaxDays < r.MinDays {
+		return fmt.Errorf("%w: rate %q has an invalid delivery estimate", ErrInvalidProfile, r.Name)
+	}
+	switch r.Type {
+	case FlatRate:
+		if r.Amount < 0 {
+			return fmt.Errorf("%w: rate %q has a negative amount", ErrInvalidProfile, r.Name)
+		}
+	case FreeOverRate:
+		if r.Amount < 0 || r.FreeOver <= 0 {
+			return fmt.Errorf("%w: rate %q needs an amount and a positive free_over threshold", ErrInvalidProfile, r.Name)
+		}
+	case WeightRate, PriceTierRate:
+		if len(r.Tiers) == 0 {
+			return fmt.Errorf("%w: rate %q needs at least one tier", ErrInvalidProfile, r.Name)
+		}
+		var previous int64
+		for i, t := range r.Tiers {
+			if t.Amount < 0 {
+				return fmt.Errorf("%w: rate %q has a negative tier amount", ErrInvalidProfile, r.Name)
+			}
+			if t.UpTo == 0 && i != len(r.Tiers)-1 {
+				return fmt.Errorf("%w: rate %q may only leave the last tier unbounded", ErrInvalidProfile, r.Name)
+			}
+			if t.UpTo != 0 && t.UpTo <= previous {
+				return fmt.Errorf("%w: rate %q tiers must be in ascending order", ErrInvalidProfile, r.Name)
+			}
+			previous = t.UpTo
+		}
+	default:
+		return fmt.Errorf("%w: rate %q has unknown type %q", ErrInvalidProfile, r.Name, r.Type)
+	}
+	return nil
+}
+
+// Price returns the shipping cost for an order, or false if the rate doesn't cover it.
+func (r *Rate) Price(subtotal, weightGrams int64) (int64, bool) {
+	switch r.Type {
+	case FlatRate:
+		return r.Amount, true
+	case FreeOverRate:
+		if subtotal >= r.FreeOver {
+			return 0, true
+		}
+		return r.Amount, true
+	case WeightRate:
+		return priceFromTiers(r.Tiers, weightGrams)
+	case PriceTierRate:
+		return priceFromTiers(r.Tiers, subtotal)
+	}
+	return 0, false
+}
+
+func priceFromTiers(tiers []Tier, value int64) (int64, bool) {
+	for _, t := range tiers {
+		if t.UpTo == 0 || value <= t.UpTo {
+			return t.Amount, true
+		}
+	}
+	return 0, false
+}
+
+// matchScore ranks how specifically a zone covers an address; 0 means no match.
+// Postal prefixes beat regions, which beat countries, which beat the wildcard.
+func (z *Zone) matchScore(addr Address) int {
+	country := false
+	for _, c := range z.Countries {
+		if strings.EqualFold(c, addr.Country) {
+			country = true
+			break
+		}
+	}
+	if !country {
+		for _, c := range z.Countries {
+			if c == WildcardCountry {
+				return 1
+			}
+		}
+		return 0
+	}
+
+	score := 2
+	if len(z.Regions) > 0 {
+		found := false
+		for _, r := range z.Regions {
+			if strings.EqualFold(r, addr.Region) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0
+		}
+		score = 3
+	}
+	if len(z.PostalPrefixes) > 0 {
+		postal := normalizePostal(addr.PostalCode)
+		longest := 0
+		for _, p := range z.PostalPrefixes {
+			p = normalizePostal(p)
+			if p != "" && strings.HasPrefix(postal, p) && len(p) > longest {
+				longest = len(p)
+			}
+		}
+		if longest == 0 {
+			return 0
+		}
+		score = 4 + longest
+	}
+	return score
+}
+
+// MatchZone returns the most specific zone covering the address.
+func (p *Profile) MatchZone(addr Address) *Zone {
+	var best *Zone
+	bestScore := 0
+	for i := range p.Zones {
+		if score := p.Zones[i].matchScore(addr); score > bestScore {
+			best = &p.Zones[i]
+			bestScore = score
+		}
+	}
+	return best
+}
+
+func normalizePostal(postal string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(postal), " ", ""))
+}
+
+func (p *Profile) ToResponse() ProfileResponse {
+	zones := p.Zones
+	if zones == nil {
+		zones = []Zone{}
+	}
+	return ProfileResponse{
+		ID:        p.ID.Hex(),
+		VendorID:  p.VendorID.Hex(),
+		Currency:  p.Currency,
+		Zones:     zones,
+		UpdatedAt: p.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
+
+func sortOptions(options []QuoteOption) {
+	sort.SliceStable(options, func(i, j int) bool {
+		if options[i].Amount != options[j].Amount {
+			return options[i].Amount < options[j].Amount
+		}
+		return options[i].MaxDays < options[j].MaxDays
+	})
+}
@@ -6,6 +6,8 @@ type CreateUserProfileRequest struct {
 	Street    string `json:"street" binding:"required,min=5,max=200"`
 	City      string `json:"city" binding:"required,min=2,max=100"`
 	Country   string `json:"country" binding:"required,min=2,max=100"`
+	Region    string `json:"region" binding:"omitempty,max=100"`
+	PostalCode string `json:"postal_code" binding:"omitempty,max=20"`
 	IsDefault bool   `json:"is_default"`
 }
 
@@ -15,6 +17,8 @@ type UpdateUserProfileRequest struct {
 	Street    *string `json:"street,omitempty" binding:"omitempty,min=5,max=200"`
 	City      *string `json:"city,omitempty" binding:"omitempty,min=2,max=100"`
 	Country   *string `json:"country,omitempty" binding:"omitempty,min=2,max=100"`
+	Region    *string `json:"region,omitempty" binding:"omitempty,max=100"`
+	PostalCode *string `json:"postal_code,omitempty" binding:"omitempty,max=20"`
 	IsDefault *bool   `json:"is_default,omitempty"`
 }
 
@@ -26,7 +30,21 @@ type UserProfileResponse struct {
 	Street    string `json:"street"`
 	City      string `json:"city"`
 	Country   string `json:"country"`
+	Region    string `json:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty"`
 	IsDefault bool   `json:"is_default"`
 	CreatedAt string `json:"created_at"`
 	UpdatedAt string `json:"updated_at"`
-}
\ No newline at end of file
+}
+
+type UserResponse struct {
+	ID               string `json:"id"`
+	Email            string `json:"email"`
+	Role             Role   `json:"role"`
+	IsVerified       bool   `json:"is_verified"`
+	IsSuspended      bool   `json:"is_suspended"`
+	SuspendedAt      string `json:"suspended_at,omitempty"`
+	SuspensionReason string `json:"suspension_reason,omitempty"`
+	CreatedAt        string `json:"created_at"`
+	UpdatedAt        string `json:"updated_at"`
+}
@@ -24,7 +24,7 @@ func (h *Handler) CreateUserProfile(c *gin.Context) {
 		response.BadRequest(c, "Invalide request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
 		return
 	}
-	userIDVal, exists := c.Get("userID")
+	userIDVal, exists := c.Get("subjectID")
 	if !exists {
 		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
 		return
@@ -57,7 +57,7 @@ func (h *Handler) UpdateUserProfile(c *gin.Context) {
 		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
 		return
 	}
-	userIDVal, exists := c.Get("userID")
+	userIDVal, exists := c.Get("subjectID")
 	if !exists {
 		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
 		return
@@ -81,7 +81,7 @@ func (h *Handler) UpdateUserProfile(c *gin.Context) {
 }
 
 func (h *Handler) GetUserProfile(c *gin.Context) {
-	userIDVal, exists := c.Get("userID")
+	userIDVal, exists := c.Get("subjectID")
 	if !exists {
 		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
 		return
@@ -106,7 +106,7 @@ func (h *Handler) GetUserProfile(c *gin.Context) {
 }
 
 func (h *Handler) DeleteUserProfile(c *gin.Context) {
-	userIDVal, exists := c.Get("userID")
+	userIDVal, exists := c.Get("subjectID")
 	if !exists {
 		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
 		return
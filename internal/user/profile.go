@@ -14,6 +14,8 @@ type UserProfile struct {
 	Street string `json:"street" bson:"street"`
 	City string `json:"city" bson:"city"`
 	Country string `json:"country" bson:"country"`
+	Region string `json:"region,omitempty" bson:"region,omitempty"`
+	PostalCode string `json:"postal_code,omitempty" bson:"postal_code,omitempty"`
 	IsDefault   bool `json:"is_default" bson:"is_default"`
 	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
@@ -45,6 +47,8 @@ type UpdateProfileRequest struct {
 	Street    *string `json:"street,omitempty" binding:"omitempty"`
 	City      *string `json:"city,omitempty" binding:"omitempty"`
 	Country   *string `json:"country,omitempty" binding:"omitempty"`
+	Region    *string `json:"region,omitempty" binding:"omitempty,max=100"`
+	PostalCode *string `json:"postal_code,omitempty" binding:"omitempty,max=20"`
 	IsDefault *bool   `json:"is_default,omitempty" binding:"omitempty"`
 }
 
@@ -65,6 +69,12 @@ func (p *UserProfile) Apply(req UpdateProfileRequest) {
 	if req.Country != nil {
 		p.Country = *req.Country
 	}
+	if req.Region != nil {
+		p.Region = *req.Region
+	}
+	if req.PostalCode != nil {
+		p.PostalCode = *req.PostalCode
+	}
 	if req.IsDefault != nil {
 		p.IsDefault = *req.IsDefault
 	}
@@ -80,6 +90,8 @@ func (p *UserProfile) ToResponse() UserProfileResponse {
         Street: p.Street,
         City: p.City,
         Country: p.Country,
+        Region: p.Region,
+        PostalCode: p.PostalCode,
         IsDefault: p.IsDefault,
         CreatedAt: p.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
         UpdatedAt: p.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
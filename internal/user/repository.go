@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"regexp"
 	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
@@ -19,6 +20,8 @@ type Repository interface {
 	Update(ctx context.Context, u *User) error
 	Verify(ctx context.Context, id primitive.ObjectID) error
 	Exists(ctx context.Context, email string) (bool, error) 
+	List(ctx context.Context, filter ListFilter, page, pageSize int) ([]User, int64, error)
+	SetSuspended(ctx context.Context, id primitive.ObjectID, suspended bool, reason string) error
 
 	CreateProfile(ctx context.Context, p *UserProfile) error
 	GetProfileByUserID(ctx context.Context, userID primitive.ObjectID) (*UserProfile, error)
@@ -28,6 +31,14 @@ type Repository interface {
 	RegisterProfile(ctx context.Context, userID primitive.ObjectID) error
 }
 
+type ListFilter struct {
+	Role          Role
+	Verified      *bool
+	Suspended     *bool
+	EmailPrefix   string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+}
 
 type UserRepository struct {
 	collection *mongo.Collection
@@ -56,7 +67,7 @@ func (r *UserRepository) FindByEmail(ctx context.Context, email string) (*User,
 	var u User
 	err := r.collection.FindOne(ctx, bson.M{"email": email}).Decode(&u)
 	if err == mongo.ErrNoDocuments {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	}
 	return &u, err
 }
@@ -65,7 +76,7 @@ func (r *UserRepository) FindByID(ctx context.Context, id primitive.ObjectID) (*
 	var u User
 	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&u)
 	if err == mongo.ErrNoDocuments {
-		return nil, errors.New("user not found")
+		return nil, ErrUserNotFound
 	}
 	return &u, err
 }
@@ -103,6 +114,83 @@ func (r *UserRepository) Exists(ctx context.Context, email string) (bool, error)
 	return count > 0, nil
 }
 
+func (r *UserRepository) List(ctx context.Context, filter ListFilter, page, pageSize int) ([]User, int64, error) {
+	query := bson.M{}
+	if filter.Role != "" {
+		query["role"] = filter.Role
+	}
+	if filter.Verified != nil {
+		query["is_verified"] = *filter.Verified
+	}
+	if filter.Suspended != nil {
+		if *filter.Suspended {
+			query["is_suspended"] = true
+		} else {
+			query["is_suspended"] = bson.M{"$ne": true}
+		}
+	}
+	if filter.EmailPrefix != "" {
+		// Anchored, case-sensitive regex so the email index can serve it as a range scan.
+		query["email"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(filter.EmailPrefix)}
+	}
+	created := bson.M{}
+	if !filter.CreatedAfter.IsZero() {
+		created["$gte"] = filter.CreatedAfter
+	}
+	if !filter.CreatedBefore.IsZero() {
+		created["$lt"] = filter.CreatedBefore
+	}
+	if len(created) > 0 {
+		query["created_at"] = created
+	}
+
+	total, err := r.collection.CountDocuments(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.collection.Find(ctx, query, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	users := []User{}
+	if err := cursor.All(ctx, &users); err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+func (r *UserRepository) SetSuspended(ctx context.Context, id primitive.ObjectID, suspended bool, reason string) error {
+	update := bson.M{
+		"$set": bson.M{
+			"is_suspended":      true,
+			"suspended_at":      time.Now(),
+			"suspension_reason": reason,
+			"updated_at":        time.Now(),
+		},
+	}
+	if !suspended {
+		update = bson.M{
+			"$set":   bson.M{"is_suspended": false, "updated_at": time.Now()},
+			"$unset": bson.M{"suspended_at": "", "suspension_reason": ""},
+		}
+	}
+
+	res, err := r.collection.UpdateOne(ctx, bson.M{"_id": id}, update)
+	if err != nil {
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
 func (r *UserRepository) CreateProfile (ctx context.Context, p *UserProfile)error{
 	exists, err := r.ProfileExists(ctx, p.UserID)
 	if err != nil {
@@ -151,7 +239,7 @@ func (r *UserRepository) GetProfileByUserID(ctx context.Context, userID primitiv
 	fmt.Println("Getting profile for userID:", userID) // Debug log
 	err := r.profileCollection.FindOne(ctx, bson.M{"user_id": userID}).Decode(&p)
 	if err == mongo.ErrNoDocuments {
-		return nil, errors.New("user profile not found")
+		return nil, ErrProfileNotFound
 	}
 	fmt.Printf("Found profile: %+v\n", p) // Debug log
 	return &p, err
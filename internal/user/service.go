@@ -11,6 +11,7 @@ var (
 	ErrUserAlreadyExists = errors.New("user already exists")
 	ErrUserProfileExists = errors.New("profile already exist")
 	ErrUserNotFound      = errors.New("user not found")
+	ErrProfileNotFound   = errors.New("user profile not found")
 )
 
 type Service interface {
@@ -54,6 +55,8 @@ func (s *service) CreateUserProfile(ctx context.Context, userID primitive.Object
 		req.Country,
 		req.IsDefault,
 	)
+	profile.Region = req.Region
+	profile.PostalCode = req.PostalCode
 
 	if err := s.userRepo.CreateProfile(ctx, profile); err != nil {
 		return UserProfileResponse{}, err
@@ -11,6 +11,7 @@ type Role string
 const (
 	RoleVendor Role = "vendor"
 	RoleUser  Role = "user"
+	RoleAdmin Role = "admin"
 )
 
 type User struct {
@@ -19,6 +20,9 @@ type User struct {
 	PasswordHash string             `json:"-" bson:"password_hash"` // Fixed typo: Passwordhash → PasswordHash
 	Role         Role               `json:"role" bson:"role"`
 	IsVerified   bool               `json:"is_verified" bson:"is_verified"`
+	IsSuspended  bool               `json:"is_suspended" bson:"is_suspended"`
+	SuspendedAt  *time.Time         `json:"suspended_at,omitempty" bson:"suspended_at,omitempty"`
+	SuspensionReason string         `json:"suspension_reason,omitempty" bson:"suspension_reason,omitempty"`
 	CreatedAt    time.Time          `json:"created_at" bson:"created_at"`
 	UpdatedAt    time.Time          `json:"updated_at" bson:"updated_at"`
 }
@@ -41,6 +45,23 @@ func (u *User) UpdateTimestamp() {
 }
 
 
+func (u *User) ToResponse() UserResponse {
+	resp := UserResponse{
+		ID:               u.ID.Hex(),
+		Email:            u.Email,
+		Role:             u.Role,
+		IsVerified:       u.IsVerified,
+		IsSuspended:      u.IsSuspended,
+		SuspensionReason: u.SuspensionReason,
+		CreatedAt:        u.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:        u.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if u.SuspendedAt != nil {
+		resp.SuspendedAt = u.SuspendedAt.Format("2006-01-02T15:04:05Z07:00")
+	}
+	return resp
+}
+
 func (u *User) TableName() string {
 	return "users"
 }
\ No newline at end of file
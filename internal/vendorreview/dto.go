@@ -0,0 +1,46 @@
+package vendorreview
+
+type CreateReviewRequest struct {
+	Rating int    `json:"rating" binding:"required,min=1,max=5"`
+	Title  string `json:"title" binding:"omitempty,max=120"`
+	Body   string `json:"body" binding:"omitempty,max=2000"`
+}
+
+type UpdateReviewRequest struct {
+	Rating *int    `json:"rating,omitempty" binding:"omitempty,min=1,max=5"`
+	Title  *string `json:"title,omitempty" binding:"omitempty,max=120"`
+	Body   *string `json:"body,omitempty" binding:"omitempty,max=2000"`
+}
+
+type ReplyRequest struct {
+	Body string `json:"body" binding:"required,min=1,max=2000"`
+}
+
+type ListReviewsQuery struct {
+	Page     int `form:"page" binding:"omitempty,min=1"`
+	PageSize int `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type ReplyResponse struct {
+	Body      string `json:"body"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+}
+
+type ReviewResponse struct {
+	ID        string         `json:"id"`
+	VendorID  string         `json:"vendor_id"`
+	BuyerID   string         `json:"buyer_id"`
+	Rating    int            `json:"rating"`
+	Title     string         `json:"title"`
+	Body      string         `json:"body"`
+	Reply     *ReplyResponse `json:"reply,omitempty"`
+	Editable  bool           `json:"editable"`
+	CreatedAt string         `json:"created_at"`
+	UpdatedAt string         `json:"updated_at"`
+}
+
+type RecomputeResponse struct {
+	VendorsUpdated int   `json:"vendors_updated"`
+	VendorsReset   int64 `json:"vendors_reset"`
+}
@@ -0,0 +1,181 @@
+package vendorreview
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/techrook/23-market/internal/user"
+	"github.com/techrook/23-market/internal/vendor"
+	"github.com/techrook/23-market/pkg/response"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Handler struct {
+	reviewService Service
+}
+
+func NewHandler(reviewService Service) *Handler {
+	return &Handler{
+		reviewService: reviewService,
+	}
+}
+
+func (h *Handler) ListReviews(c *gin.Context) {
+	var query ListReviewsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		response.BadRequest(c, "Invalid query parameters", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+	if query.Page == 0 {
+		query.Page = 1
+	}
+	if query.PageSize == 0 {
+		query.PageSize = 20
+	}
+
+	reviews, total, err := h.reviewService.ListReviews(c.Request.Context(), c.Param("slug"), query.Page, query.PageSize)
+	if err != nil {
+		handleError(c, err, "Failed to list reviews")
+		return
+	}
+	response.Paginated(c, reviews, query.Page, query.PageSize, int(total), "Reviews retrieved successfully")
+}
+
+func (h *Handler) CreateReview(c *gin.Context) {
+	buyerID, ok := callerID(c)
+	if !ok {
+		return
+	}
+
+	var req CreateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	review, err := h.reviewService.CreateReview(c.Request.Context(), c.Param("slug"), buyerID, req)
+	if err != nil {
+		handleError(c, err, "Failed to create review")
+		return
+	}
+	response.Created(c, review, "Review created successfully")
+}
+
+func (h *Handler) UpdateReview(c *gin.Context) {
+	buyerID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	reviewID, ok := reviewIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req UpdateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	review, err := h.reviewService.UpdateReview(c.Request.Context(), reviewID, buyerID, req)
+	if err != nil {
+		handleError(c, err, "Failed to update review")
+		return
+	}
+	response.OK(c, review, "Review updated successfully")
+}
+
+func (h *Handler) DeleteReview(c *gin.Context) {
+	actorID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	reviewID, ok := reviewIDParam(c)
+	if !ok {
+		return
+	}
+	role, _ := c.Get("userRole")
+
+	if err := h.reviewService.DeleteReview(c.Request.Context(), reviewID, actorID, role == user.RoleAdmin); err != nil {
+		handleError(c, err, "Failed to delete review")
+		return
+	}
+	response.OK(c, nil, "Review deleted successfully")
+}
+
+func (h *Handler) ReplyToReview(c *gin.Context) {
+	vendorUserID, ok := callerID(c)
+	if !ok {
+		return
+	}
+	reviewID, ok := reviewIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req ReplyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.BadRequest(c, "Invalid request format", gin.H{"errors": err.Error()}, response.IsProduction(c))
+		return
+	}
+
+	review, err := h.reviewService.ReplyToReview(c.Request.Context(), reviewID, vendorUserID, req.Body)
+	if err != nil {
+		handleError(c, err, "Failed to reply to review")
+		return
+	}
+	response.OK(c, review, "Reply saved successfully")
+}
+
+func (h *Handler) RecomputeRatings(c *gin.Context) {
+	result, err := h.reviewService.RecomputeRatings(c.Request.Context())
+	if err != nil {
+		response.InternalError(c, "Failed to recompute ratings", err, response.IsProduction(c))
+		return
+	}
+	response.OK(c, result, "Vendor ratings recomputed")
+}
+
+func handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, ErrReviewNotFound):
+		response.NotFound(c, "Review", response.IsProduction(c))
+	case errors.Is(err, vendor.ErrVendorNotFound):
+		response.NotFound(c, "Vendor", response.IsProduction(c))
+	case errors.Is(err, ErrAlreadyReviewed):
+		response.Conflict(c, "You have already reviewed this vendor", nil, response.IsProduction(c))
+	case errors.Is(err, ErrConcurrentEdit):
+		response.Conflict(c, "Review was changed by another request, please retry", nil, response.IsProduction(c))
+	case errors.Is(err, ErrEditWindowClosed):
+		response.Forbidden(c, "Reviews can only be edited within 7 days of posting", response.IsProduction(c))
+	case errors.Is(err, ErrNotReviewAuthor):
+		response.Forbidden(c, "Only the author can change this review", response.IsProduction(c))
+	case errors.Is(err, ErrNotReviewVendor):
+		response.Forbidden(c, "Only the reviewed vendor can reply", response.IsProduction(c))
+	default:
+		response.InternalError(c, message, err, response.IsProduction(c))
+	}
+}
+
+func reviewIDParam(c *gin.Context) (primitive.ObjectID, bool) {
+	reviewID, err := primitive.ObjectIDFromHex(c.Param("reviewID"))
+	if err != nil {
+		response.BadRequest(c, "Invalid review ID", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return reviewID, true
+}
+
+func callerID(c *gin.Context) (primitive.ObjectID, bool) {
+	val, exists := c.Get("userID")
+	if !exists {
+		response.Unauthorized(c, "Authentication required", response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	userID, ok := val.(primitive.ObjectID)
+	if !ok {
+		response.InternalError(c, "Invalid user context", nil, response.IsProduction(c))
+		return primitive.NilObjectID, false
+	}
+	return userID, true
+}
@@ -0,0 +1,138 @@
+package vendorreview
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type Repository interface {
+	Create(ctx context.Context, review *Review) error
+	GetByID(ctx context.Context, id primitive.ObjectID) (*Review, error)
+	ListByVendor(ctx context.Context, vendorID primitive.ObjectID, page, pageSize int) ([]Review, int64, error)
+	// Update applies set only if the review still has expectedRating, so the
+	// caller's rating delta is based on what was actually replaced.
+	Update(ctx context.Context, id primitive.ObjectID, expectedRating int, set bson.M) (*Review, error)
+	Delete(ctx context.Context, id primitive.ObjectID) (*Review, error)
+	SetReply(ctx context.Context, id primitive.ObjectID, body string) (*Review, error)
+	AggregateByVendor(ctx context.Context) ([]VendorRating, error)
+}
+
+type ReviewRepository struct {
+	collection *mongo.Collection
+}
+
+func NewReviewRepository(db *mongo.Database) Repository {
+	return &ReviewRepository{
+		collection: db.Collection("vendor_reviews"),
+	}
+}
+
+func (r *ReviewRepository) Create(ctx context.Context, review *Review) error {
+	_, err := r.collection.InsertOne(ctx, review)
+	if mongo.IsDuplicateKeyError(err) {
+		return ErrAlreadyReviewed
+	}
+	return err
+}
+
+func (r *ReviewRepository) GetByID(ctx context.Context, id primitive.ObjectID) (*Review, error) {
+	var review Review
+	err := r.collection.FindOne(ctx, bson.M{"_id": id}).Decode(&review)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReviewNotFound
+	}
+	return &review, err
+}
+
+func (r *ReviewRepository) ListByVendor(ctx context.Context, vendorID primitive.ObjectID, page, pageSize int) ([]Review, int64, error) {
+	filter := bson.M{"vendor_id": vendorID}
+
+	total, err := r.collection.CountDocuments(ctx, filter)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "created_at", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip(int64((page - 1) * pageSize)).
+		SetLimit(int64(pageSize))
+
+	cursor, err := r.collection.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	reviews := []Review{}
+	if err := cursor.All(ctx, &reviews); err != nil {
+		return nil, 0, err
+	}
+	return reviews, total, nil
+}
+
+func (r *ReviewRepository) Update(ctx context.Context, id primitive.ObjectID, expectedRating int, set bson.M) (*Review, error) {
+	set["updated_at"] = time.Now()
+
+	var review Review
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id, "rating": expectedRating},
+		bson.M{"$set": set},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&review)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrConcurrentEdit
+	}
+	return &review, err
+}
+
+func (r *ReviewRepository) Delete(ctx context.Context, id primitive.ObjectID) (*Review, error) {
+	var review Review
+	err := r.collection.FindOneAndDelete(ctx, bson.M{"_id": id}).Decode(&review)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReviewNotFound
+	}
+	return &review, err
+}
+
+func (r *ReviewRepository) SetReply(ctx context.Context, id primitive.ObjectID, body string) (*Review, error) {
+	now := time.Now()
+	var review Review
+	err := r.collection.FindOneAndUpdate(
+		ctx,
+		bson.M{"_id": id},
+		bson.A{bson.M{"$set": bson.M{
+			"reply": bson.M{
+				"body":       body,
+				"created_at": bson.M{"$ifNull": bson.A{"$reply.created_at", now}},
+				"updated_at": now,
+			},
+		}}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&review)
+	if err == mongo.ErrNoDocuments {
+		return nil, ErrReviewNotFound
+	}
+	return &review, err
+}
+
+func (r *ReviewRepository) AggregateByVendor(ctx context.Context) ([]VendorRating, error) {
+	cursor, err := r.collection.Aggregate(ctx, mongo.Pipeline{
+		{{Key: "$group", Value: bson.M{
+			"_id":   "$vendor_id",
+			"sum":   bson.M{"$sum": bson.M{"$toLong": "$rating"}},
+			"count": bson.M{"$sum": 1},
+		}}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ratings := []VendorRating{}
+	if err := cursor.All(ctx, &ratings); err != nil {
+		return nil, err
+	}
+	return ratings, nil
+}
@@ -0,0 +1,75 @@
+package vendorreview
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// EditWindow is how long after posting a buyer may still change their review.
+const EditWindow = 7 * 24 * time.Hour
+
+type Reply struct {
+	Body      string    `json:"body" bson:"body"`
+	CreatedAt time.Time `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" bson:"updated_at"`
+}
+
+type Review struct {
+	ID        primitive.ObjectID `json:"id" bson:"_id,omitempty"`
+	VendorID  primitive.ObjectID `json:"vendor_id" bson:"vendor_id"`
+	BuyerID   primitive.ObjectID `json:"buyer_id" bson:"buyer_id"`
+	Rating    int                `json:"rating" bson:"rating"`
+	Title     string             `json:"title" bson:"title"`
+	Body      string             `json:"body" bson:"body"`
+	Reply     *Reply             `json:"reply,omitempty" bson:"reply,omitempty"`
+	CreatedAt time.Time          `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
+}
+
+// VendorRating is a vendor's rating totals as computed from its reviews.
+type VendorRating struct {
+	VendorID primitive.ObjectID `bson:"_id"`
+	Sum      int64              `bson:"sum"`
+	Count    int32              `bson:"count"`
+}
+
+func NewReview(vendorID, buyerID primitive.ObjectID, rating int, title, body string) *Review {
+	now := time.Now()
+	return &Review{
+		ID:        primitive.NewObjectID(),
+		VendorID:  vendorID,
+		BuyerID:   buyerID,
+		Rating:    rating,
+		Title:     title,
+		Body:      body,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+func (r *Review) IsEditable(now time.Time) bool {
+	return now.Before(r.CreatedAt.Add(EditWindow))
+}
+
+func (r *Review) ToResponse() ReviewResponse {
+	resp := ReviewResponse{
+		ID:        r.ID.Hex(),
+		VendorID:  r.VendorID.Hex(),
+		BuyerID:   r.BuyerID.Hex(),
+		Rating:    r.Rating,
+		Title:     r.Title,
+		Body:      r.Body,
+		Editable:  r.IsEditable(time.Now()),
+		CreatedAt: r.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: r.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if r.Reply != nil {
+		resp.Reply = &ReplyResponse{
+			Body:      r.Reply.Body,
+			CreatedAt: r.Reply.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt: r.Reply.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+	return resp
+}
@@ -0,0 +1,191 @@
+package vendorreview
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/techrook/23-market/internal/vendor"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	ErrReviewNotFound   = errors.New("review not found")
+	ErrAlreadyReviewed  = errors.New("vendor already reviewed by this buyer")
+	ErrConcurrentEdit   = errors.New("review was changed concurrently")
+	ErrEditWindowClosed = errors.New("review can no longer be edited")
+	ErrNotReviewAuthor  = errors.New("only the author can change this review")
+	ErrNotReviewVendor  = errors.New("only the reviewed vendor can reply")
+)
+
+type Service interface {
+	CreateReview(ctx context.Context, slug string, buyerID primitive.ObjectID, req CreateReviewRequest) (*ReviewResponse, error)
+	ListReviews(ctx context.Context, slug string, page, pageSize int) ([]ReviewResponse, int64, error)
+	UpdateReview(ctx context.Context, reviewID, buyerID primitive.ObjectID, req UpdateReviewRequest) (*ReviewResponse, error)
+	DeleteReview(ctx context.Context, reviewID, actorID primitive.ObjectID, isAdmin bool) error
+	ReplyToReview(ctx context.Context, reviewID, vendorUserID primitive.ObjectID, body string) (*ReviewResponse, error)
+	RecomputeRatings(ctx context.Context) (*RecomputeResponse, error)
+}
+
+type service struct {
+	reviewRepo Repository
+	vendorRepo vendor.Repository
+}
+
+func NewService(reviewRepo Repository, vendorRepo vendor.Repository) Service {
+	return &service{
+		reviewRepo: reviewRepo,
+		vendorRepo: vendorRepo,
+	}
+}
+
+func (s *service) CreateReview(ctx context.Context, slug string, buyerID primitive.ObjectID, req CreateReviewRequest) (*ReviewResponse, error) {
+	v, err := s.storefrontVendor(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	review := NewReview(v.ID, buyerID, req.Rating, req.Title, req.Body)
+	if err := s.reviewRepo.Create(ctx, review); err != nil {
+		return nil, err
+	}
+	s.adjustRating(ctx, v.ID, int64(review.Rating), 1)
+
+	resp := review.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) ListReviews(ctx context.Context, slug string, page, pageSize int) ([]ReviewResponse, int64, error) {
+	v, err := s.storefrontVendor(ctx, slug)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	reviews, total, err := s.reviewRepo.ListByVendor(ctx, v.ID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	resp := make([]ReviewResponse, 0, len(reviews))
+	for i := range reviews {
+		resp = append(resp, reviews[i].ToResponse())
+	}
+	return resp, total, nil
+}
+
+func (s *service) UpdateReview(ctx context.Context, reviewID, buyerID primitive.ObjectID, req UpdateReviewRequest) (*ReviewResponse, error) {
+	review, err := s.reviewRepo.GetByID(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	if review.BuyerID != buyerID {
+		return nil, ErrNotReviewAuthor
+	}
+	if !review.IsEditable(time.Now()) {
+		return nil, ErrEditWindowClosed
+	}
+
+	set := bson.M{}
+	if req.Rating != nil {
+		set["rating"] = *req.Rating
+	}
+	if req.Title != nil {
+		set["title"] = *req.Title
+	}
+	if req.Body != nil {
+		set["body"] = *req.Body
+	}
+
+	updated, err := s.reviewRepo.Update(ctx, reviewID, review.Rating, set)
+	if err != nil {
+		return nil, err
+	}
+	if delta := updated.Rating - review.Rating; delta != 0 {
+		s.adjustRating(ctx, review.VendorID, int64(delta), 0)
+	}
+
+	resp := updated.ToResponse()
+	return &resp, nil
+}
+
+func (s *service) DeleteReview(ctx context.Context, reviewID, actorID primitive.ObjectID, isAdmin bool) error {
+	review, err := s.reviewRepo.GetByID(ctx, reviewID)
+	if err != nil {
+		return err
+	}
+	if review.BuyerID != actorID && !isAdmin {
+		return ErrNotReviewAuthor
+	}
+
+	deleted, err := s.reviewRepo.Delete(ctx, reviewID)
+	if err != nil {
+		return err
+	}
+	s.adjustRating(ctx, deleted.VendorID, -int64(deleted.Rating), -1)
+	return nil
+}
+
+func (s *service) ReplyToReview(ctx context.Context, reviewID, vendorUserID primitive.ObjectID, body string) (*ReviewResponse, error) {
+	review, err := s.reviewRepo.GetByID(ctx, reviewID)
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.vendorRepo.GetVendorByUserID(ctx, vendorUserID)
+	if err != nil {
+		return nil, err
+	}
+	if review.VendorID != v.ID {
+		return nil, ErrNotReviewVendor
+	}
+
+	updated, err := s.reviewRepo.SetReply(ctx, reviewID, body)
+	if err != nil {
+		return nil, err
+	}
+	resp := updated.ToResponse()
+	return &resp, nil
+}
+
+// RecomputeRatings rebuilds every vendor's rating from its reviews, repairing
+// any drift left by failed incremental updates.
+func (s *service) RecomputeRatings(ctx context.Context) (*RecomputeResponse, error) {
+	ratings, err := s.reviewRepo.AggregateByVendor(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(ratings))
+	for _, r := range ratings {
+		if err := s.vendorRepo.SetRating(ctx, r.VendorID, r.Sum, r.Count); err != nil {
+			return nil, err
+		}
+		ids = append(ids, r.VendorID)
+	}
+
+	reset, err := s.vendorRepo.ResetRatingsExcept(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	return &RecomputeResponse{VendorsUpdated: len(ratings), VendorsReset: reset}, nil
+}
+
+func (s *service) storefrontVendor(ctx context.Context, slug string) (*vendor.Vendor, error) {
+	v, err := s.vendorRepo.GetVendorBySlug(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	if !v.CanSell() {
+		return nil, vendor.ErrVendorNotFound
+	}
+	return v, nil
+}
+
+// adjustRating is best effort: the review write has already succeeded and
+// RecomputeRatings repairs the aggregate if this fails.
+func (s *service) adjustRating(ctx context.Context, vendorID primitive.ObjectID, sumDelta int64, countDelta int32) {
+	if err := s.vendorRepo.AdjustRating(ctx, vendorID, sumDelta, countDelta); err != nil {
+		log.Printf("⚠️ Failed to update rating for vendor %s: %v", vendorID.Hex(), err)
+	}
+}
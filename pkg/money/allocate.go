@@ -0,0 +1,121 @@
+package money
+
+import (
+	"math/big"
+	"sort"
+)
+
+// Rounding decides what happens to a fraction of a minor unit.
+type Rounding int
+
+const (
+	// HalfEven rounds to the nearest unit and ties to the even one (banker's
+	// rounding), so repeated rounding doesn't drift in one direction.
+	HalfEven Rounding = iota
+	// HalfUp rounds to the nearest unit and ties away from zero, as shoppers
+	// expect on displayed prices.
+	HalfUp
+	// Down truncates towards zero.
+	Down
+	// Up rounds away from zero.
+	Up
+)
+
+func (mode Rounding) round(r *big.Rat) *big.Int {
+	q, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+	if rem.Sign() == 0 {
+		return q
+	}
+
+	awayFromZero := false
+	switch mode {
+	case Up:
+		awayFromZero = true
+	case HalfUp, HalfEven:
+		// Compare the dropped fraction with one half.
+		twice := new(big.Int).Abs(rem)
+		twice.Lsh(twice, 1)
+		switch twice.Cmp(r.Denom()) {
+		case 1:
+			awayFromZero = true
+		case 0:
+			awayFromZero = mode == HalfUp || q.Bit(0) == 1
+		}
+	}
+	if awayFromZero {
+		if r.Sign() < 0 {
+			return q.Sub(q, big.NewInt(1))
+		}
+		return q.Add(q, big.NewInt(1))
+	}
+	return q
+}
+
+// Allocate splits m in proportion to ratios without losing or inventing a
+// minor unit: the parts always add up to m exactly. Units left over after
+// rounding every part down go to the parts whose exact share was rounded
+// down the most, earlier parts first on ties. Splitting 100 cents 1:1:1
+// gives 34, 33, 33.
+func (m Money) Allocate(ratios ...int64) ([]Money, error) {
+	if !m.IsSet() {
+		return nil, ErrUnknownCurrency
+	}
+	total := new(big.Int)
+	for _, ratio := range ratios {
+		if ratio < 0 {
+			return nil, ErrInvalidRatios
+		}
+		total.Add(total, big.NewInt(ratio))
+	}
+	if total.Sign() == 0 {
+		return nil, ErrInvalidRatios
+	}
+
+	// Work on the magnitude so negative amounts round the same way and get
+	// the sign back at the end.
+	abs := new(big.Int).Abs(big.NewInt(m.amount))
+	shares := make([]*big.Int, len(ratios))
+	remainders := make([]*big.Int, len(ratios))
+	left := new(big.Int).Set(abs)
+	for i, ratio := range ratios {
+		exact := new(big.Int).Mul(abs, big.NewInt(ratio))
+		shares[i], remainders[i] = new(big.Int).QuoRem(exact, total, new(big.Int))
+		left.Sub(left, shares[i])
+	}
+
+	order := make([]int, len(ratios))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return remainders[order[a]].Cmp(remainders[order[b]]) > 0
+	})
+	for _, i := range order[:left.Int64()] {
+		shares[i].Add(shares[i], big.NewInt(1))
+	}
+
+	parts := make([]Money, len(ratios))
+	for i, share := range shares {
+		if m.amount < 0 {
+			share.Neg(share)
+		}
+		part, err := m.withBig(share)
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = part
+	}
+	return parts, nil
+}
+
+// Split divides m into n parts that differ by at most one minor unit.
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, ErrInvalidRatios
+	}
+	ratios := make([]int64, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
@@ -0,0 +1,106 @@
+package money
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		num, den                   int64
+		halfEven, halfUp, down, up int64
+	}{
+		{5, 2, 2, 3, 2, 3},      // 2.5
+		{7, 2, 4, 4, 3, 4},      // 3.5
+		{-5, 2, -2, -3, -2, -3}, // -2.5
+		{-7, 2, -4, -4, -3, -4}, // -3.5
+		{1, 3, 0, 0, 0, 1},      // 0.333…
+		{-2, 3, -1, -1, 0, -1},  // -0.666…
+		{8, 2, 4, 4, 4, 4},      // exact
+	}
+
+	for _, tt := range tests {
+		r := big.NewRat(tt.num, tt.den)
+		for mode, want := range map[Rounding]int64{HalfEven: tt.halfEven, HalfUp: tt.halfUp, Down: tt.down, Up: tt.up} {
+			if got := mode.round(r); got.Int64() != want {
+				t.Errorf("mode %d round(%s) = %s, want %d", mode, r.RatString(), got, want)
+			}
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		ratios []int64
+		want   []int64
+	}{
+		{name: "100 split 1:1:1", amount: 100, ratios: []int64{1, 1, 1}, want: []int64{34, 33, 33}},
+		{name: "negative amounts mirror positive", amount: -100, ratios: []int64{1, 1, 1}, want: []int64{-34, -33, -33}},
+		{name: "zero ratio gets nothing", amount: 100, ratios: []int64{1, 0, 1}, want: []int64{50, 0, 50}},
+		{name: "leftover goes to largest remainder", amount: 10, ratios: []int64{1, 2}, want: []int64{3, 7}},
+		{name: "ties go to earlier parts", amount: 5, ratios: []int64{3, 7}, want: []int64{2, 3}},
+		{name: "exact split", amount: 90, ratios: []int64{2, 1}, want: []int64{60, 30}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parts, err := MustNew(tt.amount, "USD").Allocate(tt.ratios...)
+			if err != nil {
+				t.Fatalf("Allocate: %v", err)
+			}
+			if len(parts) != len(tt.want) {
+				t.Fatalf("got %d parts, want %d", len(parts), len(tt.want))
+			}
+			var sum int64
+			for i, p := range parts {
+				if p.Amount() != tt.want[i] || p.Currency() != "USD" {
+					t.Errorf("part %d = %s, want %d USD", i, p, tt.want[i])
+				}
+				sum += p.Amount()
+			}
+			if sum != tt.amount {
+				t.Errorf("parts add up to %d, want %d", sum, tt.amount)
+			}
+		})
+	}
+}
+
+func TestAllocateErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		money  Money
+		ratios []int64
+		want   error
+	}{
+		{name: "no ratios", money: MustNew(100, "USD"), want: ErrInvalidRatios},
+		{name: "all zero", money: MustNew(100, "USD"), ratios: []int64{0, 0}, want: ErrInvalidRatios},
+		{name: "negative ratio", money: MustNew(100, "USD"), ratios: []int64{2, -1}, want: ErrInvalidRatios},
+		{name: "unset money", money: Money{}, ratios: []int64{1}, want: ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.money.Allocate(tt.ratios...); !errors.Is(err, tt.want) {
+				t.Errorf("Allocate error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplit(t *testing.T) {
+	parts, err := MustNew(100, "JPY").Split(3)
+	if err != nil {
+		t.Fatalf("Split: %v", err)
+	}
+	for i, want := range []int64{34, 33, 33} {
+		if parts[i].Amount() != want {
+			t.Errorf("part %d = %d, want %d", i, parts[i].Amount(), want)
+		}
+	}
+	if _, err := MustNew(100, "JPY").Split(0); !errors.Is(err, ErrInvalidRatios) {
+		t.Errorf("Split(0) error = %v, want %v", err, ErrInvalidRatios)
+	}
+}
@@ -0,0 +1,92 @@
+package money
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// wire is how Money is stored and sent. Amount stays in minor units so
+// clients never parse decimals; Display is the major-unit string for
+// showing as-is and is ignored on input.
+type wire struct {
+	Amount   int64  `json:"amount" bson:"amount"`
+	Currency string `json:"currency" bson:"currency"`
+	Display  string `json:"display,omitempty" bson:"-"`
+}
+
+func (m Money) toWire() wire {
+	return wire{Amount: m.amount, Currency: m.currency.Code, Display: m.Decimal()}
+}
+
+func fromWire(w wire) (Money, error) {
+	if w.Currency == "" {
+		return Money{}, fmt.Errorf("money: %w: currency is required", ErrUnknownCurrency)
+	}
+	m, err := New(w.Amount, w.Currency)
+	if err != nil {
+		return Money{}, fmt.Errorf("money: %w: %q", err, w.Currency)
+	}
+	return m, nil
+}
+
+// MarshalJSON writes {"amount": 1250, "currency": "USD", "display": "12.50"},
+// or null for the zero value.
+func (m Money) MarshalJSON() ([]byte, error) {
+	if !m.IsSet() {
+		return []byte("null"), nil
+	}
+	return json.Marshal(m.toWire())
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		*m = Money{}
+		return nil
+	}
+	var w wire
+	if err := json.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	parsed, err := fromWire(w)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// MarshalBSONValue stores Money as an {amount, currency} subdocument so it
+// can be queried and indexed like any other field, or as null for the zero
+// value.
+func (m Money) MarshalBSONValue() (bsontype.Type, []byte, error) {
+	if !m.IsSet() {
+		return bsontype.Null, nil, nil
+	}
+	data, err := bson.Marshal(m.toWire())
+	return bsontype.EmbeddedDocument, data, err
+}
+
+func (m *Money) UnmarshalBSONValue(t bsontype.Type, data []byte) error {
+	switch t {
+	case bsontype.Null, bsontype.Undefined:
+		*m = Money{}
+		return nil
+	case bsontype.EmbeddedDocument:
+	default:
+		return fmt.Errorf("money: cannot decode BSON %s", t)
+	}
+	var w wire
+	if err := bson.Unmarshal(data, &w); err != nil {
+		return err
+	}
+	parsed, err := fromWire(w)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
@@ -0,0 +1,112 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		money Money
+		json  string
+	}{
+		{MustNew(1250, "USD"), `{"amount":1250,"currency":"USD","display":"12.50"}`},
+		{MustNew(-7, "USD"), `{"amount":-7,"currency":"USD","display":"-0.07"}`},
+		{MustNew(1500, "JPY"), `{"amount":1500,"currency":"JPY","display":"1500"}`},
+		{MustNew(1234, "KWD"), `{"amount":1234,"currency":"KWD","display":"1.234"}`},
+		{Money{}, `null`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.json, func(t *testing.T) {
+			data, err := json.Marshal(tt.money)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			if string(data) != tt.json {
+				t.Errorf("Marshal = %s, want %s", data, tt.json)
+			}
+			var back Money
+			if err := json.Unmarshal(data, &back); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if back != tt.money {
+				t.Errorf("round trip = %s, want %s", back, tt.money)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Money
+		err  error
+	}{
+		// Display is output only and a lower-case code is accepted.
+		{in: `{"amount":99,"currency":"usd","display":"1000.00"}`, want: MustNew(99, "USD")},
+		{in: `{"amount":5}`, err: ErrUnknownCurrency},
+		{in: `{"amount":5,"currency":"XXX"}`, err: ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var got Money
+			err := json.Unmarshal([]byte(tt.in), &got)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Unmarshal error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Unmarshal = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBSONRoundTrip(t *testing.T) {
+	type priced struct {
+		Price    Money `bson:"price"`
+		Discount Money `bson:"discount"`
+	}
+
+	tests := []priced{
+		{Price: MustNew(1250, "USD")},
+		{Price: MustNew(1500, "JPY"), Discount: MustNew(-100, "JPY")},
+		{Price: MustNew(1234, "KWD"), Discount: MustNew(1, "KWD")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Price.String(), func(t *testing.T) {
+			data, err := bson.Marshal(tt)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+
+			// Stored as a queryable subdocument without the display string.
+			raw := bson.Raw(data)
+			if amount, ok := raw.Lookup("price", "amount").Int64OK(); !ok || amount != tt.Price.Amount() {
+				t.Errorf("price.amount = %v, want %d", raw.Lookup("price", "amount"), tt.Price.Amount())
+			}
+			if _, err := raw.LookupErr("price", "display"); err == nil {
+				t.Errorf("price.display stored, want it left out")
+			}
+
+			var back priced
+			if err := bson.Unmarshal(data, &back); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if back != tt {
+				t.Errorf("round trip = %+v, want %+v", back, tt)
+			}
+		})
+	}
+}
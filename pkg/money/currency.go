@@ -0,0 +1,68 @@
+package money
+
+import (
+	"sort"
+	"strings"
+)
+
+// Currency is an ISO 4217 currency. Digits is how many minor units make up
+// one major unit as a power of ten: 2 for USD (cents), 0 for JPY, 3 for KWD.
+type Currency struct {
+	Code   string
+	Digits int
+}
+
+// currencies lists the active ISO 4217 codes by their number of minor unit
+// digits. Funds codes without a minor unit (XAU and the like) are left out;
+// they can't price anything.
+var currencies = func() map[string]Currency {
+	byDigits := map[int]string{
+		0: "BIF CLP DJF GNF ISK JPY KMF KRW PYG RWF UGX UYI VND VUV XAF XOF XPF",
+		2: "AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BOV BRL BSD " +
+			"BTN BWP BYN BZD CAD CDF CHE CHF CHW CNY COP COU CRC CUP CVE CZK DKK DOP DZD EGP " +
+			"ERN ETB EUR FJD FKP GBP GEL GHS GIP GMD GTQ GYD HKD HNL HTG HUF IDR ILS INR IRR " +
+			"JMD KES KGS KHR KPW KYD KZT LAK LBP LKR LRD LSL MAD MDL MGA MKD MMK MNT MOP MRU " +
+			"MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD PAB PEN PGK PHP PKR PLN QAR " +
+			"RON RSD RUB SAR SBD SCR SDG SEK SGD SHP SLE SOS SRD SSP STN SVC SYP SZL THB TJS " +
+			"TMT TOP TRY TTD TWD TZS UAH USD USN UYU UZS VED VES WST XCD YER ZAR ZMW ZWG",
+		3: "BHD IQD JOD KWD LYD OMR TND",
+		4: "CLF UYW",
+	}
+	m := make(map[string]Currency)
+	for digits, codes := range byDigits {
+		for _, code := range strings.Fields(codes) {
+			m[code] = Currency{Code: code, Digits: digits}
+		}
+	}
+	return m
+}()
+
+// LookupCurrency returns the currency with the given code. Codes are matched
+// case-insensitively.
+func LookupCurrency(code string) (Currency, error) {
+	c, ok := currencies[normalizeCode(code)]
+	if !ok {
+		return Currency{}, ErrUnknownCurrency
+	}
+	return c, nil
+}
+
+// IsCurrency reports whether code is a known ISO 4217 currency.
+func IsCurrency(code string) bool {
+	_, err := LookupCurrency(code)
+	return err == nil
+}
+
+// Currencies returns every known currency code in alphabetical order.
+func Currencies() []string {
+	codes := make([]string, 0, len(currencies))
+	for code := range currencies {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
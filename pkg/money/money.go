@@ -0,0 +1,233 @@
+// Package money represents amounts as integer minor units of an ISO 4217
+// currency (cents, pence, kobo), so prices and balances never pass through
+// floating point.
+package money
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrUnknownCurrency  = errors.New("unknown currency")
+	ErrCurrencyMismatch = errors.New("currencies do not match")
+	ErrOverflow         = errors.New("amount out of range")
+	ErrInvalidAmount    = errors.New("invalid amount")
+	ErrInvalidRatios    = errors.New("allocation ratios must be non-negative and not all zero")
+)
+
+// Money is an amount of minor units in one currency. The zero value has no
+// currency and is only useful as "not set"; arithmetic on it fails.
+type Money struct {
+	amount   int64
+	currency Currency
+}
+
+// New returns amount minor units of the currency with the given code.
+func New(amount int64, code string) (Money, error) {
+	c, err := LookupCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{amount: amount, currency: c}, nil
+}
+
+// MustNew is New for amounts known to be valid, such as constants.
+func MustNew(amount int64, code string) Money {
+	m, err := New(amount, code)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+// Parse reads a decimal amount in major units, such as "12.50" or "-3". It
+// rejects more decimal places than the currency has rather than rounding
+// them away.
+func Parse(s, code string) (Money, error) {
+	c, err := LookupCurrency(code)
+	if err != nil {
+		return Money{}, err
+	}
+
+	s = strings.TrimSpace(s)
+	sign := ""
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		sign, s = s[:1], s[1:]
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || !isDigits(whole) || !isDigits(frac) || len(frac) > c.Digits {
+		return Money{}, ErrInvalidAmount
+	}
+	if whole == "" {
+		whole = "0"
+	}
+	frac += strings.Repeat("0", c.Digits-len(frac))
+
+	amount, err := strconv.ParseInt(strings.TrimPrefix(sign, "+")+whole+frac, 10, 64)
+	if err != nil {
+		if errors.Is(err, strconv.ErrRange) {
+			return Money{}, ErrOverflow
+		}
+		return Money{}, ErrInvalidAmount
+	}
+	return Money{amount: amount, currency: c}, nil
+}
+
+// Amount is the value in minor units.
+func (m Money) Amount() int64 {
+	return m.amount
+}
+
+// Currency is the ISO 4217 code, or "" for the zero value.
+func (m Money) Currency() string {
+	return m.currency.Code
+}
+
+// IsSet reports whether m has a currency, i.e. isn't the zero value.
+func (m Money) IsSet() bool {
+	return m.currency.Code != ""
+}
+
+func (m Money) IsZero() bool {
+	return m.amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.amount < 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.amount > 0
+}
+
+// SameCurrency reports whether m and o can be combined.
+func (m Money) SameCurrency(o Money) bool {
+	return m.IsSet() && m.currency == o.currency
+}
+
+func (m Money) Add(o Money) (Money, error) {
+	if !m.SameCurrency(o) {
+		return Money{}, ErrCurrencyMismatch
+	}
+	sum := m.amount + o.amount
+	if (o.amount > 0 && sum < m.amount) || (o.amount < 0 && sum > m.amount) {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: sum, currency: m.currency}, nil
+}
+
+func (m Money) Sub(o Money) (Money, error) {
+	if o.amount == minInt64 {
+		return Money{}, ErrOverflow
+	}
+	return m.Add(Money{amount: -o.amount, currency: o.currency})
+}
+
+// Neg fails only for the most negative amount, which has no positive twin.
+func (m Money) Neg() (Money, error) {
+	if m.amount == minInt64 {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: -m.amount, currency: m.currency}, nil
+}
+
+func (m Money) Abs() (Money, error) {
+	if m.amount < 0 {
+		return m.Neg()
+	}
+	return m, nil
+}
+
+// Cmp returns -1, 0 or +1 as m is less than, equal to or greater than o.
+func (m Money) Cmp(o Money) (int, error) {
+	if !m.SameCurrency(o) {
+		return 0, ErrCurrencyMismatch
+	}
+	switch {
+	case m.amount < o.amount:
+		return -1, nil
+	case m.amount > o.amount:
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Mul multiplies by a whole quantity, e.g. a unit price by a line quantity.
+func (m Money) Mul(n int64) (Money, error) {
+	product := new(big.Int).Mul(big.NewInt(m.amount), big.NewInt(n))
+	return m.withBig(product)
+}
+
+// MulFrac multiplies by num/den and rounds the result to a whole minor unit.
+// A 2.5% fee is MulFrac(250, 10000, HalfEven).
+func (m Money) MulFrac(num, den int64, mode Rounding) (Money, error) {
+	if den == 0 {
+		return Money{}, ErrInvalidAmount
+	}
+	return m.MulRat(big.NewRat(num, den), mode)
+}
+
+// MulRat multiplies by an exact rational factor and rounds the result.
+func (m Money) MulRat(factor *big.Rat, mode Rounding) (Money, error) {
+	if !m.IsSet() {
+		return Money{}, ErrUnknownCurrency
+	}
+	r := new(big.Rat).Mul(new(big.Rat).SetInt64(m.amount), factor)
+	return m.withBig(mode.round(r))
+}
+
+// Decimal formats the amount in major units, e.g. "12.50", "-0.07" or "1500"
+// for a currency without minor units.
+func (m Money) Decimal() string {
+	digits := m.currency.Digits
+	abs := strconv.FormatUint(absUint(m.amount), 10)
+	if digits > 0 {
+		if len(abs) <= digits {
+			abs = strings.Repeat("0", digits-len(abs)+1) + abs
+		}
+		abs = abs[:len(abs)-digits] + "." + abs[len(abs)-digits:]
+	}
+	if m.amount < 0 {
+		return "-" + abs
+	}
+	return abs
+}
+
+// String formats m as "12.50 USD".
+func (m Money) String() string {
+	if !m.IsSet() {
+		return "<unset>"
+	}
+	return m.Decimal() + " " + m.currency.Code
+}
+
+func (m Money) withBig(amount *big.Int) (Money, error) {
+	if !m.IsSet() {
+		return Money{}, ErrUnknownCurrency
+	}
+	if !amount.IsInt64() {
+		return Money{}, ErrOverflow
+	}
+	return Money{amount: amount.Int64(), currency: m.currency}, nil
+}
+
+const minInt64 = -1 << 63
+
+func absUint(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
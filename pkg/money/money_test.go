@@ -0,0 +1,77 @@
+package money
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in, code string
+		want     int64
+		err      error
+	}{
+		{in: "12.50", code: "USD", want: 1250},
+		{in: "12.5", code: "USD", want: 1250},
+		{in: ".5", code: "USD", want: 50},
+		{in: "-3", code: "USD", want: -300},
+		{in: "+1", code: "usd", want: 100},
+		{in: " 7.01 ", code: "USD", want: 701},
+		{in: "1.234", code: "USD", err: ErrInvalidAmount},
+
+		{in: "1500", code: "JPY", want: 1500},
+		{in: "-20", code: "JPY", want: -20},
+		{in: "1500.5", code: "JPY", err: ErrInvalidAmount},
+
+		{in: "1.234", code: "KWD", want: 1234},
+		{in: "1.2", code: "KWD", want: 1200},
+		{in: "0.005", code: "KWD", want: 5},
+		{in: "1.2345", code: "KWD", err: ErrInvalidAmount},
+
+		{in: "", code: "USD", err: ErrInvalidAmount},
+		{in: ".", code: "USD", err: ErrInvalidAmount},
+		{in: "1,000", code: "USD", err: ErrInvalidAmount},
+		{in: "1e3", code: "USD", err: ErrInvalidAmount},
+		{in: "99999999999999999999", code: "USD", err: ErrOverflow},
+		{in: "1", code: "XXX", err: ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code+" "+tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in, tt.code)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Parse(%q, %s) error = %v, want %v", tt.in, tt.code, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q, %s): %v", tt.in, tt.code, err)
+			}
+			if got.Amount() != tt.want || !got.SameCurrency(MustNew(0, tt.code)) {
+				t.Errorf("Parse(%q, %s) = %s, want %d minor units", tt.in, tt.code, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{MustNew(1250, "USD"), "12.50 USD"},
+		{MustNew(5, "USD"), "0.05 USD"},
+		{MustNew(-7, "USD"), "-0.07 USD"},
+		{MustNew(1500, "JPY"), "1500 JPY"},
+		{MustNew(1234, "KWD"), "1.234 KWD"},
+		{MustNew(-1, "KWD"), "-0.001 KWD"},
+		{Money{}, "<unset>"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
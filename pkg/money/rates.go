@@ -0,0 +1,147 @@
+package money
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNoRate      = errors.New("no exchange rate for currency")
+	ErrInvalidRate = errors.New("exchange rate must be a positive number")
+)
+
+// Rates is an exchange-rate table quoted against one base currency: each
+// rate is how many major units of a currency one major unit of the base
+// buys. Conversions between two quoted currencies go through the base.
+//
+// Rates is safe for concurrent use. Replace swaps the whole table at once so
+// readers never see a half-updated set.
+type Rates struct {
+	base Currency
+
+	mu    sync.RWMutex
+	rates map[string]*big.Rat
+	asOf  time.Time
+}
+
+func NewRates(base string) (*Rates, error) {
+	c, err := LookupCurrency(base)
+	if err != nil {
+		return nil, err
+	}
+	return &Rates{base: c, rates: map[string]*big.Rat{c.Code: big.NewRat(1, 1)}}, nil
+}
+
+// ParseRate reads a decimal rate such as "1550.25" exactly.
+func ParseRate(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() <= 0 {
+		return nil, ErrInvalidRate
+	}
+	return r, nil
+}
+
+func (r *Rates) Base() string {
+	return r.base.Code
+}
+
+// Replace installs a new table. Every code must be a known currency and every
+// rate positive; on error the old table stays in place.
+func (r *Rates) Replace(rates map[string]string, asOf time.Time) error {
+	next := make(map[string]*big.Rat, len(rates)+1)
+	for code, value := range rates {
+		c, err := LookupCurrency(code)
+		if err != nil {
+			return fmt.Errorf("%w: %q", err, code)
+		}
+		rate, err := ParseRate(value)
+		if err != nil {
+			return fmt.Errorf("%w: %s %q", err, c.Code, value)
+		}
+		next[c.Code] = rate
+	}
+	next[r.base.Code] = big.NewRat(1, 1)
+
+	r.mu.Lock()
+	r.rates = next
+	r.asOf = asOf
+	r.mu.Unlock()
+	return nil
+}
+
+// AsOf is when the current table was published.
+func (r *Rates) AsOf() time.Time {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.asOf
+}
+
+// Rate returns how many major units of to one major unit of from buys.
+func (r *Rates) Rate(from, to string) (*big.Rat, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rate(normalizeCode(from), normalizeCode(to))
+}
+
+func (r *Rates) rate(from, to string) (*big.Rat, error) {
+	if from == to {
+		return big.NewRat(1, 1), nil
+	}
+	fromRate, ok := r.rates[from]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrNoRate, from)
+	}
+	toRate, ok := r.rates[to]
+	if !ok {
+		return nil, fmt.Errorf("%w %s", ErrNoRate, to)
+	}
+	return new(big.Rat).Quo(toRate, fromRate), nil
+}
+
+// Convert prices m in another currency, rounding to that currency's minor
+// unit. Converted amounts are for showing buyers; anything that moves money
+// should stay in the original currency.
+func (r *Rates) Convert(m Money, to string, mode Rounding) (Money, error) {
+	target, err := LookupCurrency(to)
+	if err != nil {
+		return Money{}, err
+	}
+	if !m.IsSet() {
+		return Money{}, ErrUnknownCurrency
+	}
+	if m.currency == target {
+		return m, nil
+	}
+
+	r.mu.RLock()
+	rate, err := r.rate(m.currency.Code, target.Code)
+	r.mu.RUnlock()
+	if err != nil {
+		return Money{}, err
+	}
+
+	// Scale between the two currencies' minor units as well as by the rate.
+	factor := new(big.Rat).Set(rate)
+	if shift := target.Digits - m.currency.Digits; shift > 0 {
+		factor.Mul(factor, new(big.Rat).SetInt(pow10(shift)))
+	} else if shift < 0 {
+		factor.Quo(factor, new(big.Rat).SetInt(pow10(-shift)))
+	}
+	converted := new(big.Rat).Mul(new(big.Rat).SetInt64(m.amount), factor)
+	return Money{currency: target}.withBig(mode.round(converted))
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// Quotes reports whether the table has a rate for code.
+func (r *Rates) Quotes(code string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.rates[normalizeCode(code)]
+	return ok
+}
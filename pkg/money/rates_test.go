@@ -0,0 +1,74 @@
+package money
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConvert(t *testing.T) {
+	rates, err := NewRates("USD")
+	if err != nil {
+		t.Fatalf("NewRates: %v", err)
+	}
+	err = rates.Replace(map[string]string{"JPY": "150", "KWD": "0.3", "EUR": "0.9"}, time.Now())
+	if err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		from Money
+		to   string
+		mode Rounding
+		want int64
+		err  error
+	}{
+		{name: "2 to 0 digits", from: MustNew(100, "USD"), to: "JPY", want: 150},
+		{name: "0 to 2 digits", from: MustNew(150, "JPY"), to: "USD", want: 100},
+		{name: "0 to 3 digits through the base", from: MustNew(1000, "JPY"), to: "KWD", want: 2000},
+		{name: "3 to 2 digits rounds down", from: MustNew(1234, "KWD"), to: "USD", mode: HalfEven, want: 411},
+		{name: "3 to 2 digits rounds up", from: MustNew(1234, "KWD"), to: "USD", mode: Up, want: 412},
+		{name: "half a yen, half even", from: MustNew(3, "USD"), to: "JPY", mode: HalfEven, want: 4},
+		{name: "half a yen, half up", from: MustNew(3, "USD"), to: "JPY", mode: HalfUp, want: 5},
+		{name: "half a yen, down", from: MustNew(3, "USD"), to: "JPY", mode: Down, want: 4},
+		{name: "negative amounts", from: MustNew(-3, "USD"), to: "JPY", mode: HalfUp, want: -5},
+		{name: "same currency is unchanged", from: MustNew(1234, "KWD"), to: "kwd", want: 1234},
+		{name: "unquoted currency", from: MustNew(100, "USD"), to: "GBP", err: ErrNoRate},
+		{name: "unknown currency", from: MustNew(100, "USD"), to: "XXX", err: ErrUnknownCurrency},
+		{name: "unset money", from: Money{}, to: "USD", err: ErrUnknownCurrency},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := rates.Convert(tt.from, tt.to, tt.mode)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Convert error = %v, want %v", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Convert: %v", err)
+			}
+			if got.Amount() != tt.want || !got.SameCurrency(MustNew(0, tt.to)) {
+				t.Errorf("Convert(%s, %s) = %s, want %d minor units of %s", tt.from, tt.to, got, tt.want, tt.to)
+			}
+		})
+	}
+}
+
+func TestReplaceKeepsOldTableOnError(t *testing.T) {
+	rates, _ := NewRates("USD")
+	if err := rates.Replace(map[string]string{"EUR": "0.9"}, time.Now()); err != nil {
+		t.Fatalf("Replace: %v", err)
+	}
+	for _, bad := range []map[string]string{{"XXX": "1"}, {"EUR": "0"}, {"EUR": "abc"}} {
+		if err := rates.Replace(bad, time.Now()); err == nil {
+			t.Errorf("Replace(%v) succeeded, want an error", bad)
+		}
+	}
+	if !rates.Quotes("eur") {
+		t.Errorf("EUR rate lost after a rejected Replace")
+	}
+}
@@ -25,6 +25,8 @@ type Meta struct {
 	Page       int `json:"page,omitempty"`
 	PageSize   int `json:"page_size,omitempty"`
 	TotalCount int `json:"total_count,omitempty"`
+	// NextCursor is set by cursor-paginated endpoints; empty means no more results.
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 func OK(c *gin.Context, data interface{}, message string) {
@@ -58,17 +60,24 @@ func NoContent(c *gin.Context) {
 	c.Status(http.StatusNoContent)
 }
 
-func Paginated(c *gin.Context, data interface{}, page, pageSize, total int, message string) {
+// Paginated responds with one page of results. Cursor-paginated endpoints
+// pass page and total as 0 and the cursor of the next page, empty when there
+// are no more results.
+func Paginated(c *gin.Context, data interface{}, page, pageSize, total int, message string, nextCursor ...string) {
+	meta := &Meta{
+		Page:       page,
+		PageSize:   pageSize,
+		TotalCount: total,
+	}
+	if len(nextCursor) > 0 {
+		meta.NextCursor = nextCursor[0]
+	}
 	c.JSON(http.StatusOK, Envelope{
 		Success: true,
 		Code:    http.StatusOK,
 		Message: message,
 		Data:    data,
-		Meta: &Meta{
-			Page:       page,
-			PageSize:   pageSize,
-			TotalCount: total,
-		},
+		Meta:    meta,
 	})
 }
 